@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// Alert levels.
+const (
+	AlertWarn     = "warn"
+	AlertCritical = "critical"
+)
+
+// Alert is a condition that has held long enough to be worth surfacing.
+type Alert struct {
+	Metric  string  `json:"metric"`            // e.g. "disk.used_percent"
+	Subject string  `json:"subject,omitempty"` // Mount, interface, ...
+	Level   string  `json:"level"`
+	Value   float64 `json:"value"`
+	Message string  `json:"message"`
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("[%s] %s", a.Level, a.Message)
+}
+
+// Disk usage alerting.
+const (
+	diskAlertThreshold = diskCritThreshold
+	diskAlertSustain   = 3    // Consecutive samples at or above the threshold
+	diskJumpThreshold  = 30.0 // Percentage points in one tick treated as a glitch
+	// inodeAlertThreshold applies to inode usage. Running out of inodes
+	// fails file creation however much space is free.
+	inodeAlertThreshold = diskCritThreshold
+)
+
+// diskAlertState is the per-mount history used to debounce disk alerts.
+type diskAlertState struct {
+	lastPercent float64
+	highStreak  int
+	firing      bool // The usage alert fired and has not cleared
+	inodeStreak int
+	inodeFiring bool
+	// wasWritable is set once the mount has been seen read-write, so a
+	// volume that is read-only by design (the sealed macOS system volume)
+	// never alerts, while one that turns read-only later does.
+	wasWritable bool
+}
+
+// evaluateDiskAlerts flags implausible single-tick usage jumps on the disks
+// (UsedPercent is left as measured) and raises an alert only once a mount has
+// been at or above diskAlertThreshold for diskAlertSustain samples, so a
+// mount that briefly reports the wrong filesystem doesn't page anyone. A
+// mount that turns read-only after being seen writable alerts at once. Inode
+// usage alerts on its own, the same way.
+func (c *Collector) evaluateDiskAlerts(disks []DiskStatus) []Alert {
+	if c.diskAlerts == nil {
+		c.diskAlerts = make(map[string]*diskAlertState)
+	}
+
+	var alerts []Alert
+	seen := make(map[string]bool, len(disks))
+	for i := range disks {
+		d := &disks[i]
+		seen[d.Mount] = true
+		state, ok := c.diskAlerts[d.Mount]
+		if !ok {
+			state = &diskAlertState{}
+			c.diskAlerts[d.Mount] = state
+		} else if math.Abs(d.UsedPercent-state.lastPercent) > diskJumpThreshold {
+			d.SuspectJump = true
+		}
+		state.lastPercent = d.UsedPercent
+
+		if !d.ReadOnly {
+			state.wasWritable = true
+		} else if state.wasWritable {
+			alerts = append(alerts, Alert{
+				Metric:  "disk.read_only",
+				Subject: d.Mount,
+				Level:   AlertCritical,
+				Value:   1,
+				Message: fmt.Sprintf("%s was remounted read-only", d.Mount),
+			})
+		}
+
+		if a, ok := c.evaluateInodeAlert(d, state); ok {
+			alerts = append(alerts, a)
+		}
+
+		if state.firing && d.UsedPercent >= c.alertClearLevel(diskAlertThreshold) {
+			alerts = append(alerts, diskUsageAlert(d))
+			continue
+		}
+		state.firing = false
+		if d.UsedPercent < diskAlertThreshold {
+			state.highStreak = 0
+			continue
+		}
+		state.highStreak++
+		if state.highStreak >= diskAlertSustain {
+			state.firing = true
+			alerts = append(alerts, diskUsageAlert(d))
+		}
+	}
+	// Forget unmounted disks so a remount starts fresh.
+	for mount := range c.diskAlerts {
+		if !seen[mount] {
+			delete(c.diskAlerts, mount)
+		}
+	}
+	return alerts
+}
+
+// evaluateInodeAlert debounces inode usage the way evaluateDiskAlerts does
+// byte usage, independently of it, so an inode-full mount with free space
+// still alerts. Mounts without inode counts never do.
+func (c *Collector) evaluateInodeAlert(d *DiskStatus, state *diskAlertState) (Alert, bool) {
+	alert := Alert{
+		Metric:  "disk.inodes_used_percent",
+		Subject: d.Mount,
+		Level:   AlertCritical,
+		Value:   d.InodesUsedPercent,
+		Message: fmt.Sprintf("%s has used %.0f%% of its inodes", d.Mount, d.InodesUsedPercent),
+	}
+	if state.inodeFiring && d.InodesTotal > 0 && d.InodesUsedPercent >= c.alertClearLevel(inodeAlertThreshold) {
+		return alert, true
+	}
+	state.inodeFiring = false
+	if d.InodesTotal == 0 || d.InodesUsedPercent < inodeAlertThreshold {
+		state.inodeStreak = 0
+		return Alert{}, false
+	}
+	state.inodeStreak++
+	if state.inodeStreak < diskAlertSustain {
+		return Alert{}, false
+	}
+	state.inodeFiring = true
+	return alert, true
+}
+
+func diskUsageAlert(d *DiskStatus) Alert {
+	return Alert{
+		Metric:  "disk.used_percent",
+		Subject: d.Mount,
+		Level:   AlertCritical,
+		Value:   d.UsedPercent,
+		Message: fmt.Sprintf("%s is %.0f%% full", d.Mount, d.UsedPercent),
+	}
+}
+
+// alertClearLevel is the value a firing alert with this threshold must
+// fall below to clear, per AlertClearMargin.
+func (c *Collector) alertClearLevel(threshold float64) float64 {
+	return threshold * (1 - max(c.AlertClearMargin, 0)/100)
+}
+
+// Upload asymmetry alerting.
+const (
+	uploadAlertSustain = 3   // Consecutive upload-heavy samples before alerting
+	uploadAlertMinTx   = 0.5 // MB/s; slower uploads are never worth an alert
+)
+
+// evaluateUploadAlerts warns when an interface has uploaded more than
+// UploadAlertRatio times what it downloaded for uploadAlertSustain samples
+// in a row. A sustained upload that outweighs downloads on a workstation
+// can be a runaway sync, a backup, or data leaving that shouldn't.
+func (c *Collector) evaluateUploadAlerts(stats []NetworkStatus) []Alert {
+	if c.UploadAlertRatio <= 0 {
+		return nil
+	}
+	if c.uploadStreak == nil {
+		c.uploadStreak = make(map[string]int)
+		c.uploadFiring = make(map[string]bool)
+	}
+
+	var alerts []Alert
+	seen := make(map[string]bool, len(stats))
+	for _, n := range stats {
+		if n.Loopback || slices.Contains(c.ServerInterfaces, n.Name) {
+			continue
+		}
+		seen[n.Name] = true
+		if c.uploadFiring[n.Name] && n.TxRateMBs >= uploadAlertMinTx && n.AsymmetryRatio > c.alertClearLevel(c.UploadAlertRatio) {
+			alerts = append(alerts, uploadAlert(n))
+			continue
+		}
+		delete(c.uploadFiring, n.Name)
+		if n.TxRateMBs < uploadAlertMinTx || n.AsymmetryRatio <= c.UploadAlertRatio {
+			delete(c.uploadStreak, n.Name)
+			continue
+		}
+		c.uploadStreak[n.Name]++
+		if c.uploadStreak[n.Name] >= uploadAlertSustain {
+			c.uploadFiring[n.Name] = true
+			alerts = append(alerts, uploadAlert(n))
+		}
+	}
+	for name := range c.uploadStreak {
+		if !seen[name] {
+			delete(c.uploadStreak, name)
+			delete(c.uploadFiring, name)
+		}
+	}
+	return alerts
+}
+
+func uploadAlert(n NetworkStatus) Alert {
+	return Alert{
+		Metric:  "net.asymmetry_ratio",
+		Subject: n.Name,
+		Level:   AlertWarn,
+		Value:   n.AsymmetryRatio,
+		Message: fmt.Sprintf("%s uploading %.1f MB/s, %.1fx its download rate", n.Name, n.TxRateMBs, n.AsymmetryRatio),
+	}
+}
+
+// defaultFlapAlertWindow is the flap counting window when
+// Collector.FlapAlertWindow is unset.
+const defaultFlapAlertWindow = time.Minute
+
+// defaultFlapCount is how many carrier changes within the window an
+// interface may have before it counts as flapping when
+// Collector.FlapAlertCount is unset. Unplugging and replugging a cable is
+// two.
+const defaultFlapCount = 2
+
+// flapSample is the number of carrier transitions seen on one tick.
+type flapSample struct {
+	at    time.Time
+	flaps int
+}
+
+// evaluateFlapAlerts keeps each interface's carrier transitions over the
+// last FlapAlertWindow and marks the interface Flapping while more than
+// FlapAlertCount (defaultFlapCount when unset) fall inside it. With
+// FlapAlertCount set it also warns, naming the interface. A cable or port
+// that keeps dropping the link shows up here before it fails outright.
+func (c *Collector) evaluateFlapAlerts(now time.Time, stats []NetworkStatus) []Alert {
+	limit := c.FlapAlertCount
+	if limit <= 0 {
+		limit = defaultFlapCount
+	}
+	window := c.FlapAlertWindow
+	if window <= 0 {
+		window = defaultFlapAlertWindow
+	}
+	if c.flapHistory == nil {
+		c.flapHistory = make(map[string][]flapSample)
+	}
+
+	var alerts []Alert
+	for i := range stats {
+		n := &stats[i]
+		if n.Loopback {
+			continue
+		}
+		samples := c.flapHistory[n.Name]
+		if n.CarrierFlaps > 0 {
+			samples = append(samples, flapSample{at: now, flaps: n.CarrierFlaps})
+		}
+		samples = slices.DeleteFunc(samples, func(s flapSample) bool { return now.Sub(s.at) >= window })
+		if len(samples) == 0 {
+			delete(c.flapHistory, n.Name)
+			continue
+		}
+		c.flapHistory[n.Name] = samples
+
+		total := 0
+		for _, s := range samples {
+			total += s.flaps
+		}
+		n.Flapping = total > limit
+		if n.Flapping && c.FlapAlertCount > 0 {
+			alerts = append(alerts, Alert{
+				Metric:  "net.carrier_flaps",
+				Subject: n.Name,
+				Level:   AlertWarn,
+				Value:   float64(total),
+				Message: fmt.Sprintf("%s link flapped %d times in %s", n.Name, total, window),
+			})
+		}
+	}
+	// Interfaces that left the snapshot keep their samples until they age
+	// out, so a NIC bouncing in and out of the list is still counted.
+	for name, samples := range c.flapHistory {
+		if now.Sub(samples[len(samples)-1].at) >= window {
+			delete(c.flapHistory, name)
+		}
+	}
+	return alerts
+}
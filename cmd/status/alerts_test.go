@@ -0,0 +1,252 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+func TestDiskAlertSuppressesOneTickSpike(t *testing.T) {
+	c := NewCollector()
+	samples := []float64{50, 95, 51, 52}
+	for i, pct := range samples {
+		disks := []DiskStatus{{Mount: "/", UsedPercent: pct}}
+		if alerts := c.evaluateDiskAlerts(disks); len(alerts) != 0 {
+			t.Fatalf("tick %d: spike should not alert, got %v", i, alerts)
+		}
+		if disks[0].UsedPercent != pct {
+			t.Fatalf("tick %d: raw value changed to %v", i, disks[0].UsedPercent)
+		}
+		wantJump := i == 1 || i == 2
+		if disks[0].SuspectJump != wantJump {
+			t.Fatalf("tick %d: SuspectJump = %v, want %v", i, disks[0].SuspectJump, wantJump)
+		}
+	}
+}
+
+func TestDiskAlertFiresWhenSustained(t *testing.T) {
+	c := NewCollector()
+	var alerts []Alert
+	for i, pct := range []float64{92, 93, 93.5} {
+		alerts = c.evaluateDiskAlerts([]DiskStatus{{Mount: "/data", UsedPercent: pct}})
+		if i < diskAlertSustain-1 && len(alerts) != 0 {
+			t.Fatalf("tick %d: alert fired before %d samples", i, diskAlertSustain)
+		}
+	}
+	if len(alerts) != 1 || alerts[0].Subject != "/data" || alerts[0].Level != AlertCritical {
+		t.Fatalf("expected sustained disk alert, got %v", alerts)
+	}
+
+	// Dropping below the threshold resets the streak.
+	c.evaluateDiskAlerts([]DiskStatus{{Mount: "/data", UsedPercent: 80}})
+	if alerts := c.evaluateDiskAlerts([]DiskStatus{{Mount: "/data", UsedPercent: 95}}); len(alerts) != 0 {
+		t.Fatalf("streak should restart after recovery, got %v", alerts)
+	}
+}
+
+func TestEvaluateUploadAlertsSustained(t *testing.T) {
+	c := &Collector{UploadAlertRatio: 2, ServerInterfaces: []string{"eth1"}}
+	tick := []NetworkStatus{
+		{Name: "en0", RxRateMBs: 0.5, TxRateMBs: 5, AsymmetryRatio: 10},
+		{Name: "eth1", RxRateMBs: 0.1, TxRateMBs: 50, AsymmetryRatio: 500}, // server
+		{Name: "en1", RxRateMBs: 10, TxRateMBs: 1, AsymmetryRatio: 0.1},
+	}
+	for i := 1; i < uploadAlertSustain; i++ {
+		if alerts := c.evaluateUploadAlerts(tick); len(alerts) != 0 {
+			t.Fatalf("tick %d: alerted before sustain: %+v", i, alerts)
+		}
+	}
+	alerts := c.evaluateUploadAlerts(tick)
+	if len(alerts) != 1 || alerts[0].Subject != "en0" || alerts[0].Level != AlertWarn {
+		t.Fatalf("expected one en0 warning, got %+v", alerts)
+	}
+
+	// A balanced tick resets the streak.
+	tick[0].TxRateMBs, tick[0].AsymmetryRatio = 0.5, 1
+	if alerts := c.evaluateUploadAlerts(tick); len(alerts) != 0 {
+		t.Fatalf("balanced tick still alerting: %+v", alerts)
+	}
+	if c.uploadStreak["en0"] != 0 {
+		t.Fatalf("streak not reset: %d", c.uploadStreak["en0"])
+	}
+}
+
+func TestEvaluateUploadAlertsDisabled(t *testing.T) {
+	c := &Collector{}
+	tick := []NetworkStatus{{Name: "en0", TxRateMBs: 5, AsymmetryRatio: maxAsymmetryRatio}}
+	for range uploadAlertSustain + 1 {
+		if alerts := c.evaluateUploadAlerts(tick); alerts != nil {
+			t.Fatalf("disabled alert fired: %+v", alerts)
+		}
+	}
+}
+
+func TestEvaluateFlapAlertsWithinWindow(t *testing.T) {
+	c := &Collector{FlapAlertCount: 3, FlapAlertWindow: time.Minute}
+	start := time.Now()
+	tick := func(sec int, en0Flaps int) []Alert {
+		t.Helper()
+		return c.evaluateFlapAlerts(start.Add(time.Duration(sec)*time.Second), []NetworkStatus{
+			{Name: "en0", CarrierFlaps: en0Flaps},
+			{Name: "en1"},
+		})
+	}
+
+	if alerts := tick(0, 2); len(alerts) != 0 {
+		t.Fatalf("2 flaps should not alert: %+v", alerts)
+	}
+	if alerts := tick(10, 1); len(alerts) != 0 {
+		t.Fatalf("3 flaps is at, not past, the threshold: %+v", alerts)
+	}
+	alerts := tick(20, 1)
+	if len(alerts) != 1 || alerts[0].Subject != "en0" || alerts[0].Metric != "net.carrier_flaps" || alerts[0].Value != 4 {
+		t.Fatalf("expected one en0 flap alert at 4, got %+v", alerts)
+	}
+	if !strings.Contains(alerts[0].Message, "en0") {
+		t.Fatalf("alert should name the interface: %q", alerts[0].Message)
+	}
+
+	// The first two flaps age out of the window at 60s.
+	if alerts := tick(65, 0); len(alerts) != 0 {
+		t.Fatalf("flaps outside the window still alerting: %+v", alerts)
+	}
+	if _, ok := c.flapHistory["en1"]; ok {
+		t.Fatalf("stable interface should keep no history")
+	}
+	if alerts := tick(200, 0); len(alerts) != 0 || len(c.flapHistory) != 0 {
+		t.Fatalf("history should drain once every flap aged out: %+v", c.flapHistory)
+	}
+}
+
+func TestEvaluateFlapAlertsDisabled(t *testing.T) {
+	c := &Collector{}
+	if alerts := c.evaluateFlapAlerts(time.Now(), []NetworkStatus{{Name: "en0", CarrierFlaps: 50}}); alerts != nil {
+		t.Fatalf("disabled alert fired: %+v", alerts)
+	}
+}
+
+func TestFlappingNeedsRepeatedCarrierChanges(t *testing.T) {
+	c := &Collector{}
+	start := time.Now()
+	tick := func(sec, flaps int) bool {
+		t.Helper()
+		stats := []NetworkStatus{{Name: "en0", CarrierFlaps: flaps}}
+		c.evaluateFlapAlerts(start.Add(time.Duration(sec)*time.Second), stats)
+		return stats[0].Flapping
+	}
+
+	// One unplug and replug is not flapping.
+	if tick(0, 2) {
+		t.Fatal("a single reconnect marked the link flapping")
+	}
+	if !tick(10, 1) {
+		t.Fatalf("more than %d changes within the window should mark flapping", defaultFlapCount)
+	}
+	if tick(70, 0) {
+		t.Fatal("flapping should clear once the changes age out")
+	}
+}
+
+func TestDiskAlertOnReadOnlyRemount(t *testing.T) {
+	c := &Collector{}
+	// A volume read-only from the start, like the sealed macOS system
+	// volume, never alerts.
+	sealed := DiskStatus{Mount: "/", UsedPercent: 40, ReadOnly: true}
+	data := DiskStatus{Mount: "/data", UsedPercent: 40}
+	if alerts := c.evaluateDiskAlerts([]DiskStatus{sealed, data}); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+
+	data.ReadOnly = true
+	alerts := c.evaluateDiskAlerts([]DiskStatus{sealed, data})
+	if len(alerts) != 1 || alerts[0].Metric != "disk.read_only" || alerts[0].Subject != "/data" || alerts[0].Level != AlertCritical {
+		t.Fatalf("expected a critical read-only alert for /data, got %+v", alerts)
+	}
+
+	data.ReadOnly = false
+	if alerts := c.evaluateDiskAlerts([]DiskStatus{sealed, data}); len(alerts) != 0 {
+		t.Fatalf("writable again should clear the alert: %+v", alerts)
+	}
+}
+
+func TestDiskAlertHysteresisHoldsWhileOscillating(t *testing.T) {
+	c := NewCollector()
+	c.AlertClearMargin = 5 // Clears under 85.5%
+	for _, pct := range []float64{91, 92, 93} {
+		c.evaluateDiskAlerts([]DiskStatus{{Mount: "/", UsedPercent: pct}})
+	}
+
+	// Hovering around the threshold stays one firing alert.
+	for i, pct := range []float64{89, 91, 88, 90.5, 86, 92} {
+		alerts := c.evaluateDiskAlerts([]DiskStatus{{Mount: "/", UsedPercent: pct}})
+		if len(alerts) != 1 || alerts[0].Metric != "disk.used_percent" {
+			t.Fatalf("tick %d at %v%%: alert should keep firing, got %v", i, pct, alerts)
+		}
+	}
+	if alerts := c.evaluateDiskAlerts([]DiskStatus{{Mount: "/", UsedPercent: 85}}); len(alerts) != 0 {
+		t.Fatalf("alert should clear below the margin, got %v", alerts)
+	}
+	// Once cleared, it must be sustained again before re-firing.
+	if alerts := c.evaluateDiskAlerts([]DiskStatus{{Mount: "/", UsedPercent: 91}}); len(alerts) != 0 {
+		t.Fatalf("cleared alert re-fired at once: %v", alerts)
+	}
+}
+
+func TestDiskAlertWithoutHysteresisClearsAtThreshold(t *testing.T) {
+	c := NewCollector()
+	for _, pct := range []float64{91, 92, 93} {
+		c.evaluateDiskAlerts([]DiskStatus{{Mount: "/", UsedPercent: pct}})
+	}
+	if alerts := c.evaluateDiskAlerts([]DiskStatus{{Mount: "/", UsedPercent: 89}}); len(alerts) != 0 {
+		t.Fatalf("zero margin should clear under the threshold, got %v", alerts)
+	}
+}
+
+func TestUploadAlertHysteresis(t *testing.T) {
+	c := &Collector{UploadAlertRatio: 4, AlertClearMargin: 25} // Clears at a ratio of 3
+	tick := func(ratio float64) []Alert {
+		return c.evaluateUploadAlerts([]NetworkStatus{{Name: "en0", TxRateMBs: 2, AsymmetryRatio: ratio}})
+	}
+	for range uploadAlertSustain {
+		tick(5)
+	}
+	firing := 0
+	for _, ratio := range []float64{3.8, 4.2, 3.5, 4.1} {
+		firing += len(tick(ratio))
+	}
+	if firing != 4 {
+		t.Fatalf("alert fired on %d of 4 oscillating ticks, want all", firing)
+	}
+	if alerts := tick(2.9); len(alerts) != 0 || c.uploadFiring["en0"] {
+		t.Fatalf("alert should clear under the margin, got %+v", alerts)
+	}
+}
+
+func TestDiskAlertInodeFullButByteEmpty(t *testing.T) {
+	c := NewCollector()
+	d := DiskStatus{Mount: "/var/spool", Used: 1 << 30, Total: 100 << 30, UsedPercent: 1}
+	d.InodesTotal, d.InodesUsed, d.InodesUsedPercent = inodeUsage(&disk.UsageStat{InodesTotal: 65536, InodesUsed: 65530})
+
+	var alerts []Alert
+	for range diskAlertSustain {
+		alerts = c.evaluateDiskAlerts([]DiskStatus{d})
+	}
+	if len(alerts) != 1 || alerts[0].Metric != "disk.inodes_used_percent" || alerts[0].Level != AlertCritical || alerts[0].Subject != "/var/spool" {
+		t.Fatalf("expected one critical inode alert, got %v", alerts)
+	}
+}
+
+func TestDiskAlertIgnoresMissingInodeCounts(t *testing.T) {
+	total, used, pct := inodeUsage(&disk.UsageStat{InodesTotal: 0, InodesUsed: 12, InodesUsedPercent: 100})
+	if total != 0 || used != 0 || pct != 0 {
+		t.Fatalf("inodeUsage() without a total = %d, %d, %v; want zeros", total, used, pct)
+	}
+	c := NewCollector()
+	for range diskAlertSustain + 1 {
+		if alerts := c.evaluateDiskAlerts([]DiskStatus{{Mount: "/Volumes/USB", UsedPercent: 10}}); len(alerts) != 0 {
+			t.Fatalf("filesystem without inodes alerted: %v", alerts)
+		}
+	}
+}
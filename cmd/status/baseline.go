@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Baseline comparison thresholds.
+const (
+	baselineRatioFactor     = 3.0  // Flag rates/usage at 3x baseline
+	baselineDiskGrowthPct   = 5.0  // Flag disks that grew by 5 percentage points
+	baselineMemGrowthPct    = 20.0 // Flag memory that grew by 20 percentage points
+	baselineCPUFloor        = 5.0  // Ignore CPU ratios below 5% baseline
+	baselineNetworkFloorMBs = 0.1
+	baselineProcFloor       = 50.0
+)
+
+// Deviation flags a metric that moved away from the saved baseline.
+type Deviation struct {
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	Reason   string  `json:"reason"`
+}
+
+// saveBaseline writes a snapshot as the reference "normal" state.
+func saveBaseline(path string, snap MetricsSnapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// loadBaseline reads a snapshot previously written by saveBaseline.
+func loadBaseline(path string) (MetricsSnapshot, error) {
+	var snap MetricsSnapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	return snap, nil
+}
+
+// compareToBaseline returns deviations of cur from base beyond fixed factors.
+func compareToBaseline(base, cur MetricsSnapshot) []Deviation {
+	var devs []Deviation
+
+	ratio := func(metric string, baseVal, curVal, floor float64) {
+		ref := max(baseVal, floor)
+		if curVal >= ref*baselineRatioFactor {
+			devs = append(devs, Deviation{
+				Metric:   metric,
+				Baseline: baseVal,
+				Current:  curVal,
+				Reason:   fmt.Sprintf("%.1fx baseline", curVal/ref),
+			})
+		}
+	}
+
+	ratio("cpu.usage", base.CPU.Usage, cur.CPU.Usage, baselineCPUFloor)
+	ratio("process.total", float64(base.ProcessCounts.Total), float64(cur.ProcessCounts.Total), baselineProcFloor)
+
+	baseRx, baseTx := totalNetworkRates(base.Network)
+	curRx, curTx := totalNetworkRates(cur.Network)
+	ratio("network.rx", baseRx, curRx, baselineNetworkFloorMBs)
+	ratio("network.tx", baseTx, curTx, baselineNetworkFloorMBs)
+
+	if cur.Memory.UsedPercent-base.Memory.UsedPercent >= baselineMemGrowthPct {
+		devs = append(devs, Deviation{
+			Metric:   "memory.used_percent",
+			Baseline: base.Memory.UsedPercent,
+			Current:  cur.Memory.UsedPercent,
+			Reason:   fmt.Sprintf("+%.1f points", cur.Memory.UsedPercent-base.Memory.UsedPercent),
+		})
+	}
+
+	baseDisks := make(map[string]DiskStatus, len(base.Disks))
+	for _, d := range base.Disks {
+		baseDisks[d.Mount] = d
+	}
+	for _, d := range cur.Disks {
+		b, ok := baseDisks[d.Mount]
+		if !ok {
+			continue
+		}
+		if growth := d.UsedPercent - b.UsedPercent; growth >= baselineDiskGrowthPct {
+			devs = append(devs, Deviation{
+				Metric:   "disk.used_percent:" + d.Mount,
+				Baseline: b.UsedPercent,
+				Current:  d.UsedPercent,
+				Reason:   fmt.Sprintf("grew %s", humanBytes(d.Used-min(d.Used, b.Used))),
+			})
+		}
+	}
+	return devs
+}
+
+// totalNetworkRates sums rates across interfaces, excluding loopback.
+func totalNetworkRates(stats []NetworkStatus) (rx, tx float64) {
+	for _, n := range stats {
+		if n.Loopback {
+			continue
+		}
+		rx += n.RxRateMBs
+		tx += n.TxRateMBs
+	}
+	return rx, tx
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareToStoredBaseline(t *testing.T) {
+	base := MetricsSnapshot{
+		CPU:    CPUStatus{Usage: 10},
+		Memory: MemoryStatus{UsedPercent: 40},
+		Disks:  []DiskStatus{{Mount: "/", Used: 100 << 30, UsedPercent: 50}},
+		Network: []NetworkStatus{
+			{Name: "en0", RxRateMBs: 1, TxRateMBs: 0.2},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := saveBaseline(path, base); err != nil {
+		t.Fatalf("saveBaseline: %v", err)
+	}
+	loaded, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("loadBaseline: %v", err)
+	}
+
+	cur := base
+	cur.CPU.Usage = 45
+	cur.Disks = []DiskStatus{{Mount: "/", Used: 104 << 30, UsedPercent: 52}}
+
+	devs := compareToBaseline(loaded, cur)
+	if len(devs) != 1 {
+		t.Fatalf("expected only the CPU deviation, got %+v", devs)
+	}
+	if devs[0].Metric != "cpu.usage" || devs[0].Baseline != 10 || devs[0].Current != 45 {
+		t.Fatalf("unexpected deviation: %+v", devs[0])
+	}
+}
+
+func TestCompareToBaselineDiskGrowth(t *testing.T) {
+	base := MetricsSnapshot{Disks: []DiskStatus{{Mount: "/", Used: 100 << 30, UsedPercent: 50}}}
+	cur := MetricsSnapshot{Disks: []DiskStatus{{Mount: "/", Used: 120 << 30, UsedPercent: 60}}}
+
+	devs := compareToBaseline(base, cur)
+	if len(devs) != 1 || devs[0].Metric != "disk.used_percent:/" {
+		t.Fatalf("expected disk growth deviation, got %+v", devs)
+	}
+}
+
+func TestLoadBaselineMissingFile(t *testing.T) {
+	if _, err := loadBaseline(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatalf("expected error for missing baseline")
+	}
+}
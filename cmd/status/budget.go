@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// boundedCollectors are the collectors that take a context and so stop at
+// their CollectTimeout allocation: each one shells out or waits on a
+// daemon. The rest only read kernel counters and finish on their own.
+var boundedCollectors = []string{
+	"thermal", "core_temps", "gpu", "bluetooth", "top_processes", "routes", "wifi",
+	"storage_arrays", "containers", "tcp", "neighbors", "time_sync", "updates", "firewall", "power_assertions",
+	"connectivity", "nic_drivers",
+}
+
+// collectorTimeout is the time name may take within one Collect: its
+// CollectorTimeouts entry capped at CollectTimeout, or else CollectTimeout
+// itself, since collectors run concurrently and each may use the whole
+// budget. Zero means no limit, as for every unbounded collector.
+func (c *Collector) collectorTimeout(name string) time.Duration {
+	if !slices.Contains(boundedCollectors, name) {
+		return 0
+	}
+	d, ok := c.CollectorTimeouts[name]
+	if !ok || d <= 0 {
+		return c.CollectTimeout
+	}
+	if c.CollectTimeout > 0 {
+		return min(d, c.CollectTimeout)
+	}
+	return d
+}
+
+// parseCollectorTimeouts parses "name=duration" pairs separated by commas,
+// e.g. "gpu=200ms,containers=500ms".
+func parseCollectorTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for pair := range strings.SplitSeq(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("collector timeout %q: want name=duration", pair)
+		}
+		name = strings.TrimSpace(name)
+		if !slices.Contains(boundedCollectors, name) {
+			return nil, fmt.Errorf("collector timeout %q: %q does not take a timeout (want one of %s)", pair, name, strings.Join(boundedCollectors, ", "))
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("collector timeout %q: want a positive duration", pair)
+		}
+		timeouts[name] = d
+	}
+	return timeouts, nil
+}
+
+// collectGroup runs collectors concurrently, each under its own timeout,
+// and gathers their errors and panics.
+type collectGroup struct {
+	c      *Collector
+	parent context.Context
+
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	err      error
+	errCount int
+}
+
+func newCollectGroup(parent context.Context, c *Collector) *collectGroup {
+	return &collectGroup{c: c, parent: parent}
+}
+
+// run starts fn in its own goroutine with a context that expires at
+// name's timeout. A running collector may start ones that depend on its
+// result; wait covers those too.
+func (g *collectGroup) run(name string, fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				g.c.logger().Warn("collector panic", "collector", name, "reason", r)
+				g.fail(fmt.Errorf("collector panic: %v", r))
+			}
+		}()
+		ctx, cancel := g.parent, context.CancelFunc(func() {})
+		if d := g.c.collectorTimeout(name); d > 0 {
+			ctx, cancel = context.WithTimeout(g.parent, d)
+		}
+		defer cancel()
+		err := fn(ctx)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			logDegraded(g.c.logger(), name, fmt.Errorf("timed out after %v", g.c.collectorTimeout(name)))
+		}
+		if err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *collectGroup) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.errCount++
+	if g.err == nil {
+		g.err = err
+	} else {
+		g.err = fmt.Errorf("%v; %w", g.err, err)
+	}
+}
+
+// wait blocks until every collector has returned and reports how many
+// failed and their merged error.
+func (g *collectGroup) wait() (errCount int, err error) {
+	g.wg.Wait()
+	return g.errCount, g.err
+}
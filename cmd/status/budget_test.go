@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCollectorTimeout(t *testing.T) {
+	c := &Collector{
+		CollectTimeout:    300 * time.Millisecond,
+		CollectorTimeouts: map[string]time.Duration{"gpu": 200 * time.Millisecond, "tcp": time.Second},
+	}
+	tests := []struct {
+		name string
+		want time.Duration
+	}{
+		{"gpu", 200 * time.Millisecond},
+		{"tcp", 300 * time.Millisecond}, // Capped by the budget
+		{"wifi", 300 * time.Millisecond},
+		{"cpu", 0}, // Takes no context
+	}
+	for _, tt := range tests {
+		if got := c.collectorTimeout(tt.name); got != tt.want {
+			t.Errorf("collectorTimeout(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	c.CollectTimeout = 0
+	if got := c.collectorTimeout("tcp"); got != time.Second {
+		t.Errorf("override without a budget = %v, want 1s", got)
+	}
+	if got := c.collectorTimeout("wifi"); got != 0 {
+		t.Errorf("no budget and no override = %v, want no limit", got)
+	}
+}
+
+func TestCollectGroupCancelsEachCollectorAtItsTimeout(t *testing.T) {
+	c := &Collector{
+		CollectTimeout:    400 * time.Millisecond,
+		CollectorTimeouts: map[string]time.Duration{"gpu": 50 * time.Millisecond, "tcp": 150 * time.Millisecond},
+	}
+	type result struct {
+		elapsed time.Duration
+		err     error
+	}
+	results := map[string]*result{"gpu": {}, "tcp": {}, "wifi": {}}
+	start := time.Now()
+	g := newCollectGroup(context.Background(), c)
+	for name, r := range results {
+		g.run(name, func(ctx context.Context) error {
+			<-ctx.Done() // A collector stuck on a command until cancelled
+			r.elapsed, r.err = time.Since(start), ctx.Err()
+			return nil
+		})
+	}
+	g.run("cpu", func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("cpu should run without a deadline")
+		}
+		return errors.New("cpu failed")
+	})
+	errCount, err := g.wait()
+	if errCount != 1 || err == nil {
+		t.Fatalf("wait() = %d, %v; want the cpu error", errCount, err)
+	}
+
+	want := map[string]time.Duration{"gpu": 50 * time.Millisecond, "tcp": 150 * time.Millisecond, "wifi": 400 * time.Millisecond}
+	for name, r := range results {
+		if !errors.Is(r.err, context.DeadlineExceeded) {
+			t.Errorf("%s: ctx.Err() = %v, want deadline exceeded", name, r.err)
+		}
+		// Allow scheduling slack, but not another collector's timeout.
+		if r.elapsed < want[name] || r.elapsed > want[name]+90*time.Millisecond {
+			t.Errorf("%s cancelled after %v, want about %v", name, r.elapsed, want[name])
+		}
+	}
+	if total := time.Since(start); total > 450*time.Millisecond {
+		t.Errorf("group took %v, want it bounded by the 400ms budget", total)
+	}
+}
+
+func TestCollectGroupRecoversPanics(t *testing.T) {
+	g := newCollectGroup(context.Background(), &Collector{})
+	g.run("gpu", func(context.Context) error { panic("boom") })
+	if errCount, err := g.wait(); errCount != 1 || err == nil {
+		t.Fatalf("wait() = %d, %v; want the panic as an error", errCount, err)
+	}
+}
+
+func TestParseCollectorTimeouts(t *testing.T) {
+	got, err := parseCollectorTimeouts("gpu=200ms, containers = 1s")
+	if err != nil || len(got) != 2 || got["gpu"] != 200*time.Millisecond || got["containers"] != time.Second {
+		t.Fatalf("parseCollectorTimeouts() = %v, %v", got, err)
+	}
+	for _, bad := range []string{"gpu", "gpu=fast", "gpu=-1s", "cpu=100ms"} {
+		if _, err := parseCollectorTimeouts(bad); err == nil {
+			t.Errorf("parseCollectorTimeouts(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"sort"
+)
+
+// Capability reports whether a collector can produce data on this host and,
+// when it can't, why.
+type Capability struct {
+	Available bool   `json:"available"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// capabilityProbe checks one collector prerequisite. Probes must be cheap:
+// they run once at startup.
+type capabilityProbe struct {
+	name  string
+	check func() Capability
+}
+
+var capabilityProbes = []capabilityProbe{
+	{"sensors", probeSensors},
+	{"smartctl", probeCommand("smartctl", "install smartmontools for disk health")},
+	{"gpu", probeGPU},
+	{"icmp", probeICMP},
+	{"connections", probeConnections},
+	{"routes", probeRoutes},
+	{"wifi", probeWiFi},
+	{"containers", probeContainers},
+}
+
+// CollectCapabilities runs every probe and returns the results by name. It
+// explains empty sections (no sensors, hidden PIDs) without reading logs.
+func CollectCapabilities() map[string]Capability {
+	return collectCapabilities(capabilityProbes)
+}
+
+func collectCapabilities(probes []capabilityProbe) map[string]Capability {
+	caps := make(map[string]Capability, len(probes))
+	for _, p := range probes {
+		caps[p.name] = runCapabilityProbe(p)
+	}
+	return caps
+}
+
+func runCapabilityProbe(p capabilityProbe) (c Capability) {
+	defer func() {
+		if r := recover(); r != nil {
+			c = Capability{Detail: "probe failed"}
+		}
+	}()
+	return p.check()
+}
+
+// capabilityNames returns the probe names in sorted order for display.
+func capabilityNames(caps map[string]Capability) []string {
+	names := make([]string, 0, len(caps))
+	for name := range caps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func probeCommand(name, hint string) func() Capability {
+	return func() Capability {
+		if commandExists(name) {
+			return Capability{Available: true}
+		}
+		return Capability{Detail: name + " not found; " + hint}
+	}
+}
+
+func probeSensors() Capability {
+	switch runtime.GOOS {
+	case "darwin":
+		return probeCommand("ioreg", "needed for temperatures and power")()
+	case "linux":
+		if len(collectCoreTemps(context.Background())) > 0 {
+			return Capability{Available: true, Detail: "per-core temperatures via coretemp"}
+		}
+		return Capability{Detail: "no coretemp per-core sensors found"}
+	}
+	return Capability{Detail: "thermal sensors are only read on macOS and Linux"}
+}
+
+func probeGPU() Capability {
+	if commandExists("nvidia-smi") {
+		return Capability{Available: true, Detail: "nvidia-smi"}
+	}
+	if runtime.GOOS == "darwin" && commandExists("system_profiler") {
+		return Capability{Available: true, Detail: "system_profiler"}
+	}
+	return Capability{Detail: "no GPU query tool (nvidia-smi) found"}
+}
+
+var listenICMPFunc = func() error {
+	conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func probeICMP() Capability {
+	if listenICMPFunc() == nil {
+		return Capability{Available: true, Detail: "raw socket"}
+	}
+	if commandExists("ping") {
+		return Capability{Available: true, Detail: "via ping; raw ICMP needs root"}
+	}
+	return Capability{Detail: "raw ICMP needs root and ping is missing"}
+}
+
+// probeConnections checks that sockets are listed and attributed to PIDs.
+// Unprivileged users often see other users' sockets without an owner.
+func probeConnections() Capability {
+	conns, err := connectionsFunc("inet")
+	if err != nil {
+		return Capability{Detail: err.Error()}
+	}
+	for _, conn := range conns {
+		if conn.Pid > 0 {
+			return Capability{Available: true}
+		}
+	}
+	if len(conns) == 0 {
+		return Capability{Available: true, Detail: "no open sockets"}
+	}
+	return Capability{Detail: "socket owners hidden; run with sudo for per-process data"}
+}
+
+func probeRoutes() Capability {
+	if runtime.GOOS == "darwin" {
+		return probeCommand("netstat", "needed for routes and uplinks")()
+	}
+	return probeCommand("ip", "install iproute2 for routes and uplinks")()
+}
+
+func probeWiFi() Capability {
+	switch {
+	case runtime.GOOS == "darwin":
+		return probeCommand("networksetup", "needed for the Wi-Fi network name")()
+	case commandExists("nmcli"), commandExists("iwgetid"):
+		return Capability{Available: true}
+	}
+	return Capability{Detail: "nmcli or iwgetid not found"}
+}
+
+func probeContainers() Capability {
+	for _, rt := range containerRuntimes {
+		if rt.available() {
+			return Capability{Available: true, Detail: rt.name}
+		}
+	}
+	return Capability{Detail: "no docker, podman or nerdctl found"}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestCollectCapabilitiesFromProbes(t *testing.T) {
+	probes := []capabilityProbe{
+		{"sensors", func() Capability { return Capability{Available: true} }},
+		{"smartctl", func() Capability { return Capability{Detail: "smartctl not found"} }},
+		{"broken", func() Capability { panic("boom") }},
+	}
+
+	caps := collectCapabilities(probes)
+	if !caps["sensors"].Available {
+		t.Error("sensors should be available")
+	}
+	if c := caps["smartctl"]; c.Available || c.Detail != "smartctl not found" {
+		t.Errorf("smartctl = %+v, want unavailable with detail", c)
+	}
+	if c := caps["broken"]; c.Available || c.Detail != "probe failed" {
+		t.Errorf("panicking probe = %+v, want unavailable", c)
+	}
+}
+
+func TestProbeConnectionsHiddenOwners(t *testing.T) {
+	orig := connectionsFunc
+	t.Cleanup(func() { connectionsFunc = orig })
+
+	connectionsFunc = func(string) ([]net.ConnectionStat, error) {
+		return []net.ConnectionStat{{Status: "ESTABLISHED"}, {Status: "LISTEN"}}, nil
+	}
+	if c := probeConnections(); c.Available {
+		t.Errorf("sockets without PIDs = %+v, want unavailable", c)
+	}
+
+	connectionsFunc = func(string) ([]net.ConnectionStat, error) {
+		return []net.ConnectionStat{{Status: "ESTABLISHED", Pid: 42}}, nil
+	}
+	if c := probeConnections(); !c.Available {
+		t.Errorf("sockets with PIDs = %+v, want available", c)
+	}
+
+	connectionsFunc = func(string) ([]net.ConnectionStat, error) {
+		return nil, errors.New("permission denied")
+	}
+	if c := probeConnections(); c.Available || c.Detail != "permission denied" {
+		t.Errorf("enumeration failure = %+v", c)
+	}
+}
+
+func TestProbeICMPRawSocket(t *testing.T) {
+	orig := listenICMPFunc
+	t.Cleanup(func() { listenICMPFunc = orig })
+
+	listenICMPFunc = func() error { return nil }
+	if c := probeICMP(); !c.Available || c.Detail != "raw socket" {
+		t.Errorf("raw socket allowed = %+v", c)
+	}
+	listenICMPFunc = func() error { return errors.New("operation not permitted") }
+	if c := probeICMP(); c.Detail == "raw socket" {
+		t.Errorf("raw socket denied = %+v, want ping fallback or unavailable", c)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// defaultChangeEpsilonMBs is the smallest per-interface rate change, in
+// MB/s, that counts as a change in changes-only watch mode.
+const defaultChangeEpsilonMBs = 0.1
+
+// compareToPrevious returns what changed meaningfully between two
+// consecutive ticks: interfaces that appeared or disappeared, a proxy
+// toggle, a threshold crossing, new events, alerts raised or cleared, or a
+// rate that moved by more than epsilon MB/s. Changes reuse Deviation, with
+// Baseline holding the previous tick's value.
+func compareToPrevious(prev, cur MetricsSnapshot, epsilon float64) []Deviation {
+	var changes []Deviation
+	add := func(metric string, was, now float64, reason string) {
+		changes = append(changes, Deviation{Metric: metric, Baseline: was, Current: now, Reason: reason})
+	}
+	flag := func(v bool) float64 {
+		if v {
+			return 1
+		}
+		return 0
+	}
+
+	prevNet := make(map[string]NetworkStatus, len(prev.Network))
+	for _, n := range prev.Network {
+		prevNet[n.Name] = n
+	}
+	seen := make(map[string]bool, len(cur.Network))
+	for _, n := range cur.Network {
+		seen[n.Name] = true
+		p, ok := prevNet[n.Name]
+		if !ok {
+			add("network.interface:"+n.Name, 0, 1, "appeared")
+			continue
+		}
+		if d := n.RxRateMBs - p.RxRateMBs; math.Abs(d) > epsilon {
+			add("network.rx:"+n.Name, p.RxRateMBs, n.RxRateMBs, fmt.Sprintf("%+.2f MB/s", d))
+		}
+		if d := n.TxRateMBs - p.TxRateMBs; math.Abs(d) > epsilon {
+			add("network.tx:"+n.Name, p.TxRateMBs, n.TxRateMBs, fmt.Sprintf("%+.2f MB/s", d))
+		}
+		if n.Bursting != p.Bursting {
+			reason := "burst started"
+			if !n.Bursting {
+				reason = "burst ended"
+			}
+			add("network.bursting:"+n.Name, flag(p.Bursting), flag(n.Bursting), reason)
+		}
+	}
+	for _, n := range prev.Network {
+		if !seen[n.Name] {
+			add("network.interface:"+n.Name, 1, 0, "disappeared")
+		}
+	}
+
+	if prev.Proxy.Enabled != cur.Proxy.Enabled || prev.Proxy.Host != cur.Proxy.Host {
+		reason := "proxy changed"
+		switch {
+		case cur.Proxy.Enabled && !prev.Proxy.Enabled:
+			reason = "proxy enabled"
+		case !cur.Proxy.Enabled && prev.Proxy.Enabled:
+			reason = "proxy disabled"
+		}
+		add("proxy.enabled", flag(prev.Proxy.Enabled), flag(cur.Proxy.Enabled), reason)
+	}
+
+	if was, now := prev.TCP.RetransPercent >= tcpRetransWarnPercent, cur.TCP.RetransPercent >= tcpRetransWarnPercent; was != now {
+		add("tcp.retrans_percent", prev.TCP.RetransPercent, cur.TCP.RetransPercent,
+			fmt.Sprintf("crossed %.0f%%", tcpRetransWarnPercent))
+	}
+
+	if d := cur.DiskIO.ReadRate - prev.DiskIO.ReadRate; math.Abs(d) > epsilon {
+		add("disk_io.read", prev.DiskIO.ReadRate, cur.DiskIO.ReadRate, fmt.Sprintf("%+.2f MB/s", d))
+	}
+	if d := cur.DiskIO.WriteRate - prev.DiskIO.WriteRate; math.Abs(d) > epsilon {
+		add("disk_io.write", prev.DiskIO.WriteRate, cur.DiskIO.WriteRate, fmt.Sprintf("%+.2f MB/s", d))
+	}
+
+	// Events already describe changes since the previous tick.
+	if len(cur.Events) > 0 {
+		add("events", 0, float64(len(cur.Events)), "new events")
+	}
+
+	// Alerts hold for as long as their condition does, so only a change in
+	// the set counts. A level change shows up as one cleared, one raised.
+	prevAlerts := make(map[string]bool, len(prev.Alerts))
+	for _, a := range prev.Alerts {
+		prevAlerts[alertKey(a)] = true
+	}
+	curAlerts := make(map[string]bool, len(cur.Alerts))
+	for _, a := range cur.Alerts {
+		curAlerts[alertKey(a)] = true
+		if !prevAlerts[alertKey(a)] {
+			add("alert:"+alertKey(a), 0, 1, "raised")
+		}
+	}
+	for _, a := range prev.Alerts {
+		if !curAlerts[alertKey(a)] {
+			add("alert:"+alertKey(a), 1, 0, "cleared")
+		}
+	}
+	return changes
+}
+
+// alertKey identifies an alert across ticks by metric, subject and level;
+// its value and message move with the reading.
+func alertKey(a Alert) string {
+	return a.Metric + ":" + a.Subject + ":" + a.Level
+}
+
+// changesOnlySink forwards a snapshot to sinks only when it differs
+// meaningfully from the previous tick, with the differences in Changes.
+// The first snapshot is always forwarded.
+func changesOnlySink(epsilon float64, sinks ...Sink) Sink {
+	var prev *MetricsSnapshot
+	return func(m MetricsSnapshot) error {
+		first := prev == nil
+		var changes []Deviation
+		if !first {
+			changes = compareToPrevious(*prev, m, epsilon)
+		}
+		last := m
+		prev = &last
+		if !first && len(changes) == 0 {
+			return nil
+		}
+		m.Changes = changes
+		return errors.Join(publishSnapshot(m, sinks...)...)
+	}
+}
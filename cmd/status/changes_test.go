@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+func TestChangesOnlySinkSkipsUnchangedTicks(t *testing.T) {
+	var got []MetricsSnapshot
+	sink := changesOnlySink(defaultChangeEpsilonMBs, func(m MetricsSnapshot) error {
+		got = append(got, m)
+		return nil
+	})
+	tick := MetricsSnapshot{Network: []NetworkStatus{{Name: "en0", RxRateMBs: 1.00, TxRateMBs: 0.20}}}
+
+	for range 3 {
+		if err := sink(tick); err != nil {
+			t.Fatalf("sink: %v", err)
+		}
+	}
+	if len(got) != 1 {
+		t.Fatalf("want only the first tick, got %d", len(got))
+	}
+
+	jitter := tick
+	jitter.Network = []NetworkStatus{{Name: "en0", RxRateMBs: 1.05, TxRateMBs: 0.22}}
+	_ = sink(jitter)
+	if len(got) != 1 {
+		t.Fatalf("rate jitter below epsilon should not emit: %+v", got[len(got)-1].Changes)
+	}
+
+	moved := tick
+	moved.Network = []NetworkStatus{{Name: "en0", RxRateMBs: 3, TxRateMBs: 0.22}}
+	_ = sink(moved)
+	if len(got) != 2 {
+		t.Fatalf("rate change should emit, got %d snapshots", len(got))
+	}
+	if c := got[1].Changes; len(c) != 1 || c[0].Metric != "network.rx:en0" || c[0].Baseline != 1.05 || c[0].Current != 3 {
+		t.Fatalf("changes = %+v", c)
+	}
+}
+
+func TestCompareToPrevious(t *testing.T) {
+	prev := MetricsSnapshot{
+		Network: []NetworkStatus{{Name: "en0"}, {Name: "utun3"}},
+		TCP:     TCPStatus{RetransPercent: 0.5},
+	}
+	cur := MetricsSnapshot{
+		Network: []NetworkStatus{{Name: "en0", Bursting: true}, {Name: "en7"}},
+		Proxy:   ProxyStatus{Enabled: true, Type: "HTTP", Host: "127.0.0.1:7890"},
+		TCP:     TCPStatus{RetransPercent: 4},
+	}
+	want := map[string]string{
+		"network.bursting:en0":    "burst started",
+		"network.interface:en7":   "appeared",
+		"network.interface:utun3": "disappeared",
+		"proxy.enabled":           "proxy enabled",
+		"tcp.retrans_percent":     "crossed 2%",
+	}
+	changes := compareToPrevious(prev, cur, defaultChangeEpsilonMBs)
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	for _, c := range changes {
+		if want[c.Metric] != c.Reason {
+			t.Errorf("%s: reason %q, want %q", c.Metric, c.Reason, want[c.Metric])
+		}
+	}
+
+	if changes := compareToPrevious(cur, cur, defaultChangeEpsilonMBs); len(changes) != 0 {
+		t.Fatalf("identical ticks should not differ: %+v", changes)
+	}
+}
+
+func TestCompareToPreviousAlertSets(t *testing.T) {
+	full := Alert{Metric: "disk.used_percent", Subject: "/", Level: "warning", Value: 91}
+	prev := MetricsSnapshot{Alerts: []Alert{full, {Metric: "network.flapping", Subject: "en0", Level: "warning"}}}
+
+	// The same alert with a moved value is not a change.
+	cur := MetricsSnapshot{Alerts: []Alert{{Metric: "disk.used_percent", Subject: "/", Level: "warning", Value: 92}, prev.Alerts[1]}}
+	if changes := compareToPrevious(prev, cur, defaultChangeEpsilonMBs); len(changes) != 0 {
+		t.Fatalf("standing alerts should not differ: %+v", changes)
+	}
+
+	cur = MetricsSnapshot{Alerts: []Alert{{Metric: "disk.used_percent", Subject: "/", Level: "critical", Value: 97}}}
+	want := map[string]string{
+		"alert:disk.used_percent:/:critical": "raised",
+		"alert:disk.used_percent:/:warning":  "cleared",
+		"alert:network.flapping:en0:warning": "cleared",
+	}
+	changes := compareToPrevious(prev, cur, defaultChangeEpsilonMBs)
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	for _, c := range changes {
+		if want[c.Metric] != c.Reason {
+			t.Errorf("%s: reason %q, want %q", c.Metric, c.Reason, want[c.Metric])
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import "time"
+
+// DryRunReport is a collector's effective configuration and the collectors
+// a Collect call would run, produced without collecting anything.
+type DryRunReport struct {
+	Interval         string         `json:"interval"`
+	Order            string         `json:"order"`
+	DiskSort         string         `json:"disk_sort"`
+	LowPower         bool           `json:"low_power"`
+	IncludeLoopback  bool           `json:"include_loopback"`
+	PrimaryInterface string         `json:"primary_interface,omitempty"`
+	SkipDormantAfter int            `json:"skip_dormant_after,omitempty"`
+	MinRateMBs       float64        `json:"min_rate_mbs,omitempty"`
+	AggregateSamples int            `json:"aggregate_samples,omitempty"`
+	ResolveRemotes   bool           `json:"resolve_remotes"`
+	HealthWeights    HealthWeights  `json:"health_weights"`
+	TopN             map[string]int `json:"top_n"`
+	// InterfaceDeny lists name prefixes hidden from the network section;
+	// InterfaceAllow lists exceptions to it.
+	InterfaceDeny    []string           `json:"interface_deny"`
+	InterfaceAllow   []string           `json:"interface_allow,omitempty"`
+	Quotas           []Quota            `json:"quotas,omitempty"`
+	QuotaStatePath   string             `json:"quota_state_path,omitempty"`
+	Probe            *ProbeConfig       `json:"probe,omitempty"`
+	UploadAlertRatio float64            `json:"upload_alert_ratio,omitempty"`
+	AlertClearMargin float64            `json:"alert_clear_margin,omitempty"`
+	ServerInterfaces []string           `json:"server_interfaces,omitempty"`
+	StartedAfter     *time.Time         `json:"started_after,omitempty"`
+	MaxSeries        int                `json:"max_series"`
+	Collectors       []PlannedCollector `json:"collectors"`
+}
+
+// PlannedCollector says whether one collector would run and, if not, why.
+type PlannedCollector struct {
+	Name   string `json:"name"`
+	Runs   bool   `json:"runs"`
+	Reason string `json:"reason,omitempty"`
+	// Timeout is the collector's limit per snapshot, for collectors that
+	// take one and have one set.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// DryRun reports the effective configuration and collector plan, so users
+// can check that flags and the prefs file were parsed as intended. The plan
+// mirrors the gating in Collect; keep the two in sync.
+func (c *Collector) DryRun() DryRunReport {
+	r := DryRunReport{
+		Interval:         c.interval().String(),
+		Order:            c.Order.String(),
+		DiskSort:         c.DiskSort.String(),
+		LowPower:         c.LowPower,
+		IncludeLoopback:  c.IncludeLoopback,
+		PrimaryInterface: c.PrimaryInterface,
+		SkipDormantAfter: c.SkipDormantAfter,
+		MinRateMBs:       c.MinRateMBs,
+		AggregateSamples: c.AggregateSamples,
+		ResolveRemotes:   c.ResolveRemotes,
+		HealthWeights:    c.healthWeights(),
+		TopN: map[string]int{
+			"interfaces":   maxTopInterfaces,
+			"processes":    maxTopProcesses,
+			"remote_hosts": maxRemoteHosts,
+		},
+		InterfaceDeny:    noiseInterfacePrefixes,
+		Quotas:           c.Quotas,
+		QuotaStatePath:   c.QuotaStatePath,
+		Probe:            c.Probe,
+		UploadAlertRatio: c.UploadAlertRatio,
+		AlertClearMargin: c.AlertClearMargin,
+		ServerInterfaces: c.ServerInterfaces,
+		MaxSeries:        c.maxSeries(),
+	}
+	if c.IncludeLoopback {
+		r.InterfaceAllow = []string{"loopback"}
+	}
+	if c.PrimaryInterface != "" {
+		r.InterfaceAllow = append(r.InterfaceAllow, c.PrimaryInterface)
+	}
+	if !c.StartedAfter.IsZero() {
+		after := c.StartedAfter
+		r.StartedAfter = &after
+	}
+
+	always := []string{"cpu", "memory", "disks", "disk_io", "network", "proxy", "batteries"}
+	for _, name := range always {
+		r.Collectors = append(r.Collectors, PlannedCollector{Name: name, Runs: true})
+	}
+	heavy := []string{"thermal", "core_temps", "gpu", "bluetooth", "top_processes", "process_io", "routes", "wifi", "storage_arrays", "network_mounts", "listeners", "nic_drivers", "containers", "container_network", "tcp", "ip_families", "firewall", "power_assertions", "process_counts"}
+	for _, name := range heavy {
+		p := PlannedCollector{Name: name, Runs: !c.LowPower}
+		if c.LowPower {
+			p.Reason = "low power"
+		}
+		r.Collectors = append(r.Collectors, p)
+	}
+
+	probe := PlannedCollector{Name: "connectivity", Runs: c.Probe != nil && !c.LowPower}
+	switch {
+	case c.Probe == nil:
+		probe.Reason = "no probe configured"
+	case c.LowPower:
+		probe.Reason = "low power"
+	}
+	r.Collectors = append(r.Collectors, probe)
+
+	neighbors := PlannedCollector{Name: "neighbors", Runs: c.Neighbors && !c.LowPower}
+	switch {
+	case !c.Neighbors:
+		neighbors.Reason = "not enabled"
+	case c.LowPower:
+		neighbors.Reason = "low power"
+	}
+	r.Collectors = append(r.Collectors, neighbors)
+
+	timeSync := PlannedCollector{Name: "time_sync", Runs: c.TimeSync && !c.LowPower}
+	switch {
+	case !c.TimeSync:
+		timeSync.Reason = "not enabled"
+	case c.LowPower:
+		timeSync.Reason = "low power"
+	}
+	r.Collectors = append(r.Collectors, timeSync)
+
+	updates := PlannedCollector{Name: "updates", Runs: c.Updates && !c.LowPower}
+	switch {
+	case !c.Updates:
+		updates.Reason = "not enabled"
+	case c.LowPower:
+		updates.Reason = "low power"
+	}
+	r.Collectors = append(r.Collectors, updates)
+
+	quota := PlannedCollector{Name: "quotas", Runs: len(c.Quotas) > 0}
+	if !quota.Runs {
+		quota.Reason = "no quotas configured"
+	}
+	r.Collectors = append(r.Collectors, quota)
+
+	upload := PlannedCollector{Name: "upload_alert", Runs: c.UploadAlertRatio > 0}
+	if !upload.Runs {
+		upload.Reason = "ratio not set"
+	}
+	r.Collectors = append(r.Collectors, upload)
+
+	flap := PlannedCollector{Name: "flap_alert", Runs: c.FlapAlertCount > 0}
+	if !flap.Runs {
+		flap.Reason = "count not set"
+	}
+	r.Collectors = append(r.Collectors, flap)
+
+	for i, p := range r.Collectors {
+		if d := c.collectorTimeout(p.Name); d > 0 {
+			r.Collectors[i].Timeout = d.String()
+		}
+	}
+	return r
+}
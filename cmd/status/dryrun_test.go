@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestDryRunReflectsLoadedConfig(t *testing.T) {
+	prefs := parsePrefs("health_weight_cpu=50\nquota.en0=50GB reset=3 alert=80\n")
+	weights, err := healthWeightsFromPrefs(prefs)
+	if err != nil {
+		t.Fatalf("healthWeightsFromPrefs: %v", err)
+	}
+	quotas, err := quotasFromPrefs(prefs)
+	if err != nil {
+		t.Fatalf("quotasFromPrefs: %v", err)
+	}
+
+	c := NewCollector()
+	c.HealthWeights = &weights
+	c.Quotas = quotas
+	c.LowPower = true
+	c.IncludeLoopback = true
+	c.Order = OrderStable
+
+	r := c.DryRun()
+	if r.HealthWeights.CPU != 50 || r.HealthWeights.Memory != defaultHealthWeights.Memory {
+		t.Fatalf("health weights = %+v, want cpu=50 and default memory", r.HealthWeights)
+	}
+	if len(r.Quotas) != 1 || r.Quotas[0].Interface != "en0" || r.Quotas[0].ResetDay != 3 {
+		t.Fatalf("quotas = %+v", r.Quotas)
+	}
+	if r.Order != "stable" || r.TopN["interfaces"] != maxTopInterfaces || r.MaxSeries != defaultMaxSeries {
+		t.Fatalf("order/topN/maxSeries = %q %v %d", r.Order, r.TopN, r.MaxSeries)
+	}
+	if len(r.InterfaceAllow) != 1 || len(r.InterfaceDeny) == 0 {
+		t.Fatalf("allow/deny = %v / %v", r.InterfaceAllow, r.InterfaceDeny)
+	}
+
+	plan := make(map[string]PlannedCollector)
+	for _, p := range r.Collectors {
+		plan[p.Name] = p
+	}
+	if !plan["cpu"].Runs || !plan["quotas"].Runs {
+		t.Errorf("cpu and quotas should run: %+v %+v", plan["cpu"], plan["quotas"])
+	}
+	if plan["gpu"].Runs || plan["gpu"].Reason != "low power" {
+		t.Errorf("gpu = %+v, want skipped for low power", plan["gpu"])
+	}
+	if plan["connectivity"].Runs || plan["connectivity"].Reason != "no probe configured" {
+		t.Errorf("connectivity = %+v", plan["connectivity"])
+	}
+}
+
+func TestDryRunKeepsAllZeroHealthWeights(t *testing.T) {
+	c := NewCollector()
+	if got := c.DryRun().HealthWeights; got != defaultHealthWeights {
+		t.Fatalf("unset weights = %+v, want the defaults", got)
+	}
+	c.HealthWeights = &HealthWeights{}
+	if got := c.DryRun().HealthWeights; got != (HealthWeights{}) {
+		t.Fatalf("all-zero weights = %+v, want them kept", got)
+	}
+}
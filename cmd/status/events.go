@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event kinds.
+const (
+	EventIPChanged      = "ip_changed"
+	EventNetworkChanged = "network_changed" // Different Wi-Fi network or gateway
+)
+
+// Event is a noteworthy change detected between two collections.
+type Event struct {
+	At        time.Time `json:"at"`
+	Kind      string    `json:"kind"`
+	Interface string    `json:"interface,omitempty"`
+	Old       string    `json:"old"` // Empty when the interface gained an address
+	New       string    `json:"new"` // Empty when it lost one
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case EventIPChanged:
+		switch {
+		case e.Old == "":
+			return fmt.Sprintf("%s got IP %s", e.Interface, e.New)
+		case e.New == "":
+			return fmt.Sprintf("%s lost IP %s", e.Interface, e.Old)
+		}
+		return fmt.Sprintf("%s IP %s → %s", e.Interface, e.Old, e.New)
+	case EventNetworkChanged:
+		return fmt.Sprintf("network %s → %s", orOffline(e.Old), orOffline(e.New))
+	}
+	return fmt.Sprintf("%s %s: %s → %s", e.Kind, e.Interface, e.Old, e.New)
+}
+
+// trackIPChanges compares interface addresses against the previous call and
+// returns an event per interface whose primary IP changed, appeared or went
+// away. The first call only records state. An empty info map is treated as
+// a failed lookup rather than every interface losing its address.
+func (c *Collector) trackIPChanges(now time.Time, info map[string]interfaceInfo) []Event {
+	if len(info) == 0 {
+		return nil
+	}
+	current := make(map[string]string, len(info))
+	for name, i := range info {
+		if isLoopbackInterface(name) || i.IP == "" {
+			continue
+		}
+		current[name] = i.IP
+	}
+
+	prev := c.prevIPs
+	c.prevIPs = current
+	if prev == nil {
+		return nil
+	}
+
+	var events []Event
+	for name, ip := range current {
+		if old := prev[name]; old != ip {
+			events = append(events, Event{At: now, Kind: EventIPChanged, Interface: name, Old: old, New: ip})
+		}
+	}
+	for name, old := range prev {
+		if _, ok := current[name]; !ok {
+			events = append(events, Event{At: now, Kind: EventIPChanged, Interface: name, Old: old})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Interface < events[j].Interface })
+	return events
+}
+
+func orOffline(fingerprint string) string {
+	if fingerprint == "" {
+		return "offline"
+	}
+	return fingerprint
+}
+
+// networkFingerprint identifies the network the host is attached to: the
+// Wi-Fi SSID if associated, otherwise the wired gateway interface, plus the
+// default gateway. Empty means no default route and no Wi-Fi.
+func networkFingerprint(wifi WiFiStatus, routes RouteSummary) string {
+	var parts []string
+	switch {
+	case wifi.Connected:
+		parts = append(parts, fmt.Sprintf("Wi-Fi %q", wifi.SSID))
+	case routes.GatewayInterface != "":
+		parts = append(parts, "wired "+routes.GatewayInterface)
+	}
+	if routes.DefaultGateway != "" {
+		parts = append(parts, "via "+routes.DefaultGateway)
+	}
+	return strings.Join(parts, " ")
+}
+
+// trackNetworkChange emits a network_changed event when the fingerprint
+// differs from the previous call. The first call only records state.
+func (c *Collector) trackNetworkChange(now time.Time, wifi WiFiStatus, routes RouteSummary) []Event {
+	fp := networkFingerprint(wifi, routes)
+	prev, seen := c.prevFingerprint, c.fingerprintSeen
+	c.prevFingerprint, c.fingerprintSeen = fp, true
+	if !seen || fp == prev {
+		return nil
+	}
+	return []Event{{At: now, Kind: EventNetworkChanged, Old: prev, New: fp}}
+}
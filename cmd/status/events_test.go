@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackIPChanges(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+
+	first := map[string]interfaceInfo{
+		"en0": {IP: "192.168.1.20"},
+		"en1": {IP: "10.0.0.5"},
+		"lo0": {IP: "127.0.0.1"},
+	}
+	if events := c.trackIPChanges(start, first); len(events) != 0 {
+		t.Fatalf("first tick should only record state, got %v", events)
+	}
+
+	// DHCP renews en0, en1 drops its lease and a VPN tunnel comes up.
+	second := map[string]interfaceInfo{
+		"en0":   {IP: "192.168.1.42"},
+		"en1":   {},
+		"lo0":   {IP: "127.0.0.1"},
+		"utun4": {IP: "100.64.0.2"},
+	}
+	events := c.trackIPChanges(start.Add(time.Second), second)
+	want := []Event{
+		{Kind: EventIPChanged, Interface: "en0", Old: "192.168.1.20", New: "192.168.1.42"},
+		{Kind: EventIPChanged, Interface: "en1", Old: "10.0.0.5"},
+		{Kind: EventIPChanged, Interface: "utun4", New: "100.64.0.2"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		got := events[i]
+		got.At = time.Time{}
+		if got != w {
+			t.Errorf("event %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	if events := c.trackIPChanges(start.Add(2*time.Second), second); len(events) != 0 {
+		t.Fatalf("unchanged addresses should not emit events, got %v", events)
+	}
+	if events := c.trackIPChanges(start.Add(3*time.Second), nil); len(events) != 0 {
+		t.Fatalf("failed lookup should not emit events, got %v", events)
+	}
+}
+
+func TestEventString(t *testing.T) {
+	e := Event{Kind: EventIPChanged, Interface: "en0", Old: "10.0.0.1", New: "10.0.0.2"}
+	if got := e.String(); got != "en0 IP 10.0.0.1 → 10.0.0.2" {
+		t.Fatalf("String() = %q", got)
+	}
+}
+
+func TestTrackNetworkChange(t *testing.T) {
+	c := NewCollector()
+	now := time.Now()
+	home := RouteSummary{DefaultGateway: "192.168.1.1", GatewayInterface: "en0"}
+
+	if events := c.trackNetworkChange(now, WiFiStatus{Connected: true, SSID: "Home"}, home); len(events) != 0 {
+		t.Fatalf("first tick should only record state, got %v", events)
+	}
+
+	// Roaming to another SSID.
+	events := c.trackNetworkChange(now, WiFiStatus{Connected: true, SSID: "Office"}, home)
+	if len(events) != 1 || events[0].Kind != EventNetworkChanged ||
+		events[0].Old != `Wi-Fi "Home" via 192.168.1.1` || events[0].New != `Wi-Fi "Office" via 192.168.1.1` {
+		t.Fatalf("expected SSID change event, got %+v", events)
+	}
+
+	// Same SSID, new gateway (e.g. a different access point's subnet).
+	office := RouteSummary{DefaultGateway: "10.0.0.1", GatewayInterface: "en0"}
+	events = c.trackNetworkChange(now, WiFiStatus{Connected: true, SSID: "Office"}, office)
+	if len(events) != 1 || events[0].New != `Wi-Fi "Office" via 10.0.0.1` {
+		t.Fatalf("expected gateway change event, got %+v", events)
+	}
+
+	// Docking: Wi-Fi drops and a wired uplink takes over.
+	wired := RouteSummary{DefaultGateway: "10.0.0.1", GatewayInterface: "en7"}
+	events = c.trackNetworkChange(now, WiFiStatus{}, wired)
+	if len(events) != 1 || events[0].New != "wired en7 via 10.0.0.1" {
+		t.Fatalf("expected wired transition event, got %+v", events)
+	}
+
+	if events := c.trackNetworkChange(now, WiFiStatus{}, wired); len(events) != 0 {
+		t.Fatalf("unchanged network should not emit events, got %v", events)
+	}
+
+	events = c.trackNetworkChange(now, WiFiStatus{}, RouteSummary{})
+	if len(events) != 1 || events[0].String() != "network wired en7 via 10.0.0.1 → offline" {
+		t.Fatalf("expected offline event, got %v", events)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fleet collection defaults.
+const (
+	defaultFleetCommand     = "mo status -json"
+	defaultFleetTimeout     = 15 * time.Second
+	defaultFleetConcurrency = 8
+	fleetConnectTimeoutSecs = 5
+)
+
+// FleetOptions configures CollectFleet. The zero value runs
+// defaultFleetCommand on up to defaultFleetConcurrency hosts at a time, each
+// bounded by defaultFleetTimeout.
+type FleetOptions struct {
+	Command     string        // Remote command printing JSON snapshots
+	Timeout     time.Duration // Per host, including the SSH handshake
+	Concurrency int
+}
+
+// CollectFleet runs the status command on each host over the system ssh
+// client and returns the snapshots that came back, keyed by host. Hosts that
+// fail or time out are left out of the map and reported together in the
+// error, so a partial fleet still returns what it collected. ssh runs in
+// batch mode: keys or an agent must already be set up.
+func CollectFleet(ctx context.Context, hosts []string, opts FleetOptions) (map[string]MetricsSnapshot, error) {
+	if opts.Command == "" {
+		opts.Command = defaultFleetCommand
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultFleetTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultFleetConcurrency
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		snaps  = make(map[string]MetricsSnapshot, len(hosts))
+		failed = make(map[string]error)
+		slots  = make(chan struct{}, opts.Concurrency)
+	)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			snap, err := collectRemote(ctx, host, opts)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[host] = err
+				return
+			}
+			snaps[host] = snap
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) == 0 {
+		return snaps, nil
+	}
+	names := make([]string, 0, len(failed))
+	for host := range failed {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	errs := make([]error, 0, len(names))
+	for _, host := range names {
+		errs = append(errs, fmt.Errorf("%s: %w", host, failed[host]))
+	}
+	return snaps, errors.Join(errs...)
+}
+
+func collectRemote(ctx context.Context, host string, opts FleetOptions) (MetricsSnapshot, error) {
+	// A host list entry must never be read by ssh as an option such as
+	// -oProxyCommand.
+	if strings.HasPrefix(host, "-") {
+		return MetricsSnapshot{}, errors.New("host name must not start with '-'")
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	out, err := runCmd(ctx, "ssh",
+		"-o", "BatchMode=yes",
+		"-o", fmt.Sprintf("ConnectTimeout=%d", fleetConnectTimeoutSecs),
+		"--", host, opts.Command)
+	if ctx.Err() != nil {
+		return MetricsSnapshot{}, fmt.Errorf("timed out after %s", opts.Timeout)
+	}
+	if err != nil {
+		return MetricsSnapshot{}, err
+	}
+	return parseRemoteSnapshot(out)
+}
+
+// parseRemoteSnapshot returns the last JSON snapshot in out, skipping any
+// banner or MOTD lines the remote shell printed first. Snapshots may be
+// indented across several lines, as mo status -json prints them, or one per
+// line as with -jsonl.
+func parseRemoteSnapshot(out string) (MetricsSnapshot, error) {
+	err := errors.New("no snapshot in remote output")
+	offset := 0
+	for line := range strings.Lines(out) {
+		start := offset
+		offset += len(line)
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			continue
+		}
+		// A banner line may open a brace too; if it does not decode, try
+		// the next line that opens an object.
+		snap, decodeErr := decodeSnapshots(out[start:])
+		if decodeErr == nil {
+			return snap, nil
+		}
+		err = fmt.Errorf("parse remote snapshot: %w", decodeErr)
+	}
+	return MetricsSnapshot{}, err
+}
+
+// decodeSnapshots decodes consecutive JSON snapshots from the start of s and
+// returns the last one. Anything after the final complete snapshot is
+// ignored.
+func decodeSnapshots(s string) (MetricsSnapshot, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	var snap MetricsSnapshot
+	if err := dec.Decode(&snap); err != nil {
+		return MetricsSnapshot{}, err
+	}
+	for {
+		var next MetricsSnapshot
+		if dec.Decode(&next) != nil {
+			return snap, nil
+		}
+		snap = next
+	}
+}
+
+// loadFleetHosts splits the -fleet value into host names. "@file" reads one
+// host per line, ignoring blank lines and # comments.
+func loadFleetHosts(value string) ([]string, error) {
+	var hosts []string
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			if line = strings.TrimSpace(line); line != "" {
+				hosts = append(hosts, line)
+			}
+		}
+		return hosts, scanner.Err()
+	}
+	for host := range strings.SplitSeq(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
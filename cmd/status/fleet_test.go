@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectFleetPartialFailure(t *testing.T) {
+	orig := runCmd
+	t.Cleanup(func() { runCmd = orig })
+	runCmd = func(ctx context.Context, name string, args ...string) (string, error) {
+		if name != "ssh" || !slices.Contains(args, "BatchMode=yes") {
+			t.Errorf("unexpected command %s %v", name, args)
+		}
+		if args[len(args)-3] != "--" {
+			t.Errorf("host not separated from ssh options: %v", args)
+		}
+		host, command := args[len(args)-2], args[len(args)-1]
+		if strings.HasPrefix(host, "-") {
+			t.Errorf("ssh ran with option-like host %q", host)
+		}
+		if command != defaultFleetCommand {
+			t.Errorf("remote command = %q", command)
+		}
+		switch host {
+		case "web1":
+			return "Welcome to web1\n" + `{"schema_version":"1.1.0","host":"web1","cpu":{"usage":12.5}}` + "\n", nil
+		case "web2":
+			// With several snapshots, the last one wins.
+			return `{"host":"web2","cpu":{"usage":0}}` + "\n" + `{"host":"web2","cpu":{"usage":40}}` + "\n", nil
+		case "db1":
+			return "", errors.New("exit status 255")
+		case "slow":
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "bash: mo: command not found\n", nil
+	}
+
+	snaps, err := CollectFleet(context.Background(), []string{"web1", "web2", "db1", "slow", "bare", "-oProxyCommand=touch /tmp/pwned"},
+		FleetOptions{Timeout: 50 * time.Millisecond})
+	if len(snaps) != 2 || snaps["web1"].CPU.Usage != 12.5 || snaps["web2"].CPU.Usage != 40 {
+		t.Fatalf("snapshots = %+v", snaps)
+	}
+	if err == nil {
+		t.Fatal("expected an error naming the failed hosts")
+	}
+	msg := err.Error()
+	for _, want := range []string{"bare: no snapshot", "db1: exit status 255", "slow: timed out", "must not start with '-'"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q missing %q", msg, want)
+		}
+	}
+}
+
+func TestParseRemoteSnapshotIndented(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("Last login: Mon Oct 12 09:00:00 2026\n{ maintenance tonight }\n")
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(MetricsSnapshot{Host: "web1", CPU: CPUStatus{Usage: 12.5}}); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := parseRemoteSnapshot(buf.String())
+	if err != nil {
+		t.Fatalf("parseRemoteSnapshot: %v", err)
+	}
+	if snap.Host != "web1" || snap.CPU.Usage != 12.5 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+}
+
+func TestLoadFleetHosts(t *testing.T) {
+	hosts, err := loadFleetHosts(" web1, web2 ,,db1")
+	if err != nil || !slices.Equal(hosts, []string{"web1", "web2", "db1"}) {
+		t.Fatalf("loadFleetHosts(list) = %v, %v", hosts, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte("# fleet\nweb1\n\nadmin@db1 # primary\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err = loadFleetHosts("@" + path)
+	if err != nil || !slices.Equal(hosts, []string{"web1", "admin@db1"}) {
+		t.Fatalf("loadFleetHosts(@file) = %v, %v", hosts, err)
+	}
+}
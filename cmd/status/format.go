@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// UnitMode selects binary (1024) or decimal (1000) steps when scaling rates.
+type UnitMode int
+
+const (
+	UnitsBinary UnitMode = iota
+	UnitsDecimal
+)
+
+// parseUnitMode maps the -units flag value to a UnitMode.
+func parseUnitMode(s string) (UnitMode, error) {
+	switch s {
+	case "", "binary":
+		return UnitsBinary, nil
+	case "decimal", "si":
+		return UnitsDecimal, nil
+	}
+	return UnitsBinary, fmt.Errorf("unknown unit mode %q (want binary or decimal)", s)
+}
+
+// NumberFormat sets the separators used for human-readable numbers. The zero
+// value renders plain "1234.5". JSON output is never affected.
+type NumberFormat struct {
+	Grouping string // Thousands separator; empty disables grouping
+	Decimal  string // Decimal separator; empty means "."
+}
+
+// Validate rejects separator pairs that would make numbers ambiguous.
+func (f NumberFormat) Validate() error {
+	if dec := f.decimal(); dec == f.Grouping {
+		return fmt.Errorf("decimal and grouping separators must differ (both %q)", dec)
+	}
+	return nil
+}
+
+// decimal is the effective decimal separator.
+func (f NumberFormat) decimal() string {
+	if f.Decimal == "" {
+		return "."
+	}
+	return f.Decimal
+}
+
+// format renders v with prec decimals using f's separators.
+func (f NumberFormat) format(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if f == (NumberFormat{}) {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+	if f.Grouping != "" && len(intPart) > 3 {
+		var b strings.Builder
+		lead := len(intPart) % 3
+		if lead > 0 {
+			b.WriteString(intPart[:lead])
+		}
+		for i := lead; i < len(intPart); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(f.Grouping)
+			}
+			b.WriteString(intPart[i : i+3])
+		}
+		intPart = b.String()
+	}
+	if !hasFrac {
+		return sign + intPart
+	}
+	return sign + intPart + f.decimal() + frac
+}
+
+// FormatOptions controls the human-readable (compact) formatter.
+type FormatOptions struct {
+	Units   UnitMode
+	Numbers NumberFormat
+	// DisplayNames maps interface names to labels such as "en0" to
+	// "WiFi". Only rendering uses them; snapshots keep the kernel names.
+	DisplayNames map[string]string
+	// Spark selects the sparkline glyphs.
+	Spark SparkStyle
+}
+
+// interfaceLabel returns the display name configured for an interface,
+// else a label for its role when the name gives it away, else the name.
+// The role is qualified with the name, "WiFi (wlp2s0)", when another of
+// stats would fall back to the same role.
+func (o FormatOptions) interfaceLabel(name string, stats []NetworkStatus) string {
+	if label := o.DisplayNames[name]; label != "" {
+		return label
+	}
+	role := interfaceRole(name)
+	if role == "" {
+		return name
+	}
+	for _, n := range stats {
+		if n.Name != name && !n.Loopback && o.DisplayNames[n.Name] == "" && interfaceRole(n.Name) == role {
+			return role + " (" + name + ")"
+		}
+	}
+	return role
+}
+
+// interfaceRole guesses an interface's role from its name. macOS "enN"
+// can be WiFi or Ethernet, so it is left alone; Linux predictable names
+// (enp3s0, wlp2s0) and tunnel drivers are unambiguous.
+func interfaceRole(name string) string {
+	hasAny := func(prefixes ...string) bool {
+		return slices.ContainsFunc(prefixes, func(p string) bool { return strings.HasPrefix(name, p) })
+	}
+	switch {
+	case hasAny("wlp", "wlan", "wlx"):
+		return "WiFi"
+	case hasAny("enp", "eno", "ens", "enx", "eth"):
+		return "Ethernet"
+	case hasAny("utun", "tun", "tap", "wg", "ppp", "ipsec", "tailscale"):
+		return "VPN"
+	}
+	return ""
+}
+
+// parseDisplayNames parses -display-names, "en0=WiFi,utun3=Work VPN".
+func parseDisplayNames(s string) (map[string]string, error) {
+	names := make(map[string]string)
+	for pair := range strings.SplitSeq(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		iface, label, ok := strings.Cut(pair, "=")
+		iface, label = strings.TrimSpace(iface), strings.TrimSpace(label)
+		if !ok || iface == "" || label == "" {
+			return nil, fmt.Errorf("display name %q: want interface=label", pair)
+		}
+		names[iface] = label
+	}
+	return names, nil
+}
+
+// formatScaledRate renders a MB/s rate (as collected, 1024-based) in the
+// KB/MB/GB unit that keeps the value readable, with one decimal.
+func formatScaledRate(mbs float64, mode UnitMode) string {
+	return formatRateWith(mbs, FormatOptions{Units: mode})
+}
+
+func formatRateWith(mbs float64, opts FormatOptions) string {
+	value, unit := scaleRate(mbs, opts.Units)
+	return opts.Numbers.format(value, 1) + " " + unit
+}
+
+// formatLinkRateWith renders a MB/s rate in the unit that suits a link of
+// linkMbps, so readings on one link keep the same unit as traffic rises and
+// falls. Unknown speeds fall back to scaling by magnitude.
+func formatLinkRateWith(mbs float64, linkMbps int, opts FormatOptions) string {
+	unit := linkRateUnit(linkMbps)
+	if unit == "" {
+		return formatRateWith(mbs, opts)
+	}
+	step := 1024.0
+	if opts.Units == UnitsDecimal {
+		step = 1000
+	}
+	value := mbs * 1024 * 1024 / step // KB/s
+	switch unit {
+	case "MB/s":
+		value /= step
+	case "GB/s":
+		value /= step * step
+	}
+	return opts.Numbers.format(value, 1) + " " + unit
+}
+
+// linkRateUnit picks the unit that shows a link's full rate comfortably:
+// GB/s from 10G links up, MB/s from roughly 1G, and KB/s for slower links
+// such as 100M Ethernet. It returns "" when the speed is unknown.
+func linkRateUnit(linkMbps int) string {
+	if linkMbps <= 0 {
+		return ""
+	}
+	maxMBs := float64(linkMbps) / 8
+	switch {
+	case maxMBs >= 1000:
+		return "GB/s"
+	case maxMBs >= 100:
+		return "MB/s"
+	}
+	return "KB/s"
+}
+
+// fastestLinkMbps returns the highest known link speed among non-loopback
+// interfaces, or 0 when none is known.
+func fastestLinkMbps(stats []NetworkStatus) int {
+	fastest := 0
+	for _, n := range stats {
+		if !n.Loopback {
+			fastest = max(fastest, n.LinkSpeedMbps)
+		}
+	}
+	return fastest
+}
+
+// scaleRate converts a MB/s rate to the largest unit below one step.
+func scaleRate(mbs float64, mode UnitMode) (float64, string) {
+	step := 1024.0
+	if mode == UnitsDecimal {
+		step = 1000
+	}
+	value := mbs * 1024 * 1024 / step
+	unit := "KB/s"
+	for _, next := range []string{"MB/s", "GB/s"} {
+		if value < step {
+			break
+		}
+		value /= step
+		unit = next
+	}
+	return value, unit
+}
+
+// formatCompact renders a single-line summary for status bars and logs.
+// Rates use the unit suited to the fastest known link; without link speeds,
+// download and upload rates are scaled independently.
+func formatCompact(m MetricsSnapshot, opts FormatOptions) string {
+	pct := func(v float64) string { return opts.Numbers.format(v, 1) + "%" }
+	cpuPart := "CPU " + pct(m.CPU.Usage)
+	if m.CPU.StealPercent >= cpuStealNotablePercent {
+		cpuPart += " (steal " + pct(m.CPU.StealPercent) + ")"
+	}
+	parts := []string{
+		cpuPart,
+		"MEM " + pct(m.Memory.UsedPercent),
+	}
+	if len(m.Disks) > 0 {
+		parts = append(parts, "DISK "+pct(m.Disks[0].UsedPercent))
+	}
+
+	rx, tx := totalNetworkRates(m.Network)
+	link := fastestLinkMbps(m.Network)
+	parts = append(parts, fmt.Sprintf("↓%s ↑%s", formatLinkRateWith(rx, link, opts), formatLinkRateWith(tx, link, opts)))
+
+	if n, ok := primaryNetwork(m.Network); ok {
+		parts = append(parts, opts.interfaceLabel(n.Name, m.Network)+" "+n.IP)
+	}
+	if m.Proxy.Enabled {
+		parts = append(parts, "Proxy "+m.Proxy.Type)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// primaryNetworkIP returns the pinned interface's IP, or else the first
+// interface IP in display order.
+func primaryNetworkIP(stats []NetworkStatus) string {
+	n, _ := primaryNetwork(stats)
+	return n.IP
+}
+
+// primaryNetwork returns the interface primaryNetworkIP reports.
+func primaryNetwork(stats []NetworkStatus) (NetworkStatus, bool) {
+	for _, n := range stats {
+		if n.Primary && n.IP != "" {
+			return n, true
+		}
+	}
+	for _, n := range stats {
+		if n.IP != "" && !n.Loopback {
+			return n, true
+		}
+	}
+	return NetworkStatus{}, false
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatCompact(t *testing.T) {
+	snap := MetricsSnapshot{
+		CPU:    CPUStatus{Usage: 12.34},
+		Memory: MemoryStatus{UsedPercent: 45.6},
+		Disks:  []DiskStatus{{UsedPercent: 67}},
+		Network: []NetworkStatus{
+			{Name: "en0", RxRateMBs: 1.5, TxRateMBs: 0.25, IP: "192.168.1.2"},
+			{Name: "en1", RxRateMBs: 0.5},
+		},
+		Proxy: ProxyStatus{Enabled: true, Type: "HTTP"},
+	}
+
+	want := "CPU 12.3% · MEM 45.6% · DISK 67.0% · ↓2.0 MB/s ↑256.0 KB/s · en0 192.168.1.2 · Proxy HTTP"
+	if got := formatCompact(snap, FormatOptions{}); got != want {
+		t.Fatalf("formatCompact() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatScaledRate(t *testing.T) {
+	tests := []struct {
+		name string
+		mbs  float64
+		mode UnitMode
+		want string
+	}{
+		{"sub-MB binary", 0.01, UnitsBinary, "10.2 KB/s"},
+		{"sub-MB decimal", 0.5, UnitsDecimal, "524.3 KB/s"},
+		{"MB binary", 12.34, UnitsBinary, "12.3 MB/s"},
+		{"MB decimal", 1, UnitsDecimal, "1.0 MB/s"},
+		{"GB binary", 2048, UnitsBinary, "2.0 GB/s"},
+		{"GB decimal", 1200, UnitsDecimal, "1.3 GB/s"},
+		{"zero", 0, UnitsBinary, "0.0 KB/s"},
+	}
+	for _, tt := range tests {
+		if got := formatScaledRate(tt.mbs, tt.mode); got != tt.want {
+			t.Errorf("%s: formatScaledRate(%v) = %q, want %q", tt.name, tt.mbs, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCompactScalesDirectionsIndependently(t *testing.T) {
+	snap := MetricsSnapshot{Network: []NetworkStatus{
+		{Name: "en0", RxRateMBs: 1500, TxRateMBs: 0.002},
+		{Name: "en1", RxRateMBs: 100},
+	}}
+	got := formatCompact(snap, FormatOptions{})
+	if !strings.Contains(got, "↓1.6 GB/s ↑2.0 KB/s") {
+		t.Fatalf("expected independently scaled rates, got %q", got)
+	}
+}
+
+func TestFormatLinkRatePicksUnitFromLinkSpeed(t *testing.T) {
+	tests := []struct {
+		link int
+		mbs  float64
+		want string
+	}{
+		{100, 3.5, "3584.0 KB/s"},
+		{100, 0.01, "10.2 KB/s"},
+		{1000, 0.5, "0.5 MB/s"},
+		{1000, 95, "95.0 MB/s"},
+		{2500, 200, "200.0 MB/s"},
+		{10000, 512, "0.5 GB/s"},
+		{40000, 2048, "2.0 GB/s"},
+		{0, 0.5, "512.0 KB/s"}, // Unknown speed scales by magnitude
+		{0, 2048, "2.0 GB/s"},
+	}
+	for _, tt := range tests {
+		if got := formatLinkRateWith(tt.mbs, tt.link, FormatOptions{}); got != tt.want {
+			t.Errorf("formatLinkRateWith(%v, %dM) = %q, want %q", tt.mbs, tt.link, got, tt.want)
+		}
+	}
+	if got := formatLinkRateWith(1, 1000, FormatOptions{Units: UnitsDecimal}); got != "1.0 MB/s" {
+		t.Errorf("decimal units on a 1G link = %q", got)
+	}
+}
+
+func TestFormatCompactUsesFastestLinkUnit(t *testing.T) {
+	snap := MetricsSnapshot{Network: []NetworkStatus{
+		{Name: "en0", RxRateMBs: 300, TxRateMBs: 0.002, LinkSpeedMbps: 10000},
+		{Name: "en1", RxRateMBs: 100, LinkSpeedMbps: 1000},
+	}}
+	got := formatCompact(snap, FormatOptions{})
+	if !strings.Contains(got, "↓0.4 GB/s ↑0.0 GB/s") {
+		t.Fatalf("expected GB/s for a 10G link, got %q", got)
+	}
+}
+
+func TestParseUnitMode(t *testing.T) {
+	if mode, err := parseUnitMode("decimal"); err != nil || mode != UnitsDecimal {
+		t.Fatalf("parseUnitMode(decimal) = %v, %v", mode, err)
+	}
+	if _, err := parseUnitMode("furlongs"); err == nil {
+		t.Fatal("expected error for unknown unit mode")
+	}
+}
+
+func TestNumberFormatGroupedThousands(t *testing.T) {
+	nf := NumberFormat{Grouping: ","}
+	tests := map[float64]string{
+		1234.5:     "1,234.5",
+		1234567.25: "1,234,567.2",
+		999:        "999.0",
+		-12345:     "-12,345.0",
+	}
+	for v, want := range tests {
+		if got := nf.format(v, 1); got != want {
+			t.Errorf("format(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestFormatCompactCommaDecimal(t *testing.T) {
+	snap := MetricsSnapshot{
+		CPU:     CPUStatus{Usage: 12.34},
+		Memory:  MemoryStatus{UsedPercent: 45.6},
+		Network: []NetworkStatus{{Name: "en0", RxRateMBs: 2048, IP: "192.168.1.2"}},
+	}
+	opts := FormatOptions{Numbers: NumberFormat{Grouping: ".", Decimal: ","}}
+
+	want := "CPU 12,3% · MEM 45,6% · ↓2,0 GB/s ↑0,0 KB/s · en0 192.168.1.2"
+	if got := formatCompact(snap, opts); got != want {
+		t.Fatalf("formatCompact() = %q, want %q", got, want)
+	}
+	if err := (NumberFormat{Grouping: ",", Decimal: ","}).Validate(); err == nil {
+		t.Fatal("expected error for identical separators")
+	}
+	// An unset decimal separator is ".", so "." cannot also group.
+	if err := (NumberFormat{Grouping: "."}).Validate(); err == nil {
+		t.Fatal("expected error for grouping with the default decimal separator")
+	}
+}
+
+func TestFormatCompactUsesDisplayNames(t *testing.T) {
+	snap := MetricsSnapshot{Network: []NetworkStatus{{Name: "en0", RxRateMBs: 1, IP: "192.168.1.2"}}}
+
+	if got := formatCompact(snap, FormatOptions{DisplayNames: map[string]string{"en0": "WiFi"}}); !strings.HasSuffix(got, " · WiFi 192.168.1.2") {
+		t.Fatalf("mapped interface: formatCompact() = %q", got)
+	}
+	if got := formatCompact(snap, FormatOptions{DisplayNames: map[string]string{"en1": "Dock"}}); !strings.HasSuffix(got, " · en0 192.168.1.2") {
+		t.Fatalf("unmapped interface: formatCompact() = %q", got)
+	}
+	if snap.Network[0].Name != "en0" {
+		t.Fatal("formatting must not rename the snapshot's interfaces")
+	}
+}
+
+func TestInterfaceLabelFallsBackToRole(t *testing.T) {
+	opts := FormatOptions{DisplayNames: map[string]string{"wlp2s0": "Home"}}
+	tests := map[string]string{
+		"wlp2s0": "Home",
+		"wlan0":  "WiFi",
+		"enp3s0": "Ethernet",
+		"utun3":  "VPN",
+		"wg0":    "VPN",
+		"en0":    "en0", // macOS enN may be WiFi or Ethernet
+		"bond0":  "bond0",
+	}
+	for name, want := range tests {
+		if got := opts.interfaceLabel(name, nil); got != want {
+			t.Errorf("interfaceLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseDisplayNames(t *testing.T) {
+	got, err := parseDisplayNames(" en0=WiFi , utun3=Work VPN,")
+	if err != nil || len(got) != 2 || got["en0"] != "WiFi" || got["utun3"] != "Work VPN" {
+		t.Fatalf("parseDisplayNames() = %v, %v", got, err)
+	}
+	for _, bad := range []string{"en0", "=WiFi", "en0="} {
+		if _, err := parseDisplayNames(bad); err == nil {
+			t.Errorf("parseDisplayNames(%q) should fail", bad)
+		}
+	}
+}
+
+func TestInterfaceLabelQualifiesSharedRole(t *testing.T) {
+	stats := []NetworkStatus{{Name: "wlp2s0"}, {Name: "wlan1"}, {Name: "wg0"}, {Name: "tun0"}, {Name: "enp3s0"}}
+	opts := FormatOptions{DisplayNames: map[string]string{"tun0": "Work"}}
+	tests := map[string]string{
+		"wlp2s0": "WiFi (wlp2s0)",
+		"wlan1":  "WiFi (wlan1)",
+		"wg0":    "VPN", // tun0 has its own name
+		"tun0":   "Work",
+		"enp3s0": "Ethernet",
+	}
+	for name, want := range tests {
+		if got := opts.interfaceLabel(name, stats); got != want {
+			t.Errorf("interfaceLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -2,26 +2,96 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-const refreshInterval = time.Second
+const (
+	refreshInterval = time.Second
+	jsonSampleDelay = time.Second
+)
 
 var (
 	Version   = "dev"
 	BuildTime = ""
 
 	// Command-line flags
-	jsonOutput = flag.Bool("json", false, "output metrics as JSON instead of TUI")
+	fleetHosts        = flag.String("fleet", "", "collect from these SSH hosts (comma-separated, or @file) and print a JSON object of host to snapshot")
+	fleetTimeout      = flag.Duration("fleet-timeout", defaultFleetTimeout, "per-host timeout for -fleet")
+	jsonOutput        = flag.Bool("json", false, "output metrics as JSON instead of TUI")
+	jsonProfile       = flag.String("json-profile", "full", "JSON detail: full, or compact for headline metrics only")
+	redactOutput      = flag.Bool("redact", false, "mask IP addresses and proxy hosts in output")
+	saveBaselinePath  = flag.String("save-baseline", "", "save the collected snapshot as a baseline file (JSON mode)")
+	baselinePath      = flag.String("baseline", "", "flag deviations from a saved baseline file (JSON mode)")
+	watchMode         = flag.Bool("watch", false, "collect continuously and print a compact line per tick")
+	jsonlPath         = flag.String("jsonl", "", "append JSON lines to this file in watch mode")
+	serveAddr         = flag.String("serve", "", "serve Prometheus metrics (/metrics) and rate history (/history) on this address in watch mode (e.g. :9100)")
+	changesOnly       = flag.Bool("changes-only", false, "in watch mode, print and log a tick only when something meaningful changed")
+	changeEpsilon     = flag.Float64("change-epsilon", defaultChangeEpsilonMBs, "smallest rate change in MB/s that counts as a change with -changes-only")
+	socketPath        = flag.String("socket", "", "stream JSON lines to clients of this Unix socket in watch mode")
+	statsdAddr        = flag.String("statsd", "", "send StatsD gauges over UDP to this address in watch mode (e.g. 127.0.0.1:8125)")
+	statsdTags        = flag.String("statsd-tags", "dogstatsd", "StatsD tag format: dogstatsd, influx or none")
+	showCapabilities  = flag.Bool("capabilities", false, "report which collectors can run on this host and exit")
+	flapAlertCount    = flag.Int("flap-alert", 0, "alert when an interface's link flaps more than this many times within -flap-window (0 disables)")
+	flapAlertWindow   = flag.Duration("flap-window", defaultFlapAlertWindow, "window for -flap-alert")
+	uploadAlertRatio  = flag.Float64("upload-alert", 0, "alert when upload stays above this multiple of download (0 disables)")
+	alertClearMargin  = flag.Float64("alert-clear-margin", 0, "percent of a threshold a firing alert must fall below it to clear")
+	serverIfaces      = flag.String("server-ifaces", "", "comma-separated interfaces exempt from the upload alert")
+	statePath         = flag.String("state", "", "in watch mode, restore rate baselines and history from this file and save them on exit")
+	storeDir          = flag.String("store", "", "append snapshots to an on-disk ring in this directory in watch mode")
+	storeSince        = flag.Duration("since", 0, "print stored snapshots from this far back as JSON lines and exit (needs -store)")
+	watchlist         = flag.String("watchlist", "", "comma-separated process names (substrings) to always list with the top processes")
+	startedAfter      = flag.String("started-after", "", "only list top processes started after this: a duration ago (30m) or an RFC 3339 time")
+	includeUnknown    = flag.Bool("include-unknown-start", false, "with -started-after, keep processes whose start time is unreadable")
+	dryRun            = flag.Bool("dry-run", false, "print the effective configuration and planned collectors as JSON and exit")
+	ifaceName         = flag.String("iface", "", "print JSON status for just this network interface")
+	rawCounters       = flag.Bool("raw-counters", false, "print raw cumulative network counters with a timestamp as JSON (a line per tick with -watch)")
+	resolveRemotes    = flag.Bool("resolve", false, "reverse-resolve top remote hosts (needs DNS)")
+	neighborsFlag     = flag.Bool("neighbors", false, "count ARP/NDP neighbor cache entries")
+	proxyDetail       = flag.Bool("proxy-detail", false, "list every proxy source's findings in JSON output (for support bundles)")
+	proxyEnvOrder     = flag.String("proxy-env-order", "", "comma-separated proxy env vars to check first, e.g. ALL_PROXY,https_proxy (the rest follow in default order)")
+	timeSyncFlag      = flag.Bool("timesync", false, "report NTP sync state and clock offset")
+	updatesFlag       = flag.Bool("updates", false, "report pending package updates (apt, dnf, brew)")
+	includeLoopback   = flag.Bool("loopback", false, "include loopback interface traffic (kept out of totals)")
+	skipDormant       = flag.Int("skip-dormant", 0, "hide interfaces that are down or unused after this many unchanged ticks (0 keeps all)")
+	aggregate         = flag.Int("aggregate", 0, "show each interface's rates as the average of its last N samples (history stays raw)")
+	minRate           = flag.Float64("min-rate", 0, "hide interfaces below this combined rate in MB/s from the top interfaces")
+	burstK            = flag.Float64("burst-k", 0, "standard deviations above the recent mean that mark an interface as bursting (default 3, negative disables)")
+	primaryIface      = flag.String("primary-iface", "", "pin this network interface to the top (falls back to the default-route interface)")
+	debugLog          = flag.Bool("debug", false, "log collector diagnostics to stderr (non-TUI modes)")
+	probeEnabled      = flag.Bool("probe", false, "check for captive portals and proxy reachability over HTTP")
+	probeURL          = flag.String("probe-url", "", "URL for connectivity probes (implies -probe)")
+	probeMethod       = flag.String("probe-method", "", "HTTP method for connectivity probes: GET or HEAD")
+	probeStatus       = flag.Int("probe-status", 0, "HTTP status the probe URL returns when unobstructed")
+	collectTimeout    = flag.Duration("collect-timeout", 0, "bound each command-backed collector to this long per snapshot (0 means no limit)")
+	collectorTimeouts = flag.String("collector-timeouts", "", "per-collector limits within -collect-timeout, e.g. gpu=200ms,containers=500ms")
+	lowPower          = flag.Bool("low-power", false, "skip exec-heavy collectors and cache longer (for always-on status bars)")
+	unitsFlag         = flag.String("units", "binary", "rate units in watch mode: binary (1024) or decimal (1000)")
+	groupingSep       = flag.String("grouping-sep", "", "thousands separator for watch-mode numbers (e.g. \",\" or \".\")")
+	waybarFlag        = flag.String("waybar", "", "print waybar JSON lines with this metric as the text: cpu, memory, disk, network or health")
+	templateText      = flag.String("template", "", "render each snapshot with this Go text/template (@file reads it from a file)")
+	diskSort          = flag.String("disk-sort", "used", "order of the disks section: used, free, mount or size")
+	decimalSep        = flag.String("decimal-sep", "", "decimal separator for watch-mode numbers (default \".\")")
+	sparkStyle        = flag.String("spark-style", "blocks", "sparkline glyphs: blocks, braille or ascii")
+	displayNames      = flag.String("display-names", "", "interface labels for watch-mode output, e.g. en0=WiFi,utun3=VPN")
 )
 
 func shouldUseJSONOutput(forceJSON bool, stdout *os.File) bool {
@@ -57,6 +127,9 @@ type model struct {
 	collecting  bool
 	animFrame   int
 	catHidden   bool // true = hidden, false = visible
+	redact      bool
+	resetStats  bool // reset session totals before the next collection
+	format      FormatOptions
 }
 
 // getConfigPath returns the path to the status preferences file.
@@ -68,21 +141,39 @@ func getConfigPath() string {
 	return filepath.Join(home, ".config", "mole", "status_prefs")
 }
 
-// loadCatHidden loads the cat hidden preference from config file.
-func loadCatHidden() bool {
+// loadPrefs reads key=value lines from the preferences file. Blank lines
+// and lines starting with # are ignored.
+func loadPrefs() map[string]string {
+	prefs := make(map[string]string)
 	path := getConfigPath()
 	if path == "" {
-		return false
+		return prefs
 	}
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return false
+		return prefs
 	}
-	return strings.TrimSpace(string(data)) == "cat_hidden=true"
+	return parsePrefs(string(data))
 }
 
-// saveCatHidden saves the cat hidden preference to config file.
-func saveCatHidden(hidden bool) {
+func parsePrefs(data string) map[string]string {
+	prefs := make(map[string]string)
+	for line := range strings.Lines(data) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		prefs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return prefs
+}
+
+// savePref sets one key in the preferences file, keeping the others.
+func savePref(key, value string) {
 	path := getConfigPath()
 	if path == "" {
 		return
@@ -92,17 +183,184 @@ func saveCatHidden(hidden bool) {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return
 	}
-	value := "cat_hidden=false"
-	if hidden {
-		value = "cat_hidden=true"
+	prefs := loadPrefs()
+	prefs[key] = value
+
+	keys := make([]string, 0, len(prefs))
+	for k := range prefs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, prefs[k])
+	}
+	_ = os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// loadCatHidden loads the cat hidden preference from config file.
+func loadCatHidden() bool {
+	return loadPrefs()["cat_hidden"] == "true"
+}
+
+// saveCatHidden saves the cat hidden preference to config file.
+func saveCatHidden(hidden bool) {
+	savePref("cat_hidden", strconv.FormatBool(hidden))
+}
+
+// parseStartedAfter accepts a duration before now ("30m") or an absolute
+// RFC 3339 time.
+func parseStartedAfter(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -started-after %q (want a duration like 30m or an RFC 3339 time)", value)
+}
+
+// newCollectorFromFlags returns a collector configured from the shared
+// command-line flags and preferences. Invalid probe flags exit the program.
+func newCollectorFromFlags(interval time.Duration) *Collector {
+	collector := NewCollector()
+	collector.Interval = interval
+	weights := loadHealthWeights()
+	collector.HealthWeights = &weights
+	collector.IncludeLoopback = *includeLoopback
+	collector.PrimaryInterface = *primaryIface
+	collector.SkipDormantAfter = *skipDormant
+	collector.BurstK = *burstK
+	collector.MinRateMBs = *minRate
+	collector.AggregateSamples = *aggregate
+	if collector.PrimaryInterface == "" {
+		collector.PrimaryInterface = loadPrefs()["primary_interface"]
+	}
+	collector.ResolveRemotes = *resolveRemotes
+	collector.Neighbors = *neighborsFlag
+	collector.TimeSync = *timeSyncFlag
+	collector.Updates = *updatesFlag
+	collector.ProxyDetail = *proxyDetail
+	if *proxyEnvOrder != "" {
+		var keys []string
+		for key := range strings.SplitSeq(*proxyEnvOrder, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		order, err := validateEnvPrecedence(keys)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: -proxy-env-order: %v\n", err)
+			os.Exit(2)
+		}
+		collector.EnvPrecedence = order
+	}
+	collector.UploadAlertRatio = *uploadAlertRatio
+	collector.AlertClearMargin = *alertClearMargin
+	collector.FlapAlertCount = *flapAlertCount
+	collector.FlapAlertWindow = *flapAlertWindow
+	for name := range strings.SplitSeq(*serverIfaces, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			collector.ServerInterfaces = append(collector.ServerInterfaces, name)
+		}
+	}
+	for name := range strings.SplitSeq(*watchlist, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			collector.ProcessWatchlist = append(collector.ProcessWatchlist, name)
+		}
+	}
+	collector.LowPower = *lowPower
+	collector.CollectTimeout = *collectTimeout
+	if *collectorTimeouts != "" {
+		timeouts, err := parseCollectorTimeouts(*collectorTimeouts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		collector.CollectorTimeouts = timeouts
+	}
+	sortMode, err := parseDiskSort(*diskSort)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	collector.DiskSort = sortMode
+	if *startedAfter != "" {
+		cutoff, err := parseStartedAfter(*startedAfter, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		collector.StartedAfter = cutoff
+		collector.IncludeUnknownStart = *includeUnknown
+	}
+
+	quotas, err := quotasFromPrefs(loadPrefs())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring quotas: %v\n", err)
+	}
+	collector.Quotas = quotas
+	if path := getConfigPath(); path != "" {
+		collector.QuotaStatePath = filepath.Join(filepath.Dir(path), "status_quota.json")
+	}
+
+	probe, err := probeFromFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
 	}
-	_ = os.WriteFile(path, []byte(value+"\n"), 0644)
+	collector.Probe = probe
+	return collector
+}
+
+// probeFromFlags builds the connectivity probe, or nil when probing is off.
+func probeFromFlags() (*ProbeConfig, error) {
+	if !*probeEnabled && *probeURL == "" {
+		return nil, nil
+	}
+	probe := DefaultProbeConfig()
+	if *probeURL != "" {
+		probe.URL = *probeURL
+	}
+	if *probeMethod != "" {
+		probe.Method = strings.ToUpper(*probeMethod)
+	}
+	if *probeStatus != 0 {
+		probe.ExpectStatus = *probeStatus
+	}
+	if err := probe.Validate(); err != nil {
+		return nil, err
+	}
+	return &probe, nil
+}
+
+// diagnosticsLogger returns a stderr debug logger when -debug is set. The
+// TUI never uses it since writes to stderr would corrupt the screen.
+func diagnosticsLogger() *slog.Logger {
+	if !*debugLog {
+		return nil
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// loadHealthWeights returns health score weights tuned by health_weight_*
+// preferences. Bad values are reported once and fall back to defaults.
+func loadHealthWeights() HealthWeights {
+	weights, err := healthWeightsFromPrefs(loadPrefs())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return weights
 }
 
 func newModel() model {
+	collector := newCollectorFromFlags(refreshInterval)
+	collector.Order = OrderStable
 	return model{
-		collector: NewCollector(),
+		collector: collector,
 		catHidden: loadCatHidden(),
+		redact:    *redactOutput,
+		format:    formatOptionsFromFlags(),
 	}
 }
 
@@ -121,6 +379,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.catHidden = !m.catHidden
 			saveCatHidden(m.catHidden)
 			return m, nil
+		case "r":
+			// Applied on the collection goroutine to avoid racing Collect.
+			m.resetStats = true
+			return m, nil
 		}
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -131,7 +393,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.collecting = true
-		return m, m.collectCmd()
+		cmd := m.collectCmd(m.resetStats)
+		m.resetStats = false
+		return m, cmd
 	case metricsMsg:
 		if msg.err != nil {
 			m.errMessage = msg.err.Error()
@@ -163,14 +427,19 @@ func (m model) View() string {
 		termWidth = 80
 	}
 
-	header, mole := renderHeader(m.metrics, m.errMessage, m.animFrame, termWidth, m.catHidden)
+	metrics := m.metrics
+	if m.redact {
+		metrics = redactSnapshot(metrics)
+	}
+
+	header, mole := renderHeader(metrics, m.errMessage, m.animFrame, termWidth, m.catHidden)
 
 	if termWidth <= 80 {
 		cardWidth := termWidth
 		if cardWidth > 2 {
 			cardWidth -= 2
 		}
-		cards := buildCards(m.metrics, cardWidth)
+		cards := buildCards(metrics, cardWidth, m.format)
 
 		var rendered []string
 		for i, c := range cards {
@@ -190,7 +459,7 @@ func (m model) View() string {
 	}
 
 	cardWidth := max(24, termWidth/2-4)
-	cards := buildCards(m.metrics, cardWidth)
+	cards := buildCards(metrics, cardWidth, m.format)
 	twoCol := renderTwoColumns(cards, termWidth)
 	// Combine header, mole, and cards with consistent spacing
 	var content []string
@@ -202,8 +471,11 @@ func (m model) View() string {
 	return lipgloss.JoinVertical(lipgloss.Left, content...)
 }
 
-func (m model) collectCmd() tea.Cmd {
+func (m model) collectCmd(resetStats bool) tea.Cmd {
 	return func() tea.Msg {
+		if resetStats {
+			m.collector.ResetSessionBaseline()
+		}
 		data, err := m.collector.Collect()
 		return metricsMsg{data: data, err: err}
 	}
@@ -225,13 +497,15 @@ func animTickWithSpeed(cpuUsage float64) tea.Cmd {
 
 // runJSONMode collects metrics once and outputs as JSON.
 func runJSONMode() {
-	collector := NewCollector()
+	profile := jsonProfileFromFlags()
+	collector := newCollectorFromFlags(jsonSampleDelay)
+	collector.Logger = diagnosticsLogger()
 
 	// First collection initializes network state (returns nil for network)
 	_, _ = collector.Collect()
 
-	// Wait 1 second for network rate calculation
-	time.Sleep(1 * time.Second)
+	// Wait for network rate calculation
+	time.Sleep(jsonSampleDelay)
 
 	// Second collection has actual network data
 	data, err := collector.Collect()
@@ -240,18 +514,399 @@ func runJSONMode() {
 		os.Exit(1)
 	}
 
+	if *saveBaselinePath != "" {
+		if err := saveBaseline(*saveBaselinePath, data); err != nil {
+			fmt.Fprintf(os.Stderr, "error saving baseline: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *baselinePath != "" {
+		base, err := loadBaseline(*baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading baseline: %v\n", err)
+			os.Exit(1)
+		}
+		data.Deviations = compareToBaseline(base, data)
+	}
+
+	if *redactOutput {
+		data = redactSnapshot(data)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(profiled(data, profile)); err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runInterfaceMode samples one interface twice, jsonSampleDelay apart, and
+// prints its status as JSON.
+func runInterfaceMode(name string) {
+	collector := newCollectorFromFlags(jsonSampleDelay)
+	collector.Logger = diagnosticsLogger()
+	if _, err := collector.SampleInterface(name); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	time.Sleep(jsonSampleDelay)
+	status, err := collector.SampleInterface(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if *redactOutput {
+		status.IP = redactIP(status.IP)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(status); err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runRawCountersMode prints RawCounters as JSON: once, or as a JSON line
+// every refreshInterval until interrupted when watch is set.
+func runRawCountersMode(watch bool) {
+	emit := func(encoder *json.Encoder) {
+		raw, err := CollectRawCounters()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := encoder.Encode(raw); err != nil {
+			fmt.Fprintf(os.Stderr, "error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	if !watch {
+		encoder.SetIndent("", "  ")
+		emit(encoder)
+		return
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	emit(encoder)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			emit(encoder)
+		}
+	}
+}
+
+// formatOptionsFromFlags returns the -units and separator flags as
+// FormatOptions, with -display-names falling back to the prefs file.
+// Invalid values exit the program.
+func formatOptionsFromFlags() FormatOptions {
+	units, err := parseUnitMode(*unitsFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	numbers := NumberFormat{Grouping: *groupingSep, Decimal: *decimalSep}
+	if err := numbers.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	spec := *displayNames
+	if spec == "" {
+		spec = loadPrefs()["display_names"]
+	}
+	names, err := parseDisplayNames(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	spark, err := parseSparkStyle(*sparkStyle)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	return FormatOptions{Units: units, Numbers: numbers, DisplayNames: names, Spark: spark}
+}
+
+// jsonProfileFromFlags returns -json-profile. An invalid value exits the
+// program.
+func jsonProfileFromFlags() JSONProfile {
+	profile, err := parseJSONProfile(*jsonProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	return profile
+}
+
+// templateFromFlags loads and compiles -template. An unreadable file or an
+// invalid template exits the program before anything is collected.
+func templateFromFlags(opts FormatOptions) *template.Template {
+	text, err := loadTemplateFlag(*templateText)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading template: %v\n", err)
+		os.Exit(2)
+	}
+	t, err := ParseTemplate(text, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	return t
+}
+
+// runTemplateMode collects one snapshot, like JSON mode, and prints it
+// through -template.
+func runTemplateMode() {
+	t := templateFromFlags(formatOptionsFromFlags())
+	collector := newCollectorFromFlags(jsonSampleDelay)
+	collector.Logger = diagnosticsLogger()
+	_, _ = collector.Collect()
+	time.Sleep(jsonSampleDelay)
+	data, err := collector.Collect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error collecting metrics: %v\n", err)
+		os.Exit(1)
+	}
+	if *redactOutput {
+		data = redactSnapshot(data)
+	}
+	if err := templateSink(os.Stdout, t)(data); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runWatchMode collects every refreshInterval and fans snapshots out to the
+// stdout, JSONL file, Prometheus, Unix socket and StatsD sinks until
+// interrupted.
+func runWatchMode() {
+	opts := formatOptionsFromFlags()
+	sinks := []Sink{compactSink(os.Stdout, opts)}
+	if *templateText != "" {
+		sinks[0] = templateSink(os.Stdout, templateFromFlags(opts))
+	}
+	if *waybarFlag != "" {
+		metric, err := parseWaybarMetric(*waybarFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		sinks[0] = waybarSink(os.Stdout, metric, opts)
+	}
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+	}()
+
+	if *jsonlPath != "" {
+		sink, closer, err := openJSONLFileSink(*jsonlPath, jsonProfileFromFlags())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening %s: %v\n", *jsonlPath, err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+		closers = append(closers, closer)
+	}
+	// Only the log-style sinks are filtered; scrapers, socket clients and
+	// the store still see every tick.
+	if *changesOnly {
+		sinks = []Sink{changesOnlySink(*changeEpsilon, sinks...)}
+	}
+	if *serveAddr != "" {
+		sink, closer, err := startPrometheusSink(*serveAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error serving metrics on %s: %v\n", *serveAddr, err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+		closers = append(closers, closer)
+	}
+	if *socketPath != "" {
+		sink, closer, err := startSocketSink(*socketPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error listening on %s: %v\n", *socketPath, err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+		closers = append(closers, closer)
+	}
+	if *storeDir != "" {
+		store, err := OpenSnapshotStore(*storeDir, StoreOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening store %s: %v\n", *storeDir, err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, store.Sink())
+		closers = append(closers, store)
+	}
+	if *statsdAddr != "" {
+		format, err := parseStatsDTagFormat(*statsdTags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		sink, closer, err := startStatsDSink(*statsdAddr, format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening statsd %s: %v\n", *statsdAddr, err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+		closers = append(closers, closer)
+	}
+
+	collector := newCollectorFromFlags(refreshInterval)
+	collector.Order = OrderStable
+	collector.Logger = diagnosticsLogger()
+	if *statePath != "" {
+		if err := collector.LoadState(*statePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			collector.logger().Info("starting without saved state", "path", *statePath, "reason", err)
+		}
+	}
+	// First collection initializes network state, or continues from the
+	// restored state.
+	_, _ = collector.Collect()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			if *statePath != "" {
+				if err := collector.SaveState(*statePath); err != nil {
+					fmt.Fprintf(os.Stderr, "error saving state: %v\n", err)
+				}
+			}
+			if err := collector.SaveQuotas(); err != nil {
+				fmt.Fprintf(os.Stderr, "error saving quota usage: %v\n", err)
+			}
+			return
+		case <-ticker.C:
+			data, err := collector.Collect()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "collect: %v\n", err)
+			}
+			if *redactOutput {
+				data = redactSnapshot(data)
+			}
+			for _, err := range publishSnapshot(data, sinks...) {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+			}
+		}
+	}
+}
+
+// runDryRunMode prints what a watch-mode collector would be configured to
+// collect, without collecting.
+func runDryRunMode() {
+	collector := newCollectorFromFlags(refreshInterval)
+	collector.Order = OrderStable
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
+	if err := encoder.Encode(collector.DryRun()); err != nil {
 		fmt.Fprintf(os.Stderr, "error encoding JSON: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// runStoreQueryMode prints the snapshots stored in dir over the last since
+// as JSON lines.
+func runStoreQueryMode(dir string, since time.Duration) {
+	if dir == "" {
+		fmt.Fprintln(os.Stderr, "error: -since needs -store")
+		os.Exit(2)
+	}
+	store, err := OpenSnapshotStoreReadOnly(dir, StoreOptions{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening store %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	snaps, err := store.Range(time.Now().Add(-since), time.Time{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading store: %v\n", err)
+		os.Exit(1)
+	}
+	sink := jsonlSink(os.Stdout, jsonProfileFromFlags())
+	for _, m := range snaps {
+		if *redactOutput {
+			m = redactSnapshot(m)
+		}
+		if err := sink(m); err != nil {
+			fmt.Fprintf(os.Stderr, "error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// runFleetMode collects from every -fleet host and prints the snapshots
+// that came back as one JSON object keyed by host. Failed hosts are reported
+// on stderr; the exit status is 1 only when no host answered.
+func runFleetMode() {
+	hosts, err := loadFleetHosts(*fleetHosts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading hosts: %v\n", err)
+		os.Exit(2)
+	}
+	if len(hosts) == 0 {
+		fmt.Fprintln(os.Stderr, "error: -fleet lists no hosts")
+		os.Exit(2)
+	}
+	snaps, err := CollectFleet(context.Background(), hosts, FleetOptions{Timeout: *fleetTimeout})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	if *redactOutput {
+		for host, m := range snaps {
+			snaps[host] = redactSnapshot(m)
+		}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snaps); err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+	if len(snaps) == 0 {
+		os.Exit(1)
+	}
+}
+
+// runCapabilitiesMode prints one line per collector prerequisite, so users
+// can tell why a section is empty.
+func runCapabilitiesMode() {
+	caps := CollectCapabilities()
+	for _, name := range capabilityNames(caps) {
+		c := caps[name]
+		state := "ok"
+		if !c.Available {
+			state = "missing"
+		}
+		fmt.Printf("%-12s %-8s %s\n", name, state, c.Detail)
+	}
+}
+
 // runTUIMode runs the interactive terminal UI.
 func runTUIMode() {
-	p := tea.NewProgram(newModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	m := newModel()
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err := p.Run()
+	if saveErr := m.collector.SaveQuotas(); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "error saving quota usage: %v\n", saveErr)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "system status error: %v\n", err)
 		os.Exit(1)
 	}
@@ -260,7 +915,41 @@ func runTUIMode() {
 func main() {
 	flag.Parse()
 
-	if shouldUseJSONOutput(*jsonOutput, os.Stdout) {
+	if *showCapabilities {
+		runCapabilitiesMode()
+		return
+	}
+	if *dryRun {
+		runDryRunMode()
+		return
+	}
+	if *fleetHosts != "" {
+		runFleetMode()
+		return
+	}
+	if *storeSince > 0 {
+		runStoreQueryMode(*storeDir, *storeSince)
+		return
+	}
+	if *ifaceName != "" {
+		runInterfaceMode(*ifaceName)
+		return
+	}
+	if *rawCounters {
+		runRawCountersMode(*watchMode)
+		return
+	}
+	if *watchMode || *waybarFlag != "" || *jsonlPath != "" || *serveAddr != "" || *statsdAddr != "" || *storeDir != "" || *socketPath != "" {
+		runWatchMode()
+		return
+	}
+	if *templateText != "" {
+		runTemplateMode()
+		return
+	}
+
+	forceJSON := *jsonOutput || *saveBaselinePath != "" || *baselinePath != ""
+	if shouldUseJSONOutput(forceJSON, os.Stdout) {
 		runJSONMode()
 	} else {
 		runTUIMode()
@@ -42,3 +42,13 @@ func TestShouldUseJSONOutput_NonTTYFile(t *testing.T) {
 		t.Fatalf("expected file stdout to use JSON mode")
 	}
 }
+
+func TestParsePrefs(t *testing.T) {
+	prefs := parsePrefs("# comment\ncat_hidden=true\n\nhealth_weight_cpu = 40\nbogus\n")
+	if prefs["cat_hidden"] != "true" || prefs["health_weight_cpu"] != "40" {
+		t.Fatalf("unexpected prefs: %v", prefs)
+	}
+	if len(prefs) != 2 {
+		t.Fatalf("expected 2 prefs, got %v", prefs)
+	}
+}
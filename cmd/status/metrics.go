@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"math"
 	"os/exec"
+	"slices"
 	"sync"
 	"time"
 
@@ -56,29 +59,143 @@ func (rb *RingBuffer) Slice() []float64 {
 	return res
 }
 
+// MeanLast returns the mean of the newest k values, or of all of them when
+// fewer are buffered.
+func (rb *RingBuffer) MeanLast(k int) float64 {
+	k = min(k, rb.size)
+	if k <= 0 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i <= k; i++ {
+		sum += rb.data[(rb.index-i+rb.cap)%rb.cap]
+	}
+	return sum / float64(k)
+}
+
+// Percentile returns the p-th percentile (0-100) of the buffered values,
+// interpolating linearly between the closest ranks. Only filled slots count,
+// so a partially filled buffer isn't skewed by zeros. Empty buffers yield 0.
+func (rb *RingBuffer) Percentile(p float64) float64 {
+	if rb.size == 0 {
+		return 0
+	}
+	sorted := slices.Clone(rb.data[:rb.size])
+	slices.Sort(sorted)
+	p = min(max(p, 0), 100)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[lo+1]-sorted[lo])*(rank-float64(lo))
+}
+
+// MeanStdDev returns the mean and population standard deviation of the
+// filled slots, and how many there are.
+func (rb *RingBuffer) MeanStdDev() (mean, stddev float64, n int) {
+	if rb.size == 0 {
+		return 0, 0, 0
+	}
+	for _, v := range rb.data[:rb.size] {
+		mean += v
+	}
+	mean /= float64(rb.size)
+	var sq float64
+	for _, v := range rb.data[:rb.size] {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(rb.size)), rb.size
+}
+
+// SnapshotSchemaVersion is the semantic version of the JSON snapshot format.
+// Adding fields bumps the minor version; renaming, removing or changing the
+// meaning of a field bumps the major version. Consumers should accept any
+// snapshot with the major version they understand and ignore unknown fields.
+const SnapshotSchemaVersion = "2.0.0"
+
 type MetricsSnapshot struct {
+	SchemaVersion  string       `json:"schema_version"` // Always first in JSON output
 	CollectedAt    time.Time    `json:"collected_at"`
 	Host           string       `json:"host"`
 	Platform       string       `json:"platform"`
 	Uptime         string       `json:"uptime"`
 	Procs          uint64       `json:"procs"`
+	Session        SessionInfo  `json:"session"`
 	Hardware       HardwareInfo `json:"hardware"`
 	HealthScore    int          `json:"health_score"`     // 0-100 system health score
 	HealthScoreMsg string       `json:"health_score_msg"` // Brief explanation
 
-	CPU            CPUStatus         `json:"cpu"`
-	GPU            []GPUStatus       `json:"gpu"`
-	Memory         MemoryStatus      `json:"memory"`
-	Disks          []DiskStatus      `json:"disks"`
-	DiskIO         DiskIOStatus      `json:"disk_io"`
-	Network        []NetworkStatus   `json:"network"`
-	NetworkHistory NetworkHistory    `json:"network_history"`
-	Proxy          ProxyStatus       `json:"proxy"`
-	Batteries      []BatteryStatus   `json:"batteries"`
-	Thermal        ThermalStatus     `json:"thermal"`
-	Sensors        []SensorReading   `json:"sensors"`
-	Bluetooth      []BluetoothDevice `json:"bluetooth"`
-	TopProcesses   []ProcessInfo     `json:"top_processes"`
+	CPU            CPUStatus          `json:"cpu"`
+	GPU            []GPUStatus        `json:"gpu"`
+	Memory         MemoryStatus       `json:"memory"`
+	Disks          []DiskStatus       `json:"disks"`
+	DiskIO         DiskIOStatus       `json:"disk_io"`
+	Network        []NetworkStatus    `json:"network"`
+	NetworkHistory NetworkHistory     `json:"network_history"`
+	Proxy          ProxyStatus        `json:"proxy"`
+	Connectivity   ConnectivityStatus `json:"connectivity"`
+	Batteries      []BatteryStatus    `json:"batteries"`
+	Thermal        ThermalStatus      `json:"thermal"`
+	Sensors        []SensorReading    `json:"sensors"`
+	Bluetooth      []BluetoothDevice  `json:"bluetooth"`
+	TopProcesses   []ProcessInfo      `json:"top_processes"`
+	TopTruncated   bool               `json:"top_processes_truncated,omitempty"` // Enumeration hit its deadline
+	ProcessCounts  ProcessCountStatus `json:"process_counts"`
+	MultiHomed     bool               `json:"multi_homed"` // More than one interface holds a default route
+	Uplinks        []string           `json:"uplinks"`
+	Routes         RouteSummary       `json:"routes"`
+	WiFi           WiFiStatus         `json:"wifi"`
+	StorageArrays  []ArrayStatus      `json:"storage_arrays"`
+	Listeners      []ListenerStatus   `json:"listeners"`
+	RemoteHosts    []RemoteHost       `json:"remote_hosts"`
+	// OtherConns counts established connections on no listed interface.
+	OtherConns int             `json:"other_established_conns,omitempty"`
+	Deviations []Deviation     `json:"deviations,omitempty"` // Set when compared to a baseline
+	Events     []Event         `json:"events,omitempty"`     // Changes since the previous collection
+	Changes    []Deviation     `json:"changes,omitempty"`    // Set in changes-only watch mode
+	Alerts     []Alert         `json:"alerts,omitempty"`
+	Quotas     []QuotaStatus   `json:"quotas,omitempty"`
+	TCP        TCPStatus       `json:"tcp"`
+	IPFamilies IPFamilyStatus  `json:"ip_families"`
+	Containers ContainerStatus `json:"containers"`
+	// ContainerNetwork replaces the veth interfaces it could map; unmapped
+	// veths stay in Network.
+	ContainerNetwork []ContainerNetStatus  `json:"container_network,omitempty"`
+	Neighbors        NeighborStatus        `json:"neighbors"` // Only with Collector.Neighbors
+	TimeSync         TimeSyncStatus        `json:"time_sync"` // Only with Collector.TimeSync
+	Updates          UpdateStatus          `json:"updates"`   // Only with Collector.Updates
+	NetworkMounts    []DiskStatus          `json:"network_mounts,omitempty"`
+	Firewall         FirewallStatus        `json:"firewall"`
+	PowerAssertions  PowerAssertionStatus  `json:"power_assertions"`       // What keeps the Mac awake
+	Capabilities     map[string]Capability `json:"capabilities,omitempty"` // Probed once per collector
+}
+
+// RouteSummary is a compact view of the IPv4 routing table.
+type RouteSummary struct {
+	DefaultGateway   string         `json:"default_gateway"` // Preferred default next hop
+	GatewayInterface string         `json:"gateway_interface"`
+	Defaults         []DefaultRoute `json:"defaults"`
+	RouteCount       int            `json:"route_count"`
+	// KillSwitchLikely is a heuristic: a tunnel is in the routing table
+	// but no physical interface holds a default route, so traffic cannot
+	// bypass the VPN. Firewall-based kill switches are not detected.
+	KillSwitchLikely bool `json:"kill_switch_likely"`
+}
+
+type DefaultRoute struct {
+	Gateway   string `json:"gateway"`
+	Interface string `json:"interface"`
+}
+
+// ListenerStatus is a listening socket and the process that owns it.
+type ListenerStatus struct {
+	Proto       string `json:"proto"`
+	Addr        string `json:"addr"` // "*" for wildcard binds
+	Port        uint32 `json:"port"`
+	PID         int32  `json:"pid,omitempty"` // 0 when not visible to this user
+	ProcessName string `json:"process_name,omitempty"`
+	Hostname    string `json:"hostname,omitempty"` // Reverse DNS of Addr, when resolution is enabled
 }
 
 type HardwareInfo struct {
@@ -93,25 +210,76 @@ type HardwareInfo struct {
 type DiskIOStatus struct {
 	ReadRate  float64 `json:"read_rate"`  // MB/s
 	WriteRate float64 `json:"write_rate"` // MB/s
+
+	// Busiest processes by storage reads and writes (Linux only).
+	// HiddenProcesses counts processes whose I/O could not be read without
+	// more privileges, so the lists may miss them.
+	TopReaders      []ProcessDiskIO `json:"top_readers,omitempty"`
+	TopWriters      []ProcessDiskIO `json:"top_writers,omitempty"`
+	HiddenProcesses int             `json:"hidden_processes,omitempty"`
 }
 
 type ProcessInfo struct {
+	PID    int32   `json:"pid"`
 	Name   string  `json:"name"`
 	CPU    float64 `json:"cpu"`
 	Memory float64 `json:"memory"`
+	// Watchlisted marks a process matching Collector.ProcessWatchlist; it
+	// is listed even when not among the top consumers.
+	Watchlisted bool `json:"watchlisted,omitempty"`
+
+	// Username and Cmdline tell apart processes sharing a name. Cmdline is
+	// cut to maxCmdlineLen; both are empty when the process is not
+	// readable, as other users' processes often are.
+	Username string `json:"username,omitempty"`
+	Cmdline  string `json:"cmdline,omitempty"`
+
+	// Open inet sockets owned by the process, refreshed with the listener
+	// scan. SocketStates breaks them down by TCP state (UDP shows as NONE).
+	SocketCount  int            `json:"socket_count"`
+	SocketStates map[string]int `json:"socket_states,omitempty"`
+}
+
+// ProcessCountStatus aggregates process states and threads system-wide.
+type ProcessCountStatus struct {
+	Total    int `json:"total"`
+	Running  int `json:"running"`
+	Sleeping int `json:"sleeping"`
+	Zombie   int `json:"zombie"`
+	Threads  int `json:"threads"`
+	// Zombies lists zombie processes by parent, up to maxZombies, so the
+	// parent failing to reap them can be found.
+	Zombies []ZombieProcess `json:"zombies,omitempty"`
+}
+
+// ZombieProcess is an exited process its parent has not reaped yet.
+type ZombieProcess struct {
+	PID        int32  `json:"pid"`
+	Name       string `json:"name,omitempty"`
+	PPID       int32  `json:"ppid"`
+	ParentName string `json:"parent_name,omitempty"` // Empty when the parent is gone or unreadable
+	// Reparented is set when the original parent exited and init (PID 1)
+	// adopted the zombie; init normally reaps such zombies promptly.
+	Reparented bool `json:"reparented,omitempty"`
 }
 
 type CPUStatus struct {
 	Usage            float64   `json:"usage"`
 	PerCore          []float64 `json:"per_core"`
 	PerCoreEstimated bool      `json:"per_core_estimated"`
-	Load1            float64   `json:"load1"`
-	Load5            float64   `json:"load5"`
-	Load15           float64   `json:"load15"`
-	CoreCount        int       `json:"core_count"`
-	LogicalCPU       int       `json:"logical_cpu"`
-	PCoreCount       int       `json:"p_core_count"` // Performance cores (Apple Silicon)
-	ECoreCount       int       `json:"e_core_count"` // Efficiency cores (Apple Silicon)
+	// StealPercent is CPU time taken by the hypervisor for other guests
+	// during the sample. Only Linux guests report it; 0 elsewhere.
+	StealPercent float64 `json:"steal_percent"`
+	// PerCoreTemp is each CPU's core temperature in °C, aligned with
+	// PerCore; 0 where no sensor matched. Empty without per-core sensors.
+	PerCoreTemp []float64 `json:"per_core_temp,omitempty"`
+	Load1       float64   `json:"load1"`
+	Load5       float64   `json:"load5"`
+	Load15      float64   `json:"load15"`
+	CoreCount   int       `json:"core_count"`
+	LogicalCPU  int       `json:"logical_cpu"`
+	PCoreCount  int       `json:"p_core_count"` // Performance cores (Apple Silicon)
+	ECoreCount  int       `json:"e_core_count"` // Efficiency cores (Apple Silicon)
 }
 
 type GPUStatus struct {
@@ -131,6 +299,16 @@ type MemoryStatus struct {
 	SwapTotal   uint64  `json:"swap_total"`
 	Cached      uint64  `json:"cached"`   // File cache that can be freed if needed
 	Pressure    string  `json:"pressure"` // macOS memory pressure: normal/warn/critical
+
+	// macOS paging activity from vm_stat. Rates are per second since the
+	// previous sample (0 on the first); sustained pageouts and swapouts mean
+	// real memory pressure. Compressed counts are pages.
+	PageinsPerSec   float64 `json:"pageins_per_sec,omitempty"`
+	PageoutsPerSec  float64 `json:"pageouts_per_sec,omitempty"`
+	SwapinsPerSec   float64 `json:"swapins_per_sec,omitempty"`
+	SwapoutsPerSec  float64 `json:"swapouts_per_sec,omitempty"`
+	CompressedPages uint64  `json:"compressed_pages,omitempty"` // Pages stored in the compressor
+	CompressorPages uint64  `json:"compressor_pages,omitempty"` // Physical pages the compressor occupies
 }
 
 type DiskStatus struct {
@@ -141,6 +319,42 @@ type DiskStatus struct {
 	UsedPercent float64 `json:"used_percent"`
 	Fstype      string  `json:"fstype"`
 	External    bool    `json:"external"`
+	// Inode counts; all zero for filesystems that don't report them
+	// (APFS through some APIs, FAT, many network filesystems).
+	InodesTotal       uint64  `json:"inodes_total,omitempty"`
+	InodesUsed        uint64  `json:"inodes_used,omitempty"`
+	InodesUsedPercent float64 `json:"inodes_used_percent,omitempty"`
+	// MountOpts are the options the filesystem is mounted with, e.g. rw,
+	// nosuid, noexec. ReadOnly is derived from them; a disk that turns
+	// read-only mid-session usually hit I/O errors and raises an alert.
+	MountOpts []string `json:"mount_opts,omitempty"`
+	ReadOnly  bool     `json:"read_only,omitempty"`
+
+	ReadLatencyMs  float64 `json:"read_latency_ms"`  // Avg per read since last sample (Linux)
+	WriteLatencyMs float64 `json:"write_latency_ms"` // Avg per write since last sample (Linux)
+
+	TimeToFullSeconds float64 `json:"time_to_full_seconds,omitempty"` // Projected from recent growth; zero if flat or shrinking
+
+	SuspectJump bool `json:"suspect_jump,omitempty"` // Usage moved implausibly far since the last sample
+
+	// NetworkFS marks NFS, SMB, AFP, WebDAV and SSHFS mounts, which are
+	// listed under network_mounts rather than disks. Reachable says
+	// whether the server answered; usage is zero when it did not.
+	NetworkFS bool  `json:"network_fs,omitempty"`
+	Reachable *bool `json:"reachable,omitempty"`
+}
+
+// ArrayStatus describes a ZFS pool or mdraid array.
+type ArrayStatus struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`  // zfs, mdraid
+	State         string   `json:"state"` // ONLINE/DEGRADED/FAULTED (zfs), ACTIVE/DEGRADED (mdraid)
+	DegradedDisks []string `json:"degraded_disks"`
+}
+
+// Healthy reports whether the array is fully operational.
+func (a ArrayStatus) Healthy() bool {
+	return (a.State == "ONLINE" || a.State == "ACTIVE") && len(a.DegradedDisks) == 0
 }
 
 type NetworkStatus struct {
@@ -148,20 +362,104 @@ type NetworkStatus struct {
 	RxRateMBs float64 `json:"rx_rate_mbs"`
 	TxRateMBs float64 `json:"tx_rate_mbs"`
 	IP        string  `json:"ip"`
+
+	SessionRxBytes uint64 `json:"session_rx_bytes"` // Since start or last reset
+	SessionTxBytes uint64 `json:"session_tx_bytes"`
+
+	// Utilization relative to the negotiated link speed; -1 when the speed
+	// is unknown. Implausible marks rates above the link speed (counter
+	// artifacts or a wrong speed report) that were clamped to 100.
+	LinkSpeedMbps int     `json:"link_speed_mbps,omitempty"`
+	RxUtilization float64 `json:"rx_utilization_percent"`
+	TxUtilization float64 `json:"tx_utilization_percent"`
+	Implausible   bool    `json:"implausible,omitempty"`
+
+	// CarrierFlaps counts link up/down transitions since the previous tick
+	// (Linux only). Non-zero means the link is renegotiating.
+	CarrierFlaps int `json:"carrier_flaps,omitempty"`
+	// Flapping is set while the carrier changed more than FlapAlertCount
+	// times (defaultFlapCount when unset) within the flap window.
+	Flapping bool `json:"flapping,omitempty"`
+
+	// Average bytes per packet since the previous tick; 0 when no packets
+	// moved. Small values mean chatty traffic, large ones bulk transfers.
+	AvgRxPacketSize float64 `json:"avg_rx_packet_size"`
+	AvgTxPacketSize float64 `json:"avg_tx_packet_size"`
+
+	// AsymmetryRatio is the upload rate over the download rate: below 1 is
+	// download-dominant, above 1 upload-dominant. It is 0 when idle and
+	// capped at maxAsymmetryRatio when only uploading.
+	AsymmetryRatio float64 `json:"asymmetry_ratio"`
+
+	// Vendor is the interface's MAC vendor from the embedded OUI table, or
+	// LocallyAdministered for randomized and virtual addresses.
+	Vendor string `json:"vendor,omitempty"`
+
+	// Driver and FirmwareVersion come from `ethtool -i` on Linux; empty
+	// elsewhere, without ethtool, or for virtual interfaces.
+	Driver          string `json:"driver,omitempty"`
+	FirmwareVersion string `json:"firmware_version,omitempty"`
+
+	// Loopback marks lo when IncludeLoopback is set. It is never counted
+	// in aggregate rates or history.
+	Loopback bool `json:"loopback,omitempty"`
+
+	// QuietSince is when the interface last moved traffic, set only while
+	// it is idle. An interface quiet for long while others are busy may
+	// have lost its uplink.
+	QuietSince time.Time `json:"quiet_since,omitzero"`
+
+	// Bursting marks a rate far above the interface's recent baseline
+	// (mean + Collector.BurstK standard deviations).
+	Bursting bool `json:"bursting,omitempty"`
+
+	// Primary marks the pinned interface (Collector.PrimaryInterface, or
+	// the default-route interface when that one is absent).
+	Primary bool `json:"primary,omitempty"`
+
+	// EstablishedConns counts established connections whose local address
+	// is on this interface, from the listener scan. Zero in low-power mode.
+	EstablishedConns int `json:"established_conns"`
 }
 
 // NetworkHistory holds the global network usage history.
 type NetworkHistory struct {
 	RxHistory []float64 `json:"rx_history"`
 	TxHistory []float64 `json:"tx_history"`
+	// Session bytes summed over every interface counted in the history,
+	// including those hidden from Network.
+	SessionRxBytes uint64 `json:"session_rx_bytes"`
+	SessionTxBytes uint64 `json:"session_tx_bytes"`
 }
 
 const NetworkHistorySize = 120 // Increased history size for wider graph
 
 type ProxyStatus struct {
-	Enabled bool   `json:"enabled"`
-	Type    string `json:"type"` // HTTP, HTTPS, SOCKS, PAC, WPAD, TUN
-	Host    string `json:"host"`
+	Enabled    bool      `json:"enabled"`
+	Type       string    `json:"type"` // HTTP, HTTPS, SOCKS, PAC, WPAD, TUN
+	Host       string    `json:"host"`
+	LastChange time.Time `json:"last_change,omitzero"`           // Last enabled/disabled transition; unset until one is seen
+	Flapping   bool      `json:"flapping"`                       // Toggled repeatedly within the flap window
+	Underlying string    `json:"underlying_interface,omitempty"` // Physical egress of a TUN proxy
+	// UnderlyingGuess is set when Underlying is the default route rather
+	// than the route to the tunnel's endpoint.
+	UnderlyingGuess bool `json:"underlying_guess,omitempty"`
+	// Effective compares env and system proxies per scheme; nil when
+	// neither is configured.
+	Effective []EffectiveProxy `json:"effective,omitempty"`
+	// Sources lists every proxy source's findings; only with
+	// Collector.ProxyDetail.
+	Sources []ProxySourceReport `json:"sources,omitempty"`
+}
+
+// ConnectivityStatus is the result of the optional HTTP probes.
+type ConnectivityStatus struct {
+	Checked        bool    `json:"checked"`
+	CaptivePortal  bool    `json:"captive_portal"` // Direct probe got an unexpected status
+	ProxyChecked   bool    `json:"proxy_checked"`
+	ProxyReachable bool    `json:"proxy_reachable"`
+	LatencyMs      float64 `json:"latency_ms"`
+	Error          string  `json:"error,omitempty"`
 }
 
 type BatteryStatus struct {
@@ -181,6 +479,13 @@ type ThermalStatus struct {
 	SystemPower  float64 `json:"system_power"`  // System power consumption in Watts
 	AdapterPower float64 `json:"adapter_power"` // AC adapter max power in Watts
 	BatteryPower float64 `json:"battery_power"` // Battery charge/discharge power in Watts (positive = discharging)
+
+	// Throttling, from CollectThermalState. The limits are percentages of
+	// full speed (100 = unrestricted) on macOS and 0 where unreported;
+	// Throttled on Linux is inferred from clock speed and temperature.
+	CPUSpeedLimit  int  `json:"cpu_speed_limit,omitempty"`
+	SchedulerLimit int  `json:"scheduler_limit,omitempty"`
+	Throttled      bool `json:"throttled"`
 }
 
 type SensorReading struct {
@@ -196,36 +501,290 @@ type BluetoothDevice struct {
 	Battery   string `json:"battery"`
 }
 
+// defaultSampleInterval is the assumed spacing between Collect calls.
+const defaultSampleInterval = time.Second
+
 type Collector struct {
+	// Interval is the nominal spacing between Collect calls. It is used to
+	// synthesize history timestamps (e.g. WriteHistoryCSV) and to clamp
+	// implausibly short rate windows. Callers that collect on their own
+	// schedule should set it to match, or CSV timestamps will drift.
+	Interval time.Duration
+
+	// EnvPrecedence reorders the proxy env vars checked before system
+	// settings (e.g. ALL_PROXY first). Empty keeps the default order;
+	// otherwise it should come from validateEnvPrecedence, since only the
+	// listed vars are checked.
+	EnvPrecedence []string
+
+	// Getenv overrides the environment used for proxy detection and the
+	// session info, e.g. to inspect another process's env. Nil means
+	// os.Getenv.
+	Getenv func(string) string
+
+	// Order controls how network interfaces are ordered and trimmed.
+	Order OrderMode
+
+	// DiskSort orders the disks section. The health score and hardware
+	// summary still use the primary disk whatever the order.
+	DiskSort DiskSort
+
+	// IncludeLoopback reports loopback interfaces alongside the top
+	// interfaces, outside the aggregate totals.
+	IncludeLoopback bool
+
+	// PrimaryInterface pins one interface to the top of the network
+	// section, listed even when idle or matching the noise prefixes. When
+	// it is absent the default-route interface is pinned instead.
+	PrimaryInterface string
+
+	// SkipDormantAfter leaves out interfaces that are down or have never
+	// moved a byte once they have been unchanged for this many ticks; 0
+	// keeps every interface. DormantInterfaces lists the skipped ones.
+	SkipDormantAfter int
+
+	// MinRateMBs hides interfaces whose combined rx+tx rate is below it
+	// from the listed top interfaces; they still count toward the totals.
+	// When every interface is idle the list is empty.
+	MinRateMBs float64
+
+	// AggregateSamples presents each interface's rates as the mean of its
+	// last AggregateSamples samples, smoothing a fast sample interval for
+	// display. History, percentiles, totals and burst detection keep the
+	// raw samples. 0 or 1 presents raw rates.
+	AggregateSamples int
+
+	// BurstK is how many standard deviations above its recent mean an
+	// interface's rate must be to count as bursting. Zero uses
+	// defaultBurstK; negative disables burst detection.
+	BurstK float64
+
+	// ResolveRemotes adds reverse DNS names to the top remote hosts and to
+	// listeners bound to a specific address. Each lookup has a short
+	// deadline, and answers are cached, up to maxHostnameCache IPs, for the
+	// collector's lifetime.
+	ResolveRemotes bool
+	// Resolver does the lookups; nil uses the system resolver.
+	Resolver NameResolver
+
+	// Neighbors counts ARP/NDP neighbor cache entries. It is off by
+	// default: few users need it and it shells out on every refresh.
+	Neighbors bool
+
+	// ProxyDetail adds every proxy source's findings to ProxyStatus.Sources
+	// for support bundles, instead of just the resolved proxy.
+	ProxyDetail bool
+
+	// TimeSync reports NTP sync state and clock offset. It is off by
+	// default since the macOS offset check queries time.apple.com.
+	TimeSync bool
+
+	// Updates counts pending package updates. It is off by default since
+	// apt, dnf and brew take seconds to answer even from their caches.
+	Updates bool
+
+	// LowPower keeps only the cheap collectors (CPU, memory, disks, disk IO,
+	// network) for always-on status bars on battery. It skips GPU,
+	// Bluetooth, thermal, top processes, process counts, containers, TCP,
+	// listeners, remote hosts and socket counts, storage arrays, routes,
+	// Wi-Fi (and so network change events) and connectivity probes, and
+	// stretches the remaining caches (hardware, interface metadata, proxy,
+	// batteries) by lowPowerTTLFactor.
+	LowPower bool
+
+	// Quotas tracks monthly data caps per interface. Usage is persisted to
+	// QuotaStatePath (when set) so it survives restarts.
+	Quotas         []Quota
+	QuotaStatePath string
+
+	// Probe enables connectivity checks (captive portal and proxy
+	// reachability) using this request. Nil disables them.
+	Probe *ProbeConfig
+
+	// UploadAlertRatio raises an alert when an interface's upload rate stays
+	// above this multiple of its download rate (e.g. 2 for "twice as much
+	// up as down"). Zero disables the alert. ServerInterfaces are exempt,
+	// since serving traffic is upload-heavy by design.
+	UploadAlertRatio float64
+	ServerInterfaces []string
+
+	// AlertClearMargin adds hysteresis to threshold alerts: once firing,
+	// an alert clears only when its value falls this percentage of the
+	// threshold below it (5 keeps a 90% disk alert until usage is under
+	// 85.5%). Zero clears as soon as the value is back under the threshold.
+	AlertClearMargin float64
+
+	// FlapAlertCount raises an alert naming an interface whose carrier
+	// changed more than this many times within FlapAlertWindow (zero uses
+	// defaultFlapAlertWindow). Zero disables the alert.
+	FlapAlertCount  int
+	FlapAlertWindow time.Duration
+
+	// StartedAfter limits top processes to those created after this time,
+	// to surface what a deploy or login spawned. Processes whose start time
+	// is unreadable are dropped unless IncludeUnknownStart is set. Zero
+	// disables the filter.
+	StartedAfter        time.Time
+	IncludeUnknownStart bool
+
+	// ProcessWatchlist names processes to list alongside the top consumers
+	// whatever their usage, matched as case-insensitive substrings of the
+	// process name (e.g. "postgres", "MyApp").
+	ProcessWatchlist []string
+
+	// MaxSeries caps per-key state (interfaces, mounts, PIDs) across all
+	// kinds; the least recently updated series are evicted past it. Zero
+	// means defaultMaxSeries.
+	MaxSeries int
+
+	// Logger receives debug records when a collector degrades (missing
+	// tool, permission denied, unsupported platform). Nil discards them.
+	Logger *slog.Logger
+
+	// HealthWeights tunes the health score. Nil uses defaultHealthWeights;
+	// all-zero weights are honoured and always score 100.
+	HealthWeights *HealthWeights
+
+	// CollectTimeout bounds each context-aware collector (boundedCollectors)
+	// in one Collect; CollectorTimeouts gives individual collectors a
+	// shorter or, without CollectTimeout, any limit. A collector out of time
+	// returns whatever it has by then, often nothing. Zero means no limit.
+	CollectTimeout    time.Duration
+	CollectorTimeouts map[string]time.Duration
+
 	// Static cache.
 	cachedHW  HardwareInfo
 	lastHWAt  time.Time
 	hasStatic bool
+	caps      map[string]Capability
 
 	// Slow cache (30s-1m).
-	lastBTAt time.Time
-	lastBT   []BluetoothDevice
+	lastBTAt           time.Time
+	lastBT             []BluetoothDevice
+	lastProcCountAt    time.Time
+	cachedProcCounts   ProcessCountStatus
+	lastRouteAt        time.Time
+	cachedUplinks      []string
+	cachedRoutes       RouteSummary
+	cachedRouteEntries []routeEntry
+	lastWiFiAt         time.Time
+	cachedWiFi         WiFiStatus
+	lastWiFiLinkAt     time.Time
+	cachedWiFiLink     WiFiStatus
+	lastArrayAt        time.Time
+	cachedArrays       []ArrayStatus
+	lastListenerAt     time.Time
+	cachedListeners    []ListenerStatus
+	lastContainerAt    time.Time
+	cachedContainers   ContainerStatus
+	lastNeighborsAt    time.Time
+	cachedNeighbors    NeighborStatus
+	lastTimeSyncAt     time.Time
+	cachedTimeSync     TimeSyncStatus
+	lastUpdatesAt      time.Time
+	cachedUpdates      UpdateStatus
+	lastFirewallAt     time.Time
+	lastAssertionsAt   time.Time
+	cachedAssertions   PowerAssertionStatus
+	lastNetMountAt     time.Time
+	cachedNetMounts    []DiskStatus
+	cachedFirewall     FirewallStatus
+	cachedRemotes      []RemoteHost
+	cachedConnsByIP    map[string]int           // ESTABLISHED connections by local IP
+	remoteNames        *hostnameCache           // Reverse DNS cache by IP
+	cachedSockets      map[int32]map[string]int // Per-PID socket counts by state
+	lastProbeAt        time.Time
+	cachedConnectivity ConnectivityStatus
+	lastProxyAt        time.Time // LowPower only
+	cachedProxy        ProxyStatus
+	lastBatteryAt      time.Time // LowPower only
+	cachedBatteries    []BatteryStatus
 
 	// Fast metrics (1s).
-	prevNet      map[string]net.IOCountersStat
-	lastNetAt    time.Time
-	rxHistoryBuf *RingBuffer
-	txHistoryBuf *RingBuffer
-	lastGPUAt    time.Time
-	cachedGPU    []GPUStatus
-	prevDiskIO   disk.IOCountersStat
-	lastDiskAt   time.Time
+	prevNet       map[string]net.IOCountersStat
+	prevNetAt     map[string]time.Time // When each prevNet entry was taken
+	prevCarrier   map[string]uint64
+	lastNetAt     time.Time
+	sessionBase   map[string]net.IOCountersStat
+	ifaceCache    map[string]interfaceInfo
+	ifaceMissing  map[string]bool      // Counter names the last refresh didn't return
+	nicDrivers    map[string]nicDriver // Per interface, filled by nic_drivers and never refreshed
+	containerNet  []ContainerNetStatus // Set by collectNetwork
+	lastVethMapAt time.Time
+	cachedVethMap map[string]string // Host veth to container name
+	prevIPs       map[string]string
+	lastIfaceAt   time.Time
+	rxHistoryBuf  *RingBuffer
+	sessionRx     uint64 // Session bytes over every interface in the history
+	sessionTx     uint64
+	txHistoryBuf  *RingBuffer
+	ifaceRxHist   map[string]*RingBuffer // Per-interface rates, same window
+	ifaceTxHist   map[string]*RingBuffer
+	lastGPUAt     time.Time
+	cachedGPU     []GPUStatus
+	prevDiskIO    disk.IOCountersStat
+	prevProcIO    map[int32]procIO // Per PID, replaced every sample
+	lastProcIOAt  time.Time
+	lastDiskAt    time.Time
+	ifaceOrder    []string // OrderStable: names in first-seen order
+	prevTCP       tcpCounters
+	prevTCPAt     time.Time
+	prevFamily    familyOctets
+	prevFamilyAt  time.Time
+	prevVMStat    vmStatSample // macOS vm_stat counters for paging rates
+	prevVMStatAt  time.Time
+	prevDiskstat  map[string]diskstatsSample
+	diskTrend     map[string][]usageSample
+	diskAlerts    map[string]*diskAlertState
+	uploadStreak  map[string]int  // Consecutive upload-heavy ticks per interface
+	uploadFiring  map[string]bool // Interfaces whose upload alert is firing
+	flapHistory   map[string][]flapSample
+	lastActiveAt  map[string]time.Time // Last tick each interface moved traffic
+	dormantTicks  map[string]int       // Consecutive unchanged ticks of down or unused interfaces
+
+	prevProcCPU map[int32]procCPUSample // Per-PID CPU time for top processes
+	procPass    uint64                  // Top-process passes run so far
+	procResume  int32                   // PID a truncated pass stopped at; 0 after a complete one
+
+	// Quota accounting. quotaMu guards quotaUsage, quotaUnsaved and
+	// quotaDirty, which SaveQuotas may use while a Collect is in flight.
+	quotaPending  map[string]uint64 // Bytes seen since the last trackQuotas
+	quotaMu       sync.Mutex
+	quotaUsage    map[string]quotaUsage
+	quotaUnsaved  map[string]uint64 // Bytes counted since the last save
+	quotaDirty    bool              // Usage changed since the last save
+	lastQuotaSave time.Time
+
+	// Network change tracking.
+	prevFingerprint string
+	fingerprintSeen bool
+
+	// Proxy state tracking.
+	proxySeen        bool
+	proxyEnabled     bool
+	proxyLastChange  time.Time
+	proxyTransitions []time.Time
 }
 
 func NewCollector() *Collector {
 	return &Collector{
+		Interval:     defaultSampleInterval,
 		prevNet:      make(map[string]net.IOCountersStat),
+		prevNetAt:    make(map[string]time.Time),
+		prevCarrier:  make(map[string]uint64),
+		sessionBase:  make(map[string]net.IOCountersStat),
 		rxHistoryBuf: NewRingBuffer(NetworkHistorySize),
 		txHistoryBuf: NewRingBuffer(NetworkHistorySize),
 	}
 }
 
 func (c *Collector) Collect() (MetricsSnapshot, error) {
+	return c.CollectContext(context.Background())
+}
+
+// CollectContext is Collect under ctx: cancelling it stops the collectors
+// that take a context, which then return what they have.
+func (c *Collector) CollectContext(ctx context.Context) (MetricsSnapshot, error) {
 	now := time.Now()
 
 	// Host info is cached by gopsutil; fetch once.
@@ -233,100 +792,194 @@ func (c *Collector) Collect() (MetricsSnapshot, error) {
 	if hostInfo == nil {
 		hostInfo = &host.InfoStat{}
 	}
+	if c.caps == nil {
+		c.caps = CollectCapabilities()
+	}
 
 	var (
-		wg       sync.WaitGroup
-		errMu    sync.Mutex
-		mergeErr error
-
 		cpuStats     CPUStatus
 		memStats     MemoryStatus
 		diskStats    []DiskStatus
 		diskIO       DiskIOStatus
 		netStats     []NetworkStatus
 		proxyStats   ProxyStatus
+		connectivity ConnectivityStatus
 		batteryStats []BatteryStatus
 		thermalStats ThermalStatus
 		sensorStats  []SensorReading
 		gpuStats     []GPUStatus
 		btStats      []BluetoothDevice
 		topProcs     []ProcessInfo
+		topTruncated bool
+		procReaders  []ProcessDiskIO
+		procWriters  []ProcessDiskIO
+		procIOHidden int
+		containers   ContainerStatus
+		tcpStats     TCPStatus
+		ipFamilies   IPFamilyStatus
+		neighbors    NeighborStatus
+		coreTemps    map[coreKey]float64
+		netMounts    []DiskStatus
+		timeSync     TimeSyncStatus
+		updates      UpdateStatus
+		firewall     FirewallStatus
+		assertions   PowerAssertionStatus
+		procCounts   ProcessCountStatus
+		uplinks      []string
+		routes       RouteSummary
+		wifi         WiFiStatus
+		arrays       []ArrayStatus
+		listeners    []ListenerStatus
+		nicDrivers   map[string]nicDriver
 	)
+	pendingDrivers := c.pendingNICDrivers()
 
-	// Helper to launch concurrent collection.
-	collect := func(fn func() error) {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					errMu.Lock()
-					panicErr := fmt.Errorf("collector panic: %v", r)
-					if mergeErr == nil {
-						mergeErr = panicErr
-					} else {
-						mergeErr = fmt.Errorf("%v; %w", mergeErr, panicErr)
-					}
-					errMu.Unlock()
-				}
-			}()
-			if err := fn(); err != nil {
-				errMu.Lock()
-				if mergeErr == nil {
-					mergeErr = err
-				} else {
-					mergeErr = fmt.Errorf("%v; %w", mergeErr, err)
-				}
-				errMu.Unlock()
-			}
-		}()
-	}
+	g := newCollectGroup(ctx, c)
 
 	// Launch independent collection tasks.
-	collect(func() (err error) { cpuStats, err = collectCPU(); return })
-	collect(func() (err error) { memStats, err = collectMemory(); return })
-	collect(func() (err error) { diskStats, err = collectDisks(); return })
-	collect(func() (err error) { diskIO = c.collectDiskIO(now); return nil })
-	collect(func() (err error) { netStats, err = c.collectNetwork(now); return })
-	collect(func() (err error) { proxyStats = collectProxy(); return nil })
-	collect(func() (err error) { batteryStats, _ = collectBatteries(); return nil })
-	collect(func() (err error) { thermalStats = collectThermal(); return nil })
-	// Sensors disabled - CPU temp already shown in CPU card
-	// collect(func() (err error) { sensorStats, _ = collectSensors(); return nil })
-	collect(func() (err error) { gpuStats, err = c.collectGPU(now); return })
-	collect(func() (err error) {
-		// Bluetooth is slow; cache for 30s.
-		if now.Sub(c.lastBTAt) > 30*time.Second || len(c.lastBT) == 0 {
-			btStats = c.collectBluetooth(now)
-			c.lastBT = btStats
-			c.lastBTAt = now
-		} else {
-			btStats = c.lastBT
+	g.run("cpu", func(context.Context) (err error) { cpuStats, err = collectCPU(); return })
+	g.run("memory", func(context.Context) (err error) { memStats, err = c.collectMemory(now); return })
+	g.run("disks", func(context.Context) (err error) { diskStats, err = collectDisks(); return })
+	g.run("disk_io", func(context.Context) (err error) { diskIO = c.collectDiskIO(now); return nil })
+	g.run("network", func(context.Context) (err error) { netStats, err = c.collectNetwork(now); return })
+	g.run("proxy", func(context.Context) (err error) {
+		proxyStats = c.collectProxy(now)
+		if !c.LowPower {
+			// Probes depend on the detected proxy, so they start once it is
+			// known, under their own timeout.
+			proxy := proxyStats
+			g.run("connectivity", func(ctx context.Context) (err error) {
+				connectivity = c.collectConnectivity(ctx, now, proxy)
+				return nil
+			})
 		}
 		return nil
 	})
-	collect(func() (err error) { topProcs = collectTopProcesses(); return nil })
+	g.run("batteries", func(context.Context) (err error) { batteryStats = c.collectBatteries(now); return nil })
+
+	// Everything below shells out or walks every process; LowPower skips it.
+	if !c.LowPower {
+		g.run("thermal", func(ctx context.Context) (err error) {
+			thermalStats = collectThermal(ctx)
+			if state, err := CollectThermalState(ctx); err == nil {
+				thermalStats.CPUSpeedLimit, thermalStats.SchedulerLimit, thermalStats.Throttled = state.CPUSpeedLimit, state.SchedulerLimit, state.Throttled
+			} else {
+				logDegraded(c.logger(), "thermal_state", err)
+			}
+			return nil
+		})
+		g.run("core_temps", func(ctx context.Context) (err error) { coreTemps = collectCoreTemps(ctx); return nil })
+		// Sensors disabled - CPU temp already shown in CPU card
+		// g.run("sensors", func(context.Context) (err error) { sensorStats, _ = collectSensors(); return nil })
+		g.run("gpu", func(ctx context.Context) (err error) { gpuStats, err = c.collectGPU(ctx, now); return })
+		g.run("bluetooth", func(ctx context.Context) (err error) {
+			// Bluetooth is slow; cache for 30s.
+			if now.Sub(c.lastBTAt) > 30*time.Second || len(c.lastBT) == 0 {
+				btStats = c.collectBluetooth(ctx, now)
+				c.lastBT = btStats
+				c.lastBTAt = now
+			} else {
+				btStats = c.lastBT
+			}
+			return nil
+		})
+		g.run("top_processes", func(ctx context.Context) (err error) { topProcs, topTruncated = c.topProcesses(ctx, now); return nil })
+		g.run("process_io", func(context.Context) (err error) {
+			procReaders, procWriters, procIOHidden = c.collectProcessDiskIO(now)
+			return nil
+		})
+		g.run("routes", func(ctx context.Context) (err error) { routes, uplinks = c.collectRouteSummary(ctx, now); return nil })
+		g.run("wifi", func(ctx context.Context) (err error) { wifi = c.collectWiFi(ctx, now); return nil })
+		g.run("storage_arrays", func(ctx context.Context) (err error) { arrays = c.collectStorageArrays(ctx, now); return nil })
+		g.run("listeners", func(context.Context) (err error) { listeners = c.collectListeners(now); return nil })
+		g.run("nic_drivers", func(ctx context.Context) (err error) {
+			if len(pendingDrivers) > 0 && commandExists("ethtool") {
+				nicDrivers = lookupNICDrivers(ctx, pendingDrivers)
+			}
+			return nil
+		})
+		g.run("containers", func(ctx context.Context) (err error) { containers = c.collectContainers(ctx, now); return nil })
+		g.run("tcp", func(ctx context.Context) (err error) { tcpStats = c.collectTCP(ctx, now); return nil })
+		g.run("ip_families", func(context.Context) (err error) { ipFamilies = c.collectIPFamilies(now); return nil })
+		g.run("neighbors", func(ctx context.Context) (err error) { neighbors = c.collectNeighbors(ctx, now); return nil })
+		g.run("time_sync", func(ctx context.Context) (err error) { timeSync = c.collectTimeSync(ctx, now); return nil })
+		g.run("updates", func(ctx context.Context) (err error) { updates = c.collectUpdates(ctx, now); return nil })
+		g.run("firewall", func(ctx context.Context) (err error) { firewall = c.collectFirewall(ctx, now); return nil })
+		g.run("power_assertions", func(ctx context.Context) (err error) { assertions = c.collectPowerAssertions(ctx, now); return nil })
+		g.run("network_mounts", func(context.Context) (err error) { netMounts = c.collectNetworkMounts(now); return nil })
+		g.run("process_counts", func(context.Context) (err error) {
+			// Per-process status reads are slow on macOS; cache for 30s.
+			if now.Sub(c.lastProcCountAt) > processCountCacheTTL || c.cachedProcCounts.Total == 0 {
+				if counts, err := collectProcessCounts(); err != nil {
+					logDegraded(c.logger(), "process_counts", err)
+				} else {
+					c.cachedProcCounts = counts
+					c.lastProcCountAt = now
+				}
+			}
+			procCounts = c.cachedProcCounts
+			return nil
+		})
+	}
 
 	// Wait for all to complete.
-	wg.Wait()
+	errCount, mergeErr := g.wait()
 
 	// Dependent tasks (post-collect).
 	// Cache hardware info as it's expensive and rarely changes.
-	if !c.hasStatic || now.Sub(c.lastHWAt) > 10*time.Minute {
+	if !c.hasStatic || now.Sub(c.lastHWAt) > c.ttl(10*time.Minute) {
 		c.cachedHW = collectHardware(memStats.Total, diskStats)
 		c.lastHWAt = now
 		c.hasStatic = true
 	}
 	hwInfo := c.cachedHW
 
-	score, scoreMsg := calculateHealthScore(cpuStats, memStats, diskStats, diskIO, thermalStats)
+	// Drivers looked up this tick show from the next one.
+	for name, d := range nicDrivers {
+		if c.nicDrivers == nil {
+			c.nicDrivers = make(map[string]nicDriver)
+		}
+		c.nicDrivers[name] = d
+	}
+
+	diskIO.TopReaders, diskIO.TopWriters, diskIO.HiddenProcesses = procReaders, procWriters, procIOHidden
+	cpuStats.PerCoreTemp = mergeCoreTemps(cpuStats.PerCore, coreTemps, cpuCoreIDFunc)
+	c.annotateDiskLatency(diskStats)
+	c.annotateDiskTrends(now, diskStats)
+	proxyStats = c.trackProxyState(now, proxyStats)
+	if proxyStats.Type == "TUN" {
+		proxyStats.Underlying, proxyStats.UnderlyingGuess = tunnelEgress(c.cachedRouteEntries)
+	}
+	events := c.trackIPChanges(now, c.ifaceCache)
+	if !c.LowPower {
+		events = append(events, c.trackNetworkChange(now, wifi, routes)...)
+	}
+	if c.PrimaryInterface != "" && !slices.ContainsFunc(netStats, func(n NetworkStatus) bool { return n.Primary }) && len(uplinks) > 0 {
+		pinInterface(netStats, uplinks[0])
+	}
+	alerts := c.evaluateDiskAlerts(diskStats)
+	annotateSocketCounts(topProcs, c.cachedSockets)
+	otherConns := annotateInterfaceConns(netStats, c.cachedConnsByIP, c.ifaceCache)
+	quotas, quotaAlerts := c.trackQuotas(now)
+	if n := c.evictSeries(); n > 0 {
+		c.logger().Debug("evicted stale series", "count", n, "max", c.maxSeries())
+	}
+	alerts = append(alerts, quotaAlerts...)
+	alerts = append(alerts, c.evaluateUploadAlerts(netStats)...)
+	alerts = append(alerts, c.evaluateFlapAlerts(now, netStats)...)
+
+	score, scoreMsg := calculateWeightedHealthScore(c.healthWeights(), cpuStats, memStats, diskStats, diskIO, thermalStats, errCount)
+	sortDisks(diskStats, c.DiskSort)
 
 	return MetricsSnapshot{
+		SchemaVersion:  SnapshotSchemaVersion,
+		Capabilities:   c.caps,
 		CollectedAt:    now,
 		Host:           hostInfo.Hostname,
 		Platform:       fmt.Sprintf("%s %s", hostInfo.Platform, hostInfo.PlatformVersion),
 		Uptime:         formatUptime(hostInfo.Uptime),
 		Procs:          hostInfo.Procs,
+		Session:        collectSession(c.Getenv),
 		Hardware:       hwInfo,
 		HealthScore:    score,
 		HealthScoreMsg: scoreMsg,
@@ -337,19 +990,142 @@ func (c *Collector) Collect() (MetricsSnapshot, error) {
 		DiskIO:         diskIO,
 		Network:        netStats,
 		NetworkHistory: NetworkHistory{
-			RxHistory: c.rxHistoryBuf.Slice(),
-			TxHistory: c.txHistoryBuf.Slice(),
+			RxHistory:      c.rxHistoryBuf.Slice(),
+			TxHistory:      c.txHistoryBuf.Slice(),
+			SessionRxBytes: c.sessionRx,
+			SessionTxBytes: c.sessionTx,
 		},
-		Proxy:        proxyStats,
-		Batteries:    batteryStats,
-		Thermal:      thermalStats,
-		Sensors:      sensorStats,
-		Bluetooth:    btStats,
-		TopProcesses: topProcs,
+		IPFamilies:       ipFamilies,
+		Proxy:            proxyStats,
+		Connectivity:     connectivity,
+		Batteries:        batteryStats,
+		Thermal:          thermalStats,
+		Sensors:          sensorStats,
+		Bluetooth:        btStats,
+		TopProcesses:     topProcs,
+		TopTruncated:     topTruncated,
+		ProcessCounts:    procCounts,
+		MultiHomed:       len(uplinks) > 1,
+		Uplinks:          uplinks,
+		Routes:           routes,
+		WiFi:             wifi,
+		StorageArrays:    arrays,
+		Listeners:        listeners,
+		RemoteHosts:      c.cachedRemotes,
+		OtherConns:       otherConns,
+		Containers:       containers,
+		ContainerNetwork: c.containerNet,
+		TCP:              tcpStats,
+		Neighbors:        neighbors,
+		TimeSync:         timeSync,
+		Updates:          updates,
+		Firewall:         firewall,
+		PowerAssertions:  assertions,
+		NetworkMounts:    netMounts,
+		Events:           events,
+		Alerts:           alerts,
+		Quotas:           quotas,
 	}, mergeErr
 }
 
-func runCmd(ctx context.Context, name string, args ...string) (string, error) {
+func (c *Collector) logger() *slog.Logger {
+	if c.Logger == nil {
+		return discardLogger
+	}
+	return c.Logger
+}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// logDegraded records why a collector returned partial or empty data.
+func logDegraded(log *slog.Logger, collector string, reason error) {
+	log.Debug("collector degraded", "collector", collector, "reason", reason)
+}
+
+// interval returns the configured sample interval, falling back to the default.
+func (c *Collector) interval() time.Duration {
+	if c.Interval <= 0 {
+		return defaultSampleInterval
+	}
+	return c.Interval
+}
+
+// lowPowerTTLFactor stretches cache lifetimes in LowPower mode.
+const lowPowerTTLFactor = 6
+
+// lowPowerRefresh is how long LowPower reuses per-tick collectors (proxy,
+// batteries) that otherwise run every sample.
+const lowPowerRefresh = 30 * time.Second
+
+// ttl scales a cache lifetime for the current power mode.
+func (c *Collector) ttl(d time.Duration) time.Duration {
+	if c.LowPower {
+		return d * lowPowerTTLFactor
+	}
+	return d
+}
+
+func (c *Collector) collectProxy(now time.Time) ProxyStatus {
+	if c.LowPower && !c.lastProxyAt.IsZero() && now.Sub(c.lastProxyAt) < lowPowerRefresh {
+		return c.cachedProxy
+	}
+	c.cachedProxy = collectProxyDetailed(c.Getenv, nil, c.EnvPrecedence, c.logger(), c.ProxyDetail)
+	c.lastProxyAt = now
+	return c.cachedProxy
+}
+
+func (c *Collector) collectBatteries(now time.Time) []BatteryStatus {
+	if c.LowPower && !c.lastBatteryAt.IsZero() && now.Sub(c.lastBatteryAt) < lowPowerRefresh {
+		return c.cachedBatteries
+	}
+	c.cachedBatteries, _ = collectBatteries()
+	c.lastBatteryAt = now
+	return c.cachedBatteries
+}
+
+// rateWindow returns the elapsed seconds to use for rate math. Windows shorter
+// than half the sample interval (including clock steps backwards) are clamped
+// so a tick that fires early doesn't inflate rates.
+func (c *Collector) rateWindow(now, last time.Time) float64 {
+	elapsed := now.Sub(last).Seconds()
+	minWindow := c.interval().Seconds() / 2
+	if elapsed < minWindow {
+		return minWindow
+	}
+	return elapsed
+}
+
+// A tick whose elapsed time is negative or beyond clockJumpFactor intervals
+// (and at least minClockJump) is treated as a clock step rather than a
+// slow tick: averaging counters over it would report bogus rates.
+const (
+	clockJumpFactor = 30
+	minClockJump    = 2 * time.Minute
+)
+
+// clockJumped reports whether the wall-clock time from last to now is
+// implausible for a rate window, logging the jump against collector when it
+// is. Monotonic readings never go backwards and on most platforms stop
+// while the machine sleeps, so comparing them would only ever fire for
+// synthetic or restored times; the wall clock also shows a step or a
+// resume from suspend.
+func (c *Collector) clockJumped(collector string, now, last time.Time) bool {
+	elapsed := now.Round(0).Sub(last.Round(0))
+	if elapsed >= 0 && elapsed <= max(clockJumpFactor*c.interval(), minClockJump) {
+		return false
+	}
+	c.logger().Warn("clock jump, resetting rate baseline", "collector", collector, "elapsed", elapsed.String())
+	return true
+}
+
+// cmdRunner runs an external command and returns its stdout.
+type cmdRunner func(ctx context.Context, name string, args ...string) (string, error)
+
+// runCmd is the runner used by all collectors. It is a variable so tests can
+// record or fake external commands.
+var runCmd cmdRunner = execCmd
+
+func execCmd(ctx context.Context, name string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, name, args...)
 	output, err := cmd.Output()
 	if err != nil {
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	powerAssertionsCacheTTL = 30 * time.Second
+	pmsetAssertionsTimeout  = 2 * time.Second
+)
+
+// sleepAssertionTypes are the assertion types that keep a Mac, or its
+// display, awake.
+var sleepAssertionTypes = []string{
+	"PreventUserIdleSystemSleep",
+	"PreventSystemSleep",
+	"PreventUserIdleDisplaySleep",
+	"NoIdleSleepAssertion",
+	"NoDisplaySleepAssertion",
+}
+
+// PowerAssertion is one process's hold on sleep, from `pmset -g
+// assertions`.
+type PowerAssertion struct {
+	PID     int32  `json:"pid"`
+	Process string `json:"process"`
+	Type    string `json:"type"` // e.g. PreventUserIdleSystemSleep
+	Name    string `json:"name"` // The holder's description
+	// OnBehalfOf is the PID a daemon took the assertion for, e.g. the app
+	// playing through coreaudiod; 0 when the holder took it for itself.
+	OnBehalfOf int32 `json:"on_behalf_of,omitempty"`
+}
+
+// PowerAssertionStatus says what is keeping the machine awake. Supported
+// is false off macOS.
+type PowerAssertionStatus struct {
+	Supported bool             `json:"supported"`
+	Holders   []PowerAssertion `json:"holders,omitempty"`
+}
+
+var pmsetAssertionRe = regexp.MustCompile(`^\s*pid (\d+)\((.*)\): \[0x[0-9a-fA-F]+\] \S+ (\S+) named: "(.*)"`)
+
+// parsePmsetAssertions returns the sleep-preventing assertions in the
+// "Listed by owning process" section of `pmset -g assertions`, e.g.
+//
+//	pid 123(coreaudiod): [0x0000a0c100018a3b] 00:05:12 PreventUserIdleSystemSleep named: "com.apple.audio.context.preventuseridlesleep"
+//		Created for PID: 456.
+func parsePmsetAssertions(out string) []PowerAssertion {
+	var holders []PowerAssertion
+	inProcesses := false
+	for line := range strings.Lines(out) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "Listed by owning process:"):
+			inProcesses = true
+			continue
+		case strings.HasPrefix(trimmed, "Kernel Assertions:"), strings.HasPrefix(trimmed, "Idle sleep preventers:"):
+			inProcesses = false
+			continue
+		}
+		if !inProcesses {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(trimmed, "Created for PID:"); ok {
+			pid, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(rest), "."), 10, 32)
+			if err == nil && len(holders) > 0 {
+				holders[len(holders)-1].OnBehalfOf = int32(pid)
+			}
+			continue
+		}
+		m := pmsetAssertionRe.FindStringSubmatch(line)
+		if m == nil || !slices.Contains(sleepAssertionTypes, m[3]) {
+			continue
+		}
+		pid, _ := strconv.ParseInt(m[1], 10, 32)
+		holders = append(holders, PowerAssertion{PID: int32(pid), Process: m[2], Type: m[3], Name: m[4]})
+	}
+	return holders
+}
+
+// CollectPowerAssertions reports the processes holding sleep assertions.
+func CollectPowerAssertions(ctx context.Context) PowerAssertionStatus {
+	if runtime.GOOS != "darwin" || !commandExists("pmset") {
+		return PowerAssertionStatus{}
+	}
+	ctx, cancel := context.WithTimeout(ctx, pmsetAssertionsTimeout)
+	defer cancel()
+	out, err := runCmd(ctx, "pmset", "-g", "assertions")
+	if err != nil {
+		return PowerAssertionStatus{}
+	}
+	return PowerAssertionStatus{Supported: true, Holders: parsePmsetAssertions(out)}
+}
+
+func (c *Collector) collectPowerAssertions(ctx context.Context, now time.Time) PowerAssertionStatus {
+	if !c.lastAssertionsAt.IsZero() && now.Sub(c.lastAssertionsAt) < c.ttl(powerAssertionsCacheTTL) {
+		return c.cachedAssertions
+	}
+	c.cachedAssertions = CollectPowerAssertions(ctx)
+	c.lastAssertionsAt = now
+	return c.cachedAssertions
+}
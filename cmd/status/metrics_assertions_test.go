@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const pmsetAssertionsOutput = `2024-05-10 10:12:33 -0700
+Assertion status system-wide:
+   BackgroundTask                 0
+   ApplePushServiceTask           0
+   UserIsActive                   1
+   PreventUserIdleDisplaySleep    1
+   PreventSystemSleep             0
+   ExternalMedia                  0
+   PreventUserIdleSystemSleep     1
+   NetworkClientActive            0
+Listed by owning process:
+   pid 123(coreaudiod): [0x0000a0c100018a3b] 00:05:12 PreventUserIdleSystemSleep named: "com.apple.audio.AppleHDAEngineOutput:1B,0,1,1:0.context.preventuseridlesleep"  
+	Created for PID: 456. 
+   pid 789(Google Chrome Helper (Renderer)): [0x0000a0c200018a3c] 00:01:00 PreventUserIdleDisplaySleep named: "Video Wake Lock"  
+   pid 88(WindowServer): [0x0000a0c300018a3d] 00:30:00 UserIsActive named: "com.apple.iohideventsystem.queue.tickle serviceID:1000 product:Keyboard"  
+	Timeout will fire in 120 secs Action=TimeoutActionRelease
+   pid 2001(caffeinate): [0x0000a0c400018a3e] 00:00:42 PreventUserIdleSystemSleep named: "caffeinate command-line tool"  
+	Details: caffeinate asserting on behalf of '/bin/sleep 600' (pid 2000)
+Kernel Assertions: 0x4=USB
+   id=500  level=255 0x4=USB mod=1/1/70, 12:00 AM description=com.apple.usb.externaldevice.14100000 owner=AppleUSBXHCIPort
+Idle sleep preventers: IODisplayWrangler
+`
+
+func TestParsePmsetAssertions(t *testing.T) {
+	got := parsePmsetAssertions(pmsetAssertionsOutput)
+	want := []PowerAssertion{
+		{PID: 123, Process: "coreaudiod", Type: "PreventUserIdleSystemSleep", Name: "com.apple.audio.AppleHDAEngineOutput:1B,0,1,1:0.context.preventuseridlesleep", OnBehalfOf: 456},
+		{PID: 789, Process: "Google Chrome Helper (Renderer)", Type: "PreventUserIdleDisplaySleep", Name: "Video Wake Lock"},
+		{PID: 2001, Process: "caffeinate", Type: "PreventUserIdleSystemSleep", Name: "caffeinate command-line tool"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parsePmsetAssertions() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParsePmsetAssertionsNoHolders(t *testing.T) {
+	out := "Assertion status system-wide:\n   PreventUserIdleSystemSleep     0\nListed by owning process:\nNo kernel assertions.\n"
+	if got := parsePmsetAssertions(out); len(got) != 0 {
+		t.Fatalf("expected no holders, got %+v", got)
+	}
+}
@@ -169,7 +169,7 @@ func getSystemPowerOutput() string {
 	return cachedPower
 }
 
-func collectThermal() ThermalStatus {
+func collectThermal(ctx context.Context) ThermalStatus {
 	if runtime.GOOS != "darwin" {
 		return ThermalStatus{}
 	}
@@ -192,7 +192,7 @@ func collectThermal() ThermalStatus {
 	}
 
 	// Power metrics from ioreg (fast, real-time).
-	ctxPower, cancelPower := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	ctxPower, cancelPower := context.WithTimeout(ctx, 500*time.Millisecond)
 	defer cancelPower()
 	if out, err := runCmd(ctxPower, "ioreg", "-rn", "AppleSmartBattery"); err == nil {
 		for line := range strings.Lines(out) {
@@ -268,7 +268,7 @@ func collectThermal() ThermalStatus {
 
 	// Fallback: thermal level proxy.
 	if thermal.CPUTemp == 0 {
-		ctx2, cancel2 := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		ctx2, cancel2 := context.WithTimeout(ctx, 500*time.Millisecond)
 		defer cancel2()
 		out2, err := runCmd(ctx2, "sysctl", "-n", "machdep.xcpm.cpu_thermal_level")
 		if err == nil {
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// vethMapTimeout bounds the runtime CLI calls behind one mapping.
+	vethMapTimeout  = 2 * time.Second
+	maxContainerNet = 10
+)
+
+// ContainerNetStatus is one container's throughput, summed over the host
+// veth interfaces attached to it. Rates are from the container's side: Rx
+// is what it received, which is what its host veth sent.
+type ContainerNetStatus struct {
+	Name       string   `json:"name"`
+	Interfaces []string `json:"interfaces"` // Host-side veths
+	RxRateMBs  float64  `json:"rx_rate_mbs"`
+	TxRateMBs  float64  `json:"tx_rate_mbs"`
+}
+
+// vethMapperFunc maps host veth names to container names. It is a
+// variable so tests can supply a mapping without a runtime.
+var vethMapperFunc = mapVethsToContainers
+
+// mapVethsToContainers pairs each host veth with the container whose
+// network namespace holds its peer: the peer's iflink, read through
+// /proc/<pid>/root/sys, is the host veth's ifindex. Linux only, and it
+// needs permission to read the container's root. Containers sharing the
+// host network have no veth and are left out.
+func mapVethsToContainers(ctx context.Context) (map[string]string, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.ErrUnsupported
+	}
+	hostVeths := make(map[int]string)
+	entries, _ := os.ReadDir("/sys/class/net")
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "veth") {
+			continue
+		}
+		raw, err := readSysNetAttr(e.Name(), "ifindex")
+		if err != nil {
+			continue
+		}
+		if idx, err := strconv.Atoi(raw); err == nil {
+			hostVeths[idx] = e.Name()
+		}
+	}
+	if len(hostVeths) == 0 {
+		return nil, nil
+	}
+	pids, err := containerPIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mapping := make(map[string]string)
+	for name, pid := range pids {
+		links, _ := filepath.Glob(filepath.Join("/proc", strconv.Itoa(pid), "root/sys/class/net/*/iflink"))
+		for _, path := range links {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			idx, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				continue
+			}
+			if veth, ok := hostVeths[idx]; ok {
+				mapping[veth] = name
+			}
+		}
+	}
+	return mapping, nil
+}
+
+// containerPIDs returns running containers' names and init PIDs from the
+// first runtime CLI found.
+func containerPIDs(ctx context.Context) (map[string]int, error) {
+	for _, cli := range []string{"docker", "podman", "nerdctl"} {
+		if !commandExists(cli) {
+			continue
+		}
+		ids, err := runCmd(ctx, cli, "ps", "-q")
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.Fields(ids)) == 0 {
+			return nil, nil
+		}
+		args := append([]string{"inspect", "--format", "{{.Name}} {{.State.Pid}}"}, strings.Fields(ids)...)
+		out, err := runCmd(ctx, cli, args...)
+		if err != nil {
+			return nil, err
+		}
+		return parseContainerPIDs(out), nil
+	}
+	return nil, errors.New("no container runtime CLI")
+}
+
+// parseContainerPIDs parses "name pid" lines from `inspect --format`.
+// Docker prefixes names with "/"; a PID of 0 means the container stopped
+// between ps and inspect.
+func parseContainerPIDs(out string) map[string]int {
+	pids := make(map[string]int)
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil || pid <= 0 {
+			continue
+		}
+		pids[strings.TrimPrefix(fields[0], "/")] = pid
+	}
+	return pids
+}
+
+// aggregateContainerNet moves the interfaces in mapping out of stats and
+// sums them per container, busiest first. Interfaces it cannot map stay in
+// rest, so an empty mapping leaves stats as they were.
+func aggregateContainerNet(stats []NetworkStatus, mapping map[string]string) (containers []ContainerNetStatus, rest []NetworkStatus) {
+	if len(mapping) == 0 {
+		return nil, stats
+	}
+	byName := make(map[string]*ContainerNetStatus)
+	for _, s := range stats {
+		name, ok := mapping[s.Name]
+		if !ok {
+			rest = append(rest, s)
+			continue
+		}
+		cs := byName[name]
+		if cs == nil {
+			cs = &ContainerNetStatus{Name: name}
+			byName[name] = cs
+		}
+		cs.Interfaces = append(cs.Interfaces, s.Name)
+		cs.RxRateMBs += s.TxRateMBs
+		cs.TxRateMBs += s.RxRateMBs
+	}
+	for _, cs := range byName {
+		slices.Sort(cs.Interfaces)
+		containers = append(containers, *cs)
+	}
+	slices.SortFunc(containers, func(a, b ContainerNetStatus) int {
+		if c := cmp.Compare(b.RxRateMBs+b.TxRateMBs, a.RxRateMBs+a.TxRateMBs); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(containers) > maxContainerNet {
+		containers = containers[:maxContainerNet]
+	}
+	return containers, rest
+}
+
+// vethContainers returns the cached veth-to-container mapping, refreshed
+// every containerCacheTTL. LowPower never maps, so veths are reported as
+// plain interfaces.
+func (c *Collector) vethContainers(now time.Time) map[string]string {
+	if c.LowPower {
+		return nil
+	}
+	if !c.lastVethMapAt.IsZero() && now.Sub(c.lastVethMapAt) < c.ttl(containerCacheTTL) {
+		return c.cachedVethMap
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), vethMapTimeout)
+	defer cancel()
+	mapping, err := vethMapperFunc(ctx)
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		logDegraded(c.logger(), "container_network", err)
+	}
+	c.cachedVethMap = mapping
+	c.lastVethMapAt = now
+	return c.cachedVethMap
+}
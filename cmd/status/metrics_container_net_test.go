@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	gopsutilnet "github.com/shirou/gopsutil/v4/net"
+)
+
+func TestCollectNetworkGroupsVethsByContainer(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{
+		{Name: "eth0"},
+		{Name: "veth1a2b"},
+		{Name: "veth3c4d"},
+		{Name: "veth5e6f"},
+		{Name: "vethdead"}, // No container found for it
+	}
+	stubNetworkCounters(t, &counters)
+	orig := vethMapperFunc
+	t.Cleanup(func() { vethMapperFunc = orig })
+	calls := 0
+	vethMapperFunc = func(context.Context) (map[string]string, error) {
+		calls++
+		return map[string]string{"veth1a2b": "web", "veth3c4d": "web", "veth5e6f": "db"}, nil
+	}
+
+	const mb = 1024 * 1024
+	c := NewCollector()
+	start := time.Now()
+	c.collectNetwork(start)
+	counters = []gopsutilnet.IOCountersStat{
+		{Name: "eth0", BytesRecv: 8 * mb, BytesSent: 8 * mb},
+		{Name: "veth1a2b", BytesRecv: 1 * mb, BytesSent: 4 * mb},
+		{Name: "veth3c4d", BytesRecv: 1 * mb, BytesSent: 2 * mb},
+		{Name: "veth5e6f", BytesRecv: 1 * mb},
+		{Name: "vethdead", BytesRecv: 1 * mb},
+	}
+	stats, err := c.collectNetwork(start.Add(time.Second))
+	if err != nil {
+		t.Fatalf("collectNetwork: %v", err)
+	}
+
+	want := []ContainerNetStatus{
+		{Name: "web", Interfaces: []string{"veth1a2b", "veth3c4d"}, RxRateMBs: 6, TxRateMBs: 2},
+		{Name: "db", Interfaces: []string{"veth5e6f"}, TxRateMBs: 1},
+	}
+	if !reflect.DeepEqual(c.containerNet, want) {
+		t.Fatalf("containerNet = %+v, want %+v", c.containerNet, want)
+	}
+	var names []string
+	for _, s := range stats {
+		names = append(names, s.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"eth0", "vethdead"}) {
+		t.Fatalf("interfaces = %v, want eth0 and the unmapped veth", names)
+	}
+	if rx := c.rxHistoryBuf.MeanLast(1); rx != 9 {
+		t.Fatalf("total rx = %v, want 9 without container veths", rx)
+	}
+	if calls != 1 {
+		t.Fatalf("mapper called %d times, want once within the cache TTL", calls)
+	}
+}
+
+func TestCollectNetworkKeepsVethsWithoutMapping(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "veth1a2b"}}
+	stubNetworkCounters(t, &counters)
+	orig := vethMapperFunc
+	t.Cleanup(func() { vethMapperFunc = orig })
+	vethMapperFunc = func(context.Context) (map[string]string, error) { return nil, context.DeadlineExceeded }
+
+	c := NewCollector()
+	start := time.Now()
+	c.collectNetwork(start)
+	counters = []gopsutilnet.IOCountersStat{{Name: "veth1a2b", BytesRecv: 1024}}
+	stats, _ := c.collectNetwork(start.Add(time.Second))
+	if len(stats) != 1 || stats[0].Name != "veth1a2b" || c.containerNet != nil {
+		t.Fatalf("stats = %+v, containers = %+v; want the raw veth", stats, c.containerNet)
+	}
+}
+
+func TestParseContainerPIDs(t *testing.T) {
+	out := "/web 4121\n/db 4388\n/exited 0\nmalformed\npodman-name 5120\n"
+	want := map[string]int{"web": 4121, "db": 4388, "podman-name": 5120}
+	if got := parseContainerPIDs(out); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseContainerPIDs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	containerCacheTTL = 30 * time.Second
+	containerTimeout  = time.Second
+)
+
+// ContainerStatus is the number of running containers on the first
+// container runtime found. Runtime is empty when none is present.
+type ContainerStatus struct {
+	Runtime      string `json:"runtime,omitempty"` // docker, podman or nerdctl
+	RunningCount int    `json:"running_count"`
+}
+
+// containerRuntime is one way of counting running containers. available
+// must be cheap (a stat or PATH lookup); count is time-boxed by ctx.
+type containerRuntime struct {
+	name      string
+	available func() bool
+	count     func(ctx context.Context) (int, error)
+}
+
+var containerRuntimes = []containerRuntime{
+	dockerSocketRuntime("/var/run/docker.sock"),
+	containerCLIRuntime("docker"),
+	containerCLIRuntime("podman"),
+	containerCLIRuntime("nerdctl"), // containerd
+}
+
+// collectContainers counts running containers on the first available
+// runtime. An error from that runtime is returned with its name so callers
+// can tell "no containers" from "daemon not answering".
+func collectContainers(ctx context.Context, runtimes []containerRuntime) (ContainerStatus, error) {
+	for _, rt := range runtimes {
+		if !rt.available() {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, containerTimeout)
+		n, err := rt.count(ctx)
+		cancel()
+		if err != nil {
+			return ContainerStatus{Runtime: rt.name}, err
+		}
+		return ContainerStatus{Runtime: rt.name, RunningCount: n}, nil
+	}
+	return ContainerStatus{}, nil
+}
+
+// dockerSocketRuntime queries the Docker Engine API over its unix socket,
+// which avoids spawning the CLI. Podman's Docker-compatible socket works too
+// when it is linked to this path.
+func dockerSocketRuntime(path string) containerRuntime {
+	return containerRuntime{
+		name: "docker",
+		available: func() bool {
+			info, err := os.Stat(path)
+			return err == nil && info.Mode()&os.ModeSocket != 0
+		},
+		count: func(ctx context.Context) (int, error) {
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			}}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/containers/json", nil)
+			if err != nil {
+				return 0, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return 0, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return 0, fmt.Errorf("docker API: %s", resp.Status)
+			}
+			var containers []json.RawMessage
+			if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+				return 0, err
+			}
+			return len(containers), nil
+		},
+	}
+}
+
+// containerCLIRuntime counts `<cli> ps -q` lines; ps lists only running
+// containers by default.
+func containerCLIRuntime(cli string) containerRuntime {
+	return containerRuntime{
+		name:      cli,
+		available: func() bool { return commandExists(cli) },
+		count: func(ctx context.Context) (int, error) {
+			out, err := runCmd(ctx, cli, "ps", "-q")
+			if err != nil {
+				return 0, err
+			}
+			return len(strings.Fields(out)), nil
+		},
+	}
+}
+
+func (c *Collector) collectContainers(ctx context.Context, now time.Time) ContainerStatus {
+	if !c.lastContainerAt.IsZero() && now.Sub(c.lastContainerAt) < containerCacheTTL {
+		return c.cachedContainers
+	}
+	status, err := collectContainers(ctx, containerRuntimes)
+	if err != nil {
+		logDegraded(c.logger(), "containers", fmt.Errorf("%s: %w", status.Runtime, err))
+	}
+	c.cachedContainers = status
+	c.lastContainerAt = now
+	return c.cachedContainers
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func fixedRuntime(name string, available bool, n int, err error) containerRuntime {
+	return containerRuntime{
+		name:      name,
+		available: func() bool { return available },
+		count:     func(context.Context) (int, error) { return n, err },
+	}
+}
+
+func TestCollectContainersFirstAvailableRuntime(t *testing.T) {
+	runtimes := []containerRuntime{
+		fixedRuntime("docker", false, 99, nil),
+		fixedRuntime("podman", true, 3, nil),
+		fixedRuntime("nerdctl", true, 7, nil),
+	}
+	got, err := collectContainers(context.Background(), runtimes)
+	if err != nil {
+		t.Fatalf("collectContainers: %v", err)
+	}
+	if got != (ContainerStatus{Runtime: "podman", RunningCount: 3}) {
+		t.Fatalf("collectContainers() = %+v, want podman with 3", got)
+	}
+}
+
+func TestCollectContainersNoRuntime(t *testing.T) {
+	got, err := collectContainers(context.Background(), []containerRuntime{fixedRuntime("docker", false, 5, nil)})
+	if err != nil || got != (ContainerStatus{}) {
+		t.Fatalf("collectContainers() = %+v, %v; want empty", got, err)
+	}
+}
+
+func TestCollectContainersRuntimeError(t *testing.T) {
+	got, err := collectContainers(context.Background(), []containerRuntime{fixedRuntime("docker", true, 0, errors.New("daemon not running"))})
+	if err == nil || got.Runtime != "docker" || got.RunningCount != 0 {
+		t.Fatalf("collectContainers() = %+v, %v; want docker with error", got, err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/sensors"
+)
+
+// sensorTemperaturesFunc reads hardware temperature sensors. It is a
+// variable so tests can inject readings.
+var sensorTemperaturesFunc = sensors.TemperaturesWithContext
+
+// coreKey identifies a physical core. Core IDs restart on each package
+// (socket), so the package is part of the key.
+type coreKey struct {
+	pkg  int
+	core int
+}
+
+// cpuCoreIDFunc maps a logical CPU to the physical core its sensor reports
+// on. Hyperthread siblings share a core and so share a temperature.
+var cpuCoreIDFunc = func(cpu int) (coreKey, error) {
+	if runtime.GOOS != "linux" {
+		return coreKey{}, errors.ErrUnsupported
+	}
+	dir := fmt.Sprintf("/sys/devices/system/cpu/cpu%d/topology/", cpu)
+	pkg, err := readSysfsInt(dir + "physical_package_id")
+	if err != nil {
+		return coreKey{}, err
+	}
+	core, err := readSysfsInt(dir + "core_id")
+	if err != nil {
+		return coreKey{}, err
+	}
+	return coreKey{pkg: pkg, core: core}, nil
+}
+
+func readSysfsInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// collectCoreTemps returns per-core temperatures from the Intel coretemp
+// driver, whose sensors are labelled "Package id N" and "Core N". Other
+// platforms expose no per-core sensors and return nil.
+func collectCoreTemps(ctx context.Context) map[coreKey]float64 {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	// Partial reads come back with an error alongside the readings that
+	// worked; use whatever was read.
+	stats, _ := sensorTemperaturesFunc(ctx)
+	return parseCoreTemps(stats)
+}
+
+// parseCoreTemps picks coretemp_core_<id> readings. Each package has its own
+// coretemp device, read in order with its package sensor first, so a core
+// belongs to the package sensor most recently seen; without one it is
+// taken to be on package 0.
+func parseCoreTemps(stats []sensors.TemperatureStat) map[coreKey]float64 {
+	var temps map[coreKey]float64
+	pkg := 0
+	for _, s := range stats {
+		if rest, ok := strings.CutPrefix(s.SensorKey, "coretemp_package_id_"); ok {
+			if id, err := strconv.Atoi(strings.TrimSuffix(rest, "_input")); err == nil {
+				pkg = id
+			}
+			continue
+		}
+		rest, ok := strings.CutPrefix(s.SensorKey, "coretemp_core_")
+		if !ok || s.Temperature <= 0 {
+			continue
+		}
+		rest = strings.TrimSuffix(rest, "_input")
+		id, err := strconv.Atoi(rest)
+		if err != nil {
+			continue
+		}
+		if temps == nil {
+			temps = make(map[coreKey]float64)
+		}
+		key := coreKey{pkg: pkg, core: id}
+		if _, seen := temps[key]; !seen {
+			temps[key] = s.Temperature
+		}
+	}
+	return temps
+}
+
+// mergeCoreTemps aligns core temperatures with per-CPU usage. When the core
+// topology is unreadable, CPU i is assumed to sit on core i of package 0.
+// CPUs without a matching sensor, such as those on a package whose sensors
+// could not be told apart, get 0; nil means no CPU matched at all.
+func mergeCoreTemps(perCore []float64, temps map[coreKey]float64, coreID func(int) (coreKey, error)) []float64 {
+	if len(perCore) == 0 || len(temps) == 0 {
+		return nil
+	}
+	out := make([]float64, len(perCore))
+	matched := false
+	for cpu := range perCore {
+		key, err := coreID(cpu)
+		if err != nil {
+			key = coreKey{core: cpu}
+		}
+		if t, ok := temps[key]; ok {
+			out[cpu] = t
+			matched = true
+		}
+	}
+	if !matched {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/sensors"
+)
+
+var coretempReadings = []sensors.TemperatureStat{
+	{SensorKey: "coretemp_package_id_0", Temperature: 61},
+	{SensorKey: "coretemp_core_0", Temperature: 55},
+	{SensorKey: "coretemp_core_1", Temperature: 58},
+	{SensorKey: "coretemp_core_2", Temperature: 64},
+	{SensorKey: "coretemp_core_3", Temperature: 52},
+	{SensorKey: "acpitz", Temperature: 40},
+	{SensorKey: "coretemp_core_9", Temperature: 0}, // Unreadable sensor
+}
+
+func TestParseCoreTemps(t *testing.T) {
+	got := parseCoreTemps(coretempReadings)
+	if len(got) != 4 || got[coreKey{core: 0}] != 55 || got[coreKey{core: 2}] != 64 {
+		t.Fatalf("parseCoreTemps() = %v", got)
+	}
+	if got := parseCoreTemps([]sensors.TemperatureStat{{SensorKey: "k10temp_tctl", Temperature: 70}}); got != nil {
+		t.Fatalf("no per-core sensors should give nil, got %v", got)
+	}
+}
+
+func TestMergeCoreTempsWithHyperthreads(t *testing.T) {
+	// Eight logical CPUs on four cores: CPU i and i+4 are siblings.
+	load := []float64{90, 10, 20, 30, 80, 15, 25, 35}
+	coreID := func(cpu int) (coreKey, error) { return coreKey{core: cpu % 4}, nil }
+
+	got := mergeCoreTemps(load, parseCoreTemps(coretempReadings), coreID)
+	want := []float64{55, 58, 64, 52, 55, 58, 64, 52}
+	if !slices.Equal(got, want) {
+		t.Fatalf("mergeCoreTemps() = %v, want %v", got, want)
+	}
+}
+
+func TestMergeCoreTempsAcrossSockets(t *testing.T) {
+	// Two packages with two cores each; core IDs repeat per package.
+	readings := []sensors.TemperatureStat{
+		{SensorKey: "coretemp_package_id_0", Temperature: 60},
+		{SensorKey: "coretemp_core_0", Temperature: 50},
+		{SensorKey: "coretemp_core_1", Temperature: 51},
+		{SensorKey: "coretemp_package_id_1", Temperature: 80},
+		{SensorKey: "coretemp_core_0", Temperature: 70},
+		{SensorKey: "coretemp_core_1", Temperature: 71},
+	}
+	coreID := func(cpu int) (coreKey, error) { return coreKey{pkg: cpu / 2, core: cpu % 2}, nil }
+
+	got := mergeCoreTemps([]float64{1, 2, 3, 4}, parseCoreTemps(readings), coreID)
+	if want := []float64{50, 51, 70, 71}; !slices.Equal(got, want) {
+		t.Fatalf("mergeCoreTemps() = %v, want %v", got, want)
+	}
+
+	// Without package sensors the second socket can't be told apart, so its
+	// CPUs are left at 0 rather than showing the first socket's readings.
+	got = mergeCoreTemps([]float64{1, 2, 3, 4}, parseCoreTemps(slices.Concat(readings[1:3], readings[4:])), coreID)
+	if want := []float64{50, 51, 0, 0}; !slices.Equal(got, want) {
+		t.Fatalf("without package sensors: mergeCoreTemps() = %v, want %v", got, want)
+	}
+}
+
+func TestMergeCoreTempsMismatchedCounts(t *testing.T) {
+	noTopology := func(int) (coreKey, error) { return coreKey{}, errors.ErrUnsupported }
+	temps := map[coreKey]float64{{core: 0}: 50, {core: 1}: 51}
+
+	// More CPUs than sensors: unmatched CPUs report 0.
+	got := mergeCoreTemps([]float64{1, 2, 3}, temps, noTopology)
+	if !slices.Equal(got, []float64{50, 51, 0}) {
+		t.Fatalf("more CPUs than sensors: got %v", got)
+	}
+	// More sensors than CPUs: extra sensors are ignored.
+	if got := mergeCoreTemps([]float64{1}, temps, noTopology); !slices.Equal(got, []float64{50}) {
+		t.Fatalf("more sensors than CPUs: got %v", got)
+	}
+	if got := mergeCoreTemps([]float64{1, 2}, map[coreKey]float64{{core: 7}: 60}, noTopology); got != nil {
+		t.Fatalf("no matching core should give nil, got %v", got)
+	}
+	if got := mergeCoreTemps(nil, temps, noTopology); got != nil {
+		t.Fatalf("no per-core load should give nil, got %v", got)
+	}
+}
@@ -15,8 +15,30 @@ import (
 
 const (
 	cpuSampleInterval = 200 * time.Millisecond
+	// cpuStealNotablePercent is the steal share worth surfacing: below it,
+	// hypervisor scheduling noise is not a likely cause of slowness.
+	cpuStealNotablePercent = 2.0
 )
 
+// cpuTimesFunc reads aggregate CPU times. It is a variable so tests can
+// feed fixed samples.
+var cpuTimesFunc = func() ([]cpu.TimesStat, error) { return cpu.Times(false) }
+
+// stealPercent is the share of CPU time between two samples that the
+// hypervisor gave to other guests. Guest time is already counted in user
+// time on Linux, so it is left out of the total.
+func stealPercent(before, after cpu.TimesStat) float64 {
+	busy := func(t cpu.TimesStat) float64 {
+		return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+	}
+	total := busy(after) - busy(before)
+	steal := after.Steal - before.Steal
+	if total <= 0 || steal <= 0 {
+		return 0
+	}
+	return min(steal/total*100, 100)
+}
+
 func collectCPU() (CPUStatus, error) {
 	counts, countsErr := cpu.Counts(false)
 	if countsErr != nil || counts == 0 {
@@ -31,10 +53,21 @@ func collectCPU() (CPUStatus, error) {
 		logical = 1
 	}
 
-	// Two-call pattern for more reliable CPU usage.
+	// Two-call pattern for more reliable CPU usage. Only Linux reports
+	// steal time; elsewhere the field stays zero.
+	var timesBefore []cpu.TimesStat
+	if runtime.GOOS == "linux" {
+		timesBefore, _ = cpuTimesFunc()
+	}
 	warmUpCPU()
 	time.Sleep(cpuSampleInterval)
 	percents, err := cpu.Percent(0, true)
+	var steal float64
+	if len(timesBefore) > 0 {
+		if timesAfter, err := cpuTimesFunc(); err == nil && len(timesAfter) > 0 {
+			steal = stealPercent(timesBefore[0], timesAfter[0])
+		}
+	}
 	var totalPercent float64
 	perCoreEstimated := false
 	if err != nil || len(percents) == 0 {
@@ -73,6 +106,7 @@ func collectCPU() (CPUStatus, error) {
 		Usage:            totalPercent,
 		PerCore:          percents,
 		PerCoreEstimated: perCoreEstimated,
+		StealPercent:     steal,
 		Load1:            loadAvg.Load1,
 		Load5:            loadAvg.Load5,
 		Load15:           loadAvg.Load15,
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+func TestStealPercent(t *testing.T) {
+	before := cpu.TimesStat{User: 1000, System: 200, Idle: 8000, Steal: 40}
+	// 100 ticks elapse: 40 user, 20 system, 10 idle and 30 stolen.
+	after := cpu.TimesStat{User: 1040, System: 220, Idle: 8010, Steal: 70, Guest: 25}
+	if got := stealPercent(before, after); math.Abs(got-30) > 1e-9 {
+		t.Fatalf("stealPercent = %v, want 30", got)
+	}
+
+	// Platforms without steal accounting report it as zero throughout.
+	noSteal := cpu.TimesStat{User: 1040, System: 220, Idle: 8040}
+	if got := stealPercent(cpu.TimesStat{User: 1000, System: 200, Idle: 8000}, noSteal); got != 0 {
+		t.Fatalf("stealPercent without steal = %v, want 0", got)
+	}
+	// Counter resets must not produce negative or huge values.
+	if got := stealPercent(after, before); got != 0 {
+		t.Fatalf("stealPercent after a reset = %v, want 0", got)
+	}
+}
+
+func TestStealShownWhenNotable(t *testing.T) {
+	card := renderCPUCard(CPUStatus{Usage: 12, StealPercent: 18.5}, ThermalStatus{})
+	if !strings.Contains(card.lines[0], "steal 18.5%") {
+		t.Fatalf("header = %q, want steal shown", card.lines[0])
+	}
+	card = renderCPUCard(CPUStatus{Usage: 12, StealPercent: 0.4}, ThermalStatus{})
+	if strings.Contains(card.lines[0], "steal") {
+		t.Fatalf("negligible steal should stay hidden: %q", card.lines[0])
+	}
+	if got := formatCompact(MetricsSnapshot{CPU: CPUStatus{Usage: 12, StealPercent: 18.5}}, FormatOptions{}); !strings.HasPrefix(got, "CPU 12.0% (steal 18.5%)") {
+		t.Fatalf("formatCompact = %q", got)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -74,14 +77,18 @@ func collectDisks() ([]DiskStatus, error) {
 		if seenVolume[volKey] {
 			continue
 		}
-		disks = append(disks, DiskStatus{
+		d := DiskStatus{
 			Mount:       part.Mountpoint,
 			Device:      part.Device,
 			Used:        usage.Used,
 			Total:       usage.Total,
 			UsedPercent: usage.UsedPercent,
 			Fstype:      part.Fstype,
-		})
+			MountOpts:   part.Opts,
+			ReadOnly:    mountReadOnly(part.Opts),
+		}
+		d.InodesTotal, d.InodesUsed, d.InodesUsedPercent = inodeUsage(usage)
+		disks = append(disks, d)
 		seenDevice[baseDevice] = true
 		seenVolume[volKey] = true
 	}
@@ -104,6 +111,84 @@ func collectDisks() ([]DiskStatus, error) {
 	return disks, nil
 }
 
+// DiskSort selects how the disks section is ordered. Which disks are listed
+// does not depend on it: the largest internal ones always are.
+type DiskSort int
+
+const (
+	// DiskByUsedPercent puts the fullest disks first.
+	DiskByUsedPercent DiskSort = iota
+	// DiskByFreeBytes puts the disks with the least free space first.
+	DiskByFreeBytes
+	// DiskByMount sorts by mount path, for an order that never shifts.
+	DiskByMount
+	// DiskBySize puts the largest disks first.
+	DiskBySize
+)
+
+func (s DiskSort) String() string {
+	switch s {
+	case DiskByFreeBytes:
+		return "free"
+	case DiskByMount:
+		return "mount"
+	case DiskBySize:
+		return "size"
+	}
+	return "used"
+}
+
+// parseDiskSort maps the -disk-sort flag value to a DiskSort.
+func parseDiskSort(s string) (DiskSort, error) {
+	for _, mode := range []DiskSort{DiskByUsedPercent, DiskByFreeBytes, DiskByMount, DiskBySize} {
+		if s == mode.String() {
+			return mode, nil
+		}
+	}
+	if s == "" {
+		return DiskByUsedPercent, nil
+	}
+	return DiskByUsedPercent, fmt.Errorf("unknown disk sort %q (want used, free, mount or size)", s)
+}
+
+// sortDisks orders disks by mode. Ties keep the collected order.
+func sortDisks(disks []DiskStatus, mode DiskSort) {
+	var less func(a, b DiskStatus) bool
+	switch mode {
+	case DiskByFreeBytes:
+		less = func(a, b DiskStatus) bool { return a.Total-a.Used < b.Total-b.Used }
+	case DiskByMount:
+		less = func(a, b DiskStatus) bool { return a.Mount < b.Mount }
+	case DiskBySize:
+		less = func(a, b DiskStatus) bool { return a.Total > b.Total }
+	default:
+		less = func(a, b DiskStatus) bool { return a.UsedPercent > b.UsedPercent }
+	}
+	sort.SliceStable(disks, func(i, j int) bool { return less(disks[i], disks[j]) })
+}
+
+// inodeUsage returns usage's inode counts, or zeros when the filesystem
+// reports no inode total, so the percentage is never computed from junk.
+func inodeUsage(usage *disk.UsageStat) (total, used uint64, percent float64) {
+	if usage.InodesTotal == 0 {
+		return 0, 0, 0
+	}
+	used = min(usage.InodesUsed, usage.InodesTotal)
+	return usage.InodesTotal, used, float64(used) / float64(usage.InodesTotal) * 100
+}
+
+// mountReadOnly reports whether mount options say read-only: "ro" on
+// Linux and macOS, "rdonly" in BSD mount(8) output.
+func mountReadOnly(opts []string) bool {
+	for _, opt := range opts {
+		switch opt {
+		case "ro", "rdonly", "read-only":
+			return true
+		}
+	}
+	return false
+}
+
 func shouldSkipDiskPartition(part disk.PartitionStat) bool {
 	if strings.HasPrefix(part.Device, "/dev/loop") {
 		return true
@@ -226,16 +311,13 @@ func (c *Collector) collectDiskIO(now time.Time) DiskIOStatus {
 		total.WriteBytes += v.WriteBytes
 	}
 
-	if c.lastDiskAt.IsZero() {
+	if c.lastDiskAt.IsZero() || c.clockJumped("disk_io", now, c.lastDiskAt) {
 		c.prevDiskIO = total
 		c.lastDiskAt = now
 		return DiskIOStatus{}
 	}
 
-	elapsed := now.Sub(c.lastDiskAt).Seconds()
-	if elapsed <= 0 {
-		elapsed = 1
-	}
+	elapsed := c.rateWindow(now, c.lastDiskAt)
 
 	readRate := float64(total.ReadBytes-c.prevDiskIO.ReadBytes) / 1024 / 1024 / elapsed
 	writeRate := float64(total.WriteBytes-c.prevDiskIO.WriteBytes) / 1024 / 1024 / elapsed
@@ -252,3 +334,158 @@ func (c *Collector) collectDiskIO(now time.Time) DiskIOStatus {
 
 	return DiskIOStatus{ReadRate: readRate, WriteRate: writeRate}
 }
+
+var procDiskstatsPath = "/proc/diskstats"
+
+// diskstatsSample holds the cumulative /proc/diskstats counters used for latency.
+type diskstatsSample struct {
+	Reads   uint64
+	ReadMs  uint64
+	Writes  uint64
+	WriteMs uint64
+}
+
+// parseDiskstats parses /proc/diskstats into per-device counters.
+func parseDiskstats(raw string) map[string]diskstatsSample {
+	result := make(map[string]diskstatsSample)
+	for line := range strings.Lines(raw) {
+		fields := strings.Fields(line)
+		// major minor name reads merged sectors ms_read writes merged sectors ms_write ...
+		if len(fields) < 11 {
+			continue
+		}
+		var vals [4]uint64
+		ok := true
+		for i, idx := range []int{3, 6, 7, 10} {
+			v, err := strconv.ParseUint(fields[idx], 10, 64)
+			if err != nil {
+				ok = false
+				break
+			}
+			vals[i] = v
+		}
+		if !ok {
+			continue
+		}
+		result[fields[2]] = diskstatsSample{Reads: vals[0], ReadMs: vals[1], Writes: vals[2], WriteMs: vals[3]}
+	}
+	return result
+}
+
+// diskLatency returns average read/write latency (ms) between two samples.
+func diskLatency(prev, cur diskstatsSample) (readMs, writeMs float64) {
+	if cur.Reads > prev.Reads && cur.ReadMs >= prev.ReadMs {
+		readMs = float64(cur.ReadMs-prev.ReadMs) / float64(cur.Reads-prev.Reads)
+	}
+	if cur.Writes > prev.Writes && cur.WriteMs >= prev.WriteMs {
+		writeMs = float64(cur.WriteMs-prev.WriteMs) / float64(cur.Writes-prev.Writes)
+	}
+	return readMs, writeMs
+}
+
+// annotateDiskLatency fills per-disk latency from /proc/diskstats deltas.
+// Platforms without the file leave latency at zero.
+func (c *Collector) annotateDiskLatency(disks []DiskStatus) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+	data, err := os.ReadFile(procDiskstatsPath)
+	if err != nil {
+		return
+	}
+	cur := parseDiskstats(string(data))
+	prev := c.prevDiskstat
+	c.prevDiskstat = cur
+	if prev == nil {
+		return
+	}
+	for i := range disks {
+		name := filepath.Base(disks[i].Device)
+		p, okPrev := prev[name]
+		n, okCur := cur[name]
+		if !okPrev || !okCur {
+			continue
+		}
+		disks[i].ReadLatencyMs, disks[i].WriteLatencyMs = diskLatency(p, n)
+	}
+}
+
+// Disk usage trend sampling for time-to-full estimates.
+const (
+	diskTrendSampleGap  = 30 * time.Second
+	diskTrendMaxSamples = 20
+	diskTrendMinSamples = 3
+	// Projections further out than this are noise from a tiny slope and
+	// are reported as not filling.
+	diskTrendHorizon = 365 * 24 * time.Hour
+)
+
+type usageSample struct {
+	at   time.Time
+	used float64
+}
+
+// annotateDiskTrends records per-mount usage and fills TimeToFullSeconds.
+// Mounts that disappear drop their history.
+func (c *Collector) annotateDiskTrends(now time.Time, disks []DiskStatus) {
+	if c.diskTrend == nil {
+		c.diskTrend = make(map[string][]usageSample)
+	}
+	seen := make(map[string]bool, len(disks))
+	for i := range disks {
+		mount := disks[i].Mount
+		seen[mount] = true
+
+		samples := c.diskTrend[mount]
+		if len(samples) == 0 || now.Sub(samples[len(samples)-1].at) >= diskTrendSampleGap {
+			samples = append(samples, usageSample{at: now, used: float64(disks[i].Used)})
+			if len(samples) > diskTrendMaxSamples {
+				samples = samples[len(samples)-diskTrendMaxSamples:]
+			}
+			c.diskTrend[mount] = samples
+		}
+		disks[i].TimeToFullSeconds = estimateTimeToFull(samples, disks[i].Total).Seconds()
+	}
+	for mount := range c.diskTrend {
+		if !seen[mount] {
+			delete(c.diskTrend, mount)
+		}
+	}
+}
+
+// estimateTimeToFull fits a least-squares line to usage samples and projects
+// when the disk reaches total. Flat or shrinking usage, or growth too slow
+// to fill the disk within diskTrendHorizon, returns zero.
+func estimateTimeToFull(samples []usageSample, total uint64) time.Duration {
+	if len(samples) < diskTrendMinSamples {
+		return 0
+	}
+	origin := samples[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.at.Sub(origin).Seconds()
+		sumX += x
+		sumY += s.used
+		sumXY += x * s.used
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	slope := (n*sumXY - sumX*sumY) / denom // bytes per second
+	if slope <= 0 {
+		return 0
+	}
+	remaining := float64(total) - samples[len(samples)-1].used
+	if remaining <= 0 {
+		return 0
+	}
+	// Compare in float seconds: a tiny slope would overflow a Duration.
+	secs := remaining / slope
+	if secs > diskTrendHorizon.Seconds() {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
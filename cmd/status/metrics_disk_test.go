@@ -1,7 +1,11 @@
 package main
 
 import (
+	"encoding/json"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/shirou/gopsutil/v4/disk"
 )
@@ -58,3 +62,164 @@ func TestShouldSkipDiskPartition(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskLatencyFromDiskstats(t *testing.T) {
+	first := `   8       0 sda 1000 10 8000 2000 500 5 4000 5000 0 3000 7000
+   8       1 sda1 900 10 7000 1800 400 5 3000 4000 0 2500 5800
+ 259       0 nvme0n1 50 0 400 25 10 0 80 30 0 40 55
+`
+	second := `   8       0 sda 1100 10 8800 2400 600 5 4800 5800 0 3400 8200
+   8       1 sda1 1000 10 7800 2300 400 5 3000 4000 0 2900 6300
+ 259       0 nvme0n1 50 0 400 25 10 0 80 30 0 40 55
+`
+	prev := parseDiskstats(first)
+	cur := parseDiskstats(second)
+	if len(cur) != 3 {
+		t.Fatalf("expected 3 devices, got %d", len(cur))
+	}
+
+	readMs, writeMs := diskLatency(prev["sda"], cur["sda"])
+	if readMs != 4 || writeMs != 8 {
+		t.Fatalf("sda latency = %.1f/%.1f ms, want 4/8", readMs, writeMs)
+	}
+
+	readMs, writeMs = diskLatency(prev["sda1"], cur["sda1"])
+	if readMs != 5 || writeMs != 0 {
+		t.Fatalf("sda1 latency = %.1f/%.1f ms, want 5/0 (no writes)", readMs, writeMs)
+	}
+
+	readMs, writeMs = diskLatency(prev["nvme0n1"], cur["nvme0n1"])
+	if readMs != 0 || writeMs != 0 {
+		t.Fatalf("idle device should report zero latency, got %.1f/%.1f", readMs, writeMs)
+	}
+}
+
+func TestAnnotateDiskTrendsLinearGrowth(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+	const total = 100 << 30
+
+	// 1 GiB per minute with 40 GiB left after the last sample -> ~40 minutes.
+	var disks []DiskStatus
+	for i := range 5 {
+		disks = []DiskStatus{{Mount: "/", Total: total, Used: uint64(56+i) << 30}}
+		c.annotateDiskTrends(start.Add(time.Duration(i)*time.Minute), disks)
+	}
+
+	got := disks[0].TimeToFullSeconds
+	if got < 39*60 || got > 41*60 {
+		t.Fatalf("TimeToFullSeconds = %v, want ~2400", got)
+	}
+	if raw, _ := json.Marshal(disks[0]); !strings.Contains(string(raw), `"time_to_full_seconds":24`) {
+		t.Fatalf("time to full should serialize in seconds, got %s", raw)
+	}
+}
+
+func TestAnnotateDiskTrendsFlatOrShrinking(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+	used := []uint64{60, 60, 60, 59, 58}
+
+	var disks []DiskStatus
+	for i, u := range used {
+		disks = []DiskStatus{{Mount: "/data", Total: 100 << 30, Used: u << 30}}
+		c.annotateDiskTrends(start.Add(time.Duration(i)*time.Minute), disks)
+	}
+	if disks[0].TimeToFullSeconds != 0 {
+		t.Fatalf("shrinking usage should have no estimate, got %v", disks[0].TimeToFullSeconds)
+	}
+	if raw, _ := json.Marshal(disks[0]); strings.Contains(string(raw), "time_to_full") {
+		t.Fatalf("no estimate should leave the field out, got %s", raw)
+	}
+}
+
+func TestAnnotateDiskTrendsIgnoresNegligibleGrowth(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+	const total = 1 << 40
+
+	// About 10 B/s of log growth with 500 GB free: centuries to fill.
+	var disks []DiskStatus
+	for i := range 5 {
+		disks = []DiskStatus{{Mount: "/", Total: total, Used: total - 500e9 + uint64(i)*600}}
+		c.annotateDiskTrends(start.Add(time.Duration(i)*time.Minute), disks)
+	}
+	if disks[0].TimeToFullSeconds != 0 {
+		t.Fatalf("TimeToFullSeconds = %v, want 0 beyond the horizon", disks[0].TimeToFullSeconds)
+	}
+}
+
+func TestAnnotateDiskTrendsNeedsEnoughSamples(t *testing.T) {
+	c := NewCollector()
+	now := time.Now()
+	disks := []DiskStatus{{Mount: "/", Total: 100 << 30, Used: 50 << 30}}
+	c.annotateDiskTrends(now, disks)
+	// Within the sample gap: not recorded again.
+	disks[0].Used = 60 << 30
+	c.annotateDiskTrends(now.Add(time.Second), disks)
+	if disks[0].TimeToFullSeconds != 0 || len(c.diskTrend["/"]) != 1 {
+		t.Fatalf("expected a single sample and no estimate, got %d samples, %v", len(c.diskTrend["/"]), disks[0].TimeToFullSeconds)
+	}
+}
+
+func TestMountReadOnly(t *testing.T) {
+	for _, tc := range []struct {
+		opts []string
+		want bool
+	}{
+		{[]string{"rw", "relatime", "errors=remount-ro"}, false},
+		{[]string{"ro", "relatime", "errors=remount-ro"}, true},
+		{[]string{"ro"}, true}, // gopsutil on macOS
+		{[]string{"rdonly", "local"}, true},
+		{[]string{"rw", "nosuid", "nodev", "noexec"}, false},
+		{nil, false},
+	} {
+		if got := mountReadOnly(tc.opts); got != tc.want {
+			t.Errorf("mountReadOnly(%v) = %v, want %v", tc.opts, got, tc.want)
+		}
+	}
+}
+
+func TestSortDisks(t *testing.T) {
+	const gb = 1 << 30
+	fixed := []DiskStatus{
+		{Mount: "/", Used: 400 * gb, Total: 500 * gb, UsedPercent: 80},
+		{Mount: "/data", Used: 1000 * gb, Total: 4000 * gb, UsedPercent: 25},
+		{Mount: "/boot", Used: 1900 << 20, Total: 2 * gb, UsedPercent: 95},
+		{Mount: "/home", Used: 900 * gb, Total: 1000 * gb, UsedPercent: 90},
+	}
+	tests := []struct {
+		mode DiskSort
+		want []string
+	}{
+		{DiskByUsedPercent, []string{"/boot", "/home", "/", "/data"}},
+		{DiskByFreeBytes, []string{"/boot", "/", "/home", "/data"}},
+		{DiskByMount, []string{"/", "/boot", "/data", "/home"}},
+		{DiskBySize, []string{"/data", "/home", "/", "/boot"}},
+	}
+	for _, tt := range tests {
+		disks := append([]DiskStatus(nil), fixed...)
+		sortDisks(disks, tt.mode)
+		var got []string
+		for _, d := range disks {
+			got = append(got, d.Mount)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: order = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseDiskSort(t *testing.T) {
+	for _, mode := range []DiskSort{DiskByUsedPercent, DiskByFreeBytes, DiskByMount, DiskBySize} {
+		if got, err := parseDiskSort(mode.String()); err != nil || got != mode {
+			t.Errorf("parseDiskSort(%q) = %v, %v", mode, got, err)
+		}
+	}
+	if got, err := parseDiskSort(""); err != nil || got != DiskByUsedPercent {
+		t.Errorf("default = %v, %v; want used", got, err)
+	}
+	if _, err := parseDiskSort("inode"); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	firewallCacheTTL = time.Minute
+	firewallTimeout  = time.Second
+)
+
+// FirewallStatus reports whether a host firewall is filtering traffic.
+// Backend is "unknown" when no supported tool answered, in which case
+// Enabled says nothing.
+type FirewallStatus struct {
+	Enabled bool   `json:"enabled"`
+	Backend string `json:"backend"` // ufw, firewalld, socketfilterfw, pf, netsh or unknown
+}
+
+// firewallBackend is one firewall tool and the parser for its status
+// output. parse reports ok=false when the output is not recognized.
+type firewallBackend struct {
+	name  string
+	goos  string
+	tool  string
+	args  []string
+	parse func(out string) (enabled, ok bool)
+}
+
+// firewallBackends are tried in order. ufw and pfctl need root and are
+// skipped when they refuse to answer.
+var firewallBackends = []firewallBackend{
+	{"ufw", "linux", "ufw", []string{"status"}, parseUFWStatus},
+	{"firewalld", "linux", "firewall-cmd", []string{"--state"}, parseFirewalldState},
+	{"socketfilterfw", "darwin", "/usr/libexec/ApplicationFirewall/socketfilterfw", []string{"--getglobalstate"}, parseSocketFilterFW},
+	{"pf", "darwin", "pfctl", []string{"-s", "info"}, parsePFInfo},
+	{"netsh", "windows", "netsh", []string{"advfirewall", "show", "allprofiles", "state"}, parseNetshFirewall},
+}
+
+// CollectFirewall returns the first backend reporting an enabled firewall,
+// or else the first one that answered at all.
+func CollectFirewall(ctx context.Context) FirewallStatus {
+	return collectFirewall(ctx, firewallBackends, runtime.GOOS)
+}
+
+func collectFirewall(ctx context.Context, backends []firewallBackend, goos string) FirewallStatus {
+	status := FirewallStatus{Backend: "unknown"}
+	for _, b := range backends {
+		if b.goos != goos || !commandExists(b.tool) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, firewallTimeout)
+		out, err := runCmd(ctx, b.tool, b.args...)
+		cancel()
+		if err != nil {
+			continue
+		}
+		enabled, ok := b.parse(out)
+		if !ok {
+			continue
+		}
+		if enabled {
+			return FirewallStatus{Enabled: true, Backend: b.name}
+		}
+		if status.Backend == "unknown" {
+			status.Backend = b.name
+		}
+	}
+	return status
+}
+
+// parseUFWStatus reads "Status: active" or "Status: inactive".
+func parseUFWStatus(out string) (enabled, ok bool) {
+	for line := range strings.Lines(out) {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "Status:"); found {
+			switch strings.TrimSpace(value) {
+			case "active":
+				return true, true
+			case "inactive":
+				return false, true
+			}
+		}
+	}
+	return false, false
+}
+
+// parseFirewalldState reads "running" or "not running".
+func parseFirewalldState(out string) (enabled, ok bool) {
+	switch strings.TrimSpace(out) {
+	case "running":
+		return true, true
+	case "not running":
+		return false, true
+	}
+	return false, false
+}
+
+// parseSocketFilterFW reads the macOS Application Firewall state, e.g.
+// "Firewall is enabled. (State = 1)".
+func parseSocketFilterFW(out string) (enabled, ok bool) {
+	switch {
+	case strings.Contains(out, "Firewall is enabled"):
+		return true, true
+	case strings.Contains(out, "Firewall is disabled"):
+		return false, true
+	}
+	return false, false
+}
+
+// parsePFInfo reads the "Status: Enabled for 0 days 01:02:03" line of
+// pfctl -s info.
+func parsePFInfo(out string) (enabled, ok bool) {
+	for line := range strings.Lines(out) {
+		value, found := strings.CutPrefix(strings.TrimSpace(line), "Status:")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return false, false
+		}
+		switch fields[0] {
+		case "Enabled":
+			return true, true
+		case "Disabled":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// parseNetshFirewall reads one "State ON|OFF" line per profile; the
+// firewall counts as enabled when any profile is on.
+func parseNetshFirewall(out string) (enabled, ok bool) {
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "State" {
+			continue
+		}
+		ok = true
+		if strings.EqualFold(fields[1], "ON") {
+			enabled = true
+		}
+	}
+	return enabled, ok
+}
+
+// collectFirewall caches CollectFirewall; firewall state rarely changes
+// and the tools are slow to start.
+func (c *Collector) collectFirewall(ctx context.Context, now time.Time) FirewallStatus {
+	if !c.lastFirewallAt.IsZero() && now.Sub(c.lastFirewallAt) < c.ttl(firewallCacheTTL) {
+		return c.cachedFirewall
+	}
+	c.cachedFirewall = CollectFirewall(ctx)
+	c.lastFirewallAt = now
+	return c.cachedFirewall
+}
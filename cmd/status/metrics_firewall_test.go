@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFirewallParsers(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		parse   func(string) (bool, bool)
+		out     string
+		enabled bool
+		ok      bool
+	}{
+		{"ufw active", parseUFWStatus, "Status: active\n\nTo                         Action      From\n--                         ------      ----\n22/tcp                     ALLOW       Anywhere\n", true, true},
+		{"ufw inactive", parseUFWStatus, "Status: inactive\n", false, true},
+		{"ufw needs root", parseUFWStatus, "ERROR: You need to be root to run this script\n", false, false},
+		{"firewalld running", parseFirewalldState, "running\n", true, true},
+		{"firewalld stopped", parseFirewalldState, "not running\n", false, true},
+		{"socketfilterfw on", parseSocketFilterFW, "Firewall is enabled. (State = 1)\n", true, true},
+		{"socketfilterfw off", parseSocketFilterFW, "Firewall is disabled. (State = 0)\n", false, true},
+		{"pf enabled", parsePFInfo, "Status: Enabled for 0 days 01:12:07           Debug: Urgent\n\nState Table                          Total             Rate\n  current entries                       42\n", true, true},
+		{"pf disabled", parsePFInfo, "Status: Disabled for 0 days 00:00:00           Debug: Urgent\n", false, true},
+		{"netsh mixed", parseNetshFirewall, "\nDomain Profile Settings:\n----------------------------------------------------------------------\nState                                 OFF\n\nPrivate Profile Settings:\n----------------------------------------------------------------------\nState                                 ON\n\nOk.\n", true, true},
+		{"netsh off", parseNetshFirewall, "Domain Profile Settings:\nState                                 OFF\nPublic Profile Settings:\nState                                 OFF\n", false, true},
+	} {
+		enabled, ok := tc.parse(tc.out)
+		if enabled != tc.enabled || ok != tc.ok {
+			t.Errorf("%s: got enabled=%v ok=%v, want %v %v", tc.name, enabled, ok, tc.enabled, tc.ok)
+		}
+	}
+}
+
+func TestCollectFirewallPrefersEnabledBackend(t *testing.T) {
+	outputs := map[string]string{
+		"off": "Firewall is disabled. (State = 0)\n",
+		"on":  "Status: Enabled for 0 days 00:01:00\n",
+	}
+	orig := runCmd
+	runCmd = func(_ context.Context, _ string, args ...string) (string, error) {
+		if out, ok := outputs[args[0]]; ok {
+			return out, nil
+		}
+		return "", errors.New("permission denied")
+	}
+	t.Cleanup(func() { runCmd = orig })
+
+	// "sh" stands in for the firewall tools so the PATH check passes.
+	backend := func(name, arg string, parse func(string) (bool, bool)) firewallBackend {
+		return firewallBackend{name, "test", "sh", []string{arg}, parse}
+	}
+	backends := []firewallBackend{
+		backend("denied", "root", parseUFWStatus),
+		backend("socketfilterfw", "off", parseSocketFilterFW),
+		backend("pf", "on", parsePFInfo),
+	}
+	if got := collectFirewall(context.Background(), backends, "test"); got != (FirewallStatus{Enabled: true, Backend: "pf"}) {
+		t.Fatalf("collectFirewall() = %+v, want enabled pf", got)
+	}
+	if got := collectFirewall(context.Background(), backends[:2], "test"); got != (FirewallStatus{Backend: "socketfilterfw"}) {
+		t.Fatalf("collectFirewall() = %+v, want disabled socketfilterfw", got)
+	}
+	if got := collectFirewall(context.Background(), backends, "plan9"); got.Backend != "unknown" {
+		t.Fatalf("no backend for the platform should be unknown, got %+v", got)
+	}
+}
@@ -23,11 +23,11 @@ var (
 	gpuIdleResidencyRe   = regexp.MustCompile(`GPU idle residency:\s+([\d.]+)%`)
 )
 
-func (c *Collector) collectGPU(now time.Time) ([]GPUStatus, error) {
+func (c *Collector) collectGPU(ctx context.Context, now time.Time) ([]GPUStatus, error) {
 	if runtime.GOOS == "darwin" {
 		// Static GPU info (cached 10 min).
 		if len(c.cachedGPU) == 0 || c.lastGPUAt.IsZero() || now.Sub(c.lastGPUAt) >= macGPUInfoTTL {
-			if gpus, err := readMacGPUInfo(); err == nil && len(gpus) > 0 {
+			if gpus, err := readMacGPUInfo(ctx); err == nil && len(gpus) > 0 {
 				c.cachedGPU = gpus
 				c.lastGPUAt = now
 			}
@@ -35,7 +35,7 @@ func (c *Collector) collectGPU(now time.Time) ([]GPUStatus, error) {
 
 		// Real-time GPU usage.
 		if len(c.cachedGPU) > 0 {
-			usage := getMacGPUUsage()
+			usage := getMacGPUUsage(ctx)
 			result := make([]GPUStatus, len(c.cachedGPU))
 			copy(result, c.cachedGPU)
 			// Apply usage to first GPU (Apple Silicon).
@@ -46,7 +46,7 @@ func (c *Collector) collectGPU(now time.Time) ([]GPUStatus, error) {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	ctx, cancel := context.WithTimeout(ctx, 600*time.Millisecond)
 	defer cancel()
 
 	if !commandExists("nvidia-smi") {
@@ -90,8 +90,8 @@ func (c *Collector) collectGPU(now time.Time) ([]GPUStatus, error) {
 	return gpus, nil
 }
 
-func readMacGPUInfo() ([]GPUStatus, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), systemProfilerTimeout)
+func readMacGPUInfo(ctx context.Context) ([]GPUStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, systemProfilerTimeout)
 	defer cancel()
 
 	if !commandExists("system_profiler") {
@@ -152,8 +152,8 @@ func readMacGPUInfo() ([]GPUStatus, error) {
 }
 
 // getMacGPUUsage reads GPU active residency from powermetrics.
-func getMacGPUUsage() float64 {
-	ctx, cancel := context.WithTimeout(context.Background(), powermetricsTimeout)
+func getMacGPUUsage(ctx context.Context) float64 {
+	ctx, cancel := context.WithTimeout(ctx, powermetricsTimeout)
 	defer cancel()
 
 	// powermetrics may require root.
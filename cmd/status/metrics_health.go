@@ -2,18 +2,34 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
-// Health score weights and thresholds.
-const (
-	// Weights.
-	healthCPUWeight     = 30.0
-	healthMemWeight     = 25.0
-	healthDiskWeight    = 20.0
-	healthThermalWeight = 15.0
-	healthIOWeight      = 10.0
+// HealthWeights is the maximum penalty each sub-metric can take off the
+// score. Weights don't need to sum to 100; the result is clamped to 0-100.
+type HealthWeights struct {
+	CPU     float64
+	Memory  float64
+	Disk    float64
+	Thermal float64
+	IO      float64
+	Swap    float64
+	Errors  float64
+}
+
+var defaultHealthWeights = HealthWeights{
+	CPU:     30,
+	Memory:  25,
+	Disk:    20,
+	Thermal: 15,
+	IO:      10,
+	Swap:    10,
+	Errors:  10,
+}
 
+// Health score thresholds.
+const (
 	// CPU.
 	cpuNormalThreshold = 30.0
 	cpuHighThreshold   = 70.0
@@ -35,9 +51,73 @@ const (
 	// Disk IO (MB/s).
 	ioNormalThreshold = 50.0
 	ioHighThreshold   = 150.0
+
+	// Swap (percent of swap in use).
+	swapNormalThreshold = 25.0
+	swapHighThreshold   = 75.0
+
+	// Collector errors in a single sample; more than this costs the full weight.
+	healthMaxErrors = 3
 )
 
+// healthWeightPrefs maps status_prefs keys to the weight they tune.
+var healthWeightPrefs = map[string]func(*HealthWeights) *float64{
+	"health_weight_cpu":     func(w *HealthWeights) *float64 { return &w.CPU },
+	"health_weight_memory":  func(w *HealthWeights) *float64 { return &w.Memory },
+	"health_weight_disk":    func(w *HealthWeights) *float64 { return &w.Disk },
+	"health_weight_thermal": func(w *HealthWeights) *float64 { return &w.Thermal },
+	"health_weight_io":      func(w *HealthWeights) *float64 { return &w.IO },
+	"health_weight_swap":    func(w *HealthWeights) *float64 { return &w.Swap },
+	"health_weight_errors":  func(w *HealthWeights) *float64 { return &w.Errors },
+}
+
+// healthWeightsFromPrefs overrides the default weights with any
+// health_weight_* keys. Invalid or negative values are reported and skipped.
+func healthWeightsFromPrefs(prefs map[string]string) (HealthWeights, error) {
+	w := defaultHealthWeights
+	var errs []string
+	for key, field := range healthWeightPrefs {
+		raw, ok := prefs[key]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || v < 0 {
+			errs = append(errs, fmt.Sprintf("%s=%q", key, raw))
+			continue
+		}
+		*field(&w) = v
+	}
+	if len(errs) > 0 {
+		return w, fmt.Errorf("invalid health weights: %s", strings.Join(errs, ", "))
+	}
+	return w, nil
+}
+
+// healthWeights returns the configured weights, or the defaults when none
+// were set.
+func (c *Collector) healthWeights() HealthWeights {
+	if c.HealthWeights == nil {
+		return defaultHealthWeights
+	}
+	return *c.HealthWeights
+}
+
 func calculateHealthScore(cpu CPUStatus, mem MemoryStatus, disks []DiskStatus, diskIO DiskIOStatus, thermal ThermalStatus) (int, string) {
+	return calculateWeightedHealthScore(defaultHealthWeights, cpu, mem, disks, diskIO, thermal, 0)
+}
+
+// calculateWeightedHealthScore starts at 100 and subtracts one penalty per
+// sub-metric, each scaled by its weight:
+//   - CPU, memory, disk: 0 below the normal threshold, up to half the weight
+//     at the high threshold, then growing steeply beyond it.
+//   - Thermal, IO, swap: linear from the normal to the high threshold,
+//     capped at the full weight.
+//   - Errors: weight * min(collectErrors, 3) / 3.
+//
+// Memory pressure subtracts a fixed extra penalty. The result is clamped to
+// 0-100.
+func calculateWeightedHealthScore(w HealthWeights, cpu CPUStatus, mem MemoryStatus, disks []DiskStatus, diskIO DiskIOStatus, thermal ThermalStatus, collectErrors int) (int, string) {
 	score := 100.0
 	issues := []string{}
 
@@ -45,9 +125,9 @@ func calculateHealthScore(cpu CPUStatus, mem MemoryStatus, disks []DiskStatus, d
 	cpuPenalty := 0.0
 	if cpu.Usage > cpuNormalThreshold {
 		if cpu.Usage > cpuHighThreshold {
-			cpuPenalty = healthCPUWeight * (cpu.Usage - cpuNormalThreshold) / cpuHighThreshold
+			cpuPenalty = w.CPU * (cpu.Usage - cpuNormalThreshold) / cpuHighThreshold
 		} else {
-			cpuPenalty = (healthCPUWeight / 2) * (cpu.Usage - cpuNormalThreshold) / (cpuHighThreshold - cpuNormalThreshold)
+			cpuPenalty = (w.CPU / 2) * (cpu.Usage - cpuNormalThreshold) / (cpuHighThreshold - cpuNormalThreshold)
 		}
 	}
 	score -= cpuPenalty
@@ -59,9 +139,9 @@ func calculateHealthScore(cpu CPUStatus, mem MemoryStatus, disks []DiskStatus, d
 	memPenalty := 0.0
 	if mem.UsedPercent > memNormalThreshold {
 		if mem.UsedPercent > memHighThreshold {
-			memPenalty = healthMemWeight * (mem.UsedPercent - memNormalThreshold) / memNormalThreshold
+			memPenalty = w.Memory * (mem.UsedPercent - memNormalThreshold) / memNormalThreshold
 		} else {
-			memPenalty = (healthMemWeight / 2) * (mem.UsedPercent - memNormalThreshold) / (memHighThreshold - memNormalThreshold)
+			memPenalty = (w.Memory / 2) * (mem.UsedPercent - memNormalThreshold) / (memHighThreshold - memNormalThreshold)
 		}
 	}
 	score -= memPenalty
@@ -85,9 +165,9 @@ func calculateHealthScore(cpu CPUStatus, mem MemoryStatus, disks []DiskStatus, d
 		diskUsage := disks[0].UsedPercent
 		if diskUsage > diskWarnThreshold {
 			if diskUsage > diskCritThreshold {
-				diskPenalty = healthDiskWeight * (diskUsage - diskWarnThreshold) / (100 - diskWarnThreshold)
+				diskPenalty = w.Disk * (diskUsage - diskWarnThreshold) / (100 - diskWarnThreshold)
 			} else {
-				diskPenalty = (healthDiskWeight / 2) * (diskUsage - diskWarnThreshold) / (diskCritThreshold - diskWarnThreshold)
+				diskPenalty = (w.Disk / 2) * (diskUsage - diskWarnThreshold) / (diskCritThreshold - diskWarnThreshold)
 			}
 		}
 		score -= diskPenalty
@@ -101,10 +181,10 @@ func calculateHealthScore(cpu CPUStatus, mem MemoryStatus, disks []DiskStatus, d
 	if thermal.CPUTemp > 0 {
 		if thermal.CPUTemp > thermalNormalThreshold {
 			if thermal.CPUTemp > thermalHighThreshold {
-				thermalPenalty = healthThermalWeight
+				thermalPenalty = w.Thermal
 				issues = append(issues, "Overheating")
 			} else {
-				thermalPenalty = healthThermalWeight * (thermal.CPUTemp - thermalNormalThreshold) / (thermalHighThreshold - thermalNormalThreshold)
+				thermalPenalty = w.Thermal * (thermal.CPUTemp - thermalNormalThreshold) / (thermalHighThreshold - thermalNormalThreshold)
 			}
 		}
 		score -= thermalPenalty
@@ -115,14 +195,33 @@ func calculateHealthScore(cpu CPUStatus, mem MemoryStatus, disks []DiskStatus, d
 	totalIO := diskIO.ReadRate + diskIO.WriteRate
 	if totalIO > ioNormalThreshold {
 		if totalIO > ioHighThreshold {
-			ioPenalty = healthIOWeight
+			ioPenalty = w.IO
 			issues = append(issues, "Heavy Disk IO")
 		} else {
-			ioPenalty = healthIOWeight * (totalIO - ioNormalThreshold) / (ioHighThreshold - ioNormalThreshold)
+			ioPenalty = w.IO * (totalIO - ioNormalThreshold) / (ioHighThreshold - ioNormalThreshold)
 		}
 	}
 	score -= ioPenalty
 
+	// Swap penalty.
+	if mem.SwapTotal > 0 {
+		swapPct := float64(mem.SwapUsed) / float64(mem.SwapTotal) * 100
+		if swapPct > swapNormalThreshold {
+			if swapPct > swapHighThreshold {
+				score -= w.Swap
+				issues = append(issues, "Heavy Swap")
+			} else {
+				score -= w.Swap * (swapPct - swapNormalThreshold) / (swapHighThreshold - swapNormalThreshold)
+			}
+		}
+	}
+
+	// Collector error penalty.
+	if collectErrors > 0 {
+		score -= w.Errors * float64(min(collectErrors, healthMaxErrors)) / healthMaxErrors
+		issues = append(issues, "Collection Errors")
+	}
+
 	// Clamp score.
 	if score < 0 {
 		score = 0
@@ -194,3 +194,63 @@ func TestFormatUptimeEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthScoreMonotonicUnderPressure(t *testing.T) {
+	mem := MemoryStatus{UsedPercent: 30}
+	disks := []DiskStatus{{UsedPercent: 40}}
+
+	prev := 101
+	for usage := 0.0; usage <= 100; usage += 5 {
+		score, _ := calculateHealthScore(CPUStatus{Usage: usage}, mem, disks, DiskIOStatus{}, ThermalStatus{})
+		if score > prev {
+			t.Fatalf("score rose from %d to %d as CPU went to %.0f%%", prev, score, usage)
+		}
+		prev = score
+	}
+
+	prev = 101
+	for used := 0.0; used <= 100; used += 5 {
+		score, _ := calculateHealthScore(CPUStatus{Usage: 10}, mem, []DiskStatus{{UsedPercent: used}}, DiskIOStatus{}, ThermalStatus{})
+		if score > prev {
+			t.Fatalf("score rose from %d to %d as disk went to %.0f%%", prev, score, used)
+		}
+		prev = score
+	}
+	if prev >= 100 {
+		t.Fatalf("full disk should cost points, got %d", prev)
+	}
+}
+
+func TestWeightedHealthScoreSwapAndErrors(t *testing.T) {
+	cpu := CPUStatus{Usage: 10}
+	mem := MemoryStatus{UsedPercent: 30, SwapTotal: 100, SwapUsed: 90}
+	disks := []DiskStatus{{UsedPercent: 40}}
+
+	score, msg := calculateWeightedHealthScore(defaultHealthWeights, cpu, mem, disks, DiskIOStatus{}, ThermalStatus{}, 3)
+	if score != 80 {
+		t.Fatalf("expected full swap and error penalties (score 80), got %d", score)
+	}
+	if !strings.Contains(msg, "Heavy Swap") || !strings.Contains(msg, "Collection Errors") {
+		t.Fatalf("message should mention swap and errors: %q", msg)
+	}
+
+	noSwap := defaultHealthWeights
+	noSwap.Swap = 0
+	if score, _ := calculateWeightedHealthScore(noSwap, cpu, mem, disks, DiskIOStatus{}, ThermalStatus{}, 0); score != 100 {
+		t.Fatalf("zero swap weight should ignore swap, got %d", score)
+	}
+}
+
+func TestHealthWeightsFromPrefs(t *testing.T) {
+	w, err := healthWeightsFromPrefs(map[string]string{
+		"health_weight_cpu":  "50",
+		"health_weight_disk": "-1",
+		"cat_hidden":         "true",
+	})
+	if err == nil || !strings.Contains(err.Error(), "health_weight_disk") {
+		t.Fatalf("expected error naming the bad weight, got %v", err)
+	}
+	if w.CPU != 50 || w.Disk != defaultHealthWeights.Disk || w.Memory != defaultHealthWeights.Memory {
+		t.Fatalf("unexpected weights: %+v", w)
+	}
+}
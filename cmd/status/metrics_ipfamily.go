@@ -0,0 +1,159 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	procNetSNMP6Path   = "/proc/net/snmp6"
+	procNetNetstatPath = "/proc/net/netstat"
+)
+
+// IPFamilyStatus splits traffic between IPv4 and IPv6. On Linux it comes
+// from the kernel's per-family octet counters, loopback included. Elsewhere
+// only open connections can be classified, so Approximate is set and
+// IPv6Percent is the IPv6 share of established connections, not bytes.
+type IPFamilyStatus struct {
+	IPv4RateMBs float64 `json:"ipv4_rate_mbs"` // Received plus sent; 0 when Approximate
+	IPv6RateMBs float64 `json:"ipv6_rate_mbs"`
+	IPv6Percent float64 `json:"ipv6_percent"`
+	Approximate bool    `json:"approximate,omitempty"`
+	Source      string  `json:"source,omitempty"` // "counters" or "connections"
+}
+
+// familyOctets are cumulative bytes received plus sent per family.
+type familyOctets struct {
+	v4, v6 uint64
+}
+
+// readFamilyOctets reads IPv4 octets from the IpExt lines of
+// /proc/net/netstat and IPv6 octets from /proc/net/snmp6.
+func readFamilyOctets() (familyOctets, bool) {
+	netstat, err := os.ReadFile(procNetNetstatPath)
+	if err != nil {
+		return familyOctets{}, false
+	}
+	snmp6, err := os.ReadFile(procNetSNMP6Path)
+	if err != nil {
+		return familyOctets{}, false
+	}
+	v4, ok4 := parseIPExtOctets(string(netstat))
+	v6, ok6 := parseSNMP6Octets(string(snmp6))
+	return familyOctets{v4: v4, v6: v6}, ok4 && ok6
+}
+
+// parseSNMP6Octets sums Ip6InOctets and Ip6OutOctets from /proc/net/snmp6,
+// which has one "Name value" pair per line.
+func parseSNMP6Octets(data string) (uint64, bool) {
+	var total uint64
+	found := 0
+	for line := range strings.Lines(data) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || (fields[0] != "Ip6InOctets" && fields[0] != "Ip6OutOctets") {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		total += n
+		found++
+	}
+	return total, found == 2
+}
+
+// parseIPExtOctets sums InOctets and OutOctets from the "IpExt:" header and
+// value lines of /proc/net/netstat. These count IPv4 only.
+func parseIPExtOctets(data string) (uint64, bool) {
+	var header []string
+	for line := range strings.Lines(data) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "IpExt:" {
+			continue
+		}
+		if header == nil {
+			header = fields
+			continue
+		}
+		var total uint64
+		found := 0
+		for i, name := range header {
+			if i >= len(fields) || (name != "InOctets" && name != "OutOctets") {
+				continue
+			}
+			n, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			total += n
+			found++
+		}
+		return total, found == 2
+	}
+	return 0, false
+}
+
+// familyRates computes the split from two samples elapsed seconds apart.
+// A counter that went backwards (namespace change, wrap) yields nothing.
+func familyRates(cur, prev familyOctets, elapsed float64) IPFamilyStatus {
+	if elapsed <= 0 || cur.v4 < prev.v4 || cur.v6 < prev.v6 {
+		return IPFamilyStatus{}
+	}
+	v4, v6 := float64(cur.v4-prev.v4), float64(cur.v6-prev.v6)
+	status := IPFamilyStatus{
+		IPv4RateMBs: v4 / 1024 / 1024 / elapsed,
+		IPv6RateMBs: v6 / 1024 / 1024 / elapsed,
+		Source:      "counters",
+	}
+	if v4+v6 > 0 {
+		status.IPv6Percent = v6 / (v4 + v6) * 100
+	}
+	return status
+}
+
+// connectionFamilySplit classifies established connections by remote
+// address. IPv4-mapped IPv6 addresses count as IPv4.
+func connectionFamilySplit() (IPFamilyStatus, bool) {
+	conns, err := connectionsFunc("inet")
+	if err != nil {
+		return IPFamilyStatus{}, false
+	}
+	var v4, v6 int
+	for _, conn := range conns {
+		ip := net.ParseIP(conn.Raddr.IP)
+		if conn.Status != "ESTABLISHED" || ip == nil || ip.IsLoopback() {
+			continue
+		}
+		if ip.To4() != nil {
+			v4++
+		} else {
+			v6++
+		}
+	}
+	status := IPFamilyStatus{Approximate: true, Source: "connections"}
+	if v4+v6 > 0 {
+		status.IPv6Percent = float64(v6) / float64(v4+v6) * 100
+	}
+	return status, true
+}
+
+// collectIPFamilies prefers the kernel counters and falls back to
+// connection sampling where they do not exist. The first counter sample
+// only sets the baseline.
+func (c *Collector) collectIPFamilies(now time.Time) IPFamilyStatus {
+	cur, ok := readFamilyOctets()
+	if !ok {
+		status, _ := connectionFamilySplit()
+		return status
+	}
+	var status IPFamilyStatus
+	if !c.prevFamilyAt.IsZero() && !c.clockJumped("ip_families", now, c.prevFamilyAt) {
+		status = familyRates(cur, c.prevFamily, c.rateWindow(now, c.prevFamilyAt))
+	}
+	c.prevFamily, c.prevFamilyAt = cur, now
+	return status
+}
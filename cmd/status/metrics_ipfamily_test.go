@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gopsutilnet "github.com/shirou/gopsutil/v4/net"
+)
+
+func writeFamilyFixtures(t *testing.T, dir string, v4In, v4Out, v6In, v6Out uint64) {
+	t.Helper()
+	netstat := fmt.Sprintf(`TcpExt: SyncookiesSent SyncookiesRecv
+TcpExt: 0 0
+IpExt: InNoRoutes InTruncatedPkts InMcastPkts OutMcastPkts InBcastPkts OutBcastPkts InOctets OutOctets InMcastOctets
+IpExt: 0 0 12 0 4 0 %d %d 900
+`, v4In, v4Out)
+	snmp6 := fmt.Sprintf(`Ip6InReceives                   	3120
+Ip6InDelivers                   	3100
+Ip6OutRequests                  	2890
+Ip6InOctets                     	%d
+Ip6OutOctets                    	%d
+Ip6InMcastOctets                	4400
+Icmp6InMsgs                     	12
+`, v6In, v6Out)
+	if err := os.WriteFile(filepath.Join(dir, "netstat"), []byte(netstat), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "snmp6"), []byte(snmp6), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectIPFamiliesFromCounterDeltas(t *testing.T) {
+	dir := t.TempDir()
+	origNetstat, origSNMP6 := procNetNetstatPath, procNetSNMP6Path
+	t.Cleanup(func() { procNetNetstatPath, procNetSNMP6Path = origNetstat, origSNMP6 })
+	procNetNetstatPath, procNetSNMP6Path = filepath.Join(dir, "netstat"), filepath.Join(dir, "snmp6")
+
+	const mb = 1024 * 1024
+	writeFamilyFixtures(t, dir, 100*mb, 50*mb, 10*mb, 10*mb)
+	c := &Collector{}
+	start := time.Now()
+	if got := c.collectIPFamilies(start); got != (IPFamilyStatus{}) {
+		t.Fatalf("first sample should only set the baseline, got %+v", got)
+	}
+
+	// Over 2s: IPv4 moves 4+2 MB, IPv6 moves 1+1 MB.
+	writeFamilyFixtures(t, dir, 104*mb, 52*mb, 11*mb, 11*mb)
+	got := c.collectIPFamilies(start.Add(2 * time.Second))
+	want := IPFamilyStatus{IPv4RateMBs: 3, IPv6RateMBs: 1, IPv6Percent: 25, Source: "counters"}
+	if got != want {
+		t.Fatalf("collectIPFamilies() = %+v, want %+v", got, want)
+	}
+
+	// A counter reset reports nothing rather than a bogus split.
+	writeFamilyFixtures(t, dir, 1, 1, 1, 1)
+	if got := c.collectIPFamilies(start.Add(4 * time.Second)); got != (IPFamilyStatus{}) {
+		t.Fatalf("after reset = %+v, want empty", got)
+	}
+}
+
+func TestParseFamilyOctetsRejectsMissingFields(t *testing.T) {
+	if _, ok := parseSNMP6Octets("Ip6InOctets 5\n"); ok {
+		t.Error("snmp6 without Ip6OutOctets should not parse")
+	}
+	if _, ok := parseIPExtOctets("IpExt: InOctets\nIpExt: 5\n"); ok {
+		t.Error("IpExt without OutOctets should not parse")
+	}
+}
+
+func TestCollectIPFamiliesFallsBackToConnections(t *testing.T) {
+	origNetstat, origConns := procNetNetstatPath, connectionsFunc
+	t.Cleanup(func() { procNetNetstatPath, connectionsFunc = origNetstat, origConns })
+	procNetNetstatPath = filepath.Join(t.TempDir(), "missing")
+	connectionsFunc = func(string) ([]gopsutilnet.ConnectionStat, error) {
+		conn := func(ip, status string) gopsutilnet.ConnectionStat {
+			return gopsutilnet.ConnectionStat{Status: status, Raddr: gopsutilnet.Addr{IP: ip, Port: 443}}
+		}
+		return []gopsutilnet.ConnectionStat{
+			conn("93.184.216.34", "ESTABLISHED"),
+			conn("::ffff:10.0.0.9", "ESTABLISHED"), // IPv4-mapped
+			conn("2606:2800:220:1::1", "ESTABLISHED"),
+			conn("2a00:1450:4001::200e", "ESTABLISHED"),
+			conn("2a00:1450:4001::200f", "TIME_WAIT"),
+			conn("::1", "ESTABLISHED"),
+			conn("", "LISTEN"),
+		}, nil
+	}
+
+	got := (&Collector{}).collectIPFamilies(time.Now())
+	want := IPFamilyStatus{IPv6Percent: 50, Approximate: true, Source: "connections"}
+	if got != want {
+		t.Fatalf("collectIPFamilies() = %+v, want %+v", got, want)
+	}
+}
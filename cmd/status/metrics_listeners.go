@@ -0,0 +1,206 @@
+package main
+
+import (
+	"net/netip"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// listenerCacheTTL bounds how often sockets are enumerated; walking every
+// process's file descriptors is expensive.
+const listenerCacheTTL = 30 * time.Second
+
+var (
+	connectionsFunc = net.Connections
+	processNameFunc = func(pid int32) (string, error) {
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			return "", err
+		}
+		return p.Name()
+	}
+)
+
+// socketScan is everything derived from one walk of the socket table.
+type socketScan struct {
+	listeners   []ListenerStatus
+	sockets     map[int32]map[string]int
+	remotes     []RemoteHost
+	established map[string]int // ESTABLISHED connections by local IP
+}
+
+// collectListeners returns TCP sockets in LISTEN state with their owning
+// process, plus per-PID socket counts and top remote hosts from the same
+// scan. Without elevated privileges sockets owned by other users may lack a
+// PID; listeners are still reported with an empty process name, but such
+// sockets aren't counted.
+func collectListeners() (socketScan, error) {
+	conns, err := connectionsFunc("inet")
+	if err != nil {
+		return socketScan{}, err
+	}
+	return socketScan{
+		listeners:   listenersFromConnections(conns, processNameFunc),
+		sockets:     countSocketsByPID(conns),
+		remotes:     aggregateRemoteHosts(conns, maxRemoteHosts),
+		established: establishedByLocalIP(conns),
+	}, nil
+}
+
+// establishedByLocalIP counts ESTABLISHED connections by local address,
+// with v4-mapped addresses unmapped. Loopback connections are local IPC
+// and carry no interface load, so they are left out.
+func establishedByLocalIP(conns []net.ConnectionStat) map[string]int {
+	counts := make(map[string]int)
+	for _, conn := range conns {
+		if conn.Status != "ESTABLISHED" {
+			continue
+		}
+		addr, err := netip.ParseAddr(conn.Laddr.IP)
+		if err != nil || addr.IsLoopback() {
+			continue
+		}
+		counts[addr.Unmap().WithZone("").String()]++
+	}
+	return counts
+}
+
+// annotateInterfaceConns sets EstablishedConns on stats by matching each
+// connection's local IP to the interface holding it, and returns the
+// connections that matched no listed interface: addresses no interface
+// holds (yet), or interfaces trimmed from the snapshot.
+func annotateInterfaceConns(stats []NetworkStatus, byIP map[string]int, ifaces map[string]interfaceInfo) (other int) {
+	owner := make(map[string]string)
+	for name, info := range ifaces {
+		for _, ip := range info.Addrs {
+			if addr, err := netip.ParseAddr(ip); err == nil {
+				owner[addr.Unmap().WithZone("").String()] = name
+			}
+		}
+	}
+	byIface := make(map[string]int)
+	for ip, n := range byIP {
+		byIface[owner[ip]] += n
+	}
+	for i := range stats {
+		stats[i].EstablishedConns = byIface[stats[i].Name]
+		delete(byIface, stats[i].Name)
+	}
+	for _, n := range byIface {
+		other += n
+	}
+	return other
+}
+
+// countSocketsByPID groups connections by owning PID and state.
+func countSocketsByPID(conns []net.ConnectionStat) map[int32]map[string]int {
+	counts := make(map[int32]map[string]int)
+	for _, conn := range conns {
+		if conn.Pid <= 0 {
+			continue
+		}
+		byState := counts[conn.Pid]
+		if byState == nil {
+			byState = make(map[string]int)
+			counts[conn.Pid] = byState
+		}
+		state := conn.Status
+		if state == "" {
+			state = "NONE"
+		}
+		byState[state]++
+	}
+	return counts
+}
+
+// annotateSocketCounts fills SocketCount/SocketStates on procs by PID.
+func annotateSocketCounts(procs []ProcessInfo, counts map[int32]map[string]int) {
+	for i := range procs {
+		byState, ok := counts[procs[i].PID]
+		if !ok {
+			continue
+		}
+		procs[i].SocketStates = byState
+		procs[i].SocketCount = 0
+		for _, n := range byState {
+			procs[i].SocketCount += n
+		}
+	}
+}
+
+func listenersFromConnections(conns []net.ConnectionStat, lookup func(int32) (string, error)) []ListenerStatus {
+	type key struct {
+		proto string
+		addr  string
+		port  uint32
+		pid   int32
+	}
+	seen := make(map[key]bool)
+	names := make(map[int32]string)
+
+	var result []ListenerStatus
+	for _, conn := range conns {
+		if conn.Status != "LISTEN" || conn.Type != syscall.SOCK_STREAM {
+			continue
+		}
+		// 0.0.0.0 and :: on the same port are one service bound dual-stack.
+		addr := conn.Laddr.IP
+		if addr == "0.0.0.0" || addr == "::" || addr == "" {
+			addr = "*"
+		}
+		k := key{proto: "tcp", addr: addr, port: conn.Laddr.Port, pid: conn.Pid}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		name, ok := names[conn.Pid]
+		if !ok && conn.Pid > 0 {
+			// Processes can exit between enumeration and lookup.
+			name, _ = lookup(conn.Pid)
+			names[conn.Pid] = name
+		}
+		result = append(result, ListenerStatus{
+			Proto:       k.proto,
+			Addr:        addr,
+			Port:        conn.Laddr.Port,
+			PID:         conn.Pid,
+			ProcessName: name,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Port != result[j].Port {
+			return result[i].Port < result[j].Port
+		}
+		return result[i].Addr < result[j].Addr
+	})
+	return result
+}
+
+func (c *Collector) collectListeners(now time.Time) []ListenerStatus {
+	if !c.lastListenerAt.IsZero() && now.Sub(c.lastListenerAt) < listenerCacheTTL {
+		return c.cachedListeners
+	}
+	if scan, err := collectListeners(); err != nil {
+		logDegraded(c.logger(), "listeners", err)
+	} else {
+		if c.ResolveRemotes {
+			if c.remoteNames == nil {
+				c.remoteNames = newHostnameCache(maxHostnameCache)
+			}
+			resolveRemoteHosts(scan.remotes, c.Resolver, c.remoteNames)
+			resolveListeners(scan.listeners, c.Resolver, c.remoteNames)
+		}
+		c.cachedListeners = scan.listeners
+		c.cachedSockets = scan.sockets
+		c.cachedRemotes = scan.remotes
+		c.cachedConnsByIP = scan.established
+	}
+	c.lastListenerAt = now
+	return c.cachedListeners
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestListenersFromConnections(t *testing.T) {
+	listen := func(ip string, port uint32, pid int32) net.ConnectionStat {
+		return net.ConnectionStat{
+			Type:   syscall.SOCK_STREAM,
+			Status: "LISTEN",
+			Laddr:  net.Addr{IP: ip, Port: port},
+			Pid:    pid,
+		}
+	}
+	conns := []net.ConnectionStat{
+		listen("0.0.0.0", 22, 100),
+		listen("::", 22, 100), // dual-stack duplicate
+		listen("127.0.0.1", 5432, 200),
+		listen("0.0.0.0", 8080, 0), // owned by another user
+		listen("0.0.0.0", 9000, 300),
+		{Type: syscall.SOCK_STREAM, Status: "ESTABLISHED", Laddr: net.Addr{IP: "10.0.0.2", Port: 51000}, Pid: 100},
+		{Type: syscall.SOCK_DGRAM, Status: "NONE", Laddr: net.Addr{IP: "0.0.0.0", Port: 53}, Pid: 400},
+	}
+	lookup := func(pid int32) (string, error) {
+		switch pid {
+		case 100:
+			return "sshd", nil
+		case 200:
+			return "postgres", nil
+		}
+		return "", errors.New("no such process")
+	}
+
+	got := listenersFromConnections(conns, lookup)
+	want := []ListenerStatus{
+		{Proto: "tcp", Addr: "*", Port: 22, PID: 100, ProcessName: "sshd"},
+		{Proto: "tcp", Addr: "127.0.0.1", Port: 5432, PID: 200, ProcessName: "postgres"},
+		{Proto: "tcp", Addr: "*", Port: 8080},
+		{Proto: "tcp", Addr: "*", Port: 9000, PID: 300},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d listeners, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("listener %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCountSocketsByPID(t *testing.T) {
+	conn := func(pid int32, status string) net.ConnectionStat {
+		return net.ConnectionStat{Type: syscall.SOCK_STREAM, Status: status, Pid: pid}
+	}
+	conns := []net.ConnectionStat{
+		conn(100, "LISTEN"),
+		conn(100, "ESTABLISHED"),
+		conn(200, "TIME_WAIT"),
+		conn(200, "TIME_WAIT"),
+		conn(200, "TIME_WAIT"),
+		conn(0, "ESTABLISHED"), // unattributable
+		{Type: syscall.SOCK_DGRAM, Pid: 300},
+	}
+
+	procs := []ProcessInfo{{PID: 100, Name: "nginx"}, {PID: 200, Name: "leaky"}, {PID: 300, Name: "dns"}, {PID: 400, Name: "idle"}}
+	annotateSocketCounts(procs, countSocketsByPID(conns))
+
+	want := []struct {
+		count  int
+		states map[string]int
+	}{
+		{2, map[string]int{"LISTEN": 1, "ESTABLISHED": 1}},
+		{3, map[string]int{"TIME_WAIT": 3}},
+		{1, map[string]int{"NONE": 1}},
+		{0, nil},
+	}
+	for i, w := range want {
+		p := procs[i]
+		if p.SocketCount != w.count || len(p.SocketStates) != len(w.states) {
+			t.Fatalf("%s: got count %d states %v, want %d %v", p.Name, p.SocketCount, p.SocketStates, w.count, w.states)
+		}
+		for state, n := range w.states {
+			if p.SocketStates[state] != n {
+				t.Fatalf("%s: %s = %d, want %d", p.Name, state, p.SocketStates[state], n)
+			}
+		}
+	}
+}
+
+func TestAnnotateInterfaceConnsByLocalIP(t *testing.T) {
+	est := func(local string) net.ConnectionStat {
+		return net.ConnectionStat{Status: "ESTABLISHED", Laddr: net.Addr{IP: local, Port: 50000}, Raddr: net.Addr{IP: "203.0.113.5", Port: 443}}
+	}
+	conns := []net.ConnectionStat{
+		est("192.168.1.20"),
+		est("192.168.1.20"),
+		est("::ffff:192.168.1.20"), // v4-mapped, same address
+		est("2001:db8::20"),        // Second address on en0
+		est("10.8.0.2"),
+		est("172.17.0.1"), // docker0, not listed
+		est("198.51.100.77"),
+		est("127.0.0.1"), // Local IPC
+		{Status: "LISTEN", Laddr: net.Addr{IP: "192.168.1.20", Port: 22}},
+		{Status: "TIME_WAIT", Laddr: net.Addr{IP: "10.8.0.2", Port: 40000}},
+	}
+	ifaces := map[string]interfaceInfo{
+		"en0":     {IP: "192.168.1.20", Addrs: []string{"192.168.1.20", "fe80::1%en0", "2001:db8::20"}},
+		"utun3":   {IP: "10.8.0.2", Addrs: []string{"10.8.0.2"}},
+		"docker0": {IP: "172.17.0.1", Addrs: []string{"172.17.0.1"}},
+		"lo0":     {Addrs: []string{"127.0.0.1", "::1"}},
+	}
+	stats := []NetworkStatus{{Name: "en0"}, {Name: "utun3"}, {Name: "en1"}}
+
+	other := annotateInterfaceConns(stats, establishedByLocalIP(conns), ifaces)
+	want := map[string]int{"en0": 4, "utun3": 1, "en1": 0}
+	for _, s := range stats {
+		if s.EstablishedConns != want[s.Name] {
+			t.Errorf("%s: EstablishedConns = %d, want %d", s.Name, s.EstablishedConns, want[s.Name])
+		}
+	}
+	// docker0 is not listed and 198.51.100.77 belongs to no interface.
+	if other != 2 {
+		t.Errorf("other = %d, want 2", other)
+	}
+}
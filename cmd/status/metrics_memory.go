@@ -10,10 +10,10 @@ import (
 	"github.com/shirou/gopsutil/v4/mem"
 )
 
-func collectMemory() (MemoryStatus, error) {
+func collectMemory() (MemoryStatus, vmStatSample, error) {
 	vm, err := mem.VirtualMemory()
 	if err != nil {
-		return MemoryStatus{}, err
+		return MemoryStatus{}, vmStatSample{}, err
 	}
 
 	swap, _ := mem.SwapMemory()
@@ -23,9 +23,13 @@ func collectMemory() (MemoryStatus, error) {
 	pressure := getMemoryPressure()
 
 	// On macOS, vm.Cached is 0, so we calculate from file-backed pages.
+	var sample vmStatSample
 	cached := vm.Cached
-	if runtime.GOOS == "darwin" && cached == 0 {
-		cached = getFileBackedMemory()
+	if runtime.GOOS == "darwin" {
+		sample = readVMStat()
+		if cached == 0 {
+			cached = sample.fileBacked * sample.pageSize
+		}
 	}
 
 	return MemoryStatus{
@@ -36,46 +40,103 @@ func collectMemory() (MemoryStatus, error) {
 		SwapTotal:   swap.Total,
 		Cached:      cached,
 		Pressure:    pressure,
-	}, nil
+	}, sample, nil
+}
+
+// collectMemory adds macOS paging rates, computed from the change in
+// vm_stat counters since the previous call, to the memory status.
+func (c *Collector) collectMemory(now time.Time) (MemoryStatus, error) {
+	status, sample, err := collectMemory()
+	if err != nil || sample.pageSize == 0 {
+		return status, err
+	}
+	c.applyVMStat(now, &status, sample)
+	return status, nil
+}
+
+func (c *Collector) applyVMStat(now time.Time, status *MemoryStatus, sample vmStatSample) {
+	status.CompressedPages = sample.compressed
+	status.CompressorPages = sample.compressorOccupied
+	if prev := c.prevVMStat; !c.prevVMStatAt.IsZero() {
+		if elapsed := now.Sub(c.prevVMStatAt).Seconds(); elapsed > 0 {
+			status.PageinsPerSec = counterRate(sample.pageins, prev.pageins, elapsed)
+			status.PageoutsPerSec = counterRate(sample.pageouts, prev.pageouts, elapsed)
+			status.SwapinsPerSec = counterRate(sample.swapins, prev.swapins, elapsed)
+			status.SwapoutsPerSec = counterRate(sample.swapouts, prev.swapouts, elapsed)
+		}
+	}
+	c.prevVMStat, c.prevVMStatAt = sample, now
+}
+
+// counterRate is the per-second change of a monotonic counter; a counter
+// that went backwards (reboot, wrap) yields 0.
+func counterRate(cur, prev uint64, elapsed float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / elapsed
+}
+
+// vmStatSample holds the vm_stat counters used for memory reporting.
+// Counts are pages; pageins through swapouts are cumulative since boot.
+type vmStatSample struct {
+	pageSize           uint64
+	fileBacked         uint64
+	compressed         uint64 // Pages stored in compressor
+	compressorOccupied uint64 // Pages occupied by compressor
+	pageins            uint64
+	pageouts           uint64
+	swapins            uint64
+	swapouts           uint64
 }
 
-func getFileBackedMemory() uint64 {
+func readVMStat() vmStatSample {
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 	out, err := runCmd(ctx, "vm_stat")
 	if err != nil {
-		return 0
+		return vmStatSample{}
 	}
+	return parseVMStat(out)
+}
 
-	// Parse page size from first line: "Mach Virtual Memory Statistics: (page size of 16384 bytes)"
-	var pageSize uint64 = 4096 // Default
-	firstLine := true
+// parseVMStat parses `vm_stat` output. A missing page size line falls back
+// to 4096 bytes.
+func parseVMStat(out string) vmStatSample {
+	sample := vmStatSample{pageSize: 4096}
+	fields := map[string]*uint64{
+		"File-backed pages":            &sample.fileBacked,
+		"Pages stored in compressor":   &sample.compressed,
+		"Pages occupied by compressor": &sample.compressorOccupied,
+		"Pageins":                      &sample.pageins,
+		"Pageouts":                     &sample.pageouts,
+		"Swapins":                      &sample.swapins,
+		"Swapouts":                     &sample.swapouts,
+	}
 	for line := range strings.Lines(out) {
-		if firstLine {
-			firstLine = false
-			if strings.Contains(line, "page size of") {
-				if _, after, found := strings.Cut(line, "page size of "); found {
-					if before, _, found := strings.Cut(after, " bytes"); found {
-						if size, err := strconv.ParseUint(strings.TrimSpace(before), 10, 64); err == nil {
-							pageSize = size
-						}
-					}
+		// "Mach Virtual Memory Statistics: (page size of 16384 bytes)"
+		if _, after, found := strings.Cut(line, "page size of "); found {
+			if before, _, found := strings.Cut(after, " bytes"); found {
+				if size, err := strconv.ParseUint(strings.TrimSpace(before), 10, 64); err == nil {
+					sample.pageSize = size
 				}
 			}
+			continue
 		}
-
-		// Parse "File-backed pages: 388975."
-		if strings.Contains(line, "File-backed pages:") {
-			if _, after, found := strings.Cut(line, ":"); found {
-				numStr := strings.TrimSpace(after)
-				numStr = strings.TrimSuffix(numStr, ".")
-				if pages, err := strconv.ParseUint(numStr, 10, 64); err == nil {
-					return pages * pageSize
-				}
-			}
+		// "Pageins:                                1234567."
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		dst, ok := fields[strings.Trim(strings.TrimSpace(key), `"`)]
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), "."), 10, 64); err == nil {
+			*dst = n
 		}
 	}
-	return 0
+	return sample
 }
 
 func getMemoryPressure() string {
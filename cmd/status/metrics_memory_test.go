@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+const vmStatBefore = `Mach Virtual Memory Statistics: (page size of 16384 bytes)
+Pages free:                                6573.
+Pages active:                            241603.
+Pages inactive:                          237289.
+Pages speculative:                         2005.
+Pages throttled:                              0.
+Pages wired down:                        149740.
+Pages purgeable:                           3190.
+"Translation faults":                 588241635.
+Pages copy-on-write:                   23689703.
+Pages zero filled:                    268617466.
+Pages reactivated:                      2911022.
+Pages purged:                            848080.
+File-backed pages:                       183279.
+Anonymous pages:                         297618.
+Pages stored in compressor:              912446.
+Pages occupied by compressor:            300150.
+Decompressions:                         6290619.
+Compressions:                          10141698.
+Pageins:                                9081931.
+Pageouts:                                 90552.
+Swapins:                                 120033.
+Swapouts:                                161280.
+`
+
+const vmStatAfter = `Mach Virtual Memory Statistics: (page size of 16384 bytes)
+Pages free:                                5120.
+File-backed pages:                       183500.
+Pages stored in compressor:              913000.
+Pages occupied by compressor:            300400.
+Pageins:                                9082931.
+Pageouts:                                 90602.
+Swapins:                                 120033.
+Swapouts:                                161300.
+`
+
+func TestParseVMStat(t *testing.T) {
+	got := parseVMStat(vmStatBefore)
+	want := vmStatSample{
+		pageSize:           16384,
+		fileBacked:         183279,
+		compressed:         912446,
+		compressorOccupied: 300150,
+		pageins:            9081931,
+		pageouts:           90552,
+		swapins:            120033,
+		swapouts:           161280,
+	}
+	if got != want {
+		t.Fatalf("parseVMStat() = %+v, want %+v", got, want)
+	}
+	if got := parseVMStat("Pageins: 5."); got.pageSize != 4096 || got.pageins != 5 {
+		t.Fatalf("parseVMStat without header = %+v", got)
+	}
+}
+
+func TestApplyVMStatRates(t *testing.T) {
+	c := &Collector{}
+	base := time.Unix(1000, 0)
+
+	var first MemoryStatus
+	c.applyVMStat(base, &first, parseVMStat(vmStatBefore))
+	if first.PageinsPerSec != 0 || first.CompressedPages != 912446 {
+		t.Fatalf("first sample = %+v, want no rates yet and compressed pages set", first)
+	}
+
+	var second MemoryStatus
+	c.applyVMStat(base.Add(10*time.Second), &second, parseVMStat(vmStatAfter))
+	if second.PageinsPerSec != 100 || second.PageoutsPerSec != 5 || second.SwapinsPerSec != 0 || second.SwapoutsPerSec != 2 {
+		t.Fatalf("rates = in %v out %v swapin %v swapout %v, want 100/5/0/2",
+			second.PageinsPerSec, second.PageoutsPerSec, second.SwapinsPerSec, second.SwapoutsPerSec)
+	}
+	if second.CompressorPages != 300400 {
+		t.Fatalf("CompressorPages = %d, want 300400", second.CompressorPages)
+	}
+}
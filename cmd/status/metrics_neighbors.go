@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const neighborTimeout = 2 * time.Second
+
+// NeighborStatus summarizes the ARP/NDP neighbor cache. A cache far larger
+// than the local network suggests a scan or a misconfigured subnet.
+type NeighborStatus struct {
+	Checked    bool             `json:"checked"`
+	Total      int              `json:"total"`
+	Reachable  int              `json:"reachable"`
+	Stale      int              `json:"stale"`  // STALE, DELAY and PROBE
+	Failed     int              `json:"failed"` // FAILED and INCOMPLETE
+	Interfaces []NeighborCounts `json:"interfaces,omitempty"`
+}
+
+// NeighborCounts are the neighbor entries on one interface.
+type NeighborCounts struct {
+	Interface string `json:"interface"`
+	Total     int    `json:"total"`
+	Reachable int    `json:"reachable"`
+	Stale     int    `json:"stale"`
+	Failed    int    `json:"failed"`
+}
+
+// CollectNeighbors reads the neighbor cache with `ip neigh` on Linux (IPv4
+// and IPv6) and `arp -an` on macOS (IPv4 only; arp reports no state, so
+// resolved entries count as reachable).
+func CollectNeighbors(ctx context.Context) (NeighborStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, neighborTimeout)
+	defer cancel()
+	switch runtime.GOOS {
+	case "linux":
+		out, err := runCmd(ctx, "ip", "neigh", "show")
+		if err != nil {
+			return NeighborStatus{}, err
+		}
+		return parseIPNeigh(out), nil
+	case "darwin":
+		out, err := runCmd(ctx, "arp", "-an")
+		if err != nil {
+			return NeighborStatus{}, err
+		}
+		return parseARPTable(out), nil
+	}
+	return NeighborStatus{}, errors.New("unsupported platform")
+}
+
+// parseIPNeigh parses `ip neigh show` lines such as
+// "192.168.1.1 dev eth0 lladdr aa:bb:cc:dd:ee:ff REACHABLE".
+func parseIPNeigh(out string) NeighborStatus {
+	counts := make(map[string]*NeighborCounts)
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		iface := ""
+		for i := 1; i+1 < len(fields); i++ {
+			if fields[i] == "dev" {
+				iface = fields[i+1]
+				break
+			}
+		}
+		var entry NeighborCounts
+		switch fields[len(fields)-1] {
+		case "REACHABLE", "PERMANENT", "NOARP":
+			entry.Reachable = 1
+		case "STALE", "DELAY", "PROBE":
+			entry.Stale = 1
+		case "FAILED", "INCOMPLETE":
+			entry.Failed = 1
+		}
+		addNeighbor(counts, iface, entry)
+	}
+	return summarizeNeighbors(counts)
+}
+
+// parseARPTable parses macOS `arp -an` lines such as
+// "? (192.168.1.1) at aa:bb:cc:dd:ee:ff on en0 ifscope [ethernet]".
+func parseARPTable(out string) NeighborStatus {
+	counts := make(map[string]*NeighborCounts)
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[2] != "at" {
+			continue
+		}
+		iface := ""
+		for i := 3; i+1 < len(fields); i++ {
+			if fields[i] == "on" {
+				iface = fields[i+1]
+				break
+			}
+		}
+		var entry NeighborCounts
+		if fields[3] == "(incomplete)" {
+			entry.Failed = 1
+		} else {
+			entry.Reachable = 1
+		}
+		addNeighbor(counts, iface, entry)
+	}
+	return summarizeNeighbors(counts)
+}
+
+func addNeighbor(counts map[string]*NeighborCounts, iface string, entry NeighborCounts) {
+	c := counts[iface]
+	if c == nil {
+		c = &NeighborCounts{Interface: iface}
+		counts[iface] = c
+	}
+	c.Total++
+	c.Reachable += entry.Reachable
+	c.Stale += entry.Stale
+	c.Failed += entry.Failed
+}
+
+func summarizeNeighbors(counts map[string]*NeighborCounts) NeighborStatus {
+	status := NeighborStatus{Checked: true}
+	for _, c := range counts {
+		status.Total += c.Total
+		status.Reachable += c.Reachable
+		status.Stale += c.Stale
+		status.Failed += c.Failed
+		if c.Interface != "" {
+			status.Interfaces = append(status.Interfaces, *c)
+		}
+	}
+	sort.Slice(status.Interfaces, func(i, j int) bool {
+		return status.Interfaces[i].Interface < status.Interfaces[j].Interface
+	})
+	return status
+}
+
+// collectNeighbors runs CollectNeighbors when Neighbors is enabled, caching
+// the result since neighbor caches change slowly.
+func (c *Collector) collectNeighbors(ctx context.Context, now time.Time) NeighborStatus {
+	if !c.Neighbors {
+		return NeighborStatus{}
+	}
+	if !c.lastNeighborsAt.IsZero() && now.Sub(c.lastNeighborsAt) < c.ttl(30*time.Second) {
+		return c.cachedNeighbors
+	}
+	status, err := CollectNeighbors(ctx)
+	if err != nil {
+		logDegraded(c.logger(), "neighbors", err)
+	}
+	c.cachedNeighbors = status
+	c.lastNeighborsAt = now
+	return status
+}
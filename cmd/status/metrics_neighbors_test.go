@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const ipNeighOutput = `192.168.1.1 dev eth0 lladdr a4:2b:b0:11:22:33 REACHABLE
+192.168.1.23 dev eth0 lladdr 3c:22:fb:44:55:66 STALE
+192.168.1.40 dev eth0  FAILED
+10.8.0.1 dev wg0 lladdr 00:00:00:00:00:00 NOARP
+fe80::a62b:b0ff:fe11:2233 dev eth0 lladdr a4:2b:b0:11:22:33 router DELAY
+fe80::1 dev eth1 INCOMPLETE
+`
+
+const arpOutput = `? (192.168.1.1) at a4:2b:b0:11:22:33 on en0 ifscope [ethernet]
+? (192.168.1.23) at 3c:22:fb:44:55:66 on en0 ifscope [ethernet]
+? (192.168.1.77) at (incomplete) on en0 ifscope [ethernet]
+? (224.0.0.251) at 1:0:5e:0:0:fb on en0 ifscope permanent [ethernet]
+? (169.254.12.4) at 8a:1c:2f:aa:bb:cc on bridge100 ifscope [bridge]
+`
+
+func TestParseIPNeigh(t *testing.T) {
+	got := parseIPNeigh(ipNeighOutput)
+	if !got.Checked || got.Total != 6 || got.Reachable != 2 || got.Stale != 2 || got.Failed != 2 {
+		t.Fatalf("parseIPNeigh() totals = %+v", got)
+	}
+	want := []NeighborCounts{
+		{Interface: "eth0", Total: 4, Reachable: 1, Stale: 2, Failed: 1},
+		{Interface: "eth1", Total: 1, Failed: 1},
+		{Interface: "wg0", Total: 1, Reachable: 1},
+	}
+	if len(got.Interfaces) != len(want) {
+		t.Fatalf("interfaces = %+v, want %+v", got.Interfaces, want)
+	}
+	for i := range want {
+		if got.Interfaces[i] != want[i] {
+			t.Errorf("interface %d = %+v, want %+v", i, got.Interfaces[i], want[i])
+		}
+	}
+}
+
+func TestParseARPTable(t *testing.T) {
+	got := parseARPTable(arpOutput + "garbage line\n")
+	if got.Total != 5 || got.Reachable != 4 || got.Failed != 1 || got.Stale != 0 {
+		t.Fatalf("parseARPTable() totals = %+v", got)
+	}
+	if len(got.Interfaces) != 2 || got.Interfaces[0].Interface != "bridge100" || got.Interfaces[1].Total != 4 {
+		t.Fatalf("interfaces = %+v", got.Interfaces)
+	}
+}
+
+func TestCollectNeighborsIsOptIn(t *testing.T) {
+	c := NewCollector()
+	if got := c.collectNeighbors(context.Background(), time.Now()); got.Checked {
+		t.Fatalf("neighbors should not be collected unless enabled, got %+v", got)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+const (
+	netMountCacheTTL    = 30 * time.Second
+	netMountDialTimeout = 500 * time.Millisecond
+	// netMountUsageTimeout bounds statfs on a mount whose server answered
+	// the dial but may still hang on I/O.
+	netMountUsageTimeout = time.Second
+)
+
+// networkFSPorts maps network filesystem types to the port their server
+// listens on, used for the reachability check.
+var networkFSPorts = map[string]string{
+	"nfs":    "2049",
+	"nfs4":   "2049",
+	"cifs":   "445",
+	"smb3":   "445",
+	"smbfs":  "445",
+	"afpfs":  "548",
+	"sshfs":  "22",
+	"davfs":  "443",
+	"webdav": "443",
+}
+
+// networkFSType returns the canonical network filesystem type of a mount,
+// or "" for local ones. FUSE mounts count as sshfs when the device looks
+// like user@host:/path.
+func networkFSType(fstype, device string) string {
+	fstype = strings.ToLower(fstype)
+	if _, ok := networkFSPorts[fstype]; ok {
+		return fstype
+	}
+	if fstype == "fuse.sshfs" {
+		return "sshfs"
+	}
+	if strings.Contains(fstype, "fuse") && !strings.HasPrefix(device, "/") && strings.Contains(device, ":") {
+		return "sshfs"
+	}
+	return ""
+}
+
+// mountServer extracts the server host from a network mount's device:
+// "host:/export", "user@host:/path", "//user@host/share" or
+// "afp://host/share".
+func mountServer(device string) string {
+	if _, rest, ok := strings.Cut(device, "://"); ok {
+		device = "//" + rest
+	}
+	if rest, ok := strings.CutPrefix(device, "//"); ok {
+		host, _, _ := strings.Cut(rest, "/")
+		if _, after, found := strings.Cut(host, "@"); found {
+			host = after
+		}
+		return host
+	}
+	// Drop a user@ prefix, but not an @ inside the export path.
+	if at := strings.IndexByte(device, '@'); at >= 0 && at < strings.IndexByte(device, ':') {
+		device = device[at+1:]
+	}
+	if rest, ok := strings.CutPrefix(device, "["); ok {
+		host, _, _ := strings.Cut(rest, "]")
+		return host
+	}
+	host, _, ok := strings.Cut(device, ":")
+	if !ok {
+		return ""
+	}
+	return host
+}
+
+// mountDialFunc checks a network mount's server. It is a variable so tests
+// can avoid the network.
+var mountDialFunc = func(host, port string) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), netMountDialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// collectNetworkMounts lists NFS, SMB, AFP, WebDAV and SSHFS mounts with
+// whether their server is reachable. Usage is only read from reachable
+// mounts, and under a deadline, since statfs on a dead server can hang.
+func collectNetworkMounts(partitions []disk.PartitionStat) []DiskStatus {
+	var mounts []DiskStatus
+	for _, part := range partitions {
+		fstype := networkFSType(part.Fstype, part.Device)
+		if fstype == "" {
+			continue
+		}
+		m := DiskStatus{
+			Mount:     part.Mountpoint,
+			Device:    part.Device,
+			Fstype:    fstype,
+			MountOpts: part.Opts,
+			ReadOnly:  mountReadOnly(part.Opts),
+			NetworkFS: true,
+		}
+		reachable := false
+		if host := mountServer(part.Device); host != "" {
+			reachable = mountDialFunc(host, networkFSPorts[fstype])
+		}
+		m.Reachable = &reachable
+		if reachable {
+			if usage, ok := mountUsage(part.Mountpoint); ok {
+				m.Used, m.Total, m.UsedPercent = usage.Used, usage.Total, usage.UsedPercent
+			}
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts
+}
+
+func mountUsage(path string) (*disk.UsageStat, bool) {
+	done := make(chan *disk.UsageStat, 1)
+	go func() {
+		usage, err := disk.Usage(path)
+		if err != nil {
+			usage = nil
+		}
+		done <- usage
+	}()
+	select {
+	case usage := <-done:
+		return usage, usage != nil
+	case <-time.After(netMountUsageTimeout):
+		return nil, false
+	}
+}
+
+// collectNetworkMounts caches the network mount list; reachability checks
+// dial every server.
+func (c *Collector) collectNetworkMounts(now time.Time) []DiskStatus {
+	if !c.lastNetMountAt.IsZero() && now.Sub(c.lastNetMountAt) < c.ttl(netMountCacheTTL) {
+		return c.cachedNetMounts
+	}
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		logDegraded(c.logger(), "network_mounts", err)
+		return c.cachedNetMounts
+	}
+	c.cachedNetMounts = collectNetworkMounts(partitions)
+	c.lastNetMountAt = now
+	return c.cachedNetMounts
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+func TestNetworkFSType(t *testing.T) {
+	for _, tc := range []struct {
+		fstype, device, want string
+	}{
+		{"nfs", "nas.local:/volume1/media", "nfs"},
+		{"nfs4", "10.0.0.5:/export", "nfs4"},
+		{"cifs", "//fileserver/share", "cifs"},
+		{"smbfs", "//alice@fileserver._smb._tcp.local/Public", "smbfs"},
+		{"fuse.sshfs", "alice@build-box:/home/alice", "sshfs"},
+		{"macfuse", "alice@build-box:/srv", "sshfs"},
+		{"ext4", "/dev/nvme0n1p2", ""},
+		{"apfs", "/dev/disk3s1s1", ""},
+		{"fuseblk", "/dev/sdb1", ""}, // NTFS via FUSE is local
+	} {
+		if got := networkFSType(tc.fstype, tc.device); got != tc.want {
+			t.Errorf("networkFSType(%q, %q) = %q, want %q", tc.fstype, tc.device, got, tc.want)
+		}
+	}
+}
+
+func TestMountServer(t *testing.T) {
+	for device, want := range map[string]string{
+		"nas.local:/volume1/media":     "nas.local",
+		"alice@build-box:/home/alice":  "build-box",
+		"//alice@fileserver/share":     "fileserver",
+		"//fileserver/share":           "fileserver",
+		"afp://timecapsule.local/Data": "timecapsule.local",
+		"[fd00::5]:/export":            "fd00::5",
+		"nas:/backups/@daily":          "nas",
+		"/dev/sda1":                    "",
+	} {
+		if got := mountServer(device); got != want {
+			t.Errorf("mountServer(%q) = %q, want %q", device, got, want)
+		}
+	}
+}
+
+func TestCollectNetworkMountsChecksReachability(t *testing.T) {
+	orig := mountDialFunc
+	var dialed []string
+	mountDialFunc = func(host, port string) bool {
+		dialed = append(dialed, host+":"+port)
+		return false
+	}
+	t.Cleanup(func() { mountDialFunc = orig })
+
+	mounts := collectNetworkMounts([]disk.PartitionStat{
+		{Device: "/dev/nvme0n1p2", Mountpoint: "/", Fstype: "ext4"},
+		{Device: "nas.local:/media", Mountpoint: "/mnt/media", Fstype: "nfs4"},
+		{Device: "//fileserver/share", Mountpoint: "/mnt/share", Fstype: "cifs"},
+	})
+	if len(mounts) != 2 {
+		t.Fatalf("want only the two network mounts, got %+v", mounts)
+	}
+	for _, m := range mounts {
+		if !m.NetworkFS || m.Reachable == nil || *m.Reachable || m.Total != 0 {
+			t.Errorf("unreachable mount should be flagged with no usage: %+v", m)
+		}
+	}
+	if len(dialed) != 2 || dialed[0] != "nas.local:2049" || dialed[1] != "fileserver:445" {
+		t.Fatalf("dialed %v", dialed)
+	}
+}
@@ -2,10 +2,17 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/netip"
 	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,7 +21,186 @@ import (
 	"github.com/shirou/gopsutil/v4/net"
 )
 
-var ioCountersFunc = net.IOCounters
+var (
+	ioCountersFunc = net.IOCounters
+	interfacesFunc = net.Interfaces
+	linkSpeedFunc  = readLinkSpeed
+)
+
+// interfaceCacheTTL bounds how long interface addresses are reused between ticks.
+const interfaceCacheTTL = 5 * time.Second
+
+// interfaceInfo is the slow-changing per-interface metadata from net.Interfaces.
+type interfaceInfo struct {
+	IP        string
+	Addrs     []string // Every address on the interface, without prefix length
+	MAC       string
+	MTU       int
+	SpeedMbps int // 0 when unknown
+	Up        bool
+}
+
+// readSysNetAttr reads /sys/class/net/<name>/<attr>. It is a variable so
+// tests can supply values; outside Linux it always fails.
+var readSysNetAttr = func(name, attr string) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", errors.ErrUnsupported
+	}
+	data, err := os.ReadFile(filepath.Join("/sys/class/net", name, attr))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readLinkSpeed returns the negotiated link speed in Mbps. Only Linux exposes
+// it cheaply; elsewhere, and for virtual or down links, it reports 0.
+func readLinkSpeed(name string) int {
+	raw, err := readSysNetAttr(name, "speed")
+	if err != nil {
+		return 0
+	}
+	speed, err := strconv.Atoi(raw)
+	if err != nil || speed <= 0 {
+		return 0
+	}
+	return speed
+}
+
+// carrierFlaps returns how many carrier transitions the interface had since
+// the previous call. The first reading, a missing counter, or a counter
+// reset report 0.
+func (c *Collector) carrierFlaps(name string) int {
+	raw, err := readSysNetAttr(name, "carrier_changes")
+	if err != nil {
+		return 0
+	}
+	count, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0
+	}
+	prev, seen := c.prevCarrier[name]
+	c.prevCarrier[name] = count
+	if !seen || count < prev {
+		return 0
+	}
+	return int(count - prev)
+}
+
+// nicDriver is an interface's kernel driver and NIC firmware version.
+type nicDriver struct {
+	name     string
+	firmware string
+}
+
+// maxNICDriverLookups caps the ethtool runs per Collect, so a burst of new
+// interfaces is spread over several ticks.
+const maxNICDriverLookups = 4
+
+// virtualInterfaceFunc reports whether an interface has no backing device
+// (veth, bridge, tunnel, ...), which ethtool has nothing useful to say
+// about. It is a variable so tests can stub sysfs.
+var virtualInterfaceFunc = func(name string) bool {
+	_, err := os.Stat("/sys/class/net/" + name + "/device")
+	return err != nil
+}
+
+// nicDriver returns the interface's driver details once the nic_drivers
+// collector has looked them up; until then, and for virtual interfaces,
+// they are empty.
+func (c *Collector) nicDriver(name string) nicDriver {
+	return c.nicDrivers[name]
+}
+
+// pendingNICDrivers lists interfaces from the last interface refresh whose
+// driver has not been looked up yet, skipping virtual ones and veths mapped
+// to containers. It runs before the collectors start, so it may read their
+// caches.
+func (c *Collector) pendingNICDrivers() []string {
+	if runtime.GOOS != "linux" || c.LowPower {
+		return nil
+	}
+	var names []string
+	for name := range c.ifaceCache {
+		if _, done := c.nicDrivers[name]; done || isNoiseInterface(name) {
+			continue
+		}
+		if _, veth := c.cachedVethMap[name]; veth || virtualInterfaceFunc(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if len(names) > maxNICDriverLookups {
+		names = names[:maxNICDriverLookups]
+	}
+	return names
+}
+
+// lookupNICDrivers asks ethtool for each interface's driver. Neither the
+// driver nor the firmware changes without a reboot or module reload, so
+// failures are recorded too and not retried; interfaces not reached before
+// ctx ends are left out and tried again next tick.
+func lookupNICDrivers(ctx context.Context, names []string) map[string]nicDriver {
+	if len(names) == 0 {
+		return nil
+	}
+	drivers := make(map[string]nicDriver, len(names))
+	for _, name := range names {
+		if ctx.Err() != nil {
+			break
+		}
+		callCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		out, err := runCmd(callCtx, "ethtool", "-i", name)
+		cancel()
+		if err != nil && ctx.Err() != nil {
+			break
+		}
+		var d nicDriver
+		if err == nil {
+			d = parseEthtoolInfo(out)
+		}
+		drivers[name] = d
+	}
+	return drivers
+}
+
+// parseEthtoolInfo reads the driver and firmware-version lines of
+// `ethtool -i`. Virtual devices report firmware as "N/A" or leave it empty.
+func parseEthtoolInfo(out string) nicDriver {
+	var d nicDriver
+	for line := range strings.Lines(out) {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "driver":
+			d.name = value
+		case "firmware-version":
+			if value != "N/A" {
+				d.firmware = value
+			}
+		}
+	}
+	return d
+}
+
+// linkUtilization converts a rate in MB/s to a percentage of the link speed.
+// Unknown speeds yield -1; rates above the link speed are clamped to 100 and
+// flagged as implausible.
+func linkUtilization(rateMBs float64, speedMbps int) (percent float64, implausible bool) {
+	if speedMbps <= 0 {
+		return -1, false
+	}
+	mbps := rateMBs * 1024 * 1024 * 8 / 1e6
+	percent = mbps / float64(speedMbps) * 100
+	if percent > 100 {
+		return 100, true
+	}
+	return percent, false
+}
 
 func collectIOCountersSafely(pernic bool) (stats []net.IOCountersStat, err error) {
 	defer func() {
@@ -26,104 +212,556 @@ func collectIOCountersSafely(pernic bool) (stats []net.IOCountersStat, err error
 }
 
 func (c *Collector) collectNetwork(now time.Time) ([]NetworkStatus, error) {
+	c.containerNet = nil
 	stats, err := collectIOCountersSafely(true)
 	if err != nil {
 		// Some restricted environments can break netstat-backed collectors.
 		// Degrade gracefully to keep status output available.
+		logDegraded(c.logger(), "network", err)
 		c.rxHistoryBuf.Add(0)
 		c.txHistoryBuf.Add(0)
 		return nil, nil
 	}
 
 	// Map interface IPs.
-	ifAddrs := getInterfaceIPs()
+	ifInfo := c.interfaceInfo(now, stats)
 
-	if c.lastNetAt.IsZero() {
+	if c.lastNetAt.IsZero() || c.clockJumped("network", now, c.lastNetAt) {
 		c.lastNetAt = now
-		for _, s := range stats {
-			c.prevNet[s.Name] = s
-		}
+		c.storeNetSamples(now, stats)
 		return nil, nil
 	}
 
-	elapsed := now.Sub(c.lastNetAt).Seconds()
-	if elapsed <= 0 {
-		elapsed = 1
-	}
-
-	var result []NetworkStatus
+	var result, loopback []NetworkStatus
+	raw := make(map[string][2]float64, len(stats))
 	for _, cur := range stats {
-		if isNoiseInterface(cur.Name) {
+		loop := c.IncludeLoopback && isLoopbackInterface(cur.Name)
+		if isNoiseInterface(cur.Name) && !loop && cur.Name != c.PrimaryInterface {
 			continue
 		}
 		prev, ok := c.prevNet[cur.Name]
 		if !ok {
 			continue
 		}
-		rx := float64(cur.BytesRecv-prev.BytesRecv) / 1024.0 / 1024.0 / elapsed
-		tx := float64(cur.BytesSent-prev.BytesSent) / 1024.0 / 1024.0 / elapsed
-		if rx < 0 {
-			rx = 0
+		if c.trackDormant(cur, prev, ifInfo[cur.Name]) {
+			continue
 		}
-		if tx < 0 {
-			tx = 0
+		status := c.networkStatus(now, cur, prev, ifInfo[cur.Name])
+		status.QuietSince = c.trackQuiet(now, cur.Name, status)
+		status.Bursting = c.isBursting(cur.Name, status)
+		c.addInterfaceHistory(cur.Name, status.RxRateMBs, status.TxRateMBs)
+		raw[cur.Name] = [2]float64{status.RxRateMBs, status.TxRateMBs}
+		c.smoothRates(&status)
+		if loop {
+			status.Loopback = true
+			loopback = append(loopback, status)
+			continue
 		}
-		result = append(result, NetworkStatus{
-			Name:      cur.Name,
-			RxRateMBs: rx,
-			TxRateMBs: tx,
-			IP:        ifAddrs[cur.Name],
-		})
+		result = append(result, status)
 	}
 
 	c.lastNetAt = now
-	for _, s := range stats {
-		c.prevNet[s.Name] = s
-	}
-
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].RxRateMBs+result[i].TxRateMBs > result[j].RxRateMBs+result[j].TxRateMBs
+	c.storeNetSamples(now, stats)
+	// An interface that went away gives up its OrderStable slot.
+	c.ifaceOrder = slices.DeleteFunc(c.ifaceOrder, func(name string) bool {
+		return !slices.ContainsFunc(stats, func(s net.IOCountersStat) bool { return s.Name == name })
 	})
-	if len(result) > 3 {
-		result = result[:3]
-	}
 
+	// Container traffic also crosses the bridge and uplink, so mapped veths
+	// leave the totals along with the list.
+	c.containerNet, result = aggregateContainerNet(result, c.vethContainers(now))
+
+	// Interfaces under the floor or past the top few are hidden but still
+	// count toward totals.
 	var totalRx, totalTx float64
+	c.sessionRx, c.sessionTx = 0, 0
 	for _, r := range result {
-		totalRx += r.RxRateMBs
-		totalTx += r.TxRateMBs
+		totalRx += raw[r.Name][0]
+		totalTx += raw[r.Name][1]
+		c.sessionRx += r.SessionRxBytes
+		c.sessionTx += r.SessionTxBytes
 	}
 
+	// Pick the busiest interfaces before ordering, so with OrderStable an
+	// interface that gets busy later still makes the list.
+	sortByThroughput(result)
+	pinInterface(result, c.PrimaryInterface)
+	result, _ = splitBelowFloor(result, c.MinRateMBs)
+	if len(result) > maxTopInterfaces {
+		result = result[:maxTopInterfaces]
+	}
+	c.orderInterfaces(result)
+	pinInterface(result, c.PrimaryInterface)
+
 	// Update history using the global/aggregated stats
 	c.rxHistoryBuf.Add(totalRx)
 	c.txHistoryBuf.Add(totalTx)
 
-	return result, nil
+	// Loopback is local-only traffic: listed after the top interfaces
+	// but kept out of the totals above.
+	return append(result, loopback...), nil
+}
+
+// trackDormant counts consecutive ticks on which an interface that is down
+// or has never moved a byte stayed unchanged, and reports whether it has
+// reached SkipDormantAfter and should be left out of the snapshot. Any
+// traffic revives it at once. The pinned primary is never skipped.
+func (c *Collector) trackDormant(cur, prev net.IOCountersStat, info interfaceInfo) bool {
+	if c.SkipDormantAfter <= 0 || cur.Name == c.PrimaryInterface {
+		return false
+	}
+	if c.dormantTicks == nil {
+		c.dormantTicks = make(map[string]int)
+	}
+	idle := cur.BytesRecv == prev.BytesRecv && cur.BytesSent == prev.BytesSent
+	candidate := !info.Up || cur.BytesRecv+cur.BytesSent == 0
+	if !idle || !candidate {
+		delete(c.dormantTicks, cur.Name)
+		return false
+	}
+	c.dormantTicks[cur.Name]++
+	return c.dormantTicks[cur.Name] >= c.SkipDormantAfter
+}
+
+// DormantInterfaces lists the interfaces currently left out of snapshots by
+// SkipDormantAfter, sorted by name. Like SampleInterface, it must not be
+// called concurrently with Collect.
+func (c *Collector) DormantInterfaces() []string {
+	var names []string
+	for name, ticks := range c.dormantTicks {
+		if c.SkipDormantAfter > 0 && ticks >= c.SkipDormantAfter {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// trackQuiet returns when the interface last moved traffic, or the zero time
+// while it is active. An interface idle since it was first seen counts as
+// quiet since its previous sample.
+func (c *Collector) trackQuiet(now time.Time, name string, status NetworkStatus) time.Time {
+	if c.lastActiveAt == nil {
+		c.lastActiveAt = make(map[string]time.Time)
+	}
+	if status.RxRateMBs > 0 || status.TxRateMBs > 0 {
+		c.lastActiveAt[name] = now
+		return time.Time{}
+	}
+	since, ok := c.lastActiveAt[name]
+	if !ok {
+		since = c.prevNetAt[name]
+		c.lastActiveAt[name] = since
+	}
+	return since
+}
+
+// Burst detection.
+const (
+	defaultBurstK = 3.0
+	// burstMinSamples is how much history a baseline needs; early ticks
+	// never burst.
+	burstMinSamples = 10
+	// burstMinMBs keeps tiny jumps on a near-idle interface from counting.
+	burstMinMBs = 0.1
+)
+
+// isBursting compares the current rates with the interface's history before
+// they are added to it.
+func (c *Collector) isBursting(name string, status NetworkStatus) bool {
+	k := c.BurstK
+	if k < 0 {
+		return false
+	}
+	if k == 0 {
+		k = defaultBurstK
+	}
+	return exceedsBaseline(c.ifaceRxHist[name], status.RxRateMBs, k) ||
+		exceedsBaseline(c.ifaceTxHist[name], status.TxRateMBs, k)
+}
+
+func exceedsBaseline(hist *RingBuffer, rate, k float64) bool {
+	if hist == nil || rate < burstMinMBs {
+		return false
+	}
+	mean, stddev, n := hist.MeanStdDev()
+	return n >= burstMinSamples && rate > mean+k*stddev && rate-mean >= burstMinMBs
+}
+
+// smoothRates replaces status's rates by the mean of the interface's last
+// AggregateSamples samples, which addInterfaceHistory has just extended,
+// and recomputes the values derived from them.
+func (c *Collector) smoothRates(status *NetworkStatus) {
+	if c.AggregateSamples <= 1 {
+		return
+	}
+	status.RxRateMBs = c.ifaceRxHist[status.Name].MeanLast(c.AggregateSamples)
+	status.TxRateMBs = c.ifaceTxHist[status.Name].MeanLast(c.AggregateSamples)
+	// Implausible stays from the raw sample: a counter glitch is still one
+	// when averaged away.
+	status.RxUtilization, _ = linkUtilization(status.RxRateMBs, status.LinkSpeedMbps)
+	status.TxUtilization, _ = linkUtilization(status.TxRateMBs, status.LinkSpeedMbps)
+	status.AsymmetryRatio = asymmetryRatio(status.RxRateMBs, status.TxRateMBs)
+}
+
+func (c *Collector) addInterfaceHistory(name string, rx, tx float64) {
+	if c.ifaceRxHist == nil {
+		c.ifaceRxHist = make(map[string]*RingBuffer)
+		c.ifaceTxHist = make(map[string]*RingBuffer)
+	}
+	if c.ifaceRxHist[name] == nil {
+		c.ifaceRxHist[name] = NewRingBuffer(NetworkHistorySize)
+		c.ifaceTxHist[name] = NewRingBuffer(NetworkHistorySize)
+	}
+	c.ifaceRxHist[name].Add(rx)
+	c.ifaceTxHist[name].Add(tx)
+}
+
+// RxPercentile and TxPercentile return the p-th percentile (0-100) of the
+// aggregate download and upload rates (MB/s) over the history window. Like
+// WriteHistoryCSV, they must not be called concurrently with Collect.
+func (c *Collector) RxPercentile(p float64) float64 { return c.rxHistoryBuf.Percentile(p) }
+func (c *Collector) TxPercentile(p float64) float64 { return c.txHistoryBuf.Percentile(p) }
+
+// InterfacePercentiles returns the p-th percentile of one interface's rx and
+// tx rates over the history window. ok is false for an interface with no
+// recorded samples.
+func (c *Collector) InterfacePercentiles(name string, p float64) (rx, tx float64, ok bool) {
+	rxBuf, found := c.ifaceRxHist[name]
+	if !found {
+		return 0, 0, false
+	}
+	return rxBuf.Percentile(p), c.ifaceTxHist[name].Percentile(p), true
+}
+
+// maxTopInterfaces is how many interfaces a snapshot lists.
+const maxTopInterfaces = 3
+
+// OrderMode selects how interfaces are ordered in snapshots.
+type OrderMode int
+
+func (o OrderMode) String() string {
+	switch o {
+	case OrderByName:
+		return "name"
+	case OrderStable:
+		return "stable"
+	}
+	return "throughput"
+}
+
+const (
+	// OrderByThroughput puts the busiest interfaces first.
+	OrderByThroughput OrderMode = iota
+	// OrderByName sorts interfaces alphabetically.
+	OrderByName
+	// OrderStable keeps the order established on first sight, appending
+	// new interfaces at the end, so continuous displays don't reshuffle.
+	OrderStable
+)
+
+func (c *Collector) orderInterfaces(stats []NetworkStatus) {
+	switch c.Order {
+	case OrderByName:
+		sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	case OrderStable:
+		// Seed new interfaces by throughput so the first tick is still useful.
+		sortByThroughput(stats)
+		for _, s := range stats {
+			if !slices.Contains(c.ifaceOrder, s.Name) {
+				c.ifaceOrder = append(c.ifaceOrder, s.Name)
+			}
+		}
+		sort.SliceStable(stats, func(i, j int) bool {
+			return slices.Index(c.ifaceOrder, stats[i].Name) < slices.Index(c.ifaceOrder, stats[j].Name)
+		})
+	default:
+		sortByThroughput(stats)
+	}
+}
+
+// splitBelowFloor separates interfaces whose combined rate is under floor
+// MB/s, keeping order. The pinned primary is always kept.
+func splitBelowFloor(stats []NetworkStatus, floor float64) (kept, idle []NetworkStatus) {
+	if floor <= 0 {
+		return stats, nil
+	}
+	kept = stats[:0:0]
+	for _, s := range stats {
+		if s.Primary || s.RxRateMBs+s.TxRateMBs >= floor {
+			kept = append(kept, s)
+		} else {
+			idle = append(idle, s)
+		}
+	}
+	return kept, idle
+}
+
+// pinInterface moves the named interface to the front of stats and marks
+// it Primary. It reports false when the interface is not listed.
+func pinInterface(stats []NetworkStatus, name string) bool {
+	if name == "" {
+		return false
+	}
+	i := slices.IndexFunc(stats, func(s NetworkStatus) bool { return s.Name == name && !s.Loopback })
+	if i < 0 {
+		return false
+	}
+	pinned := stats[i]
+	pinned.Primary = true
+	copy(stats[1:i+1], stats[:i])
+	stats[0] = pinned
+	return true
+}
+
+func sortByThroughput(stats []NetworkStatus) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].RxRateMBs+stats[i].TxRateMBs > stats[j].RxRateMBs+stats[j].TxRateMBs
+	})
+}
+
+// networkStatus computes rates, session totals and utilization for one
+// interface against its previous sample.
+func (c *Collector) networkStatus(now time.Time, cur, prev net.IOCountersStat, info interfaceInfo) NetworkStatus {
+	last, ok := c.prevNetAt[cur.Name]
+	if !ok {
+		last = c.lastNetAt
+	}
+	elapsed := c.rateWindow(now, last)
+	rx := float64(cur.BytesRecv-prev.BytesRecv) / 1024.0 / 1024.0 / elapsed
+	tx := float64(cur.BytesSent-prev.BytesSent) / 1024.0 / 1024.0 / elapsed
+	if rx < 0 {
+		rx = 0
+	}
+	if tx < 0 {
+		tx = 0
+	}
+	sessionRx, sessionTx := c.sessionTotals(cur, prev)
+	rxUtil, rxOver := linkUtilization(rx, info.SpeedMbps)
+	txUtil, txOver := linkUtilization(tx, info.SpeedMbps)
+	driver := c.nicDriver(cur.Name)
+	return NetworkStatus{
+		Name:            cur.Name,
+		RxRateMBs:       rx,
+		TxRateMBs:       tx,
+		IP:              info.IP,
+		SessionRxBytes:  sessionRx,
+		SessionTxBytes:  sessionTx,
+		LinkSpeedMbps:   info.SpeedMbps,
+		RxUtilization:   rxUtil,
+		TxUtilization:   txUtil,
+		Implausible:     rxOver || txOver,
+		CarrierFlaps:    c.carrierFlaps(cur.Name),
+		AsymmetryRatio:  asymmetryRatio(rx, tx),
+		Vendor:          macVendor(info.MAC),
+		Driver:          driver.name,
+		FirmwareVersion: driver.firmware,
+
+		AvgRxPacketSize: avgPacketSize(cur.BytesRecv, prev.BytesRecv, cur.PacketsRecv, prev.PacketsRecv),
+		AvgTxPacketSize: avgPacketSize(cur.BytesSent, prev.BytesSent, cur.PacketsSent, prev.PacketsSent),
+	}
+}
+
+// maxAsymmetryRatio stands in for an infinite ratio (upload with no
+// download) so the value stays JSON-encodable.
+const maxAsymmetryRatio = 1000.0
+
+func asymmetryRatio(rx, tx float64) float64 {
+	switch {
+	case tx <= 0:
+		return 0
+	case rx <= 0:
+		return maxAsymmetryRatio
+	}
+	return min(tx/rx, maxAsymmetryRatio)
+}
+
+// avgPacketSize returns bytes per packet between two counter readings, or 0
+// when no packets moved or a counter went backwards.
+func avgPacketSize(bytes, prevBytes, packets, prevPackets uint64) float64 {
+	if packets <= prevPackets || bytes < prevBytes {
+		return 0
+	}
+	return float64(bytes-prevBytes) / float64(packets-prevPackets)
+}
+
+func (c *Collector) storeNetSamples(now time.Time, stats []net.IOCountersStat) {
+	for _, s := range stats {
+		if prev, ok := c.prevNet[s.Name]; ok {
+			c.addQuotaBytes(s.BytesRecv+s.BytesSent, prev.BytesRecv+prev.BytesSent, s.Name)
+		}
+		c.prevNet[s.Name] = s
+		c.prevNetAt[s.Name] = now
+	}
+}
+
+// SampleInterface returns the status of a single interface, bypassing noise
+// filtering and the top-3 cut. It shares (and advances) the delta state used
+// by Collect, so the first sample of an unseen interface reports zero rates.
+// It must not be called concurrently with Collect.
+func (c *Collector) SampleInterface(name string) (NetworkStatus, error) {
+	return c.sampleInterface(time.Now(), name)
 }
 
-func getInterfaceIPs() map[string]string {
-	result := make(map[string]string)
-	ifaces, err := net.Interfaces()
+func (c *Collector) sampleInterface(now time.Time, name string) (NetworkStatus, error) {
+	stats, err := collectIOCountersSafely(true)
+	if err != nil {
+		return NetworkStatus{}, err
+	}
+	idx := slices.IndexFunc(stats, func(s net.IOCountersStat) bool { return s.Name == name })
+	if idx < 0 {
+		return NetworkStatus{}, fmt.Errorf("interface %q not found", name)
+	}
+	cur := stats[idx]
+	info := c.interfaceInfo(now, stats)[name]
+
+	status := NetworkStatus{Name: name, IP: info.IP, LinkSpeedMbps: info.SpeedMbps, RxUtilization: -1, TxUtilization: -1, Vendor: macVendor(info.MAC)}
+	if prev, ok := c.prevNet[name]; ok {
+		status = c.networkStatus(now, cur, prev, info)
+	}
+	c.storeNetSamples(now, stats[idx:idx+1])
+	return status, nil
+}
+
+// sessionTotals returns bytes moved since the session baseline. Interfaces
+// seen for the first time, or whose counters went backwards, are rebased.
+func (c *Collector) sessionTotals(cur, prev net.IOCountersStat) (rx, tx uint64) {
+	base, ok := c.sessionBase[cur.Name]
+	if !ok {
+		base = prev
+		c.sessionBase[cur.Name] = base
+	}
+	if cur.BytesRecv < base.BytesRecv || cur.BytesSent < base.BytesSent {
+		base = cur
+		c.sessionBase[cur.Name] = base
+	}
+	return cur.BytesRecv - base.BytesRecv, cur.BytesSent - base.BytesSent
+}
+
+// ResetSessionBaseline restarts session totals from the latest counters.
+// Rate deltas are unaffected since prevNet is left alone.
+func (c *Collector) ResetSessionBaseline() {
+	c.sessionBase = make(map[string]net.IOCountersStat, len(c.prevNet))
+	for name, s := range c.prevNet {
+		c.sessionBase[name] = s
+	}
+}
+
+// WriteHistoryCSV writes the aggregate network history as CSV rows of
+// timestamp, rx and tx (MB/s). History samples carry no timestamps of their
+// own, so they are spaced by Interval back from the most recent sample.
+func (c *Collector) WriteHistoryCSV(w io.Writer) error {
+	rx := c.rxHistoryBuf.Slice()
+	tx := c.txHistoryBuf.Slice()
+	n := min(len(rx), len(tx))
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "rx_mbs", "tx_mbs"}); err != nil {
+		return err
+	}
+	step := c.interval()
+	for i := range n {
+		ts := c.lastNetAt.Add(-time.Duration(n-1-i) * step)
+		row := []string{
+			ts.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(rx[i], 'f', 4, 64),
+			strconv.FormatFloat(tx[i], 'f', 4, 64),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// interfaceInfo returns cached interface metadata, refreshing it when the TTL
+// expires or when the counters mention an interface the cache hasn't seen.
+// Counters can name interfaces the interface list never returns; those are
+// remembered until the TTL so they don't force a refresh every tick.
+func (c *Collector) interfaceInfo(now time.Time, stats []net.IOCountersStat) map[string]interfaceInfo {
+	stale := c.ifaceCache == nil || now.Sub(c.lastIfaceAt) >= c.ttl(interfaceCacheTTL)
+	if !stale {
+		for _, s := range stats {
+			if _, ok := c.ifaceCache[s.Name]; !ok && !c.ifaceMissing[s.Name] {
+				stale = true
+				break
+			}
+		}
+	}
+	if stale {
+		c.ifaceCache = getInterfaceInfo()
+		c.lastIfaceAt = now
+		c.ifaceMissing = make(map[string]bool)
+		for _, s := range stats {
+			if _, ok := c.ifaceCache[s.Name]; !ok {
+				c.ifaceMissing[s.Name] = true
+			}
+		}
+	}
+	return c.ifaceCache
+}
+
+// getInterfaceInfo maps every interface to its primary IP, MAC and MTU.
+// The primary IP is the first non-loopback IPv4, falling back to the first
+// global IPv6 so IPv6-only hosts still show an address.
+func getInterfaceInfo() map[string]interfaceInfo {
+	result := make(map[string]interfaceInfo)
+	ifaces, err := interfacesFunc()
 	if err != nil {
 		return result
 	}
 	for _, iface := range ifaces {
+		info := interfaceInfo{MAC: iface.HardwareAddr, MTU: iface.MTU, SpeedMbps: linkSpeedFunc(iface.Name), Up: slices.Contains(iface.Flags, "up")}
+		var globalIPv6 string
 		for _, addr := range iface.Addrs {
-			// IPv4 only.
-			if strings.Contains(addr.Addr, ".") && !strings.HasPrefix(addr.Addr, "127.") {
-				ip := strings.Split(addr.Addr, "/")[0]
-				result[iface.Name] = ip
-				break
+			ip := strings.Split(addr.Addr, "/")[0]
+			info.Addrs = append(info.Addrs, ip)
+			if info.IP != "" {
+				continue
+			}
+			if strings.Contains(ip, ".") && !strings.HasPrefix(ip, "127.") {
+				info.IP = ip
+				continue
+			}
+			if globalIPv6 == "" && isGlobalIPv6(ip) {
+				globalIPv6 = ip
 			}
 		}
+		if info.IP == "" {
+			info.IP = globalIPv6
+		}
+		result[iface.Name] = info
 	}
 	return result
 }
 
+// isGlobalIPv6 excludes loopback, link-local and multicast IPv6 addresses.
+func isGlobalIPv6(raw string) bool {
+	addr, err := netip.ParseAddr(raw)
+	if err != nil || !addr.Is6() || addr.Is4In6() {
+		return false
+	}
+	return addr.IsGlobalUnicast()
+}
+
+// isLoopbackInterface matches lo and lo0-style names.
+func isLoopbackInterface(name string) bool {
+	rest, ok := strings.CutPrefix(strings.ToLower(name), "lo")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(rest)
+	return rest == "" || err == nil
+}
+
+// noiseInterfacePrefixes are interface name prefixes hidden from the
+// network section (loopback, AWDL, tunnels, bridges and similar plumbing).
+var noiseInterfacePrefixes = []string{"lo", "awdl", "utun", "llw", "bridge", "gif", "stf", "xhc", "anpi", "ap"}
+
 func isNoiseInterface(name string) bool {
 	lower := strings.ToLower(name)
-	noiseList := []string{"lo", "awdl", "utun", "llw", "bridge", "gif", "stf", "xhc", "anpi", "ap"}
-	for _, prefix := range noiseList {
+	for _, prefix := range noiseInterfacePrefixes {
 		if strings.HasPrefix(lower, prefix) {
 			return true
 		}
@@ -131,38 +769,133 @@ func isNoiseInterface(name string) bool {
 	return false
 }
 
-func collectProxy() ProxyStatus {
-	if proxy := collectProxyFromEnv(os.Getenv); proxy.Enabled {
-		return proxy
+// Proxy flap detection.
+const (
+	proxyFlapWindow    = 5 * time.Minute
+	proxyFlapThreshold = 3 // More than this many transitions in the window
+)
+
+// trackProxyState records enabled/disabled transitions and annotates p with
+// the last change time and whether the proxy is flapping.
+func (c *Collector) trackProxyState(now time.Time, p ProxyStatus) ProxyStatus {
+	if c.proxySeen && p.Enabled != c.proxyEnabled {
+		c.proxyLastChange = now
+		c.proxyTransitions = append(c.proxyTransitions, now)
+	}
+	c.proxySeen = true
+	c.proxyEnabled = p.Enabled
+
+	cutoff := now.Add(-proxyFlapWindow)
+	kept := c.proxyTransitions[:0]
+	for _, t := range c.proxyTransitions {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	c.proxyTransitions = kept
+
+	p.LastChange = c.proxyLastChange
+	p.Flapping = len(c.proxyTransitions) > proxyFlapThreshold
+	return p
+}
+
+// defaultProxyEnvKeys is the default env lookup order. ALL_PROXY is included
+// for users running proxy tools that only export a single variable.
+var defaultProxyEnvKeys = []string{
+	"https_proxy", "HTTPS_PROXY",
+	"http_proxy", "HTTP_PROXY",
+	"all_proxy", "ALL_PROXY",
+}
+
+// validateEnvPrecedence rejects env keys that are not proxy variables and
+// returns the full check order: keys first, then the remaining defaults in
+// their usual order, so listing one variable only moves it up.
+func validateEnvPrecedence(keys []string) ([]string, error) {
+	order := make([]string, 0, len(defaultProxyEnvKeys))
+	for _, key := range keys {
+		if !slices.Contains(defaultProxyEnvKeys, key) {
+			return nil, fmt.Errorf("unknown proxy env var %q", key)
+		}
+		if !slices.Contains(order, key) {
+			order = append(order, key)
+		}
+	}
+	for _, key := range defaultProxyEnvKeys {
+		if !slices.Contains(order, key) {
+			order = append(order, key)
+		}
+	}
+	return order, nil
+}
+
+// collectProxy resolves the active proxy from env vars, then system settings.
+// getenv and run default to os.Getenv and runCmd when nil.
+func collectProxy(getenv func(string) string, run cmdRunner, envKeys []string, log *slog.Logger) ProxyStatus {
+	return collectProxyDetailed(getenv, run, envKeys, log, false)
+}
+
+// collectProxyDetailed is collectProxy that, with detail set, also fills
+// Sources with what every proxy source found.
+func collectProxyDetailed(getenv func(string) string, run cmdRunner, envKeys []string, log *slog.Logger, detail bool) ProxyStatus {
+	if log == nil {
+		log = discardLogger
+	}
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if run == nil {
+		run = runCmd
 	}
 
-	// macOS: check system proxy via scutil.
+	// macOS: the system proxy comes from scutil. It is read even when the
+	// environment sets a proxy so the two can be compared.
+	var scutilOut string
 	if runtime.GOOS == "darwin" {
 		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 		defer cancel()
-		out, err := runCmd(ctx, "scutil", "--proxy")
-		if err == nil {
-			if proxy := collectProxyFromScutilOutput(out); proxy.Enabled {
-				return proxy
-			}
+		out, err := run(ctx, "scutil", "--proxy")
+		if err != nil {
+			logDegraded(log, "proxy", fmt.Errorf("scutil --proxy: %w", err))
 		}
+		scutilOut = out
+	}
+
+	proxy := selectProxy(getenv, envKeys, scutilOut)
+	proxy.Effective = resolveEffectiveProxies(getenv, scutilOut)
+	if detail {
+		proxy.Sources = proxySourceReports(getenv, envKeys, platformProxies(run, scutilOut))
+	}
+	return proxy
+}
 
+func selectProxy(getenv func(string) string, envKeys []string, scutilOut string) ProxyStatus {
+	if proxy := collectProxyFromEnv(getenv, envKeys...); proxy.Enabled {
+		return proxy
+	}
+	if proxy := collectProxyFromSources(); proxy.Enabled {
+		return proxy
+	}
+	if runtime.GOOS == "darwin" {
+		if proxy := collectProxyFromScutilOutput(scutilOut); proxy.Enabled {
+			return proxy
+		}
 		if proxy := collectProxyFromTunInterfaces(); proxy.Enabled {
 			return proxy
 		}
 	}
-
 	return ProxyStatus{Enabled: false}
 }
 
-func collectProxyFromEnv(getenv func(string) string) ProxyStatus {
-	// Include ALL_PROXY for users running proxy tools that only export a single variable.
-	envKeys := []string{
-		"https_proxy", "HTTPS_PROXY",
-		"http_proxy", "HTTP_PROXY",
-		"all_proxy", "ALL_PROXY",
+// collectProxyFromEnv returns the first proxy found in envKeys order,
+// defaulting to defaultProxyEnvKeys. Unknown keys are ignored.
+func collectProxyFromEnv(getenv func(string) string, envKeys ...string) ProxyStatus {
+	if len(envKeys) == 0 {
+		envKeys = defaultProxyEnvKeys
 	}
 	for _, key := range envKeys {
+		if !slices.Contains(defaultProxyEnvKeys, key) {
+			continue
+		}
 		val := strings.TrimSpace(getenv(key))
 		if val == "" {
 			continue
@@ -174,11 +907,7 @@ func collectProxyFromEnv(getenv func(string) string) ProxyStatus {
 			proxyType = "SOCKS"
 		}
 
-		host := parseProxyHost(val)
-		if host == "" {
-			host = val
-		}
-		return ProxyStatus{Enabled: true, Type: proxyType, Host: host}
+		return ProxyStatus{Enabled: true, Type: proxyType, Host: proxyHost(val)}
 	}
 
 	return ProxyStatus{Enabled: false}
@@ -230,7 +959,7 @@ func collectProxyFromScutilOutput(out string) ProxyStatus {
 }
 
 func collectProxyFromTunInterfaces() ProxyStatus {
-	stats, err := net.IOCounters(true)
+	stats, err := collectIOCountersSafely(true)
 	if err != nil {
 		return ProxyStatus{Enabled: false}
 	}
@@ -291,6 +1020,26 @@ func parseProxyHost(raw string) string {
 	return strings.TrimPrefix(host, "@")
 }
 
+// proxyHost reduces a proxy URL to host:port. Values url.Parse rejects,
+// often for an unescaped # or % in the password, keep only what follows
+// the last "@", so credentials never reach the output.
+func proxyHost(raw string) string {
+	if host := parseProxyHost(raw); host != "" {
+		return host
+	}
+	raw = strings.TrimSpace(raw)
+	if _, rest, ok := strings.Cut(raw, "://"); ok {
+		raw = rest
+	}
+	if i := strings.LastIndex(raw, "@"); i >= 0 {
+		raw = raw[i+1:]
+	}
+	if i := strings.IndexAny(raw, "/?#"); i >= 0 {
+		raw = raw[:i]
+	}
+	return raw
+}
+
 func joinHostPort(host, port string) string {
 	host = strings.TrimSpace(host)
 	port = strings.TrimSpace(port)
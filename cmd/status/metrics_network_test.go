@@ -1,8 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	gopsutilnet "github.com/shirou/gopsutil/v4/net"
 )
@@ -101,3 +111,943 @@ func TestCollectIOCountersSafelyReturnsData(t *testing.T) {
 		t.Fatalf("unexpected stats: %+v", got)
 	}
 }
+
+func TestWriteHistoryCSVUsesInterval(t *testing.T) {
+	c := NewCollector()
+	c.Interval = 5 * time.Second
+	c.lastNetAt = time.Date(2025, 1, 2, 3, 4, 30, 0, time.UTC)
+	for i := range 3 {
+		c.rxHistoryBuf.Add(float64(i))
+		c.txHistoryBuf.Add(float64(i) / 2)
+	}
+
+	var buf bytes.Buffer
+	if err := c.WriteHistoryCSV(&buf); err != nil {
+		t.Fatalf("WriteHistoryCSV: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected header + 3 rows, got %d", len(rows))
+	}
+	wantTimes := []string{
+		"2025-01-02T03:04:20Z",
+		"2025-01-02T03:04:25Z",
+		"2025-01-02T03:04:30Z",
+	}
+	for i, want := range wantTimes {
+		if rows[i+1][0] != want {
+			t.Fatalf("row %d timestamp = %s, want %s", i, rows[i+1][0], want)
+		}
+	}
+	if rows[3][1] != "2.0000" || rows[3][2] != "1.0000" {
+		t.Fatalf("unexpected last row: %v", rows[3])
+	}
+}
+
+func TestRateWindowClampsShortWindows(t *testing.T) {
+	c := NewCollector()
+	c.Interval = 2 * time.Second
+	last := time.Now()
+
+	if got := c.rateWindow(last.Add(-time.Second), last); got != 1 {
+		t.Fatalf("backwards clock should clamp to half interval, got %v", got)
+	}
+	if got := c.rateWindow(last.Add(3*time.Second), last); got != 3 {
+		t.Fatalf("expected real elapsed window, got %v", got)
+	}
+}
+
+func TestInterfaceInfoCachedWithinTTL(t *testing.T) {
+	original := interfacesFunc
+	calls := 0
+	ip := "192.168.1.10"
+	interfacesFunc = func() (gopsutilnet.InterfaceStatList, error) {
+		calls++
+		return gopsutilnet.InterfaceStatList{
+			{Name: "en0", MTU: 1500, HardwareAddr: "aa:bb:cc:dd:ee:ff", Addrs: gopsutilnet.InterfaceAddrList{{Addr: ip + "/24"}}},
+		}, nil
+	}
+	t.Cleanup(func() { interfacesFunc = original })
+
+	c := NewCollector()
+	stats := []gopsutilnet.IOCountersStat{{Name: "en0"}}
+	start := time.Now()
+
+	if got := c.interfaceInfo(start, stats)["en0"]; got.IP != "192.168.1.10" || got.MTU != 1500 {
+		t.Fatalf("unexpected info: %+v", got)
+	}
+	ip = "192.168.1.99"
+	if got := c.interfaceInfo(start.Add(time.Second), stats)["en0"].IP; got != "192.168.1.10" {
+		t.Fatalf("expected cached IP within TTL, got %s", got)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 interface lookup within TTL, got %d", calls)
+	}
+
+	if got := c.interfaceInfo(start.Add(interfaceCacheTTL), stats)["en0"].IP; got != "192.168.1.99" {
+		t.Fatalf("expected refreshed IP after TTL, got %s", got)
+	}
+	if calls != 2 {
+		t.Fatalf("expected refresh after TTL, got %d lookups", calls)
+	}
+}
+
+func TestInterfaceInfoRefreshesOnNewInterface(t *testing.T) {
+	original := interfacesFunc
+	calls := 0
+	interfacesFunc = func() (gopsutilnet.InterfaceStatList, error) {
+		calls++
+		return gopsutilnet.InterfaceStatList{{Name: "en0"}, {Name: "en5"}}, nil
+	}
+	t.Cleanup(func() { interfacesFunc = original })
+
+	c := NewCollector()
+	now := time.Now()
+	c.interfaceInfo(now, []gopsutilnet.IOCountersStat{{Name: "en0"}})
+	c.interfaceInfo(now, []gopsutilnet.IOCountersStat{{Name: "en0"}, {Name: "en5"}})
+	if calls != 1 {
+		t.Fatalf("known interfaces should not trigger refresh, got %d lookups", calls)
+	}
+	c.interfaceInfo(now, []gopsutilnet.IOCountersStat{{Name: "en0"}, {Name: "en7"}})
+	if calls != 2 {
+		t.Fatalf("unknown interface should trigger refresh, got %d lookups", calls)
+	}
+
+	// en7 is still missing after that refresh; it waits for the TTL.
+	c.interfaceInfo(now.Add(time.Second), []gopsutilnet.IOCountersStat{{Name: "en0"}, {Name: "en7"}})
+	if calls != 2 {
+		t.Fatalf("interface missing from the last refresh should not trigger another, got %d lookups", calls)
+	}
+	c.interfaceInfo(now.Add(interfaceCacheTTL), []gopsutilnet.IOCountersStat{{Name: "en0"}, {Name: "en7"}})
+	if calls != 3 {
+		t.Fatalf("expected refresh after TTL, got %d lookups", calls)
+	}
+}
+
+func TestGetInterfaceInfoFallsBackToGlobalIPv6(t *testing.T) {
+	original := interfacesFunc
+	interfacesFunc = func() (gopsutilnet.InterfaceStatList, error) {
+		return gopsutilnet.InterfaceStatList{
+			{Name: "en0", Addrs: gopsutilnet.InterfaceAddrList{
+				{Addr: "fe80::1c2b:3ff:fe4d:5e6f/64"},
+				{Addr: "2001:db8:abcd:12::42/64"},
+			}},
+			{Name: "en1", Addrs: gopsutilnet.InterfaceAddrList{
+				{Addr: "2001:db8::7/64"},
+				{Addr: "192.168.1.20/24"},
+			}},
+			{Name: "lo0", Addrs: gopsutilnet.InterfaceAddrList{
+				{Addr: "127.0.0.1/8"},
+				{Addr: "::1/128"},
+			}},
+			{Name: "en2", Addrs: gopsutilnet.InterfaceAddrList{
+				{Addr: "fe80::1/64"},
+			}},
+		}, nil
+	}
+	t.Cleanup(func() { interfacesFunc = original })
+
+	info := getInterfaceInfo()
+	if got := info["en0"].IP; got != "2001:db8:abcd:12::42" {
+		t.Fatalf("IPv6-only interface should use global IPv6, got %q", got)
+	}
+	if got := info["en1"].IP; got != "192.168.1.20" {
+		t.Fatalf("IPv4 should win when present, got %q", got)
+	}
+	if got := info["lo0"].IP; got != "" {
+		t.Fatalf("loopback should stay empty, got %q", got)
+	}
+	if got := info["en2"].IP; got != "" {
+		t.Fatalf("link-local only should stay empty, got %q", got)
+	}
+}
+
+func TestCollectProxyFromEnvPrecedence(t *testing.T) {
+	env := map[string]string{
+		"HTTPS_PROXY": "http://10.0.0.1:8080",
+		"ALL_PROXY":   "socks5://127.0.0.1:7890",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	if got := collectProxyFromEnv(getenv); got.Host != "10.0.0.1:8080" {
+		t.Fatalf("default order should prefer HTTPS_PROXY, got %+v", got)
+	}
+
+	precedence, err := validateEnvPrecedence([]string{"ALL_PROXY"})
+	if err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	got := collectProxyFromEnv(getenv, precedence...)
+	if got.Type != "SOCKS" || got.Host != "127.0.0.1:7890" {
+		t.Fatalf("ALL_PROXY should win by precedence, got %+v", got)
+	}
+
+	// Unlisted vars are still checked, after the listed ones.
+	delete(env, "ALL_PROXY")
+	if got := collectProxyFromEnv(getenv, precedence...); got.Host != "10.0.0.1:8080" {
+		t.Fatalf("HTTPS_PROXY should still be found, got %+v", got)
+	}
+}
+
+func TestValidateEnvPrecedenceRejectsUnknownKeys(t *testing.T) {
+	if _, err := validateEnvPrecedence([]string{"HTTPS_PROXY", "PATH"}); err == nil {
+		t.Fatalf("expected error for non-proxy env var")
+	}
+}
+
+func TestValidateEnvPrecedenceAppendsDefaults(t *testing.T) {
+	got, err := validateEnvPrecedence([]string{"ALL_PROXY", "http_proxy", "ALL_PROXY"})
+	if err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if got[0] != "ALL_PROXY" || got[1] != "http_proxy" || len(got) != len(defaultProxyEnvKeys) {
+		t.Fatalf("order = %v, want ALL_PROXY, http_proxy, then the other defaults", got)
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(defaultProxyEnvKeys))) {
+		t.Fatalf("order = %v, want every default key once", got)
+	}
+}
+
+// stubNetworkCounters replaces the counter and interface sources so
+// collectNetwork can be driven tick by tick from *counters.
+func stubNetworkCounters(t *testing.T, counters *[]gopsutilnet.IOCountersStat) {
+	t.Helper()
+	origCounters, origIfaces := ioCountersFunc, interfacesFunc
+	ioCountersFunc = func(bool) ([]gopsutilnet.IOCountersStat, error) {
+		return *counters, nil
+	}
+	interfacesFunc = func() (gopsutilnet.InterfaceStatList, error) {
+		var list gopsutilnet.InterfaceStatList
+		for _, s := range *counters {
+			list = append(list, gopsutilnet.InterfaceStat{Name: s.Name})
+		}
+		return list, nil
+	}
+	t.Cleanup(func() {
+		ioCountersFunc, interfacesFunc = origCounters, origIfaces
+	})
+}
+
+func TestResetSessionBaselineRestartsTotals(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000, BytesSent: 500}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	now := time.Now()
+	c.collectNetwork(now)
+
+	counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 5000, BytesSent: 1500}}
+	stats, _ := c.collectNetwork(now.Add(time.Second))
+	if len(stats) != 1 || stats[0].SessionRxBytes != 4000 || stats[0].SessionTxBytes != 1000 {
+		t.Fatalf("unexpected session totals before reset: %+v", stats)
+	}
+
+	c.ResetSessionBaseline()
+
+	counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 5200, BytesSent: 1600}}
+	stats, _ = c.collectNetwork(now.Add(2 * time.Second))
+	if stats[0].SessionRxBytes != 200 || stats[0].SessionTxBytes != 100 {
+		t.Fatalf("session totals should restart after reset, got %+v", stats[0])
+	}
+	// Rate still comes from the previous tick, not the reset point.
+	wantRx := 200.0 / 1024 / 1024
+	if stats[0].RxRateMBs != wantRx {
+		t.Fatalf("reset should not disturb rate deltas: rx=%v want %v", stats[0].RxRateMBs, wantRx)
+	}
+}
+
+func TestCollectProxyUsesInjectedEnv(t *testing.T) {
+	env := map[string]string{"HTTPS_PROXY": "http://proxy.internal:3128"}
+	var ran []string
+	run := func(_ context.Context, name string, _ ...string) (string, error) {
+		ran = append(ran, name)
+		return "", errors.New("unexpected command")
+	}
+
+	got := collectProxy(func(key string) string { return env[key] }, run, nil, nil)
+	if !got.Enabled || got.Type != "HTTP" || got.Host != "proxy.internal:3128" {
+		t.Fatalf("unexpected proxy from injected env: %+v", got)
+	}
+	if len(ran) != 0 {
+		t.Fatalf("env proxy should short-circuit system lookups, ran %v", ran)
+	}
+}
+
+func TestCollectProxyFallsBackToRunner(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("scutil lookup is macOS-only")
+	}
+	run := func(_ context.Context, name string, _ ...string) (string, error) {
+		if name != "scutil" {
+			return "", errors.New("unexpected command")
+		}
+		return "<dictionary> {\n  HTTPSEnable : 1\n  HTTPSProxy : 10.1.1.1\n  HTTPSPort : 8443\n}", nil
+	}
+
+	got := collectProxy(func(string) string { return "" }, run, nil, nil)
+	if got.Type != "HTTPS" || got.Host != "10.1.1.1:8443" {
+		t.Fatalf("expected scutil proxy from runner, got %+v", got)
+	}
+}
+
+func TestTrackProxyStateFlapping(t *testing.T) {
+	c := NewCollector()
+	start := time.Now()
+
+	got := c.trackProxyState(start, ProxyStatus{Enabled: true})
+	if got.Flapping || !got.LastChange.IsZero() {
+		t.Fatalf("first observation should not count as a change: %+v", got)
+	}
+	if raw, _ := json.Marshal(got); strings.Contains(string(raw), "last_change") {
+		t.Fatalf("unset LastChange should be omitted, got %s", raw)
+	}
+
+	enabled := true
+	var last ProxyStatus
+	for i := 1; i <= 4; i++ {
+		enabled = !enabled
+		last = c.trackProxyState(start.Add(time.Duration(i)*10*time.Second), ProxyStatus{Enabled: enabled})
+		if i < 4 && last.Flapping {
+			t.Fatalf("flapping flagged too early after %d transitions", i)
+		}
+	}
+	if !last.Flapping {
+		t.Fatalf("expected flapping after 4 transitions")
+	}
+	if want := start.Add(40 * time.Second); !last.LastChange.Equal(want) {
+		t.Fatalf("LastChange = %v, want %v", last.LastChange, want)
+	}
+
+	// Stable state past the window clears the flag but keeps LastChange.
+	settled := c.trackProxyState(start.Add(40*time.Second+proxyFlapWindow), ProxyStatus{Enabled: enabled})
+	if settled.Flapping {
+		t.Fatalf("flapping should clear once transitions age out")
+	}
+	if settled.LastChange.IsZero() {
+		t.Fatalf("LastChange should persist after settling")
+	}
+}
+
+func TestLinkUtilization(t *testing.T) {
+	// 500 Mbps expressed in MB/s (MiB, matching RxRateMBs).
+	halfGig := 500 * 1e6 / 8 / 1024 / 1024
+
+	pct, implausible := linkUtilization(halfGig, 1000)
+	if implausible || pct < 49.99 || pct > 50.01 {
+		t.Fatalf("500Mbps on 1000Mbps link = %.2f%% (implausible=%v), want 50%%", pct, implausible)
+	}
+
+	pct, implausible = linkUtilization(halfGig*3, 1000)
+	if !implausible || pct != 100 {
+		t.Fatalf("over-speed rate = %.2f%% (implausible=%v), want clamped 100 and implausible", pct, implausible)
+	}
+
+	if pct, _ := linkUtilization(halfGig, 0); pct != -1 {
+		t.Fatalf("unknown link speed = %.2f, want -1", pct)
+	}
+}
+
+func interfaceNames(stats []NetworkStatus) string {
+	names := make([]string, len(stats))
+	for i, s := range stats {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestOrderInterfacesByName(t *testing.T) {
+	c := &Collector{Order: OrderByName}
+	stats := []NetworkStatus{{Name: "wlan0", RxRateMBs: 9}, {Name: "en0", RxRateMBs: 1}, {Name: "eth1", RxRateMBs: 5}}
+	c.orderInterfaces(stats)
+	if got := interfaceNames(stats); got != "en0,eth1,wlan0" {
+		t.Fatalf("order = %s, want en0,eth1,wlan0", got)
+	}
+}
+
+func TestOrderInterfacesStableAcrossTicks(t *testing.T) {
+	c := &Collector{Order: OrderStable}
+
+	first := []NetworkStatus{{Name: "en1", RxRateMBs: 1}, {Name: "en0", RxRateMBs: 5}}
+	c.orderInterfaces(first)
+	if got := interfaceNames(first); got != "en0,en1" {
+		t.Fatalf("first tick order = %s, want en0,en1", got)
+	}
+
+	// Rates reshuffle and a new interface appears; the established order holds.
+	second := []NetworkStatus{{Name: "utun3", RxRateMBs: 50}, {Name: "en1", RxRateMBs: 20}, {Name: "en0", RxRateMBs: 0.1}}
+	c.orderInterfaces(second)
+	if got := interfaceNames(second); got != "en0,en1,utun3" {
+		t.Fatalf("second tick order = %s, want en0,en1,utun3", got)
+	}
+}
+
+func TestOrderStableAdmitsInterfaceThatGetsBusy(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0"}, {Name: "en1"}, {Name: "en2"}, {Name: "en3"}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	c.Order = OrderStable
+	start := time.Now()
+	c.collectNetwork(start)
+
+	counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 4 << 20}, {Name: "en1", BytesRecv: 3 << 20}, {Name: "en2", BytesRecv: 2 << 20}, {Name: "en3", BytesRecv: 1 << 20}}
+	stats, _ := c.collectNetwork(start.Add(time.Second))
+	if got := interfaceNames(stats); got != "en0,en1,en2" {
+		t.Fatalf("first busy tick = %s, want en0,en1,en2", got)
+	}
+	if hist := c.rxHistoryBuf.Slice(); hist[len(hist)-1] != 10 {
+		t.Fatalf("rx total should include the trimmed en3: got %v, want 10", hist[len(hist)-1])
+	}
+	if c.sessionRx != 10<<20 {
+		t.Fatalf("session rx should include the trimmed en3: got %d, want %d", c.sessionRx, 10<<20)
+	}
+
+	// en3 overtakes en1; it joins the list and the survivors keep their slots.
+	counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 8 << 20}, {Name: "en1", BytesRecv: 3 << 20}, {Name: "en2", BytesRecv: 4 << 20}, {Name: "en3", BytesRecv: 7 << 20}}
+	stats, _ = c.collectNetwork(start.Add(2 * time.Second))
+	if got := interfaceNames(stats); got != "en0,en2,en3" {
+		t.Fatalf("second tick = %s, want en0,en2,en3", got)
+	}
+
+	// en0 goes away; it no longer holds a first-seen slot.
+	counters = counters[1:]
+	c.collectNetwork(start.Add(3 * time.Second))
+	if slices.Contains(c.ifaceOrder, "en0") {
+		t.Fatalf("ifaceOrder kept a vanished interface: %v", c.ifaceOrder)
+	}
+}
+
+func TestSampleInterface(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{
+		{Name: "lo0", BytesRecv: 0, BytesSent: 0},
+		{Name: "en0", BytesRecv: 0, BytesSent: 0},
+	}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	start := time.Now()
+	if _, err := c.collectNetwork(start); err != nil {
+		t.Fatalf("collectNetwork: %v", err)
+	}
+
+	counters[0].BytesRecv = 2 * 1024 * 1024
+	got, err := c.sampleInterface(start.Add(2*time.Second), "lo0")
+	if err != nil {
+		t.Fatalf("sampleInterface(lo0): %v", err)
+	}
+	// lo0 is filtered as noise in full collection but sampled directly here.
+	if got.Name != "lo0" || got.RxRateMBs != 1 {
+		t.Fatalf("expected lo0 at 1 MB/s, got %+v", got)
+	}
+
+	// Full collection continues from the sampled baseline for lo0.
+	counters[1].BytesSent = 4 * 1024 * 1024
+	stats, _ := c.collectNetwork(start.Add(4 * time.Second))
+	if len(stats) != 1 || stats[0].Name != "en0" || stats[0].TxRateMBs != 1 {
+		t.Fatalf("unexpected full collection after sampling: %+v", stats)
+	}
+	if c.prevNet["lo0"].BytesRecv != 2*1024*1024 {
+		t.Fatalf("prevNet not shared with sampling: %+v", c.prevNet["lo0"])
+	}
+}
+
+func TestSampleInterfaceMissing(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0"}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	_, err := c.SampleInterface("wlan9")
+	if err == nil || !strings.Contains(err.Error(), `"wlan9"`) {
+		t.Fatalf("expected not-found error naming the interface, got %v", err)
+	}
+}
+
+func TestCarrierFlapsDelta(t *testing.T) {
+	changes := "4"
+	orig := readSysNetAttr
+	readSysNetAttr = func(name, attr string) (string, error) {
+		if name != "eth0" || attr != "carrier_changes" {
+			return "", os.ErrNotExist
+		}
+		return changes, nil
+	}
+	t.Cleanup(func() { readSysNetAttr = orig })
+
+	c := NewCollector()
+	if got := c.carrierFlaps("eth0"); got != 0 {
+		t.Fatalf("first reading should be a baseline, got %d", got)
+	}
+	changes = "7"
+	if got := c.carrierFlaps("eth0"); got != 3 {
+		t.Fatalf("expected 3 flaps, got %d", got)
+	}
+	if got := c.carrierFlaps("eth0"); got != 0 {
+		t.Fatalf("unchanged counter should report 0, got %d", got)
+	}
+	if got := c.carrierFlaps("wlan0"); got != 0 {
+		t.Fatalf("missing counter should degrade to 0, got %d", got)
+	}
+}
+
+func TestIncludeLoopback(t *testing.T) {
+	for _, include := range []bool{false, true} {
+		counters := []gopsutilnet.IOCountersStat{{Name: "en0"}, {Name: "lo0"}}
+		stubNetworkCounters(t, &counters)
+
+		c := NewCollector()
+		c.IncludeLoopback = include
+		start := time.Now()
+		c.collectNetwork(start)
+
+		counters[0].BytesRecv = 1024 * 1024
+		counters[1].BytesRecv = 100 * 1024 * 1024
+		stats, _ := c.collectNetwork(start.Add(time.Second))
+
+		if include {
+			if len(stats) != 2 || stats[1].Name != "lo0" || !stats[1].Loopback || stats[1].RxRateMBs != 100 {
+				t.Fatalf("expected lo0 listed after en0 when included, got %+v", stats)
+			}
+		} else if len(stats) != 1 || stats[0].Name != "en0" {
+			t.Fatalf("expected loopback hidden by default, got %+v", stats)
+		}
+
+		// Loopback never feeds the aggregate history or totals.
+		hist := c.rxHistoryBuf.Slice()
+		if last := hist[len(hist)-1]; last != 1 {
+			t.Fatalf("include=%v: history should only count en0, got %v", include, last)
+		}
+		if rx, _ := totalNetworkRates(stats); rx != 1 {
+			t.Fatalf("include=%v: totalNetworkRates = %v, want 1", include, rx)
+		}
+	}
+}
+
+func TestCollectProxyLogsScutilFailure(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("scutil lookup is macOS-only")
+	}
+	run := func(context.Context, string, ...string) (string, error) {
+		return "", errors.New("scutil: command not found")
+	}
+	h := &recordingHandler{}
+	collectProxy(func(string) string { return "" }, run, nil, slog.New(h))
+
+	records := h.attrs("collector degraded")
+	if len(records) == 0 || records[0]["collector"] != "proxy" || !strings.Contains(records[0]["reason"], "command not found") {
+		t.Fatalf("expected proxy degradation record, got %v", records)
+	}
+}
+
+func TestCollectNetworkLogsCounterFailure(t *testing.T) {
+	orig := ioCountersFunc
+	ioCountersFunc = func(bool) ([]gopsutilnet.IOCountersStat, error) {
+		return nil, errors.New("permission denied")
+	}
+	t.Cleanup(func() { ioCountersFunc = orig })
+
+	h := &recordingHandler{}
+	c := NewCollector()
+	c.Logger = slog.New(h)
+	c.collectNetwork(time.Now())
+
+	records := h.attrs("collector degraded")
+	if len(records) != 1 || records[0]["collector"] != "network" || records[0]["reason"] != "permission denied" {
+		t.Fatalf("expected network degradation record, got %v", records)
+	}
+}
+
+func TestAvgPacketSize(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000, PacketsRecv: 10, BytesSent: 500, PacketsSent: 5}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	start := time.Now()
+	c.collectNetwork(start)
+
+	// Bulk download in full-size packets, chatty upload in small ones.
+	counters[0].BytesRecv += 150000
+	counters[0].PacketsRecv += 100
+	counters[0].BytesSent += 256
+	counters[0].PacketsSent += 4
+	stats, _ := c.collectNetwork(start.Add(time.Second))
+	if len(stats) != 1 || stats[0].AvgRxPacketSize != 1500 || stats[0].AvgTxPacketSize != 64 {
+		t.Fatalf("unexpected packet sizes: %+v", stats)
+	}
+
+	// No packets moved: guard against divide-by-zero.
+	stats, _ = c.collectNetwork(start.Add(2 * time.Second))
+	if stats[0].AvgRxPacketSize != 0 || stats[0].AvgTxPacketSize != 0 {
+		t.Fatalf("expected zero sizes when idle, got %+v", stats[0])
+	}
+
+	if got := avgPacketSize(100, 200, 5, 1); got != 0 {
+		t.Fatalf("counter reset should report 0, got %v", got)
+	}
+}
+
+func TestAsymmetryRatio(t *testing.T) {
+	tests := []struct {
+		name   string
+		rx, tx float64
+		want   float64
+	}{
+		{"balanced", 2, 2, 1},
+		{"download dominant", 10, 0.5, 0.05},
+		{"upload dominant", 0.5, 4, 8},
+		{"idle", 0, 0, 0},
+		{"upload only", 0, 1, maxAsymmetryRatio},
+	}
+	for _, tt := range tests {
+		if got := asymmetryRatio(tt.rx, tt.tx); got != tt.want {
+			t.Errorf("%s: asymmetryRatio(%v, %v) = %v, want %v", tt.name, tt.rx, tt.tx, got, tt.want)
+		}
+	}
+}
+
+func TestPrimaryInterfaceStaysOnTop(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{
+		{Name: "en0", BytesRecv: 0, BytesSent: 0},
+		{Name: "en1", BytesRecv: 0, BytesSent: 0},
+		{Name: "en2", BytesRecv: 0, BytesSent: 0},
+		{Name: "en3", BytesRecv: 0, BytesSent: 0},
+		{Name: "utun4", BytesRecv: 0, BytesSent: 0},
+	}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	c.PrimaryInterface = "utun4"
+	now := time.Now()
+	c.collectNetwork(now)
+
+	counters = []gopsutilnet.IOCountersStat{
+		{Name: "en0", BytesRecv: 9 << 20},
+		{Name: "en1", BytesRecv: 8 << 20},
+		{Name: "en2", BytesRecv: 7 << 20},
+		{Name: "en3", BytesRecv: 6 << 20},
+		{Name: "utun4", BytesRecv: 1},
+	}
+	stats, _ := c.collectNetwork(now.Add(time.Second))
+	if len(stats) != maxTopInterfaces {
+		t.Fatalf("got %d interfaces, want %d", len(stats), maxTopInterfaces)
+	}
+	if stats[0].Name != "utun4" || !stats[0].Primary {
+		t.Fatalf("pinned interface should lead despite low traffic and noise prefix: %+v", stats[0])
+	}
+	if stats[1].Name != "en0" || stats[2].Name != "en1" || stats[1].Primary {
+		t.Fatalf("remaining interfaces should keep throughput order: %s, %s", stats[1].Name, stats[2].Name)
+	}
+}
+
+func TestPinInterfaceFallsBackWhenAbsent(t *testing.T) {
+	stats := []NetworkStatus{{Name: "en1", IP: "10.0.0.2"}, {Name: "en0", IP: "192.168.1.5"}}
+	if pinInterface(stats, "en7") {
+		t.Fatal("pinning an absent interface should report false")
+	}
+	if !pinInterface(stats, "en0") || stats[0].Name != "en0" || stats[1].Name != "en1" {
+		t.Fatalf("default-route fallback not pinned: %+v", stats)
+	}
+	if ip := primaryNetworkIP(stats); ip != "192.168.1.5" {
+		t.Fatalf("primaryNetworkIP() = %q, want the pinned interface", ip)
+	}
+}
+
+func TestCollectNetworkResetsOnClockJump(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		step time.Duration
+	}{
+		{"backward step", -30 * time.Second},
+		{"multi-hour gap", 3 * time.Hour},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			counters := []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000, BytesSent: 500}}
+			stubNetworkCounters(t, &counters)
+
+			h := &recordingHandler{}
+			c := NewCollector()
+			c.Logger = slog.New(h)
+			start := time.Now()
+			c.collectNetwork(start)
+
+			jumped := start.Add(tc.step)
+			counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 9000, BytesSent: 4500}}
+			if stats, _ := c.collectNetwork(jumped); stats != nil {
+				t.Fatalf("clock jump should emit no rates, got %+v", stats)
+			}
+			if records := h.attrs("clock jump, resetting rate baseline"); len(records) != 1 || records[0]["collector"] != "network" {
+				t.Fatalf("expected one clock jump record, got %v", records)
+			}
+
+			// The jump becomes the new baseline for the next tick.
+			counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 9000 + 1<<20, BytesSent: 4500}}
+			stats, _ := c.collectNetwork(jumped.Add(time.Second))
+			if len(stats) != 1 || stats[0].RxRateMBs != 1 {
+				t.Fatalf("rates after reset should use the new baseline, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestQuietSinceTracksLastActivity(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	start := time.Now()
+	c.collectNetwork(start)
+
+	tick := func(i int, rx uint64) NetworkStatus {
+		t.Helper()
+		counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: rx}}
+		stats, _ := c.collectNetwork(start.Add(time.Duration(i) * time.Second))
+		if len(stats) != 1 {
+			t.Fatalf("tick %d: got %d interfaces", i, len(stats))
+		}
+		return stats[0]
+	}
+
+	if s := tick(1, 2000); !s.QuietSince.IsZero() {
+		t.Fatalf("active interface should have no QuietSince, got %v", s.QuietSince)
+	}
+	lastActive := start.Add(time.Second)
+	for i := 2; i <= 4; i++ {
+		if s := tick(i, 2000); !s.QuietSince.Equal(lastActive) {
+			t.Fatalf("tick %d: QuietSince = %v, want the last active tick %v", i, s.QuietSince, lastActive)
+		}
+	}
+	if s := tick(5, 3000); !s.QuietSince.IsZero() {
+		t.Fatalf("resumed traffic should clear QuietSince, got %v", s.QuietSince)
+	}
+	if s := tick(6, 3000); !s.QuietSince.Equal(start.Add(5 * time.Second)) {
+		t.Fatalf("QuietSince should advance to the resumed tick, got %v", s.QuietSince)
+	}
+}
+
+func TestSkipDormantInterfaceAfterThreshold(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "eth0", BytesRecv: 1000}, {Name: "eth9"}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	c.SkipDormantAfter = 2
+	start := time.Now()
+	c.collectNetwork(start)
+
+	names := func(i int) []string {
+		t.Helper()
+		counters[0].BytesRecv += 1000
+		stats, _ := c.collectNetwork(start.Add(time.Duration(i) * time.Second))
+		var out []string
+		for _, s := range stats {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+
+	if got := names(1); !slices.Equal(got, []string{"eth0", "eth9"}) {
+		t.Fatalf("tick 1: got %v, want eth9 still listed below the threshold", got)
+	}
+	for i := 2; i <= 4; i++ {
+		if got := names(i); !slices.Equal(got, []string{"eth0"}) {
+			t.Fatalf("tick %d: got %v, want the down interface skipped", i, got)
+		}
+	}
+	if got := c.DormantInterfaces(); !slices.Equal(got, []string{"eth9"}) {
+		t.Fatalf("DormantInterfaces() = %v", got)
+	}
+
+	counters[1].BytesRecv = 64
+	if got := names(5); !slices.Contains(got, "eth9") {
+		t.Fatalf("traffic should revive the interface at once, got %v", got)
+	}
+	if got := c.DormantInterfaces(); len(got) != 0 {
+		t.Fatalf("revived interface still listed as dormant: %v", got)
+	}
+}
+
+func TestBurstDetectionFlagsSpike(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0"}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	start := time.Now()
+	c.collectNetwork(start)
+
+	tick := func(i int, deltaMB uint64) NetworkStatus {
+		t.Helper()
+		counters[0].BytesRecv += deltaMB << 20
+		stats, _ := c.collectNetwork(start.Add(time.Duration(i) * time.Second))
+		return stats[0]
+	}
+
+	// A flat 1 MB/s series: early ticks lack a baseline, later ones are
+	// within it.
+	for i := 1; i <= burstMinSamples+2; i++ {
+		if s := tick(i, 1); s.Bursting {
+			t.Fatalf("tick %d: flat series should not burst", i)
+		}
+	}
+	if s := tick(burstMinSamples+3, 20); !s.Bursting {
+		t.Fatalf("20 MB/s after a flat 1 MB/s baseline should burst: %+v", s)
+	}
+
+	c.BurstK = -1
+	if s := tick(burstMinSamples+4, 80); s.Bursting {
+		t.Fatal("negative BurstK should disable detection")
+	}
+}
+
+func TestBurstDetectionNeedsHistory(t *testing.T) {
+	c := NewCollector()
+	if c.isBursting("en0", NetworkStatus{RxRateMBs: 500}) {
+		t.Fatal("an interface without history must not burst")
+	}
+	for range burstMinSamples - 1 {
+		c.addInterfaceHistory("en0", 0, 0)
+	}
+	if c.isBursting("en0", NetworkStatus{RxRateMBs: 500}) {
+		t.Fatal("a short history must not burst")
+	}
+}
+
+func TestMinRateFloorHidesIdleInterfaces(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0"}, {Name: "en1"}, {Name: "en2"}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	c.MinRateMBs = 0.5
+	start := time.Now()
+	c.collectNetwork(start)
+
+	// en0 is above the floor, en1 and en2 trickle below it.
+	counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 2 << 20}, {Name: "en1", BytesRecv: 100 << 10}, {Name: "en2", BytesSent: 200 << 10}}
+	stats, _ := c.collectNetwork(start.Add(time.Second))
+	if len(stats) != 1 || stats[0].Name != "en0" {
+		t.Fatalf("only en0 should be listed, got %+v", stats)
+	}
+	hist := c.rxHistoryBuf.Slice()
+	if want := 2 + 100.0/1024; hist[len(hist)-1] != want {
+		t.Fatalf("hidden interfaces should still count toward the rx total: got %v, want %v", hist[len(hist)-1], want)
+	}
+
+	// All idle: the list is empty rather than a column of zeros.
+	stats, _ = c.collectNetwork(start.Add(2 * time.Second))
+	if len(stats) != 0 {
+		t.Fatalf("all-idle tick should list nothing, got %+v", stats)
+	}
+	card := renderNetworkCard(stats, NetworkHistory{RxHistory: c.rxHistoryBuf.Slice()}, ProxyStatus{}, TCPStatus{}, 60, FormatOptions{Spark: SparkBlocks})
+	if !strings.Contains(strings.Join(card.lines, "\n"), "All interfaces idle") {
+		t.Fatalf("card should say interfaces are idle, got %q", card.lines)
+	}
+}
+
+func TestAggregateSamplesSmoothsPresentedRates(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0"}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	c.AggregateSamples = 5
+	start := time.Now()
+	c.collectNetwork(start)
+
+	var last NetworkStatus
+	for i, deltaMB := range []uint64{1, 2, 3, 4, 10} {
+		counters[0].BytesRecv += deltaMB << 20
+		stats, _ := c.collectNetwork(start.Add(time.Duration(i+1) * time.Second))
+		last = stats[0]
+	}
+	if last.RxRateMBs != 4 {
+		t.Fatalf("presented rate = %v, want the 5-sample average 4", last.RxRateMBs)
+	}
+	if got := c.ifaceRxHist["en0"].Slice(); !slices.Equal(got, []float64{1, 2, 3, 4, 10}) {
+		t.Fatalf("interface history = %v, want raw samples", got)
+	}
+	if got := c.rxHistoryBuf.Slice(); got[len(got)-1] != 10 {
+		t.Fatalf("aggregate history should keep the raw total, got %v", got)
+	}
+
+	// Fewer samples than the window average what there is.
+	c2 := NewCollector()
+	c2.AggregateSamples = 5
+	counters[0].BytesRecv = 0
+	c2.collectNetwork(start)
+	counters[0].BytesRecv = 6 << 20
+	stats, _ := c2.collectNetwork(start.Add(time.Second))
+	if stats[0].RxRateMBs != 6 {
+		t.Fatalf("first smoothed rate = %v, want 6", stats[0].RxRateMBs)
+	}
+}
+
+func TestParseEthtoolInfo(t *testing.T) {
+	const intel = `driver: igc
+version: 6.8.0-45-generic
+firmware-version: 1082:8770
+expansion-rom-version: 
+bus-info: 0000:03:00.0
+supports-statistics: yes
+supports-test: yes
+supports-eeprom-access: yes
+supports-register-dump: yes
+supports-priv-flags: yes
+`
+	if got := parseEthtoolInfo(intel); got != (nicDriver{name: "igc", firmware: "1082:8770"}) {
+		t.Fatalf("parseEthtoolInfo(igc) = %+v", got)
+	}
+
+	const veth = `driver: veth
+version: 1.0
+firmware-version: 
+expansion-rom-version: 
+bus-info: 
+supports-statistics: yes
+`
+	if got := parseEthtoolInfo(veth); got != (nicDriver{name: "veth"}) {
+		t.Fatalf("parseEthtoolInfo(veth) = %+v", got)
+	}
+	if got := parseEthtoolInfo("driver: virtio_net\nfirmware-version: N/A\n"); got.firmware != "" {
+		t.Fatalf("N/A firmware should be empty, got %q", got.firmware)
+	}
+}
+
+func TestPendingNICDriversSkipsVirtualAndKnown(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ethtool lookups are Linux-only")
+	}
+	orig := virtualInterfaceFunc
+	virtualInterfaceFunc = func(name string) bool { return name == "docker0" || name == "wg0" }
+	t.Cleanup(func() { virtualInterfaceFunc = orig })
+
+	c := NewCollector()
+	c.ifaceCache = map[string]interfaceInfo{"eth0": {}, "eth1": {}, "docker0": {}, "wg0": {}, "lo": {}, "veth1a2b": {}}
+	c.cachedVethMap = map[string]string{"veth1a2b": "web"}
+	c.nicDrivers = map[string]nicDriver{"eth1": {name: "igc"}}
+	if got := c.pendingNICDrivers(); !slices.Equal(got, []string{"eth0"}) {
+		t.Fatalf("pendingNICDrivers() = %v, want [eth0]", got)
+	}
+	c.LowPower = true
+	if got := c.pendingNICDrivers(); got != nil {
+		t.Fatalf("LowPower should look nothing up, got %v", got)
+	}
+}
+
+func TestLookupNICDriversStopsAtDeadline(t *testing.T) {
+	orig := runCmd
+	runCmd = func(ctx context.Context, name string, args ...string) (string, error) {
+		if args[len(args)-1] == "eth1" {
+			<-ctx.Done() // Hangs until the collector's budget runs out
+			return "", ctx.Err()
+		}
+		return "driver: igc\nfirmware-version: 1082:8770\n", nil
+	}
+	t.Cleanup(func() { runCmd = orig })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	got := lookupNICDrivers(ctx, []string{"eth0", "eth1", "eth2"})
+	if len(got) != 1 || got["eth0"].name != "igc" {
+		t.Fatalf("lookupNICDrivers() = %+v, want only eth0, with eth1 and eth2 retried later", got)
+	}
+}
@@ -3,51 +3,419 @@ package main
 import (
 	"context"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+const processCountCacheTTL = 30 * time.Second
+
+// maxTopProcesses is how many processes a snapshot lists.
+const maxTopProcesses = 5
+
+// maxWatchlisted caps how many watchlisted processes are listed beyond the
+// top ones, so a broad pattern cannot flood the section.
+const maxWatchlisted = 10
+
+// maxZombies caps the zombie list; the count covers the rest.
+const maxZombies = 20
+
+// maxCmdlineLen caps ProcessInfo.Cmdline; JVM and Electron command lines
+// run to kilobytes.
+const maxCmdlineLen = 256
+
+// topProcessDeadline bounds a portable top-process pass. Boxes with
+// thousands of processes get the busiest of those sampled so far.
+var topProcessDeadline = 500 * time.Millisecond
+
+var (
+	processesFunc       = process.Processes
+	procSampleFunc      = sampleProcess
+	processIdentityFunc = processIdentity
 )
 
-func collectTopProcesses() []ProcessInfo {
+// procStat is the per-process data needed to rank top processes.
+type procStat struct {
+	name       string
+	cpuSeconds float64 // User + system time since start
+	memPercent float64
+	createMs   int64 // Unix milliseconds; 0 when unknown
+}
+
+func sampleProcess(p *process.Process) (procStat, error) {
+	times, err := p.Times()
+	if err != nil {
+		return procStat{}, err
+	}
+	name, _ := p.Name()
+	mem, _ := p.MemoryPercent()
+	created, _ := p.CreateTime()
+	return procStat{name: name, cpuSeconds: times.User + times.System, memPercent: float64(mem), createMs: created}, nil
+}
+
+// processIdentity returns who runs pid and its command line. Either is
+// empty when it cannot be read.
+func processIdentity(pid int32) (username, cmdline string) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return "", ""
+	}
+	username, _ = p.Username()
+	cmdline, _ = p.Cmdline()
+	return username, cmdline
+}
+
+// addProcessIdentity fills Username and Cmdline for the listed processes
+// only, since reading them for every PID each tick would be wasteful.
+func addProcessIdentity(procs []ProcessInfo) {
+	for i := range procs {
+		username, cmdline := processIdentityFunc(procs[i].PID)
+		procs[i].Username = username
+		procs[i].Cmdline = truncateCmdline(cmdline)
+	}
+}
+
+// truncateCmdline cuts cmdline to maxCmdlineLen bytes on a rune boundary,
+// marking the cut with an ellipsis.
+func truncateCmdline(cmdline string) string {
+	if len(cmdline) <= maxCmdlineLen {
+		return cmdline
+	}
+	cut := maxCmdlineLen - len("…")
+	for cut > 0 && !utf8.RuneStart(cmdline[cut]) {
+		cut--
+	}
+	return cmdline[:cut] + "…"
+}
+
+// procCPUSample is the previous CPU time reading for a PID.
+type procCPUSample struct {
+	at         time.Time
+	cpuSeconds float64
+	seenPass   uint64 // Last pass the PID was listed in
+}
+
+// procAgePasses is how many passes a PID may go unlisted before its CPU
+// state is dropped, so exited PIDs don't pile up while passes keep
+// truncating.
+const procAgePasses = 3
+
+// processSample is the subset of per-process state needed for counting.
+type processSample struct {
+	status  []string
+	threads int32
+	// Zombies only.
+	pid  int32
+	ppid int32
+	name string
+}
+
+func collectTopProcesses(ctx context.Context, keep func(pid int32) bool, watch func(name string) bool) []ProcessInfo {
 	if runtime.GOOS != "darwin" {
 		return nil
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
 	// Use ps to get top processes by CPU.
-	out, err := runCmd(ctx, "ps", "-Aceo", "pcpu,pmem,comm", "-r")
+	out, err := runCmd(ctx, "ps", "-Aceo", "pid,pcpu,pmem,comm", "-r")
 	if err != nil {
 		return nil
 	}
+	return parsePSTop(out, keep, watch)
+}
 
+// parsePSTop returns the first maxTopProcesses processes from CPU-sorted ps
+// output that keep accepts, followed by any further processes watch
+// matches. watch may be nil.
+func parsePSTop(out string, keep func(pid int32) bool, watch func(name string) bool) []ProcessInfo {
 	var procs []ProcessInfo
-	i := 0
+	top, watched := 0, 0
+	header := true
 	for line := range strings.Lines(strings.TrimSpace(out)) {
-		if i == 0 {
-			i++
+		if header {
+			header = false
 			continue
 		}
-		if i > 5 {
+		full := top >= maxTopProcesses
+		if full && (watch == nil || watched >= maxWatchlisted) {
 			break
 		}
-		i++
 		fields := strings.Fields(line)
-		if len(fields) < 3 {
+		if len(fields) < 4 {
 			continue
 		}
-		cpuVal, _ := strconv.ParseFloat(fields[0], 64)
-		memVal, _ := strconv.ParseFloat(fields[1], 64)
 		name := fields[len(fields)-1]
 		// Strip path from command name.
 		if idx := strings.LastIndex(name, "/"); idx >= 0 {
 			name = name[idx+1:]
 		}
+		watchlisted := watch != nil && watch(name)
+		if full && !watchlisted {
+			continue
+		}
+		pid, _ := strconv.ParseInt(fields[0], 10, 32)
+		if !keep(int32(pid)) {
+			continue
+		}
+		cpuVal, _ := strconv.ParseFloat(fields[1], 64)
+		memVal, _ := strconv.ParseFloat(fields[2], 64)
 		procs = append(procs, ProcessInfo{
-			Name:   name,
-			CPU:    cpuVal,
-			Memory: memVal,
+			PID:         int32(pid),
+			Name:        name,
+			CPU:         cpuVal,
+			Memory:      memVal,
+			Watchlisted: watchlisted,
 		})
+		if full {
+			watched++
+		} else {
+			top++
+		}
 	}
 	return procs
 }
+
+// watchlisted reports whether name matches an entry of ProcessWatchlist,
+// as a case-insensitive substring.
+func (c *Collector) watchlisted(name string) bool {
+	lower := strings.ToLower(name)
+	for _, w := range c.ProcessWatchlist {
+		if w != "" && strings.Contains(lower, strings.ToLower(w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchFunc returns watchlisted, or nil without a watchlist.
+func (c *Collector) watchFunc() func(string) bool {
+	if len(c.ProcessWatchlist) == 0 {
+		return nil
+	}
+	return c.watchlisted
+}
+
+// selectTopProcesses keeps the first maxTopProcesses of the CPU-sorted
+// procs plus up to maxWatchlisted watchlisted ones from the rest, marking
+// every watchlisted process. watch may be nil.
+func selectTopProcesses(procs []ProcessInfo, watch func(name string) bool) []ProcessInfo {
+	var result []ProcessInfo
+	watched := 0
+	for i, p := range procs {
+		p.Watchlisted = watch != nil && watch(p.Name)
+		switch {
+		case i < maxTopProcesses:
+		case p.Watchlisted && watched < maxWatchlisted:
+			watched++
+		default:
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+var processCreateTimeFunc = func(pid int32) (int64, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return 0, err
+	}
+	return p.CreateTime()
+}
+
+// startedAfter reports whether a process created at createMs (Unix
+// milliseconds, as gopsutil reports it) passes the StartedAfter filter.
+// Processes whose start time can't be read pass only with
+// IncludeUnknownStart.
+func (c *Collector) startedAfter(createMs int64, err error) bool {
+	if c.StartedAfter.IsZero() {
+		return true
+	}
+	if err != nil || createMs <= 0 {
+		return c.IncludeUnknownStart
+	}
+	return time.UnixMilli(createMs).After(c.StartedAfter)
+}
+
+// topProcesses returns the busiest processes and whether the list was cut
+// short by topProcessDeadline. macOS uses a single ps call; elsewhere
+// processes are sampled individually.
+func (c *Collector) topProcesses(ctx context.Context, now time.Time) ([]ProcessInfo, bool) {
+	if runtime.GOOS == "darwin" {
+		keep := func(pid int32) bool { return c.startedAfter(processCreateTimeFunc(pid)) }
+		if c.StartedAfter.IsZero() {
+			keep = func(int32) bool { return true }
+		}
+		procs := collectTopProcesses(ctx, keep, c.watchFunc())
+		addProcessIdentity(procs)
+		return procs, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, topProcessDeadline)
+	defer cancel()
+	procs, truncated := c.sampleTopProcesses(ctx, now)
+	addProcessIdentity(procs)
+	return procs, truncated
+}
+
+// sampleTopProcesses computes CPU usage from the change in each process's
+// CPU time since the previous pass. When ctx expires mid-pass it returns the
+// best of the processes sampled so far, flagged as truncated, and the next
+// pass resumes at the PID where this one stopped. Only sampled PIDs have
+// their CPU state updated. Unreadable PIDs are pruned after a complete pass
+// and PIDs missing from procAgePasses listings after any pass, so a partial
+// pass never loses history for the rest.
+func (c *Collector) sampleTopProcesses(ctx context.Context, now time.Time) ([]ProcessInfo, bool) {
+	procs, err := processesFunc()
+	if err != nil {
+		logDegraded(c.logger(), "top_processes", err)
+		return nil, false
+	}
+	if c.prevProcCPU == nil {
+		c.prevProcCPU = make(map[int32]procCPUSample)
+	}
+	c.procPass++
+	for _, p := range procs {
+		if s, ok := c.prevProcCPU[p.Pid]; ok {
+			s.seenPass = c.procPass
+			c.prevProcCPU[p.Pid] = s
+		}
+	}
+
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Pid < procs[j].Pid })
+	start := sort.Search(len(procs), func(i int) bool { return procs[i].Pid >= c.procResume })
+	procs = slices.Concat(procs[start:], procs[:start])
+
+	truncated := false
+	c.procResume = 0
+	seen := make(map[int32]bool, len(procs))
+	var result []ProcessInfo
+	for _, p := range procs {
+		if ctx.Err() != nil {
+			truncated = true
+			c.procResume = p.Pid
+			break
+		}
+		stat, err := procSampleFunc(p)
+		if err != nil {
+			// Process exited or is not readable.
+			continue
+		}
+		seen[p.Pid] = true
+
+		var cpuPct float64
+		if prev, ok := c.prevProcCPU[p.Pid]; ok {
+			if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 && stat.cpuSeconds >= prev.cpuSeconds {
+				cpuPct = (stat.cpuSeconds - prev.cpuSeconds) / elapsed * 100
+			}
+		}
+		c.prevProcCPU[p.Pid] = procCPUSample{at: now, cpuSeconds: stat.cpuSeconds, seenPass: c.procPass}
+		if !c.startedAfter(stat.createMs, nil) {
+			continue
+		}
+		result = append(result, ProcessInfo{PID: p.Pid, Name: stat.name, CPU: cpuPct, Memory: stat.memPercent})
+	}
+
+	for pid, s := range c.prevProcCPU {
+		if (!truncated && !seen[pid]) || c.procPass-s.seenPass >= procAgePasses {
+			delete(c.prevProcCPU, pid)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].CPU > result[j].CPU })
+	return selectTopProcesses(result, c.watchFunc()), truncated
+}
+
+func collectProcessCounts() (ProcessCountStatus, error) {
+	procs, err := processesFunc()
+	if err != nil {
+		return ProcessCountStatus{}, err
+	}
+
+	samples := make([]processSample, 0, len(procs))
+	byPID := make(map[int32]*process.Process, len(procs))
+	for _, p := range procs {
+		byPID[p.Pid] = p
+		status, err := p.Status()
+		if err != nil {
+			// Process exited or is not readable.
+			continue
+		}
+		threads, _ := p.NumThreads()
+		s := processSample{status: status, threads: threads}
+		if len(status) > 0 && status[0] == process.Zombie {
+			s.pid = p.Pid
+			s.ppid, _ = p.Ppid()
+			s.name, _ = p.Name()
+		}
+		samples = append(samples, s)
+	}
+	counts := tallyProcessCounts(samples)
+	counts.Zombies = listZombies(samples, func(pid int32) string {
+		p, ok := byPID[pid]
+		if !ok {
+			return ""
+		}
+		name, _ := p.Name()
+		return name
+	})
+	return counts, nil
+}
+
+// listZombies describes the zombie samples, grouped by parent so one
+// negligent parent's zombies sit together. parentName is only called once
+// per parent.
+func listZombies(samples []processSample, parentName func(pid int32) string) []ZombieProcess {
+	var zombies []ZombieProcess
+	names := make(map[int32]string)
+	for _, s := range samples {
+		if len(s.status) == 0 || s.status[0] != process.Zombie {
+			continue
+		}
+		z := ZombieProcess{PID: s.pid, Name: s.name, PPID: s.ppid, Reparented: s.ppid == 1}
+		if s.ppid > 0 {
+			name, ok := names[s.ppid]
+			if !ok {
+				name = parentName(s.ppid)
+				names[s.ppid] = name
+			}
+			z.ParentName = name
+		}
+		zombies = append(zombies, z)
+	}
+	sort.Slice(zombies, func(i, j int) bool {
+		if zombies[i].PPID != zombies[j].PPID {
+			return zombies[i].PPID < zombies[j].PPID
+		}
+		return zombies[i].PID < zombies[j].PID
+	})
+	if len(zombies) > maxZombies {
+		zombies = zombies[:maxZombies]
+	}
+	return zombies
+}
+
+func tallyProcessCounts(samples []processSample) ProcessCountStatus {
+	var counts ProcessCountStatus
+	for _, s := range samples {
+		counts.Total++
+		counts.Threads += int(s.threads)
+		if len(s.status) == 0 {
+			continue
+		}
+		switch s.status[0] {
+		case process.Running:
+			counts.Running++
+		case process.Sleep, process.Idle:
+			counts.Sleeping++
+		case process.Zombie:
+			counts.Zombie++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxDiskIOProcesses = 5
+
+// ProcessDiskIO is one process's storage throughput since the previous
+// sample, in MB/s like DiskIOStatus.
+type ProcessDiskIO struct {
+	PID       int32   `json:"pid"`
+	Name      string  `json:"name"`
+	ReadRate  float64 `json:"read_rate"`
+	WriteRate float64 `json:"write_rate"`
+}
+
+// procPath is the proc filesystem root. It is a variable so tests can point
+// it at fixtures.
+var procPath = "/proc"
+
+// procIO is a process's cumulative read_bytes and write_bytes from
+// /proc/<pid>/io: bytes that reached the storage layer, not page cache hits.
+type procIO struct {
+	name        string
+	read, write uint64
+}
+
+// readProcIO reads every process's io file under root. Files that are
+// missing (the process exited) or unreadable (another user's process
+// without root) are skipped; unreadable ones are counted in denied.
+func readProcIO(root string) (counters map[int32]procIO, denied int, err error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, 0, err
+	}
+	counters = make(map[int32]procIO, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.ParseInt(e.Name(), 10, 32)
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, e.Name(), "io"))
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				denied++
+			}
+			continue
+		}
+		counters[int32(pid)] = parseProcIO(data, root, e.Name())
+	}
+	return counters, denied, nil
+}
+
+func parseProcIO(data []byte, root, pid string) procIO {
+	var p procIO
+	for line := range strings.Lines(string(data)) {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "read_bytes":
+			p.read = n
+		case "write_bytes":
+			p.write = n
+		}
+	}
+	if comm, err := os.ReadFile(filepath.Join(root, pid, "comm")); err == nil {
+		p.name = strings.TrimSpace(string(comm))
+	}
+	return p
+}
+
+// topDiskIOProcesses returns the busiest readers and writers between two
+// samples taken elapsed seconds apart. A PID whose counters went backwards
+// was reused by a new process and is skipped until its next sample.
+func topDiskIOProcesses(prev, cur map[int32]procIO, elapsed float64) (readers, writers []ProcessDiskIO) {
+	var all []ProcessDiskIO
+	for pid, c := range cur {
+		p, ok := prev[pid]
+		if !ok || c.read < p.read || c.write < p.write {
+			continue
+		}
+		rate := ProcessDiskIO{
+			PID:       pid,
+			Name:      c.name,
+			ReadRate:  float64(c.read-p.read) / 1024 / 1024 / elapsed,
+			WriteRate: float64(c.write-p.write) / 1024 / 1024 / elapsed,
+		}
+		if rate.ReadRate > 0 || rate.WriteRate > 0 {
+			all = append(all, rate)
+		}
+	}
+	top := func(rate func(ProcessDiskIO) float64) []ProcessDiskIO {
+		var list []ProcessDiskIO
+		for _, p := range all {
+			if rate(p) > 0 {
+				list = append(list, p)
+			}
+		}
+		slices.SortFunc(list, func(a, b ProcessDiskIO) int {
+			if c := cmp.Compare(rate(b), rate(a)); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.PID, b.PID)
+		})
+		return list[:min(len(list), maxDiskIOProcesses)]
+	}
+	readers = top(func(p ProcessDiskIO) float64 { return p.ReadRate })
+	writers = top(func(p ProcessDiskIO) float64 { return p.WriteRate })
+	return readers, writers
+}
+
+// collectProcessDiskIO attributes disk throughput to processes on Linux.
+// The first sample, and one after a clock jump, only sets the baseline.
+func (c *Collector) collectProcessDiskIO(now time.Time) (readers, writers []ProcessDiskIO, denied int) {
+	if runtime.GOOS != "linux" {
+		return nil, nil, 0
+	}
+	return c.sampleProcessDiskIO(now, procPath)
+}
+
+func (c *Collector) sampleProcessDiskIO(now time.Time, root string) (readers, writers []ProcessDiskIO, denied int) {
+	cur, denied, err := readProcIO(root)
+	if err != nil {
+		logDegraded(c.logger(), "process_io", err)
+		return nil, nil, 0
+	}
+	if c.prevProcIO != nil && !c.clockJumped("process_io", now, c.lastProcIOAt) {
+		readers, writers = topDiskIOProcesses(c.prevProcIO, cur, c.rateWindow(now, c.lastProcIOAt))
+	}
+	c.prevProcIO = cur
+	c.lastProcIOAt = now
+	return readers, writers, denied
+}
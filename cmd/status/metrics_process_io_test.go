@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeProcIO creates <root>/<pid>/io and comm fixtures.
+func writeProcIO(t *testing.T, root string, pid int, name string, read, write uint64) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	io := fmt.Sprintf("rchar: 99999999\nwchar: 99999999\nsyscr: 10\nsyscw: 10\nread_bytes: %d\nwrite_bytes: %d\ncancelled_write_bytes: 0\n", read, write)
+	if err := os.WriteFile(filepath.Join(dir, "io"), []byte(io), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "comm"), []byte(name+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSampleProcessDiskIOAcrossTwoSamples(t *testing.T) {
+	root := t.TempDir()
+	const mb = 1024 * 1024
+	writeProcIO(t, root, 100, "postgres", 10*mb, 50*mb)
+	writeProcIO(t, root, 200, "rsync", 0, 0)
+	writeProcIO(t, root, 300, "idle", 5*mb, 5*mb)
+	writeProcIO(t, root, 400, "reused", 80*mb, 80*mb)
+	// Kernel threads and exited processes have no io file.
+	if err := os.MkdirAll(filepath.Join(root, "2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Collector{}
+	start := time.Now()
+	readers, writers, _ := c.sampleProcessDiskIO(start, root)
+	if readers != nil || writers != nil {
+		t.Fatalf("first sample should only set the baseline, got %+v %+v", readers, writers)
+	}
+
+	writeProcIO(t, root, 100, "postgres", 12*mb, 70*mb)
+	writeProcIO(t, root, 200, "rsync", 40*mb, 0)
+	writeProcIO(t, root, 400, "reused", 1*mb, 1*mb) // PID taken by a new process
+	writeProcIO(t, root, 500, "new", 9*mb, 9*mb)    // No baseline yet
+	readers, writers, _ = c.sampleProcessDiskIO(start.Add(2*time.Second), root)
+
+	wantReaders := []ProcessDiskIO{
+		{PID: 200, Name: "rsync", ReadRate: 20},
+		{PID: 100, Name: "postgres", ReadRate: 1, WriteRate: 10},
+	}
+	wantWriters := []ProcessDiskIO{
+		{PID: 100, Name: "postgres", ReadRate: 1, WriteRate: 10},
+	}
+	if !reflect.DeepEqual(readers, wantReaders) {
+		t.Errorf("readers = %+v, want %+v", readers, wantReaders)
+	}
+	if !reflect.DeepEqual(writers, wantWriters) {
+		t.Errorf("writers = %+v, want %+v", writers, wantWriters)
+	}
+	if _, ok := c.prevProcIO[2]; ok {
+		t.Error("a PID without an io file should not be tracked")
+	}
+}
+
+func TestSampleProcessDiskIOMissingProc(t *testing.T) {
+	c := &Collector{}
+	readers, writers, denied := c.sampleProcessDiskIO(time.Now(), filepath.Join(t.TempDir(), "missing"))
+	if readers != nil || writers != nil || denied != 0 || c.prevProcIO != nil {
+		t.Fatalf("missing proc root should degrade to nothing, got %+v %+v %d", readers, writers, denied)
+	}
+}
+
+func TestReadProcIOCountsDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root reads any io file")
+	}
+	root := t.TempDir()
+	writeProcIO(t, root, 100, "other-user", 1, 1)
+	if err := os.Chmod(filepath.Join(root, "100", "io"), 0); err != nil {
+		t.Fatal(err)
+	}
+	counters, denied, err := readProcIO(root)
+	if err != nil || len(counters) != 0 || denied != 1 {
+		t.Fatalf("readProcIO() = %v, %d, %v; want one denied", counters, denied, err)
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+func TestTallyProcessCounts(t *testing.T) {
+	samples := []processSample{
+		{status: []string{process.Running}, threads: 4},
+		{status: []string{process.Sleep}, threads: 2},
+		{status: []string{process.Idle}, threads: 1},
+		{status: []string{process.Zombie}, threads: 0},
+		{status: []string{process.Stop}, threads: 3},
+	}
+
+	got := tallyProcessCounts(samples)
+	want := ProcessCountStatus{Total: 5, Running: 1, Sleeping: 2, Zombie: 1, Threads: 10}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tallyProcessCounts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTallyProcessCountsEmpty(t *testing.T) {
+	if got := tallyProcessCounts(nil); !reflect.DeepEqual(got, ProcessCountStatus{}) {
+		t.Fatalf("expected zero counts, got %+v", got)
+	}
+}
+
+func TestSampleTopProcessesPartialOnDeadline(t *testing.T) {
+	origProcs, origSample := processesFunc, procSampleFunc
+	t.Cleanup(func() { processesFunc, procSampleFunc = origProcs, origSample })
+
+	processesFunc = func() ([]*process.Process, error) {
+		return []*process.Process{{Pid: 1}, {Pid: 2}, {Pid: 3}}, nil
+	}
+	cpuBy := map[int32]float64{1: 10, 2: 20, 3: 30}
+	procSampleFunc = func(p *process.Process) (procStat, error) {
+		return procStat{name: "p", cpuSeconds: cpuBy[p.Pid]}, nil
+	}
+
+	base := time.Unix(1000, 0)
+	c := &Collector{}
+	got, truncated := c.sampleTopProcesses(context.Background(), base)
+	if truncated || len(got) != 3 {
+		t.Fatalf("first pass = %d procs, truncated=%v; want 3, false", len(got), truncated)
+	}
+
+	// Second pass: the deadline expires after the first process is sampled.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cpuBy[1] = 11
+	procSampleFunc = func(p *process.Process) (procStat, error) {
+		cancel()
+		return procStat{name: "p", cpuSeconds: cpuBy[p.Pid]}, nil
+	}
+	got, truncated = c.sampleTopProcesses(ctx, base.Add(2*time.Second))
+	if !truncated {
+		t.Fatal("expected truncated result")
+	}
+	if len(got) != 1 || got[0].PID != 1 || got[0].CPU != 50 {
+		t.Fatalf("partial result = %+v, want PID 1 at 50%%", got)
+	}
+	if prev, ok := c.prevProcCPU[3]; !ok || !prev.at.Equal(base) {
+		t.Fatalf("unsampled PID state = %+v, %v; want preserved from first pass", prev, ok)
+	}
+}
+
+func TestSampleTopProcessesResumesAndAgesOut(t *testing.T) {
+	origProcs, origSample := processesFunc, procSampleFunc
+	t.Cleanup(func() { processesFunc, procSampleFunc = origProcs, origSample })
+
+	pids := []int32{3, 1, 2}
+	processesFunc = func() ([]*process.Process, error) {
+		procs := make([]*process.Process, len(pids))
+		for i, pid := range pids {
+			procs[i] = &process.Process{Pid: pid}
+		}
+		return procs, nil
+	}
+	// Every pass runs out of time after one sample.
+	c := &Collector{}
+	var cancel context.CancelFunc
+	procSampleFunc = func(p *process.Process) (procStat, error) {
+		cancel()
+		return procStat{name: "p"}, nil
+	}
+	pass := func(at time.Time) int32 {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		got, truncated := c.sampleTopProcesses(ctx, at)
+		if !truncated || len(got) != 1 {
+			t.Fatalf("pass = %+v, truncated=%v; want one sample, truncated", got, truncated)
+		}
+		return got[0].PID
+	}
+
+	base := time.Unix(1000, 0)
+	var order []int32
+	for i := range 4 {
+		order = append(order, pass(base.Add(time.Duration(i)*time.Second)))
+	}
+	if fmt.Sprint(order) != "[1 2 3 1]" {
+		t.Fatalf("sampled PIDs = %v, want each PID in turn", order)
+	}
+
+	// PID 3 exits; truncated passes still drop it after procAgePasses.
+	pids = []int32{1, 2}
+	for i := range procAgePasses {
+		pass(base.Add(time.Duration(10+i) * time.Second))
+	}
+	if _, ok := c.prevProcCPU[3]; ok {
+		t.Fatal("exited PID kept its CPU state across truncated passes")
+	}
+}
+
+func TestSampleTopProcessesStartedAfter(t *testing.T) {
+	origProcs, origSample := processesFunc, procSampleFunc
+	t.Cleanup(func() { processesFunc, procSampleFunc = origProcs, origSample })
+
+	cutoff := time.Unix(5000, 0)
+	created := map[int32]int64{
+		1: cutoff.Add(-time.Hour).UnixMilli(),    // old
+		2: cutoff.Add(time.Minute).UnixMilli(),   // new
+		3: 0,                                     // unknown
+		4: cutoff.Add(2 * time.Hour).UnixMilli(), // new
+	}
+	processesFunc = func() ([]*process.Process, error) {
+		return []*process.Process{{Pid: 1}, {Pid: 2}, {Pid: 3}, {Pid: 4}}, nil
+	}
+	procSampleFunc = func(p *process.Process) (procStat, error) {
+		return procStat{name: "p", createMs: created[p.Pid]}, nil
+	}
+
+	pids := func(procs []ProcessInfo) map[int32]bool {
+		m := make(map[int32]bool)
+		for _, p := range procs {
+			m[p.PID] = true
+		}
+		return m
+	}
+
+	c := &Collector{StartedAfter: cutoff}
+	got, _ := c.sampleTopProcesses(context.Background(), cutoff)
+	if seen := pids(got); len(seen) != 2 || !seen[2] || !seen[4] {
+		t.Fatalf("StartedAfter kept %v, want PIDs 2 and 4", seen)
+	}
+
+	c = &Collector{StartedAfter: cutoff, IncludeUnknownStart: true}
+	got, _ = c.sampleTopProcesses(context.Background(), cutoff)
+	if seen := pids(got); len(seen) != 3 || !seen[3] {
+		t.Fatalf("IncludeUnknownStart kept %v, want PIDs 2, 3 and 4", seen)
+	}
+}
+
+func TestParsePSTopSkipsFilteredProcesses(t *testing.T) {
+	out := `  PID  %CPU %MEM COMM
+  100  50.0  1.0 /usr/bin/old
+  200  40.0  2.0 /Applications/New.app/new
+  300  30.0  3.0 fresh
+`
+	got := parsePSTop(out, func(pid int32) bool { return pid != 100 }, nil)
+	if len(got) != 2 || got[0].Name != "new" || got[1].PID != 300 {
+		t.Fatalf("parsePSTop() = %+v, want new and fresh", got)
+	}
+}
+
+func TestListZombiesNamesParents(t *testing.T) {
+	zombie := []string{process.Zombie}
+	samples := []processSample{
+		{status: []string{process.Running}, threads: 3},
+		{status: zombie, pid: 412, ppid: 300, name: "worker"},
+		{status: []string{process.Sleep}, threads: 1},
+		{status: zombie, pid: 405, ppid: 300, name: "worker"},
+		{status: zombie, pid: 900, ppid: 1, name: "orphan"}, // Parent exited, init adopted it
+		{status: zombie, pid: 950, ppid: 777, name: "lost"}, // Parent gone from the table
+	}
+	parents := map[int32]string{1: "systemd", 300: "buggy-daemon"}
+	lookups := map[int32]int{}
+	got := listZombies(samples, func(pid int32) string {
+		lookups[pid]++
+		return parents[pid]
+	})
+
+	want := []ZombieProcess{
+		{PID: 900, Name: "orphan", PPID: 1, ParentName: "systemd", Reparented: true},
+		{PID: 405, Name: "worker", PPID: 300, ParentName: "buggy-daemon"},
+		{PID: 412, Name: "worker", PPID: 300, ParentName: "buggy-daemon"},
+		{PID: 950, Name: "lost", PPID: 777},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("listZombies() = %+v, want %+v", got, want)
+	}
+	if lookups[300] != 1 {
+		t.Fatalf("parent name looked up %d times, want once", lookups[300])
+	}
+	if got := tallyProcessCounts(samples); got.Zombie != 4 {
+		t.Fatalf("zombie count = %d, want 4", got.Zombie)
+	}
+}
+
+func TestWatchlistedProcessListedBesideTopConsumers(t *testing.T) {
+	origProcs, origSample := processesFunc, procSampleFunc
+	t.Cleanup(func() { processesFunc, procSampleFunc = origProcs, origSample })
+
+	names := map[int32]string{1: "chrome", 2: "node", 3: "rustc", 4: "clang", 5: "ffmpeg", 6: "kernel_task", 7: "postgres", 8: "idle"}
+	var procs []*process.Process
+	for pid := range int32(8) {
+		procs = append(procs, &process.Process{Pid: pid + 1})
+	}
+	processesFunc = func() ([]*process.Process, error) { return procs, nil }
+	cpuSeconds := map[int32]float64{}
+	procSampleFunc = func(p *process.Process) (procStat, error) {
+		// Busiest first by PID; postgres (7) barely runs.
+		cpuSeconds[p.Pid] += float64(10 - p.Pid)
+		return procStat{name: names[p.Pid], cpuSeconds: cpuSeconds[p.Pid], memPercent: 0.5}, nil
+	}
+
+	c := &Collector{ProcessWatchlist: []string{"POSTGRES"}}
+	start := time.Now()
+	c.sampleTopProcesses(context.Background(), start)
+	got, _ := c.sampleTopProcesses(context.Background(), start.Add(time.Second))
+
+	if len(got) != maxTopProcesses+1 {
+		t.Fatalf("want the top %d plus postgres, got %+v", maxTopProcesses, got)
+	}
+	for _, p := range got[:maxTopProcesses] {
+		if p.Watchlisted || p.PID > int32(maxTopProcesses) {
+			t.Fatalf("top consumers should come first unmarked: %+v", got)
+		}
+	}
+	if last := got[maxTopProcesses]; last.Name != "postgres" || !last.Watchlisted || last.CPU != 300 || last.Memory != 0.5 {
+		t.Fatalf("watchlisted process = %+v", last)
+	}
+}
+
+func TestParsePSTopAddsWatchlisted(t *testing.T) {
+	out := "  PID  %CPU %MEM COMM\n"
+	for pid := 1; pid <= 8; pid++ {
+		out += fmt.Sprintf("  %d  %d.0  1.0 /usr/bin/proc%d\n", pid, 90-pid*10, pid)
+	}
+	out += "  99   0.1  4.0 /opt/homebrew/bin/postgres\n"
+	watch := func(name string) bool { return name == "postgres" || name == "proc2" }
+
+	got := parsePSTop(out, func(int32) bool { return true }, watch)
+	if len(got) != maxTopProcesses+1 {
+		t.Fatalf("parsePSTop() = %+v", got)
+	}
+	if !got[1].Watchlisted || got[0].Watchlisted {
+		t.Fatalf("watchlisted top process should be marked: %+v", got[:2])
+	}
+	if last := got[len(got)-1]; last.PID != 99 || !last.Watchlisted {
+		t.Fatalf("low-usage watchlisted process missing: %+v", last)
+	}
+}
+
+func TestAddProcessIdentityDisambiguatesSameName(t *testing.T) {
+	orig := processIdentityFunc
+	t.Cleanup(func() { processIdentityFunc = orig })
+
+	long := "java -jar /opt/app.jar " + strings.Repeat("-Dprop=välue ", 40)
+	identities := map[int32][2]string{
+		10: {"postgres", "postgres: checkpointer"},
+		11: {"alice", "postgres -D /home/alice/pgdata"},
+		12: {"", ""}, // Permission denied
+		13: {"svc", long},
+	}
+	processIdentityFunc = func(pid int32) (string, string) {
+		id := identities[pid]
+		return id[0], id[1]
+	}
+
+	procs := []ProcessInfo{{PID: 10, Name: "postgres"}, {PID: 11, Name: "postgres"}, {PID: 12, Name: "postgres"}, {PID: 13, Name: "java"}}
+	addProcessIdentity(procs)
+
+	if procs[0].Username != "postgres" || procs[1].Username != "alice" || procs[1].Cmdline != "postgres -D /home/alice/pgdata" {
+		t.Fatalf("same-name processes not told apart: %+v", procs[:2])
+	}
+	if procs[2].Username != "" || procs[2].Cmdline != "" {
+		t.Fatalf("unreadable process = %+v, want empty identity", procs[2])
+	}
+	if got := procs[3].Cmdline; len(got) > maxCmdlineLen || !strings.HasSuffix(got, "…") || !utf8.ValidString(got) || !strings.HasPrefix(got, "java -jar") {
+		t.Fatalf("long cmdline not truncated cleanly: %d bytes, %q", len(got), got)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import "strings"
+
+// EffectiveProxy compares the environment and system proxy for one URL
+// scheme. Command-line HTTP clients (curl, wget, most language runtimes)
+// read only the environment; GUI apps use only the system setting. When both
+// are set and differ, the two kinds of client take different paths.
+type EffectiveProxy struct {
+	Protocol string `json:"protocol"`          // http or https
+	Env      string `json:"env,omitempty"`     // Proxy from the environment
+	EnvVar   string `json:"env_var,omitempty"` // Variable that supplied Env
+	System   string `json:"system,omitempty"`  // System (GUI) proxy
+	// CommandLine is the proxy a command-line client uses ("" means direct).
+	CommandLine string `json:"command_line"`
+	Conflict    bool   `json:"conflict"` // Env and System both set and differ
+}
+
+// effectiveProxyEnvKeys is the per-scheme lookup order curl uses. curl
+// ignores uppercase HTTP_PROXY (it can be set by a CGI request header), so
+// it is not consulted for http.
+var effectiveProxyEnvKeys = map[string][]string{
+	"http":  {"http_proxy", "all_proxy", "ALL_PROXY"},
+	"https": {"https_proxy", "HTTPS_PROXY", "all_proxy", "ALL_PROXY"},
+}
+
+// resolveEffectiveProxies reports env and system proxies for http and https.
+// scutilOut is `scutil --proxy` output, empty where there is no system proxy
+// source. It returns nil when neither side configures a proxy.
+func resolveEffectiveProxies(getenv func(string) string, scutilOut string) []EffectiveProxy {
+	var result []EffectiveProxy
+	configured := false
+	for _, proto := range []string{"http", "https"} {
+		e := EffectiveProxy{Protocol: proto}
+		for _, key := range effectiveProxyEnvKeys[proto] {
+			if val := strings.TrimSpace(getenv(key)); val != "" {
+				e.Env, e.EnvVar = proxyHost(val), key
+				break
+			}
+		}
+		e.System = systemProxyFor(proto, scutilOut)
+		e.CommandLine = e.Env
+		e.Conflict = e.Env != "" && e.System != "" && !strings.EqualFold(e.Env, e.System)
+		configured = configured || e.Env != "" || e.System != ""
+		result = append(result, e)
+	}
+	if !configured {
+		return nil
+	}
+	return result
+}
+
+// systemProxyFor returns the scutil proxy the system applies to proto,
+// falling back to SOCKS the way CFNetwork does.
+func systemProxyFor(proto, scutilOut string) string {
+	if scutilOut == "" {
+		return ""
+	}
+	prefix := "HTTP"
+	if proto == "https" {
+		prefix = "HTTPS"
+	}
+	for _, p := range []string{prefix, "SOCKS"} {
+		if scutilProxyEnabled(scutilOut, p+"Enable") {
+			if host := joinHostPort(scutilProxyValue(scutilOut, p+"Proxy"), scutilProxyValue(scutilOut, p+"Port")); host != "" {
+				return host
+			}
+		}
+	}
+	return ""
+}
+
+// hasProxyConflict reports whether any scheme resolves differently for
+// command-line and GUI clients.
+func hasProxyConflict(effective []EffectiveProxy) bool {
+	for _, e := range effective {
+		if e.Conflict {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ProxySourceReport is what one proxy source found, for support bundles
+// that need more than the resolved proxy. Host never carries credentials.
+type ProxySourceReport struct {
+	Source   string `json:"source"` // env:<VAR>, app_config, scutil, gsettings or tun
+	Enabled  bool   `json:"enabled"`
+	Type     string `json:"type,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Selected bool   `json:"selected,omitempty"` // The source the resolved proxy came from
+}
+
+// platformProxy is one OS-level proxy source's result. Sources selectProxy
+// does not consult are reported for comparison but never selected.
+type platformProxy struct {
+	source     string
+	status     ProxyStatus
+	selectable bool
+}
+
+// platformProxies reads the OS-level sources for this platform: scutil and
+// TUN inference on macOS, GNOME settings on Linux.
+func platformProxies(run cmdRunner, scutilOut string) []platformProxy {
+	switch runtime.GOOS {
+	case "darwin":
+		return []platformProxy{
+			{"scutil", collectProxyFromScutilOutput(scutilOut), true},
+			{"tun", collectProxyFromTunInterfaces(), true},
+		}
+	case "linux":
+		return []platformProxy{{"gsettings", parseGSettingsProxy(readGSettingsProxy(run)), false}}
+	}
+	return nil
+}
+
+// proxySourceReports lists every source in selectProxy's precedence order
+// (env vars, registered sources, then the platform), marking the one
+// selectProxy would pick. Unset env vars are omitted; every other source is
+// listed even when it found nothing.
+func proxySourceReports(getenv func(string) string, envKeys []string, platform []platformProxy) []ProxySourceReport {
+	if len(envKeys) == 0 {
+		envKeys = defaultProxyEnvKeys
+	}
+	var reports []ProxySourceReport
+	selected := false
+	add := func(source string, p ProxyStatus, selectable bool) {
+		r := ProxySourceReport{Source: source, Enabled: p.Enabled, Type: p.Type, Host: p.Host}
+		if p.Enabled && selectable && !selected {
+			r.Selected, selected = true, true
+		}
+		reports = append(reports, r)
+	}
+
+	for _, key := range envKeys {
+		if strings.TrimSpace(getenv(key)) == "" {
+			continue
+		}
+		// collectProxyFromEnv reduces the value to host:port, dropping
+		// credentials and paths even when the URL does not parse.
+		add("env:"+key, collectProxyFromEnv(getenv, key), true)
+	}
+
+	proxySourcesMu.RLock()
+	sources := proxySources
+	proxySourcesMu.RUnlock()
+	for _, src := range sources {
+		add("app_config", src(), true)
+	}
+
+	for _, p := range platform {
+		add(p.source, p.status, p.selectable)
+	}
+	return reports
+}
+
+// gsettingsProxyKeys are the GNOME proxy settings read for the report, as
+// schema/key pairs.
+var gsettingsProxyKeys = [][2]string{
+	{"org.gnome.system.proxy", "mode"},
+	{"org.gnome.system.proxy", "autoconfig-url"},
+	{"org.gnome.system.proxy.https", "host"},
+	{"org.gnome.system.proxy.https", "port"},
+	{"org.gnome.system.proxy.http", "host"},
+	{"org.gnome.system.proxy.http", "port"},
+	{"org.gnome.system.proxy.socks", "host"},
+	{"org.gnome.system.proxy.socks", "port"},
+}
+
+// readGSettingsProxy returns the GNOME proxy settings as
+// "schema key value" lines, the format gsettings list-recursively uses.
+func readGSettingsProxy(run cmdRunner) string {
+	if !commandExists("gsettings") {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	var b strings.Builder
+	for _, k := range gsettingsProxyKeys {
+		out, err := run(ctx, "gsettings", "get", k[0], k[1])
+		if err != nil {
+			return ""
+		}
+		b.WriteString(k[0] + " " + k[1] + " " + strings.TrimSpace(out) + "\n")
+	}
+	return b.String()
+}
+
+// parseGSettingsProxy reads "schema key value" lines for the GNOME proxy.
+// Manual mode reports the first configured of HTTPS, HTTP and SOCKS; auto
+// mode reports the PAC host.
+func parseGSettingsProxy(out string) ProxyStatus {
+	values := make(map[string]string)
+	for line := range strings.Lines(out) {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) == 3 {
+			values[fields[0]+" "+fields[1]] = strings.Trim(fields[2], "'")
+		}
+	}
+	switch values["org.gnome.system.proxy mode"] {
+	case "manual":
+		for _, scheme := range []struct{ schema, typ string }{
+			{"org.gnome.system.proxy.https", "HTTPS"},
+			{"org.gnome.system.proxy.http", "HTTP"},
+			{"org.gnome.system.proxy.socks", "SOCKS"},
+		} {
+			host := values[scheme.schema+" host"]
+			if host == "" {
+				continue
+			}
+			port := values[scheme.schema+" port"]
+			if port == "0" {
+				port = ""
+			}
+			return ProxyStatus{Enabled: true, Type: scheme.typ, Host: joinHostPort(host, port)}
+		}
+	case "auto":
+		if host := parseProxyHost(values["org.gnome.system.proxy autoconfig-url"]); host != "" {
+			return ProxyStatus{Enabled: true, Type: "PAC", Host: host}
+		}
+		return ProxyStatus{Enabled: true, Type: "WPAD", Host: "Auto Discovery"}
+	}
+	return ProxyStatus{Enabled: false}
+}
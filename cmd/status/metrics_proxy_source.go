@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxySource inspects an app-specific mechanism (usually a config file) and
+// reports a proxy. Sources run after environment variables and before the
+// OS-level checks; the first enabled result wins.
+type ProxySource func() ProxyStatus
+
+var (
+	proxySourcesMu sync.RWMutex
+	proxySources   []ProxySource
+)
+
+func init() {
+	if runtime.GOOS == "linux" {
+		RegisterProxySource(redsocksProxySource("/etc/redsocks.conf"))
+	}
+}
+
+// RegisterProxySource adds src to the detection chain.
+func RegisterProxySource(src ProxySource) {
+	proxySourcesMu.Lock()
+	defer proxySourcesMu.Unlock()
+	proxySources = append(proxySources, src)
+}
+
+func collectProxyFromSources() ProxyStatus {
+	proxySourcesMu.RLock()
+	sources := proxySources
+	proxySourcesMu.RUnlock()
+
+	for _, src := range sources {
+		if proxy := src(); proxy.Enabled {
+			return proxy
+		}
+	}
+	return ProxyStatus{Enabled: false}
+}
+
+// redsocksProxySource reports the upstream proxy from a redsocks config.
+// A config left behind on disk says nothing about whether redsocks runs, so
+// the proxy only counts while something listens on the section's
+// local_port. A missing or unreadable file means redsocks isn't in use.
+func redsocksProxySource(path string) ProxySource {
+	var listening portListenCache
+	return func() ProxyStatus {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return ProxyStatus{Enabled: false}
+		}
+		values := redsocksSection(string(data))
+		if !listening.check(values["local_port"], time.Now()) {
+			return ProxyStatus{Enabled: false}
+		}
+		return redsocksProxy(values)
+	}
+}
+
+// portListenCache remembers a tcpPortListening result for listenerCacheTTL:
+// like the listener scan, each check walks every process's file
+// descriptors.
+type portListenCache struct {
+	mu        sync.Mutex
+	port      string
+	listening bool
+	checkedAt time.Time
+}
+
+// check reports whether port is listening, rescanning when the cached
+// result is for another port or older than listenerCacheTTL.
+func (p *portListenCache) check(port string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if port == p.port && !p.checkedAt.IsZero() && now.Sub(p.checkedAt) < listenerCacheTTL {
+		return p.listening
+	}
+	p.port, p.listening, p.checkedAt = port, tcpPortListening(port), now
+	return p.listening
+}
+
+// tcpPortListening reports whether a TCP socket is in LISTEN state on port.
+func tcpPortListening(port string) bool {
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || n == 0 {
+		return false
+	}
+	conns, err := connectionsFunc("tcp")
+	if err != nil {
+		return false
+	}
+	for _, conn := range conns {
+		if conn.Status == "LISTEN" && conn.Laddr.Port == uint32(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRedsocksConfig reads the upstream proxy from the first
+// "redsocks { ... }" section.
+func parseRedsocksConfig(conf string) ProxyStatus {
+	return redsocksProxy(redsocksSection(conf))
+}
+
+// redsocksSection returns the key/value pairs of the first
+// "redsocks { ... }" section.
+func redsocksSection(conf string) map[string]string {
+	inSection := false
+	values := make(map[string]string)
+	for line := range strings.Lines(conf) {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if !inSection {
+			if name, ok := strings.CutSuffix(line, "{"); ok && strings.TrimSpace(name) == "redsocks" {
+				inSection = true
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "}") {
+			break
+		}
+		key, val, ok := strings.Cut(strings.TrimSuffix(line, ";"), "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+	return values
+}
+
+func redsocksProxy(values map[string]string) ProxyStatus {
+	host := joinHostPort(values["ip"], values["port"])
+	if host == "" {
+		return ProxyStatus{Enabled: false}
+	}
+	proxyType := "HTTP"
+	if strings.HasPrefix(values["type"], "socks") {
+		proxyType = "SOCKS"
+	}
+	return ProxyStatus{Enabled: true, Type: proxyType, Host: host}
+}
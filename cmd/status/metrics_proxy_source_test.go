@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestRegisteredProxySourceReadsFixture(t *testing.T) {
+	orig := proxySources
+	proxySources = nil
+	t.Cleanup(func() { proxySources = orig })
+
+	path := filepath.Join(t.TempDir(), "app-proxy.conf")
+	if err := os.WriteFile(path, []byte("upstream=10.9.8.7:3128\n"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	RegisterProxySource(func() ProxyStatus {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return ProxyStatus{}
+		}
+		host, _ := strings.CutPrefix(strings.TrimSpace(string(data)), "upstream=")
+		return ProxyStatus{Enabled: true, Type: "HTTP", Host: host}
+	})
+
+	got := collectProxy(func(string) string { return "" }, nil, nil, nil)
+	if !got.Enabled || got.Host != "10.9.8.7:3128" {
+		t.Fatalf("expected proxy from registered source, got %+v", got)
+	}
+
+	// Env still takes precedence over registered sources.
+	got = collectProxy(func(key string) string {
+		if key == "HTTP_PROXY" {
+			return "http://env.proxy:8080"
+		}
+		return ""
+	}, nil, nil, nil)
+	if got.Host != "env.proxy:8080" {
+		t.Fatalf("expected env proxy to win, got %+v", got)
+	}
+}
+
+func TestParseRedsocksConfig(t *testing.T) {
+	conf := `base {
+	log_debug = off;
+}
+
+redsocks {
+	local_ip = 127.0.0.1;
+	local_port = 12345;
+	ip = 192.168.1.10; // upstream
+	port = 1080;
+	type = socks5;
+}
+`
+	got := parseRedsocksConfig(conf)
+	if !got.Enabled || got.Type != "SOCKS" || got.Host != "192.168.1.10:1080" {
+		t.Fatalf("unexpected redsocks proxy: %+v", got)
+	}
+
+	if got := parseRedsocksConfig("base {\n}\n"); got.Enabled {
+		t.Fatalf("config without redsocks section should not enable proxy: %+v", got)
+	}
+}
+
+func TestRedsocksSourceNeedsListeningLocalPort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redsocks.conf")
+	conf := "redsocks {\n\tlocal_port = 12345;\n\tip = 192.168.1.10;\n\tport = 1080;\n\ttype = socks5;\n}\n"
+	if err := os.WriteFile(path, []byte(conf), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	var conns []net.ConnectionStat
+	scans := 0
+	orig := connectionsFunc
+	connectionsFunc = func(string) ([]net.ConnectionStat, error) { scans++; return conns, nil }
+	t.Cleanup(func() { connectionsFunc = orig })
+
+	src := redsocksProxySource(path)
+	if got := src(); got.Enabled {
+		t.Fatalf("stale config without a listener should not enable proxy: %+v", got)
+	}
+
+	// The socket scan is cached, so a new listener shows up on the next
+	// refresh rather than the next tick.
+	conns = []net.ConnectionStat{{Status: "LISTEN", Laddr: net.Addr{IP: "127.0.0.1", Port: 12345}}}
+	if got := src(); got.Enabled || scans != 1 {
+		t.Fatalf("second tick should reuse the cached scan, got %+v after %d scans", got, scans)
+	}
+
+	src = redsocksProxySource(path)
+	if got := src(); !got.Enabled || got.Host != "192.168.1.10:1080" {
+		t.Fatalf("running redsocks should report its upstream, got %+v", got)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	gopsutilnet "github.com/shirou/gopsutil/v4/net"
+)
+
+const (
+	maxRemoteHosts    = 5
+	remoteLookupLimit = 300 * time.Millisecond // Per scan
+	maxLookupWorkers  = 8
+	maxHostnameCache  = 1024
+)
+
+var lookupAddrFunc = net.DefaultResolver.LookupAddr
+
+// RemoteHost is a remote endpoint ranked by established connections.
+// Per-connection byte counters are not exposed by the OS socket tables, so
+// connection count is the ranking signal.
+type RemoteHost struct {
+	IP          string `json:"ip"`
+	Hostname    string `json:"hostname,omitempty"` // Reverse DNS, when resolution is enabled
+	Connections int    `json:"connections"`
+	Processes   int    `json:"processes"` // Distinct owning PIDs, where visible
+}
+
+// aggregateRemoteHosts counts ESTABLISHED connections per remote IP and
+// returns the busiest. Loopback peers are local IPC, not "talking to" anyone.
+func aggregateRemoteHosts(conns []gopsutilnet.ConnectionStat, limit int) []RemoteHost {
+	type tally struct {
+		conns int
+		pids  map[int32]bool
+	}
+	byIP := make(map[string]*tally)
+	for _, conn := range conns {
+		if conn.Status != "ESTABLISHED" {
+			continue
+		}
+		addr, err := netip.ParseAddr(conn.Raddr.IP)
+		if err != nil || addr.IsLoopback() || addr.IsUnspecified() {
+			continue
+		}
+		ip := addr.Unmap().String()
+		t := byIP[ip]
+		if t == nil {
+			t = &tally{pids: make(map[int32]bool)}
+			byIP[ip] = t
+		}
+		t.conns++
+		if conn.Pid > 0 {
+			t.pids[conn.Pid] = true
+		}
+	}
+
+	hosts := make([]RemoteHost, 0, len(byIP))
+	for ip, t := range byIP {
+		hosts = append(hosts, RemoteHost{IP: ip, Connections: t.conns, Processes: len(t.pids)})
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Connections != hosts[j].Connections {
+			return hosts[i].Connections > hosts[j].Connections
+		}
+		return hosts[i].IP < hosts[j].IP
+	})
+	if len(hosts) > limit {
+		hosts = hosts[:limit]
+	}
+	return hosts
+}
+
+// NameResolver reverse-resolves an IP to hostnames, with the signature of
+// net.Resolver.LookupAddr.
+type NameResolver func(ctx context.Context, addr string) ([]string, error)
+
+// hostnameCache remembers reverse DNS answers by IP, including the empty
+// answer for IPs without PTR records, evicting the oldest entry past max.
+type hostnameCache struct {
+	max   int
+	names map[string]string
+	order []string // Insertion order, oldest first
+}
+
+func newHostnameCache(limit int) *hostnameCache {
+	return &hostnameCache{max: limit, names: make(map[string]string)}
+}
+
+func (h *hostnameCache) get(ip string) (string, bool) {
+	name, ok := h.names[ip]
+	return name, ok
+}
+
+func (h *hostnameCache) put(ip, name string) {
+	if _, ok := h.names[ip]; !ok {
+		h.order = append(h.order, ip)
+	}
+	h.names[ip] = name
+	for len(h.order) > h.max {
+		delete(h.names, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// resolveNames returns the hostname of each IP in ips, from cache or
+// looked up by up to maxLookupWorkers goroutines. The whole call waits at
+// most remoteLookupLimit, even for a resolver that ignores its context;
+// IPs without a name by then map to "". Failed lookups are cached so a host
+// without PTR records isn't retried every scan; timed-out ones are not,
+// since the resolver may answer next time.
+func resolveNames(ips []string, lookup NameResolver, cache *hostnameCache) map[string]string {
+	if lookup == nil {
+		lookup = lookupAddrFunc
+	}
+	type answer struct {
+		ip, name string
+		timedOut bool
+	}
+	names := make(map[string]string, len(ips))
+	var todo []string
+	for _, ip := range ips {
+		if _, done := names[ip]; done {
+			continue
+		}
+		if name, ok := cache.get(ip); ok {
+			names[ip] = name
+			continue
+		}
+		names[ip] = ""
+		todo = append(todo, ip)
+	}
+	if len(todo) == 0 {
+		return names
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteLookupLimit)
+	defer cancel()
+	// Buffered so workers still stuck in a lookup can finish after we stop waiting.
+	answers := make(chan answer, len(todo))
+	workers := make(chan struct{}, maxLookupWorkers)
+	for _, ip := range todo {
+		go func() {
+			select {
+			case workers <- struct{}{}:
+				defer func() { <-workers }()
+			case <-ctx.Done():
+				answers <- answer{ip: ip, timedOut: true}
+				return
+			}
+			a := answer{ip: ip}
+			if hosts, err := lookup(ctx, ip); err == nil && len(hosts) > 0 {
+				a.name = strings.TrimSuffix(hosts[0], ".")
+			} else {
+				a.timedOut = ctx.Err() != nil
+			}
+			answers <- a
+		}()
+	}
+	for range todo {
+		select {
+		case a := <-answers:
+			names[a.ip] = a.name
+			if !a.timedOut {
+				cache.put(a.ip, a.name)
+			}
+		case <-ctx.Done():
+			return names
+		}
+	}
+	return names
+}
+
+// resolveRemoteHosts fills Hostname on hosts via resolveNames.
+func resolveRemoteHosts(hosts []RemoteHost, lookup NameResolver, cache *hostnameCache) {
+	ips := make([]string, len(hosts))
+	for i, h := range hosts {
+		ips[i] = h.IP
+	}
+	names := resolveNames(ips, lookup, cache)
+	for i := range hosts {
+		hosts[i].Hostname = names[hosts[i].IP]
+	}
+}
+
+// resolveListeners fills Hostname on listeners bound to a specific
+// address. Wildcard and loopback binds have nothing useful to resolve.
+func resolveListeners(listeners []ListenerStatus, lookup NameResolver, cache *hostnameCache) {
+	var ips []string
+	for _, l := range listeners {
+		if addr, err := netip.ParseAddr(l.Addr); err == nil && !addr.IsLoopback() {
+			ips = append(ips, l.Addr)
+		}
+	}
+	if len(ips) == 0 {
+		return
+	}
+	names := resolveNames(ips, lookup, cache)
+	for i := range listeners {
+		listeners[i].Hostname = names[listeners[i].Addr]
+	}
+}
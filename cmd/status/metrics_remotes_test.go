@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestAggregateRemoteHosts(t *testing.T) {
+	est := func(remote string, pid int32) net.ConnectionStat {
+		return net.ConnectionStat{Status: "ESTABLISHED", Raddr: net.Addr{IP: remote, Port: 443}, Pid: pid}
+	}
+	conns := []net.ConnectionStat{
+		est("140.82.112.3", 100),
+		est("140.82.112.3", 100),
+		est("140.82.112.3", 200),
+		est("::ffff:17.253.144.10", 300), // v4-mapped, same host as below
+		est("17.253.144.10", 0),
+		est("127.0.0.1", 400), // local IPC
+		est("2606:4700::1111", 500),
+		{Status: "TIME_WAIT", Raddr: net.Addr{IP: "140.82.112.3", Port: 443}},
+		{Status: "LISTEN", Laddr: net.Addr{IP: "0.0.0.0", Port: 22}},
+	}
+
+	got := aggregateRemoteHosts(conns, 2)
+	want := []RemoteHost{
+		{IP: "140.82.112.3", Connections: 3, Processes: 2},
+		{IP: "17.253.144.10", Connections: 2, Processes: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("aggregateRemoteHosts() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("host %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestResolveRemoteHostsCaches(t *testing.T) {
+	orig := lookupAddrFunc
+	t.Cleanup(func() { lookupAddrFunc = orig })
+
+	var calls atomic.Int32
+	lookupAddrFunc = func(_ context.Context, addr string) ([]string, error) {
+		calls.Add(1)
+		if addr == "192.0.2.1" {
+			return []string{"web.example.com."}, nil
+		}
+		return nil, errors.New("no PTR")
+	}
+
+	cache := newHostnameCache(maxHostnameCache)
+	hosts := []RemoteHost{{IP: "192.0.2.1"}, {IP: "192.0.2.2"}}
+	resolveRemoteHosts(hosts, nil, cache)
+	if hosts[0].Hostname != "web.example.com" || hosts[1].Hostname != "" {
+		t.Fatalf("resolved hosts = %+v", hosts)
+	}
+
+	again := []RemoteHost{{IP: "192.0.2.1"}, {IP: "192.0.2.2"}}
+	resolveRemoteHosts(again, nil, cache)
+	if calls.Load() != 2 || again[0].Hostname != "web.example.com" {
+		t.Fatalf("second pass made %d lookups total (want 2 cached), hosts = %+v", calls.Load(), again)
+	}
+}
+
+func TestResolveNamesWithInjectedResolver(t *testing.T) {
+	names := map[string]string{"198.51.100.7": "db.internal.", "203.0.113.9": "api.example.net."}
+	var calls atomic.Int32
+	resolver := func(_ context.Context, addr string) ([]string, error) {
+		calls.Add(1)
+		if name, ok := names[addr]; ok {
+			return []string{name}, nil
+		}
+		return nil, errors.New("no PTR")
+	}
+
+	cache := newHostnameCache(maxHostnameCache)
+	listeners := []ListenerStatus{
+		{Proto: "tcp", Addr: "198.51.100.7", Port: 5432},
+		{Proto: "tcp", Addr: "*", Port: 22},
+		{Proto: "tcp", Addr: "127.0.0.1", Port: 631},
+		{Proto: "tcp", Addr: "192.0.2.50", Port: 8080},
+	}
+	resolveListeners(listeners, resolver, cache)
+	if listeners[0].Hostname != "db.internal" || listeners[1].Hostname != "" || listeners[2].Hostname != "" || listeners[3].Hostname != "" {
+		t.Fatalf("resolved listeners = %+v", listeners)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("made %d lookups, want 2 (wildcard and loopback skipped)", calls.Load())
+	}
+
+	hosts := []RemoteHost{{IP: "203.0.113.9"}, {IP: "198.51.100.7"}, {IP: "192.0.2.50"}}
+	resolveRemoteHosts(hosts, resolver, cache)
+	if hosts[0].Hostname != "api.example.net" || hosts[1].Hostname != "db.internal" || hosts[2].Hostname != "" {
+		t.Fatalf("resolved hosts = %+v", hosts)
+	}
+	// Only 203.0.113.9 was new; the positive and negative answers were cached.
+	if calls.Load() != 3 {
+		t.Fatalf("made %d lookups, want 3", calls.Load())
+	}
+}
+
+func TestResolveNamesDoesNotCacheTimeouts(t *testing.T) {
+	resolver := func(ctx context.Context, _ string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	cache := newHostnameCache(maxHostnameCache)
+	resolveNames([]string{"192.0.2.1"}, resolver, cache)
+	if _, ok := cache.get("192.0.2.1"); ok {
+		t.Fatal("timed-out lookup was cached")
+	}
+}
+
+func TestResolveNamesStopsWaitingOnStalledResolver(t *testing.T) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	var mu sync.Mutex
+	running, peak := 0, 0
+	resolver := func(context.Context, string) ([]string, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		<-release // Ignores ctx entirely.
+		return []string{"late.example."}, nil
+	}
+	ips := make([]string, 2*maxLookupWorkers)
+	for i := range ips {
+		ips[i] = fmt.Sprintf("192.0.2.%d", i+1)
+	}
+
+	start := time.Now()
+	names := resolveNames(ips, resolver, newHostnameCache(maxHostnameCache))
+	if elapsed := time.Since(start); elapsed > 3*remoteLookupLimit {
+		t.Fatalf("resolveNames waited %v on a stalled resolver", elapsed)
+	}
+	if len(names) != len(ips) || names["192.0.2.1"] != "" {
+		t.Fatalf("stalled lookups should map to empty names, got %v", names)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := peak; got > maxLookupWorkers {
+		t.Fatalf("%d lookups ran at once, want at most %d", got, maxLookupWorkers)
+	}
+}
+
+func TestHostnameCacheEvictsOldest(t *testing.T) {
+	cache := newHostnameCache(2)
+	cache.put("192.0.2.1", "a")
+	cache.put("192.0.2.2", "")
+	cache.put("192.0.2.1", "a2") // Update keeps its slot
+	cache.put("192.0.2.3", "c")
+	if _, ok := cache.get("192.0.2.1"); ok {
+		t.Fatal("oldest entry should have been evicted")
+	}
+	if name, ok := cache.get("192.0.2.3"); !ok || name != "c" || len(cache.names) != 2 {
+		t.Fatalf("cache = %v", cache.names)
+	}
+}
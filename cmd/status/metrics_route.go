@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"net/netip"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const routeCacheTTL = 10 * time.Second
+
+// routeEntry is a single IPv4 route parsed from the platform routing table.
+type routeEntry struct {
+	Destination string
+	Gateway     string
+	Interface   string
+	Metric      int // Linux only; lower wins
+}
+
+func (r routeEntry) isDefault() bool {
+	return r.Destination == "default" || r.Destination == "0.0.0.0/0" || r.Destination == "0.0.0.0"
+}
+
+func collectRoutes(ctx context.Context) ([]routeEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	if runtime.GOOS == "darwin" {
+		out, err := runCmd(ctx, "netstat", "-rn", "-f", "inet")
+		if err != nil {
+			return nil, err
+		}
+		return parseNetstatRoutes(out), nil
+	}
+
+	out, err := runCmd(ctx, "ip", "-4", "route", "show")
+	if err != nil {
+		return nil, err
+	}
+	return parseIPRoutes(out), nil
+}
+
+// parseNetstatRoutes parses `netstat -rn -f inet` output (macOS/BSD).
+func parseNetstatRoutes(out string) []routeEntry {
+	var routes []routeEntry
+	inTable := false
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "Destination" {
+			inTable = true
+			continue
+		}
+		if !inTable || len(fields) < 4 {
+			continue
+		}
+		routes = append(routes, routeEntry{
+			Destination: fields[0],
+			Gateway:     fields[1],
+			Interface:   fields[3],
+		})
+	}
+	return routes
+}
+
+// parseIPRoutes parses `ip -4 route show` output (Linux).
+func parseIPRoutes(out string) []routeEntry {
+	var routes []routeEntry
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		r := routeEntry{Destination: fields[0]}
+		for i := 1; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "via":
+				r.Gateway = fields[i+1]
+			case "dev":
+				r.Interface = fields[i+1]
+			case "metric":
+				r.Metric, _ = strconv.Atoi(fields[i+1])
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// detectUplinks returns the physical interfaces holding a default route.
+// Tunnels are excluded since a VPN riding on one uplink is not a second uplink.
+func detectUplinks(routes []routeEntry) []string {
+	seen := make(map[string]bool)
+	var uplinks []string
+	for _, r := range routes {
+		if !r.isDefault() || r.Interface == "" || isTunnelInterface(r.Interface) {
+			continue
+		}
+		if seen[r.Interface] {
+			continue
+		}
+		seen[r.Interface] = true
+		uplinks = append(uplinks, r.Interface)
+	}
+	sort.Strings(uplinks)
+	return uplinks
+}
+
+func isTunnelInterface(name string) bool {
+	lower := strings.ToLower(name)
+	for _, prefix := range []string{"utun", "tun", "tap", "wg", "ipsec"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tunnelEgress returns the physical interface a tunnel rides on and whether
+// that is only a guess. VPN and TUN proxy clients pin a host route to their
+// server endpoint through the real uplink so the tunnel's own traffic
+// doesn't loop back into it. When exactly one such route exists its
+// destination is taken as the endpoint, and the egress is the physical
+// route that best matches it. Otherwise the best physical default route is
+// returned as a guess, since the tunnel may leave by another uplink.
+func tunnelEgress(routes []routeEntry) (string, bool) {
+	var endpoints []netip.Addr
+	fallback, bestMetric := "", 0
+	for _, r := range routes {
+		if r.Interface == "" || isTunnelInterface(r.Interface) {
+			continue
+		}
+		if p, ok := routePrefix(r.Destination); ok && p.IsSingleIP() && !slices.Contains(endpoints, p.Addr()) {
+			if _, err := netip.ParseAddr(r.Gateway); err == nil {
+				endpoints = append(endpoints, p.Addr())
+			}
+		}
+		if r.isDefault() && (fallback == "" || r.Metric < bestMetric) {
+			fallback, bestMetric = r.Interface, r.Metric
+		}
+	}
+	if len(endpoints) == 1 {
+		if egress := physicalRouteTo(routes, endpoints[0]); egress != "" {
+			return egress, false
+		}
+	}
+	return fallback, fallback != ""
+}
+
+// physicalRouteTo returns the interface of the longest-prefix physical route
+// matching addr, preferring the lower metric between equal prefixes.
+func physicalRouteTo(routes []routeEntry, addr netip.Addr) string {
+	iface, bestBits, bestMetric := "", -1, 0
+	for _, r := range routes {
+		if r.Interface == "" || isTunnelInterface(r.Interface) {
+			continue
+		}
+		p, ok := routePrefix(r.Destination)
+		if !ok || !p.Contains(addr) {
+			continue
+		}
+		if p.Bits() > bestBits || (p.Bits() == bestBits && r.Metric < bestMetric) {
+			iface, bestBits, bestMetric = r.Interface, p.Bits(), r.Metric
+		}
+	}
+	return iface
+}
+
+// routePrefix parses an IPv4 route destination: a default, a bare address,
+// a CIDR prefix, or netstat's shortened network form ("192.168.1",
+// "169.254/16") where missing octets are zero and, without a length, each
+// given octet counts 8 bits.
+func routePrefix(dest string) (netip.Prefix, bool) {
+	if (routeEntry{Destination: dest}).isDefault() {
+		return netip.PrefixFrom(netip.IPv4Unspecified(), 0), true
+	}
+	addr, bits, hasBits := strings.Cut(dest, "/")
+	octets := strings.Count(addr, ".") + 1
+	if octets > 4 {
+		return netip.Prefix{}, false
+	}
+	addr += strings.Repeat(".0", 4-octets)
+	n := octets * 8
+	if hasBits {
+		var err error
+		if n, err = strconv.Atoi(bits); err != nil {
+			return netip.Prefix{}, false
+		}
+	}
+	p, err := netip.ParsePrefix(addr + "/" + strconv.Itoa(n))
+	if err != nil || !p.Addr().Is4() {
+		return netip.Prefix{}, false
+	}
+	return p.Masked(), true
+}
+
+// killSwitchLikely reports whether the table only lets traffic out through
+// a tunnel: some tunnel interface holds a route, while no physical
+// interface holds a default one. That is the state a VPN kill switch keeps,
+// whether the tunnel default is up or was torn down with the link, but the
+// same tables appear without one, so this is only a hint.
+func killSwitchLikely(routes []routeEntry) bool {
+	tunnel := false
+	for _, r := range routes {
+		switch {
+		case r.Interface == "":
+		case isTunnelInterface(r.Interface):
+			tunnel = true
+		case r.isDefault():
+			return false
+		}
+	}
+	return tunnel
+}
+
+// summarizeRoutes picks out the default routes and counts the table. The
+// default gateway is the default route with an IP next hop and the lowest
+// metric; link-scoped tunnel defaults (macOS "link#N") have no gateway IP.
+func summarizeRoutes(routes []routeEntry) RouteSummary {
+	summary := RouteSummary{RouteCount: len(routes), KillSwitchLikely: killSwitchLikely(routes)}
+	bestMetric := 0
+	for _, r := range routes {
+		if !r.isDefault() {
+			continue
+		}
+		summary.Defaults = append(summary.Defaults, DefaultRoute{Gateway: r.Gateway, Interface: r.Interface})
+		if _, err := netip.ParseAddr(r.Gateway); err != nil {
+			continue
+		}
+		if summary.DefaultGateway == "" || r.Metric < bestMetric {
+			summary.DefaultGateway = r.Gateway
+			summary.GatewayInterface = r.Interface
+			bestMetric = r.Metric
+		}
+	}
+	return summary
+}
+
+// collectRouteSummary returns the cached route summary and uplink list,
+// refreshing them when stale.
+func (c *Collector) collectRouteSummary(ctx context.Context, now time.Time) (RouteSummary, []string) {
+	if !c.lastRouteAt.IsZero() && now.Sub(c.lastRouteAt) < routeCacheTTL {
+		return c.cachedRoutes, c.cachedUplinks
+	}
+	routes, err := collectRoutes(ctx)
+	c.lastRouteAt = now
+	if err != nil {
+		logDegraded(c.logger(), "routes", err)
+		return c.cachedRoutes, c.cachedUplinks
+	}
+	c.cachedRouteEntries = routes
+	c.cachedRoutes = summarizeRoutes(routes)
+	c.cachedUplinks = detectUplinks(routes)
+	return c.cachedRoutes, c.cachedUplinks
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+const netstatSingleDefault = `Routing tables
+
+Internet:
+Destination        Gateway            Flags               Netif Expire
+default            192.168.1.1        UGScg                 en0
+default            link#17            UCSIg               utun3
+127                127.0.0.1          UCS                   lo0
+192.168.1          link#6             UCS                   en0      !
+`
+
+const ipRouteTwoDefaults = `default via 192.168.1.1 dev wlan0 proto dhcp metric 600
+default via 10.0.0.1 dev eth0 proto dhcp metric 100
+10.0.0.0/24 dev eth0 proto kernel scope link src 10.0.0.5 metric 100
+192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.20 metric 600
+`
+
+func TestDetectUplinksSingleDefault(t *testing.T) {
+	routes := parseNetstatRoutes(netstatSingleDefault)
+	if len(routes) != 4 {
+		t.Fatalf("expected 4 routes, got %d: %+v", len(routes), routes)
+	}
+
+	got := detectUplinks(routes)
+	if !slices.Equal(got, []string{"en0"}) {
+		t.Fatalf("detectUplinks() = %v, want [en0] (tunnel default ignored)", got)
+	}
+}
+
+func TestDetectUplinksTwoDefaults(t *testing.T) {
+	routes := parseIPRoutes(ipRouteTwoDefaults)
+	if routes[0].Gateway != "192.168.1.1" || routes[0].Interface != "wlan0" {
+		t.Fatalf("unexpected first route: %+v", routes[0])
+	}
+
+	got := detectUplinks(routes)
+	if !slices.Equal(got, []string{"eth0", "wlan0"}) {
+		t.Fatalf("detectUplinks() = %v, want [eth0 wlan0]", got)
+	}
+}
+
+func TestSummarizeRoutesMacOS(t *testing.T) {
+	got := summarizeRoutes(parseNetstatRoutes(netstatSingleDefault))
+	if got.DefaultGateway != "192.168.1.1" || got.GatewayInterface != "en0" {
+		t.Fatalf("unexpected default gateway: %+v", got)
+	}
+	if got.RouteCount != 4 || len(got.Defaults) != 2 {
+		t.Fatalf("expected 4 routes with 2 defaults, got %+v", got)
+	}
+	if got.Defaults[1] != (DefaultRoute{Gateway: "link#17", Interface: "utun3"}) {
+		t.Fatalf("unexpected tunnel default: %+v", got.Defaults[1])
+	}
+}
+
+func TestSummarizeRoutesLinuxPrefersLowestMetric(t *testing.T) {
+	got := summarizeRoutes(parseIPRoutes(ipRouteTwoDefaults))
+	if got.DefaultGateway != "10.0.0.1" || got.GatewayInterface != "eth0" {
+		t.Fatalf("expected metric-100 eth0 gateway, got %+v", got)
+	}
+	if got.RouteCount != 4 || len(got.Defaults) != 2 {
+		t.Fatalf("expected 4 routes with 2 defaults, got %+v", got)
+	}
+}
+
+func TestSummarizeRoutesNoDefault(t *testing.T) {
+	got := summarizeRoutes(parseIPRoutes("10.0.0.0/24 dev eth0 proto kernel scope link src 10.0.0.5\n"))
+	if got.DefaultGateway != "" || len(got.Defaults) != 0 || got.RouteCount != 1 {
+		t.Fatalf("unexpected summary without default route: %+v", got)
+	}
+}
+
+const netstatTunProxy = `Routing tables
+
+Internet:
+Destination        Gateway            Flags               Netif Expire
+default            link#22            UCSg                utun4
+default            10.0.0.1           UGScIg                en7
+203.0.113.7        192.168.1.1        UGHS                  en0
+192.168.1          link#6             UCS                   en0      !
+192.168.1.1        a4:2b:b0:11:22:33  UHLWIir               en0   1187
+`
+
+func TestTunnelEgressUsesEndpointRoute(t *testing.T) {
+	got, guess := tunnelEgress(parseNetstatRoutes(netstatTunProxy))
+	if got != "en0" || guess {
+		t.Fatalf("tunnelEgress() = %q, %v; want en0 (route to the tunnel endpoint)", got, guess)
+	}
+}
+
+func TestTunnelEgressFallsBackToDefault(t *testing.T) {
+	if got, guess := tunnelEgress(parseIPRoutes(ipRouteTwoDefaults)); got != "eth0" || !guess {
+		t.Fatalf("tunnelEgress() = %q, %v; want eth0 (lowest-metric default) as a guess", got, guess)
+	}
+	// Two pinned host routes: the endpoint is ambiguous, so fall back.
+	routes := parseIPRoutes(ipRouteTwoDefaults + `203.0.113.7 via 192.168.1.1 dev wlan0
+198.51.100.2 via 10.0.0.1 dev eth0
+`)
+	if got, guess := tunnelEgress(routes); got != "eth0" || !guess {
+		t.Fatalf("tunnelEgress() = %q, %v; want eth0 as a guess", got, guess)
+	}
+	if got, guess := tunnelEgress(nil); got != "" || guess {
+		t.Fatalf("tunnelEgress(nil) = %q, %v; want empty", got, guess)
+	}
+}
+
+func TestRoutePrefix(t *testing.T) {
+	for _, tc := range []struct {
+		dest string
+		want string
+	}{
+		{"default", "0.0.0.0/0"},
+		{"0.0.0.0/0", "0.0.0.0/0"},
+		{"203.0.113.7", "203.0.113.7/32"},
+		{"10.0.0.0/24", "10.0.0.0/24"},
+		{"192.168.1", "192.168.1.0/24"},
+		{"127", "127.0.0.0/8"},
+		{"169.254/16", "169.254.0.0/16"},
+		{"link#6", ""},
+		{"fe80::1", ""},
+	} {
+		p, ok := routePrefix(tc.dest)
+		got := ""
+		if ok {
+			got = p.String()
+		}
+		if got != tc.want {
+			t.Errorf("routePrefix(%q) = %q, want %q", tc.dest, got, tc.want)
+		}
+	}
+}
+
+func TestPhysicalRouteToPrefersLongestPrefix(t *testing.T) {
+	routes := parseIPRoutes(ipRouteTwoDefaults + "0.0.0.0/1 via 10.8.0.1 dev tun0\n")
+	for addr, want := range map[string]string{
+		"10.0.0.9":    "eth0",  // 10.0.0.0/24 beats the defaults
+		"192.168.1.4": "wlan0", // 192.168.1.0/24
+		"8.8.8.8":     "eth0",  // tunnel /1 skipped; lowest-metric default
+	} {
+		if got := physicalRouteTo(routes, netip.MustParseAddr(addr)); got != want {
+			t.Errorf("physicalRouteTo(%s) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func TestKillSwitchLikely(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		routes []routeEntry
+		want   bool
+	}{
+		{"physical default beside tunnel", parseNetstatRoutes(netstatSingleDefault), false},
+		{"no tunnel", parseIPRoutes(ipRouteTwoDefaults), false},
+		{"only tunnel default", parseIPRoutes(`default dev wg0 scope link
+203.0.113.7 via 192.168.1.1 dev wlan0
+192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.20
+`), true},
+		{"split tunnel keeps physical default", parseIPRoutes(`0.0.0.0/1 via 10.8.0.1 dev tun0
+128.0.0.0/1 via 10.8.0.1 dev tun0
+default via 192.168.1.1 dev wlan0 proto dhcp metric 600
+`), false},
+		{"tunnel dropped, default withheld", parseIPRoutes(`10.8.0.0/24 dev tun0 proto kernel scope link src 10.8.0.2
+192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.20
+`), true},
+		{"no routes", nil, false},
+	} {
+		if got := killSwitchLikely(tc.routes); got != tc.want {
+			t.Errorf("%s: killSwitchLikely = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if !summarizeRoutes(parseIPRoutes("default dev wg0 scope link\n")).KillSwitchLikely {
+		t.Fatalf("summarizeRoutes should carry the kill switch hint")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// SessionInfo describes the environment status runs in, so a shared
+// snapshot shows whether it came from a local terminal or over SSH.
+type SessionInfo struct {
+	Shell string `json:"shell"` // Login shell from SHELL, e.g. /bin/zsh
+	Term  string `json:"term"`  // TERM, e.g. xterm-256color
+	SSH   bool   `json:"ssh"`
+	// SSHClient is the client address from SSH_CONNECTION.
+	SSHClient string `json:"ssh_client,omitempty"`
+}
+
+// collectSession reads SHELL, TERM and the SSH variables from getenv
+// (os.Getenv when nil). sshd sets SSH_CONNECTION for every session and
+// SSH_TTY only with a terminal, so either marks an SSH login.
+func collectSession(getenv func(string) string) SessionInfo {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	s := SessionInfo{Shell: getenv("SHELL"), Term: getenv("TERM")}
+	if conn := strings.TrimSpace(getenv("SSH_CONNECTION")); conn != "" {
+		s.SSH = true
+		s.SSHClient, _, _ = strings.Cut(conn, " ")
+	} else if getenv("SSH_TTY") != "" {
+		s.SSH = true
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestCollectSession(t *testing.T) {
+	local := map[string]string{"SHELL": "/bin/zsh", "TERM": "xterm-256color"}
+	got := collectSession(func(k string) string { return local[k] })
+	if got != (SessionInfo{Shell: "/bin/zsh", Term: "xterm-256color"}) {
+		t.Fatalf("local session = %+v", got)
+	}
+
+	remote := map[string]string{
+		"SHELL":          "/bin/bash",
+		"TERM":           "screen",
+		"SSH_CONNECTION": "203.0.113.7 52144 10.0.0.2 22",
+	}
+	got = collectSession(func(k string) string { return remote[k] })
+	want := SessionInfo{Shell: "/bin/bash", Term: "screen", SSH: true, SSHClient: "203.0.113.7"}
+	if got != want {
+		t.Fatalf("ssh session = %+v, want %+v", got, want)
+	}
+
+	ttyOnly := map[string]string{"SSH_TTY": "/dev/pts/3"}
+	if got := collectSession(func(k string) string { return ttyOnly[k] }); !got.SSH || got.SSHClient != "" {
+		t.Fatalf("SSH_TTY alone = %+v, want SSH without a client", got)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const storageArrayCacheTTL = time.Minute
+
+var procMdstatPath = "/proc/mdstat"
+
+// collectStorageArrays reports ZFS pool and mdraid array health on Linux.
+// Missing tooling yields an empty result.
+func collectStorageArrays(ctx context.Context) []ArrayStatus {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	var arrays []ArrayStatus
+	if commandExists("zpool") {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		out, err := runCmd(ctx, "zpool", "status")
+		cancel()
+		if err == nil {
+			arrays = append(arrays, parseZpoolStatus(out)...)
+		}
+	}
+	if data, err := os.ReadFile(procMdstatPath); err == nil {
+		arrays = append(arrays, parseMdstat(string(data))...)
+	}
+	return arrays
+}
+
+// parseZpoolStatus parses `zpool status` output into per-pool health.
+func parseZpoolStatus(out string) []ArrayStatus {
+	var (
+		arrays   []ArrayStatus
+		current  *ArrayStatus
+		inConfig bool
+	)
+	flush := func() {
+		if current != nil {
+			arrays = append(arrays, *current)
+			current = nil
+		}
+	}
+	for line := range strings.Lines(out) {
+		trim := strings.TrimSpace(line)
+		if after, ok := strings.CutPrefix(trim, "pool:"); ok {
+			flush()
+			current = &ArrayStatus{Name: strings.TrimSpace(after), Type: "zfs"}
+			inConfig = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if after, ok := strings.CutPrefix(trim, "state:"); ok {
+			current.State = strings.TrimSpace(after)
+			continue
+		}
+		if strings.HasPrefix(trim, "config:") {
+			inConfig = true
+			continue
+		}
+		if strings.HasPrefix(trim, "errors:") {
+			inConfig = false
+			continue
+		}
+		if !inConfig {
+			continue
+		}
+		fields := strings.Fields(trim)
+		if len(fields) < 2 || fields[0] == "NAME" || fields[0] == current.Name || isZpoolVdevGroup(fields[0]) {
+			continue
+		}
+		switch fields[1] {
+		case "DEGRADED", "FAULTED", "UNAVAIL", "OFFLINE", "REMOVED":
+			current.DegradedDisks = append(current.DegradedDisks, fields[0])
+		}
+	}
+	flush()
+	return arrays
+}
+
+func isZpoolVdevGroup(name string) bool {
+	for _, prefix := range []string{"mirror", "raidz", "draid", "spare", "logs", "cache", "special", "dedup"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMdstat parses /proc/mdstat into per-array health.
+func parseMdstat(raw string) []ArrayStatus {
+	var arrays []ArrayStatus
+	for line := range strings.Lines(raw) {
+		trim := strings.TrimSpace(line)
+		if trim == "" {
+			continue
+		}
+
+		// "md0 : active raid1 sdb1[1](F) sda1[0]"
+		if name, rest, ok := strings.Cut(trim, " : "); ok && strings.HasPrefix(name, "md") {
+			fields := strings.Fields(rest)
+			array := ArrayStatus{Name: name, Type: "mdraid", State: "ACTIVE"}
+			if len(fields) > 0 && fields[0] != "active" {
+				array.State = strings.ToUpper(fields[0])
+			}
+			for _, f := range fields {
+				if dev, ok := strings.CutSuffix(f, "(F)"); ok {
+					dev, _, _ = strings.Cut(dev, "[")
+					array.DegradedDisks = append(array.DegradedDisks, dev)
+				}
+			}
+			arrays = append(arrays, array)
+			continue
+		}
+
+		// "1048512 blocks super 1.2 [2/1] [U_]" follows the array line.
+		if len(arrays) == 0 || !strings.HasSuffix(trim, "]") {
+			continue
+		}
+		last := &arrays[len(arrays)-1]
+		idx := strings.LastIndex(trim, "[")
+		if idx >= 0 && strings.Contains(trim[idx:], "_") && last.State == "ACTIVE" {
+			last.State = "DEGRADED"
+		}
+	}
+	return arrays
+}
+
+func (c *Collector) collectStorageArrays(ctx context.Context, now time.Time) []ArrayStatus {
+	if !c.lastArrayAt.IsZero() && now.Sub(c.lastArrayAt) < storageArrayCacheTTL {
+		return c.cachedArrays
+	}
+	c.cachedArrays = collectStorageArrays(ctx)
+	c.lastArrayAt = now
+	return c.cachedArrays
+}
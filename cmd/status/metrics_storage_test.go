@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+const zpoolHealthy = `  pool: tank
+ state: ONLINE
+  scan: scrub repaired 0B in 00:10:12 with 0 errors on Sun Mar  2 00:34:13 2025
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        ONLINE       0     0     0
+	  mirror-0  ONLINE       0     0     0
+	    sda     ONLINE       0     0     0
+	    sdb     ONLINE       0     0     0
+
+errors: No known data errors
+`
+
+const zpoolDegraded = `  pool: tank
+ state: DEGRADED
+status: One or more devices could not be used because the label is missing or
+	invalid.  Sufficient replicas exist for the pool to continue
+	functioning in a degraded state.
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        DEGRADED     0     0     0
+	  mirror-0  DEGRADED     0     0     0
+	    sda     ONLINE       0     0     0
+	    sdb     UNAVAIL      0     0     0  cannot open
+
+errors: No known data errors
+
+  pool: backup
+ state: ONLINE
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	backup      ONLINE       0     0     0
+	  sdc       ONLINE       0     0     0
+
+errors: No known data errors
+`
+
+const mdstatOutput = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md0 : active raid1 sdb1[1](F) sda1[0]
+      1048512 blocks super 1.2 [2/1] [U_]
+
+md1 : active raid1 sdd1[1] sdc1[0]
+      976630464 blocks super 1.2 [2/2] [UU]
+      bitmap: 0/8 pages [0KB], 65536KB chunk
+
+unused devices: <none>
+`
+
+func TestParseZpoolStatusHealthy(t *testing.T) {
+	arrays := parseZpoolStatus(zpoolHealthy)
+	if len(arrays) != 1 {
+		t.Fatalf("expected 1 pool, got %d", len(arrays))
+	}
+	if !arrays[0].Healthy() || arrays[0].Name != "tank" {
+		t.Fatalf("expected healthy tank pool, got %+v", arrays[0])
+	}
+}
+
+func TestParseZpoolStatusDegraded(t *testing.T) {
+	arrays := parseZpoolStatus(zpoolDegraded)
+	if len(arrays) != 2 {
+		t.Fatalf("expected 2 pools, got %d", len(arrays))
+	}
+	tank := arrays[0]
+	if tank.State != "DEGRADED" || tank.Healthy() {
+		t.Fatalf("expected degraded tank, got %+v", tank)
+	}
+	if !slices.Equal(tank.DegradedDisks, []string{"sdb"}) {
+		t.Fatalf("expected only leaf sdb flagged, got %v", tank.DegradedDisks)
+	}
+	if !arrays[1].Healthy() {
+		t.Fatalf("expected healthy backup pool, got %+v", arrays[1])
+	}
+}
+
+func TestParseMdstat(t *testing.T) {
+	arrays := parseMdstat(mdstatOutput)
+	if len(arrays) != 2 {
+		t.Fatalf("expected 2 arrays, got %d", len(arrays))
+	}
+	md0 := arrays[0]
+	if md0.Name != "md0" || md0.State != "DEGRADED" || md0.Healthy() {
+		t.Fatalf("expected degraded md0, got %+v", md0)
+	}
+	if !slices.Equal(md0.DegradedDisks, []string{"sdb1"}) {
+		t.Fatalf("expected faulty sdb1, got %v", md0.DegradedDisks)
+	}
+	if md1 := arrays[1]; md1.State != "ACTIVE" || !md1.Healthy() {
+		t.Fatalf("expected healthy md1, got %+v", md1)
+	}
+}
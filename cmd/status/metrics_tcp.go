@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var procNetSNMPPath = "/proc/net/snmp"
+
+// tcpRetransWarnPercent is the retransmitted share of sent segments the
+// network card flags; healthy paths stay well under 1%.
+const tcpRetransWarnPercent = 2.0
+
+// TCPStatus is system-wide TCP retransmission activity since the previous
+// sample. A rising retransmit share points at a lossy path even when no
+// interface reports drops.
+type TCPStatus struct {
+	RetransPerSec  float64 `json:"retrans_per_sec"`
+	RetransPercent float64 `json:"retrans_percent"` // Retransmitted share of segments sent
+}
+
+// tcpCounters are cumulative TCP segment counters since boot.
+type tcpCounters struct {
+	outSegs     uint64
+	retransSegs uint64
+}
+
+func readTCPCounters(ctx context.Context) (tcpCounters, bool) {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile(procNetSNMPPath)
+		if err != nil {
+			return tcpCounters{}, false
+		}
+		return parseProcNetSNMP(string(data))
+	case "darwin":
+		ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+		out, err := runCmd(ctx, "netstat", "-s", "-p", "tcp")
+		if err != nil {
+			return tcpCounters{}, false
+		}
+		return parseNetstatTCP(out)
+	}
+	return tcpCounters{}, false
+}
+
+// parseProcNetSNMP reads OutSegs and RetransSegs from /proc/net/snmp, where
+// each protocol has a header line of names followed by a line of values.
+func parseProcNetSNMP(data string) (tcpCounters, bool) {
+	var header []string
+	for line := range strings.Lines(data) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "Tcp:" {
+			continue
+		}
+		if header == nil {
+			header = fields
+			continue
+		}
+		var c tcpCounters
+		found := 0
+		for i, name := range header {
+			if i >= len(fields) {
+				break
+			}
+			switch name {
+			case "OutSegs":
+				c.outSegs, _ = strconv.ParseUint(fields[i], 10, 64)
+				found++
+			case "RetransSegs":
+				c.retransSegs, _ = strconv.ParseUint(fields[i], 10, 64)
+				found++
+			}
+		}
+		return c, found == 2
+	}
+	return tcpCounters{}, false
+}
+
+// parseNetstatTCP reads `netstat -s -p tcp` (macOS/BSD):
+//
+//	12345 packets sent
+//	        67 data packets (8901 bytes) retransmitted
+func parseNetstatTCP(out string) (tcpCounters, bool) {
+	var c tcpCounters
+	var sent, retrans bool
+	for line := range strings.Lines(out) {
+		trim := strings.TrimSpace(line)
+		num, rest, ok := strings.Cut(trim, " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case !sent && rest == "packets sent":
+			c.outSegs, sent = n, true
+		case !retrans && strings.HasPrefix(rest, "data packets") && strings.HasSuffix(rest, "retransmitted"):
+			c.retransSegs, retrans = n, true
+		}
+	}
+	return c, sent && retrans
+}
+
+// tcpStatus computes rates from two counter samples elapsed seconds apart.
+func tcpStatus(cur, prev tcpCounters, elapsed float64) TCPStatus {
+	if elapsed <= 0 || cur.retransSegs < prev.retransSegs || cur.outSegs < prev.outSegs {
+		return TCPStatus{}
+	}
+	retrans := float64(cur.retransSegs - prev.retransSegs)
+	status := TCPStatus{RetransPerSec: retrans / elapsed}
+	if sent := float64(cur.outSegs - prev.outSegs); sent > 0 {
+		status.RetransPercent = retrans / sent * 100
+	}
+	return status
+}
+
+func (c *Collector) collectTCP(ctx context.Context, now time.Time) TCPStatus {
+	cur, ok := readTCPCounters(ctx)
+	if !ok {
+		return TCPStatus{}
+	}
+	var status TCPStatus
+	if !c.prevTCPAt.IsZero() {
+		status = tcpStatus(cur, c.prevTCP, now.Sub(c.prevTCPAt).Seconds())
+	}
+	c.prevTCP, c.prevTCPAt = cur, now
+	return status
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+const procNetSNMP = `Ip: Forwarding DefaultTTL InReceives InHdrErrors InAddrErrors ForwDatagrams InUnknownProtos InDiscards InDelivers OutRequests OutDiscards OutNoRoutes ReasmTimeout ReasmReqds ReasmOKs ReasmFails FragOKs FragFails FragCreates
+Ip: 1 64 129853468 0 2 0 0 0 129793620 104353215 1820 30 0 0 0 0 0 0 0
+Icmp: InMsgs InErrors InCsumErrors InDestUnreachs InTimeExcds InParmProbs InSrcQuenchs InRedirects InEchos InEchoReps InTimestamps InTimestampReps InAddrMasks InAddrMaskReps OutMsgs OutErrors OutDestUnreachs OutTimeExcds OutParmProbs OutSrcQuenchs OutRedirects OutEchos OutEchoReps OutTimestamps OutTimestampReps OutAddrMasks OutAddrMaskReps
+Icmp: 6839 49 0 6761 25 0 0 0 53 0 0 0 0 0 6850 0 6797 0 0 0 0 0 53 0 0 0 0
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 2458730 657587 47328 280216 42 127259683 145378201 184591 57 1071222 0
+Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+Udp: 2456519 6787 0 2512745 0 0 0 2163 0
+`
+
+const netstatTCPOutput = `tcp:
+	8254113 packets sent
+		6124635 data packets (4377221961 bytes)
+		18142 data packets (20426065 bytes) retransmitted
+		0 resends initiated by MTU discovery
+		1510568 ack-only packets (87430 delayed)
+	10720371 packets received
+		5732840 acks (for 4377391172 bytes)
+		189 duplicate acks
+`
+
+func TestParseProcNetSNMP(t *testing.T) {
+	got, ok := parseProcNetSNMP(procNetSNMP)
+	if !ok || got.outSegs != 145378201 || got.retransSegs != 184591 {
+		t.Fatalf("parseProcNetSNMP() = %+v, %v", got, ok)
+	}
+	if _, ok := parseProcNetSNMP("Ip: Forwarding\nIp: 1\n"); ok {
+		t.Fatal("expected ok=false without a Tcp section")
+	}
+}
+
+func TestParseNetstatTCP(t *testing.T) {
+	got, ok := parseNetstatTCP(netstatTCPOutput)
+	if !ok || got.outSegs != 8254113 || got.retransSegs != 18142 {
+		t.Fatalf("parseNetstatTCP() = %+v, %v", got, ok)
+	}
+}
+
+func TestTCPStatusRates(t *testing.T) {
+	prev := tcpCounters{outSegs: 1000, retransSegs: 10}
+	cur := tcpCounters{outSegs: 3000, retransSegs: 50}
+	got := tcpStatus(cur, prev, 2)
+	if got.RetransPerSec != 20 || math.Abs(got.RetransPercent-2) > 1e-9 {
+		t.Fatalf("tcpStatus() = %+v, want 20/s and 2%%", got)
+	}
+	if got := tcpStatus(prev, cur, 2); got != (TCPStatus{}) {
+		t.Fatalf("counters going backwards = %+v, want zero", got)
+	}
+}
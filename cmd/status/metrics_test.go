@@ -1,10 +1,54 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
 	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+// recordingHandler captures slog records for assertions.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+// attrs returns the string attributes of records with the given message.
+func (h *recordingHandler) attrs(msg string) []map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []map[string]string
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		m := make(map[string]string)
+		r.Attrs(func(a slog.Attr) bool {
+			m[a.Key] = a.Value.String()
+			return true
+		})
+		out = append(out, m)
+	}
+	return out
+}
+
 func TestNewRingBuffer(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -179,3 +223,133 @@ func TestRingBuffer_NegativeAndZeroValues(t *testing.T) {
 		t.Errorf("Slice() with negative/zero values = %v, want %v", got, want)
 	}
 }
+
+func TestSnapshotSchemaVersion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs a full collection")
+	}
+	snap, _ := NewCollector().Collect()
+	if snap.SchemaVersion != SnapshotSchemaVersion {
+		t.Fatalf("SchemaVersion = %q, want %q", snap.SchemaVersion, SnapshotSchemaVersion)
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"schema_version":"` + SnapshotSchemaVersion + `",`
+	if !strings.HasPrefix(string(data), want) {
+		t.Fatalf("schema_version should be the first JSON field, got %.60s", data)
+	}
+}
+
+func TestLowPowerSkipsExecCollectors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs a full collection")
+	}
+	var mu sync.Mutex
+	var ran []string
+	orig := runCmd
+	runCmd = func(_ context.Context, name string, args ...string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, strings.Join(append([]string{name}, args...), " "))
+		return "", errors.New("recorded")
+	}
+	t.Cleanup(func() { runCmd = orig })
+
+	// Exec-heavy collectors that LowPower must not reach. Cheap per-tick
+	// readers (vm_stat, sysctl, pmset, scutil) are allowed.
+	heavy := []string{"nvidia-smi", "system_profiler", "powermetrics", "bluetoothctl", "zpool", "netstat", "ip ", "ioreg", "ps -Aceo pid", "ethtool"}
+	ranHeavy := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var hits []string
+		for _, cmd := range ran {
+			for _, h := range heavy {
+				if strings.HasPrefix(cmd+" ", h) {
+					hits = append(hits, cmd)
+				}
+			}
+		}
+		ran = nil
+		return hits
+	}
+
+	c := NewCollector()
+	c.hasStatic = true // Hardware info is collected once regardless.
+	c.lastHWAt = time.Now()
+
+	c.LowPower = true
+	c.Collect()
+	if hits := ranHeavy(); len(hits) != 0 {
+		t.Fatalf("LowPower ran exec-heavy collectors: %v", hits)
+	}
+
+	// The recorder does see them in normal mode (route lookup always runs).
+	c.LowPower = false
+	c.Collect()
+	if hits := ranHeavy(); len(hits) == 0 {
+		t.Fatal("expected normal mode to run exec-based collectors")
+	}
+}
+
+func TestRingBuffer_Percentile(t *testing.T) {
+	rb := NewRingBuffer(10)
+	if got := rb.Percentile(50); got != 0 {
+		t.Fatalf("empty Percentile(50) = %v, want 0", got)
+	}
+
+	// Partially filled: only the five values count, not the empty slots.
+	for _, v := range []float64{5, 1, 4, 2, 3} {
+		rb.Add(v)
+	}
+	for p, want := range map[float64]float64{0: 1, 50: 3, 100: 5, 25: 2, 90: 4.6} {
+		if got := rb.Percentile(p); math.Abs(got-want) > 1e-9 {
+			t.Errorf("partial Percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+
+	// Wrapped: 1..10 then 11..15 leaves 6..15 in the window.
+	for v := 6; v <= 15; v++ {
+		rb.Add(float64(v))
+	}
+	for p, want := range map[float64]float64{50: 10.5, 95: 14.55, 99: 14.91} {
+		if got := rb.Percentile(p); math.Abs(got-want) > 1e-9 {
+			t.Errorf("wrapped Percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestInterfacePercentiles(t *testing.T) {
+	c := NewCollector()
+	for i := 1; i <= 100; i++ {
+		c.addInterfaceHistory("en0", float64(i), float64(i)/10)
+		c.rxHistoryBuf.Add(float64(i))
+	}
+	rx, tx, ok := c.InterfacePercentiles("en0", 95)
+	// The window holds the last NetworkHistorySize (120) samples: all 100.
+	if !ok || math.Abs(rx-95.05) > 1e-9 || math.Abs(tx-9.505) > 1e-9 {
+		t.Fatalf("InterfacePercentiles(en0, 95) = %v, %v, %v", rx, tx, ok)
+	}
+	if got := c.RxPercentile(50); math.Abs(got-50.5) > 1e-9 {
+		t.Fatalf("RxPercentile(50) = %v, want 50.5", got)
+	}
+	if _, _, ok := c.InterfacePercentiles("en9", 50); ok {
+		t.Fatal("expected ok=false for an unknown interface")
+	}
+}
+
+func TestRingBuffer_MeanStdDev(t *testing.T) {
+	rb := NewRingBuffer(8)
+	if _, _, n := rb.MeanStdDev(); n != 0 {
+		t.Fatalf("empty buffer n = %d", n)
+	}
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		rb.Add(v)
+	}
+	mean, stddev, n := rb.MeanStdDev()
+	if mean != 5 || stddev != 2 || n != 8 {
+		t.Fatalf("MeanStdDev() = %v, %v, %d; want 5, 2, 8", mean, stddev, n)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/sensors"
+)
+
+// Linux throttling inference: a CPU running well under its maximum clock
+// while hot is most likely held back by the thermal governor, not idle
+// frequency scaling.
+const (
+	throttleFreqRatio = 0.8
+	throttleTempC     = 85.0
+)
+
+// cpuFreqFunc returns the mean current and maximum frequency in kHz across
+// CPUs. It is a variable so tests can supply readings.
+var cpuFreqFunc = func() (curKHz, maxKHz float64, err error) {
+	if runtime.GOOS != "linux" {
+		return 0, 0, errors.ErrUnsupported
+	}
+	dirs, _ := filepath.Glob("/sys/devices/system/cpu/cpu[0-9]*/cpufreq")
+	n := 0
+	for _, dir := range dirs {
+		cur, err1 := readKHz(filepath.Join(dir, "scaling_cur_freq"))
+		maxFreq, err2 := readKHz(filepath.Join(dir, "cpuinfo_max_freq"))
+		if err1 != nil || err2 != nil || maxFreq <= 0 {
+			continue
+		}
+		curKHz += cur
+		maxKHz += maxFreq
+		n++
+	}
+	if n == 0 {
+		return 0, 0, errors.New("no cpufreq data")
+	}
+	return curKHz / float64(n), maxKHz / float64(n), nil
+}
+
+func readKHz(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+// CollectThermalState reports thermal throttling. macOS reads the CPU speed
+// and scheduler limits from `pmset -g therm`; Linux has no such limits and
+// infers Throttled from a low clock at a high CPU temperature. Only the
+// throttling fields of the result are set.
+func CollectThermalState(ctx context.Context) (ThermalStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := runCmd(ctx, "pmset", "-g", "therm")
+		if err != nil {
+			return ThermalStatus{}, err
+		}
+		return parsePmsetTherm(out), nil
+	case "linux":
+		cur, maxFreq, err := cpuFreqFunc()
+		if err != nil {
+			return ThermalStatus{}, err
+		}
+		stats, _ := sensorTemperaturesFunc(ctx)
+		return ThermalStatus{Throttled: inferThrottled(cur, maxFreq, hottestCPUTemp(stats))}, nil
+	}
+	return ThermalStatus{}, errors.New("unsupported platform")
+}
+
+// parsePmsetTherm reads the CPU_Speed_Limit and CPU_Scheduler_Limit
+// percentages from `pmset -g therm`. Without them (the "No CPU power status
+// has been recorded" note, usual on Apple Silicon) the CPU is unrestricted
+// and both limits are 100.
+func parsePmsetTherm(out string) ThermalStatus {
+	status := ThermalStatus{CPUSpeedLimit: 100, SchedulerLimit: 100}
+	for line := range strings.Lines(out) {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "CPU_Speed_Limit":
+			status.CPUSpeedLimit = n
+		case "CPU_Scheduler_Limit":
+			status.SchedulerLimit = n
+		}
+	}
+	status.Throttled = status.CPUSpeedLimit < 100 || status.SchedulerLimit < 100
+	return status
+}
+
+// inferThrottled reports a clock below throttleFreqRatio of its maximum at
+// or above throttleTempC. Unknown readings (zero) never count.
+func inferThrottled(curKHz, maxKHz, tempC float64) bool {
+	return maxKHz > 0 && curKHz > 0 && curKHz < maxKHz*throttleFreqRatio && tempC >= throttleTempC
+}
+
+// hottestCPUTemp is the highest reading from CPU package and core sensors
+// (Intel coretemp, AMD k10temp and zenpower, ARM cpu_thermal).
+func hottestCPUTemp(stats []sensors.TemperatureStat) float64 {
+	var hottest float64
+	for _, s := range stats {
+		for _, prefix := range []string{"coretemp_", "k10temp_", "zenpower_", "cpu_thermal"} {
+			if strings.HasPrefix(s.SensorKey, prefix) {
+				hottest = max(hottest, s.Temperature)
+				break
+			}
+		}
+	}
+	return hottest
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/sensors"
+)
+
+func TestParsePmsetTherm(t *testing.T) {
+	throttled := `Note: No thermal warning level has been recorded
+Note: No performance warning level has been recorded
+2024-03-04 10:12:33 +0100 CPU Power notify
+	CPU_Scheduler_Limit 	= 100
+	CPU_Available_CPUs 	= 8
+	CPU_Speed_Limit 	= 72
+`
+	normal := `Note: No thermal warning level has been recorded
+Note: No performance warning level has been recorded
+Note: No CPU power status has been recorded
+`
+	tests := []struct {
+		name  string
+		out   string
+		speed int
+		sched int
+		want  bool
+	}{
+		{"throttled", throttled, 72, 100, true},
+		{"normal", normal, 100, 100, false},
+		{"scheduler only", "CPU_Scheduler_Limit = 50\nCPU_Speed_Limit = 100\n", 100, 50, true},
+	}
+	for _, tt := range tests {
+		got := parsePmsetTherm(tt.out)
+		if got.CPUSpeedLimit != tt.speed || got.SchedulerLimit != tt.sched || got.Throttled != tt.want {
+			t.Errorf("%s: parsePmsetTherm() = %+v, want speed %d sched %d throttled %v", tt.name, got, tt.speed, tt.sched, tt.want)
+		}
+	}
+}
+
+func TestInferThrottled(t *testing.T) {
+	tests := []struct {
+		cur, max, temp float64
+		want           bool
+	}{
+		{1_200_000, 3_600_000, 95, true},
+		{1_200_000, 3_600_000, 50, false}, // Idle scaling, not heat
+		{3_500_000, 3_600_000, 95, false}, // Hot but at full clock
+		{0, 3_600_000, 95, false},
+		{1_200_000, 0, 95, false},
+	}
+	for _, tt := range tests {
+		if got := inferThrottled(tt.cur, tt.max, tt.temp); got != tt.want {
+			t.Errorf("inferThrottled(%v, %v, %v) = %v, want %v", tt.cur, tt.max, tt.temp, got, tt.want)
+		}
+	}
+}
+
+func TestHottestCPUTempIgnoresOtherSensors(t *testing.T) {
+	stats := []sensors.TemperatureStat{
+		{SensorKey: "coretemp_package_id_0", Temperature: 88},
+		{SensorKey: "coretemp_core_1", Temperature: 91},
+		{SensorKey: "nvme_composite", Temperature: 99},
+		{SensorKey: "acpitz", Temperature: 40},
+	}
+	if got := hottestCPUTemp(stats); got != 91 {
+		t.Fatalf("hottestCPUTemp() = %v, want 91", got)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	timeSyncCacheTTL = 5 * time.Minute
+	timeSyncTimeout  = 3 * time.Second
+	// maxSyncedOffsetMs is the offset below which a clock counts as
+	// synchronized when the OS does not say so itself.
+	maxSyncedOffsetMs = 100
+)
+
+// TimeSyncStatus reports whether the system clock is kept in sync. OffsetMs
+// is the local clock minus the reference; HasOffset is false when the
+// platform reported no offset.
+type TimeSyncStatus struct {
+	Checked      bool    `json:"checked"`
+	Synchronized bool    `json:"synchronized"`
+	OffsetMs     float64 `json:"offset_ms"`
+	HasOffset    bool    `json:"has_offset"`
+}
+
+// CollectTimeSync reads time sync state from timedatectl on Linux, and from
+// sntp (offset) and systemsetup (network time setting) on macOS.
+func CollectTimeSync(ctx context.Context) (TimeSyncStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeSyncTimeout)
+	defer cancel()
+	switch runtime.GOOS {
+	case "linux":
+		return collectTimedatectl(ctx)
+	case "darwin":
+		return collectDarwinTimeSync(ctx)
+	}
+	return TimeSyncStatus{}, errors.New("unsupported platform")
+}
+
+func collectTimedatectl(ctx context.Context) (TimeSyncStatus, error) {
+	out, err := runCmd(ctx, "timedatectl", "show")
+	if err != nil {
+		return TimeSyncStatus{}, err
+	}
+	synced, ok := parseTimedatectlShow(out)
+	if !ok {
+		return TimeSyncStatus{}, errors.New("no NTPSynchronized in timedatectl output")
+	}
+	status := TimeSyncStatus{Checked: true, Synchronized: synced}
+	// timesync-status only exists with systemd-timesyncd; chrony and ntpd
+	// setups simply report no offset.
+	if out, err := runCmd(ctx, "timedatectl", "timesync-status"); err == nil {
+		status.OffsetMs, status.HasOffset = parseTimesyncOffset(out)
+	}
+	return status, nil
+}
+
+func collectDarwinTimeSync(ctx context.Context) (TimeSyncStatus, error) {
+	status := TimeSyncStatus{Checked: true}
+	sntpOut, sntpErr := runCmd(ctx, "sntp", "-t", "1", "time.apple.com")
+	if sntpErr == nil {
+		status.OffsetMs, status.HasOffset = parseSNTPOffset(sntpOut)
+	}
+	// systemsetup needs admin rights; without it, judge by the offset.
+	if out, err := runCmd(ctx, "systemsetup", "-getusingnetworktime"); err == nil {
+		if on, ok := parseNetworkTime(out); ok {
+			status.Synchronized = on && (!status.HasOffset || math.Abs(status.OffsetMs) < maxSyncedOffsetMs)
+			return status, nil
+		}
+	}
+	if !status.HasOffset {
+		if sntpErr == nil {
+			sntpErr = errors.New("no offset in sntp output")
+		}
+		return TimeSyncStatus{}, sntpErr
+	}
+	status.Synchronized = math.Abs(status.OffsetMs) < maxSyncedOffsetMs
+	return status, nil
+}
+
+// parseTimedatectlShow reads NTPSynchronized=yes|no from timedatectl show.
+func parseTimedatectlShow(out string) (synced, ok bool) {
+	for line := range strings.Lines(out) {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "NTPSynchronized="); found {
+			return value == "yes", true
+		}
+	}
+	return false, false
+}
+
+// parseTimesyncOffset reads the "Offset: -1.234ms" line of timedatectl
+// timesync-status. systemd prints the unit as us, ms, s or min.
+func parseTimesyncOffset(out string) (float64, bool) {
+	for line := range strings.Lines(out) {
+		value, found := strings.CutPrefix(strings.TrimSpace(line), "Offset:")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		for _, u := range []struct {
+			suffix string
+			ms     float64
+		}{{"us", 0.001}, {"μs", 0.001}, {"ms", 1}, {"min", 60000}, {"s", 1000}} {
+			if num, ok := strings.CutSuffix(value, u.suffix); ok {
+				v, err := strconv.ParseFloat(num, 64)
+				if err != nil {
+					return 0, false
+				}
+				return v * u.ms, true
+			}
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// parseSNTPOffset reads the offset in seconds from sntp output such as
+// "+0.012345 +/- 0.023456 time.apple.com 17.253.34.125".
+func parseSNTPOffset(out string) (float64, bool) {
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "+/-" {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, false
+		}
+		return v * 1000, true
+	}
+	return 0, false
+}
+
+// parseNetworkTime reads "Network Time: On|Off" from systemsetup.
+func parseNetworkTime(out string) (on, ok bool) {
+	value, found := strings.CutPrefix(strings.TrimSpace(out), "Network Time:")
+	if !found {
+		return false, false
+	}
+	return strings.EqualFold(strings.TrimSpace(value), "on"), true
+}
+
+// collectTimeSync runs CollectTimeSync when TimeSync is enabled. Sync state
+// moves slowly and sntp queries the network, so results are cached.
+func (c *Collector) collectTimeSync(ctx context.Context, now time.Time) TimeSyncStatus {
+	if !c.TimeSync {
+		return TimeSyncStatus{}
+	}
+	if !c.lastTimeSyncAt.IsZero() && now.Sub(c.lastTimeSyncAt) < c.ttl(timeSyncCacheTTL) {
+		return c.cachedTimeSync
+	}
+	status, err := CollectTimeSync(ctx)
+	if err != nil {
+		logDegraded(c.logger(), "time_sync", err)
+	}
+	c.cachedTimeSync = status
+	c.lastTimeSyncAt = now
+	return status
+}
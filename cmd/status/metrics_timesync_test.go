@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+const timedatectlShowSynced = `Timezone=Europe/Berlin
+LocalRTC=no
+CanNTP=yes
+NTP=yes
+NTPSynchronized=yes
+TimeUSec=Wed 2026-10-14 10:21:33 CEST
+RTCTimeUSec=Wed 2026-10-14 10:21:33 CEST
+`
+
+const timesyncStatus = `       Server: 185.125.190.56 (ntp.ubuntu.com)
+Poll interval: 34min 8s (min: 32s; max 34min 8s)
+         Leap: normal
+      Version: 4
+      Stratum: 2
+    Reference: 4FF3E6A5
+    Precision: 1us (-25)
+Root distance: 26.476ms (max: 5s)
+       Offset: -1.483ms
+        Delay: 31.262ms
+       Jitter: 2.108ms
+ Packet count: 14
+`
+
+func TestParseTimedatectlShow(t *testing.T) {
+	if synced, ok := parseTimedatectlShow(timedatectlShowSynced); !ok || !synced {
+		t.Fatalf("synced output: got synced=%v ok=%v", synced, ok)
+	}
+	unsynced := "NTP=no\nNTPSynchronized=no\n"
+	if synced, ok := parseTimedatectlShow(unsynced); !ok || synced {
+		t.Fatalf("unsynced output: got synced=%v ok=%v", synced, ok)
+	}
+	if _, ok := parseTimedatectlShow("Timezone=UTC\n"); ok {
+		t.Fatal("output without NTPSynchronized should not parse")
+	}
+}
+
+func TestParseTimesyncOffset(t *testing.T) {
+	if ms, ok := parseTimesyncOffset(timesyncStatus); !ok || math.Abs(ms+1.483) > 1e-9 {
+		t.Fatalf("parseTimesyncOffset() = %v, %v", ms, ok)
+	}
+	for out, want := range map[string]float64{
+		"Offset: +250us\n": 0.25,
+		"Offset: +2.5s\n":  2500,
+		"Offset: -3min\n":  -180000,
+	} {
+		if ms, ok := parseTimesyncOffset(out); !ok || math.Abs(ms-want) > 1e-9 {
+			t.Errorf("parseTimesyncOffset(%q) = %v, %v; want %v", out, ms, ok, want)
+		}
+	}
+}
+
+func TestParseDarwinTimeSync(t *testing.T) {
+	if ms, ok := parseSNTPOffset("+0.012345 +/- 0.023456 time.apple.com 17.253.34.125\n"); !ok || math.Abs(ms-12.345) > 1e-9 {
+		t.Fatalf("parseSNTPOffset() = %v, %v", ms, ok)
+	}
+	if _, ok := parseSNTPOffset("sntp: Exchange failed: Timeout\n"); ok {
+		t.Fatal("a failed sntp exchange should not parse")
+	}
+	if on, ok := parseNetworkTime("Network Time: On\n"); !ok || !on {
+		t.Fatalf("network time on: got %v %v", on, ok)
+	}
+	if on, ok := parseNetworkTime("Network Time: Off\n"); !ok || on {
+		t.Fatalf("network time off: got %v %v", on, ok)
+	}
+}
+
+func TestCollectTimeSyncIsOptIn(t *testing.T) {
+	if got := NewCollector().collectTimeSync(context.Background(), time.Now()); got.Checked {
+		t.Fatalf("time sync should not be collected unless enabled, got %+v", got)
+	}
+}
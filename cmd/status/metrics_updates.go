@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	updatesCacheTTL = 30 * time.Minute
+	// updatesTimeout is generous: dnf and brew read large metadata caches.
+	updatesTimeout = 20 * time.Second
+)
+
+// UpdateStatus counts pending package updates. The package lists are read
+// from the manager's local cache, which its own timers or the admin keep
+// fresh; nothing is downloaded. Manager is "unsupported" when no known
+// package manager is installed.
+type UpdateStatus struct {
+	Checked   bool   `json:"checked"`
+	Manager   string `json:"manager"` // apt, dnf, brew or unsupported
+	Available int    `json:"available"`
+	Security  int    `json:"security"`
+	// SecurityUnknown is set for managers that do not flag security fixes
+	// (brew), where Security is always 0.
+	SecurityUnknown bool `json:"security_unknown,omitempty"`
+}
+
+// updateManager is one package manager and how to count its updates.
+type updateManager struct {
+	name    string
+	goos    string
+	tool    string
+	collect func(ctx context.Context) (UpdateStatus, error)
+}
+
+var updateManagers = []updateManager{
+	{"apt", "linux", "apt", collectAptUpdates},
+	{"dnf", "linux", "dnf", collectDnfUpdates},
+	{"brew", "darwin", "brew", collectBrewUpdates},
+}
+
+// CollectUpdates counts pending updates with the first package manager
+// found for this platform.
+func CollectUpdates(ctx context.Context) (UpdateStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, updatesTimeout)
+	defer cancel()
+	for _, m := range updateManagers {
+		if m.goos != runtime.GOOS || !commandExists(m.tool) {
+			continue
+		}
+		status, err := m.collect(ctx)
+		status.Manager = m.name
+		status.Checked = err == nil
+		return status, err
+	}
+	return UpdateStatus{Manager: "unsupported"}, errors.New("no supported package manager")
+}
+
+func collectAptUpdates(ctx context.Context) (UpdateStatus, error) {
+	out, err := runCmd(ctx, "apt", "list", "--upgradable")
+	if err != nil {
+		return UpdateStatus{}, err
+	}
+	available, security := parseAptUpgradable(out)
+	return UpdateStatus{Available: available, Security: security}, nil
+}
+
+// parseAptUpgradable counts `apt list --upgradable` lines such as
+//
+//	libssl3/jammy-updates,jammy-security 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]
+//
+// A package is a security update when one of its suites (after the "/")
+// is a -security pocket.
+func parseAptUpgradable(out string) (available, security int) {
+	for line := range strings.Lines(out) {
+		if !strings.Contains(line, "[upgradable from:") {
+			continue
+		}
+		available++
+		pkg, _, _ := strings.Cut(line, " ")
+		_, suites, _ := strings.Cut(pkg, "/")
+		for suite := range strings.SplitSeq(suites, ",") {
+			if strings.HasSuffix(suite, "-security") {
+				security++
+				break
+			}
+		}
+	}
+	return available, security
+}
+
+// collectDnfUpdates uses -C so dnf answers from its metadata cache instead
+// of refreshing repositories over the network.
+func collectDnfUpdates(ctx context.Context) (UpdateStatus, error) {
+	out, err := runCmd(ctx, "dnf", "-C", "-q", "list", "--upgrades")
+	if err != nil {
+		return UpdateStatus{}, err
+	}
+	status := UpdateStatus{Available: parseDnfUpgrades(out)}
+	if sec, err := runCmd(ctx, "dnf", "-C", "-q", "updateinfo", "list", "--security"); err == nil {
+		status.Security = parseDnfSecurity(sec)
+	}
+	return status, nil
+}
+
+// parseDnfUpgrades counts `dnf list --upgrades` rows, "name.arch version
+// repo", skipping the "Available Upgrades" header.
+func parseDnfUpgrades(out string) int {
+	n := 0
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && strings.Contains(fields[0], ".") {
+			n++
+		}
+	}
+	return n
+}
+
+// parseDnfSecurity counts the packages in `dnf updateinfo list --security`
+// rows, "ADVISORY SEVERITY/Sec. package-version.arch". A package fixed by
+// several advisories counts once.
+func parseDnfSecurity(out string) int {
+	pkgs := make(map[string]bool)
+	for line := range strings.Lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && strings.HasSuffix(fields[1], "Sec.") {
+			pkgs[fields[2]] = true
+		}
+	}
+	return len(pkgs)
+}
+
+func collectBrewUpdates(ctx context.Context) (UpdateStatus, error) {
+	out, err := runCmd(ctx, "brew", "outdated", "--json=v2")
+	if err != nil {
+		return UpdateStatus{}, err
+	}
+	n, err := parseBrewOutdated(out)
+	if err != nil {
+		return UpdateStatus{}, err
+	}
+	return UpdateStatus{Available: n, SecurityUnknown: true}, nil
+}
+
+// parseBrewOutdated counts the formulae and casks in `brew outdated
+// --json=v2`.
+func parseBrewOutdated(out string) (int, error) {
+	var outdated struct {
+		Formulae []json.RawMessage `json:"formulae"`
+		Casks    []json.RawMessage `json:"casks"`
+	}
+	if err := json.Unmarshal([]byte(out), &outdated); err != nil {
+		return 0, err
+	}
+	return len(outdated.Formulae) + len(outdated.Casks), nil
+}
+
+// collectUpdates runs CollectUpdates when Updates is enabled, cached for
+// updatesCacheTTL since the package managers take seconds to answer.
+func (c *Collector) collectUpdates(ctx context.Context, now time.Time) UpdateStatus {
+	if !c.Updates {
+		return UpdateStatus{}
+	}
+	if !c.lastUpdatesAt.IsZero() && now.Sub(c.lastUpdatesAt) < c.ttl(updatesCacheTTL) {
+		return c.cachedUpdates
+	}
+	status, err := CollectUpdates(ctx)
+	if err != nil {
+		logDegraded(c.logger(), "updates", err)
+	}
+	c.cachedUpdates = status
+	c.lastUpdatesAt = now
+	return status
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseAptUpgradable(t *testing.T) {
+	out := `Listing... Done
+libssl3/jammy-updates,jammy-security 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]
+openssl/jammy-updates,jammy-security 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]
+snapd/jammy-updates 2.61.3+22.04 amd64 [upgradable from: 2.61.2+22.04]
+curl/stable-security 7.88.1-10+deb12u5 amd64 [upgradable from: 7.88.1-10+deb12u4]
+`
+	available, security := parseAptUpgradable(out)
+	if available != 4 || security != 3 {
+		t.Fatalf("parseAptUpgradable() = %d, %d; want 4, 3", available, security)
+	}
+	if available, security := parseAptUpgradable("Listing... Done\n"); available != 0 || security != 0 {
+		t.Fatalf("empty list = %d, %d", available, security)
+	}
+}
+
+func TestParseDnfUpgrades(t *testing.T) {
+	out := `Available Upgrades
+curl.x86_64                        8.2.1-4.fc39                updates
+kernel-core.x86_64                 6.8.9-100.fc39              updates
+libcurl.x86_64                     8.2.1-4.fc39                updates
+`
+	if got := parseDnfUpgrades(out); got != 3 {
+		t.Fatalf("parseDnfUpgrades() = %d, want 3", got)
+	}
+}
+
+func TestParseDnfSecurity(t *testing.T) {
+	out := `FEDORA-2024-1a2b3c4d5e Important/Sec. curl-8.2.1-4.fc39.x86_64
+FEDORA-2024-1a2b3c4d5e Important/Sec. libcurl-8.2.1-4.fc39.x86_64
+FEDORA-2024-9f8e7d6c5b Moderate/Sec.  curl-8.2.1-4.fc39.x86_64
+FEDORA-2024-0000000000 bugfix         kernel-core-6.8.9-100.fc39.x86_64
+`
+	if got := parseDnfSecurity(out); got != 2 {
+		t.Fatalf("parseDnfSecurity() = %d, want 2", got)
+	}
+}
+
+func TestParseBrewOutdated(t *testing.T) {
+	out := `{"formulae":[{"name":"git","installed_versions":["2.44.0"],"current_version":"2.45.1"},{"name":"node"}],"casks":[{"name":"firefox"}]}`
+	got, err := parseBrewOutdated(out)
+	if err != nil || got != 3 {
+		t.Fatalf("parseBrewOutdated() = %d, %v; want 3", got, err)
+	}
+	if _, err := parseBrewOutdated("Error: not json"); err == nil {
+		t.Fatal("expected an error for non-JSON output")
+	}
+}
+
+func TestCollectUpdatesCached(t *testing.T) {
+	orig := runCmd
+	t.Cleanup(func() { runCmd = orig })
+	calls := 0
+	runCmd = func(ctx context.Context, name string, args ...string) (string, error) {
+		calls++
+		return "", nil
+	}
+
+	c := &Collector{}
+	if got := c.collectUpdates(context.Background(), time.Now()); got != (UpdateStatus{}) || calls != 0 {
+		t.Fatalf("disabled collector = %+v after %d calls", got, calls)
+	}
+
+	c.Updates = true
+	now := time.Now()
+	first := c.collectUpdates(context.Background(), now)
+	if first.Manager == "" {
+		t.Fatal("expected a manager or unsupported")
+	}
+	before := calls
+	if got := c.collectUpdates(context.Background(), now.Add(time.Minute)); got != first || calls != before {
+		t.Fatalf("second call within TTL ran %d commands, got %+v", calls-before, got)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wifiCacheTTL = 10 * time.Second
+	// wifiLinkCacheTTL spaces out band and PHY lookups; system_profiler
+	// takes a second or more. A new SSID refreshes them at once.
+	wifiLinkCacheTTL = time.Minute
+	wifiLinkTimeout  = 5 * time.Second
+)
+
+// WiFiStatus is the current wireless association, if any.
+type WiFiStatus struct {
+	Connected bool   `json:"connected"`
+	SSID      string `json:"ssid"`
+	Interface string `json:"interface,omitempty"`
+	// The radio side of the association; empty when the platform tool is
+	// missing or did not say. A 2.4GHz band explains full bars but slow.
+	Band            string `json:"band,omitempty"`              // 2.4GHz, 5GHz or 6GHz
+	ChannelWidthMHz int    `json:"channel_width_mhz,omitempty"` // 20, 40, 80, 160 or 320
+	PHYMode         string `json:"phy_mode,omitempty"`          // e.g. 802.11ax
+}
+
+func collectWiFi(ctx context.Context) WiFiStatus {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	switch runtime.GOOS {
+	case "darwin":
+		// en0 is the built-in Wi-Fi port on every current Mac.
+		out, err := runCmd(ctx, "networksetup", "-getairportnetwork", "en0")
+		if err != nil {
+			return WiFiStatus{}
+		}
+		return parseAirportNetwork(out, "en0")
+	case "linux":
+		if commandExists("nmcli") {
+			if out, err := runCmd(ctx, "nmcli", "-t", "-f", "active,ssid,device", "dev", "wifi"); err == nil {
+				return parseNmcliWiFi(out)
+			}
+		}
+		if commandExists("iwgetid") {
+			if out, err := runCmd(ctx, "iwgetid", "-r"); err == nil {
+				if ssid := strings.TrimSpace(out); ssid != "" {
+					return WiFiStatus{Connected: true, SSID: ssid}
+				}
+			}
+		}
+	}
+	return WiFiStatus{}
+}
+
+// parseAirportNetwork parses `networksetup -getairportnetwork` output:
+// "Current Wi-Fi Network: Name", or a "not associated" message.
+func parseAirportNetwork(out, iface string) WiFiStatus {
+	_, ssid, ok := strings.Cut(strings.TrimSpace(out), "Network: ")
+	if !ok || ssid == "" {
+		return WiFiStatus{}
+	}
+	return WiFiStatus{Connected: true, SSID: ssid, Interface: iface}
+}
+
+// parseNmcliWiFi parses `nmcli -t -f active,ssid,device dev wifi` output,
+// returning the active network. Colons inside the SSID are escaped as "\:".
+func parseNmcliWiFi(out string) WiFiStatus {
+	for line := range strings.Lines(out) {
+		line = strings.TrimSpace(line)
+		rest, ok := strings.CutPrefix(line, "yes:")
+		if !ok {
+			continue
+		}
+		// The device is after the last unescaped colon.
+		i := strings.LastIndex(rest, ":")
+		if i < 0 {
+			continue
+		}
+		ssid := strings.ReplaceAll(rest[:i], `\:`, ":")
+		if ssid == "" {
+			continue
+		}
+		return WiFiStatus{Connected: true, SSID: ssid, Interface: rest[i+1:]}
+	}
+	return WiFiStatus{}
+}
+
+// collectWiFiLink reads band, channel width and PHY mode for the current
+// association, returned in an otherwise empty WiFiStatus: `iw dev <iface> link` on Linux, system_profiler on macOS.
+func collectWiFiLink(ctx context.Context, iface string) WiFiStatus {
+	ctx, cancel := context.WithTimeout(ctx, wifiLinkTimeout)
+	defer cancel()
+
+	switch runtime.GOOS {
+	case "darwin":
+		if out, err := runCmd(ctx, "system_profiler", "SPAirPortDataType"); err == nil {
+			return parseAirportProfile(out)
+		}
+	case "linux":
+		if iface != "" && commandExists("iw") {
+			if out, err := runCmd(ctx, "iw", "dev", iface, "link"); err == nil {
+				return parseIWLink(out)
+			}
+		}
+	}
+	return WiFiStatus{}
+}
+
+// parseIWLink parses `iw dev <iface> link` output. The band comes from
+// "freq:", the width and PHY generation from the "tx bitrate:" line, e.g.
+// "tx bitrate: 960.7 MBit/s 80MHz HE-MCS 9 HE-NSS 2 HE-GI 0 HE-DCM 0".
+func parseIWLink(out string) WiFiStatus {
+	var link WiFiStatus
+	for line := range strings.Lines(out) {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "freq:"); ok {
+			if mhz, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				link.Band = wifiBand(mhz)
+			}
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "tx bitrate:")
+		if !ok {
+			continue
+		}
+		// Without a width token the channel is 20MHz; legacy rates carry
+		// no MCS token at all.
+		link.ChannelWidthMHz = 20
+		link.PHYMode = "legacy"
+		for _, field := range strings.Fields(value) {
+			if w, ok := strings.CutSuffix(field, "MHz"); ok {
+				if n, err := strconv.Atoi(w); err == nil {
+					link.ChannelWidthMHz = n
+				}
+				continue
+			}
+			switch field {
+			case "EHT-MCS":
+				link.PHYMode = "802.11be"
+			case "HE-MCS":
+				link.PHYMode = "802.11ax"
+			case "VHT-MCS":
+				link.PHYMode = "802.11ac"
+			case "MCS":
+				link.PHYMode = "802.11n"
+			}
+		}
+		if link.PHYMode == "legacy" {
+			link.PHYMode = ""
+		}
+	}
+	return link
+}
+
+// wifiBand maps a center frequency in MHz to its band.
+func wifiBand(mhz float64) string {
+	switch {
+	case mhz >= 2400 && mhz < 2500:
+		return "2.4GHz"
+	case mhz >= 5150 && mhz < 5925:
+		return "5GHz"
+	case mhz >= 5925 && mhz <= 7125:
+		return "6GHz"
+	}
+	return ""
+}
+
+// parseAirportProfile reads the current network's "PHY Mode:" and
+// "Channel: 36 (5GHz, 80MHz)" lines from `system_profiler SPAirPortDataType`,
+// ignoring the nearby networks listed after them.
+func parseAirportProfile(out string) WiFiStatus {
+	var link WiFiStatus
+	inCurrent := false
+	for line := range strings.Lines(out) {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "Current Network Information:":
+			inCurrent = true
+			continue
+		case line == "Other Local Wi-Fi Networks:":
+			inCurrent = false
+			continue
+		}
+		if !inCurrent {
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "PHY Mode:"); ok && link.PHYMode == "" {
+			link.PHYMode = strings.TrimSpace(value)
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "Channel:")
+		if !ok || link.Band != "" {
+			continue
+		}
+		_, detail, ok := strings.Cut(value, "(")
+		if !ok {
+			continue
+		}
+		for part := range strings.SplitSeq(strings.TrimSuffix(strings.TrimSpace(detail), ")"), ",") {
+			part = strings.TrimSpace(part)
+			switch {
+			case part == "2GHz": // system_profiler's name for 2.4GHz
+				link.Band = "2.4GHz"
+			case strings.HasSuffix(part, "GHz"):
+				link.Band = part
+			case strings.HasSuffix(part, "MHz"):
+				link.ChannelWidthMHz, _ = strconv.Atoi(strings.TrimSuffix(part, "MHz"))
+			}
+		}
+	}
+	return link
+}
+
+func (c *Collector) collectWiFi(ctx context.Context, now time.Time) WiFiStatus {
+	if !c.lastWiFiAt.IsZero() && now.Sub(c.lastWiFiAt) < wifiCacheTTL {
+		return c.cachedWiFi
+	}
+	status := collectWiFi(ctx)
+	if status.Connected {
+		if status.SSID != c.cachedWiFi.SSID || c.lastWiFiLinkAt.IsZero() || now.Sub(c.lastWiFiLinkAt) >= c.ttl(wifiLinkCacheTTL) {
+			c.cachedWiFiLink = collectWiFiLink(ctx, status.Interface)
+			c.lastWiFiLinkAt = now
+		}
+		link := c.cachedWiFiLink
+		status.Band, status.ChannelWidthMHz, status.PHYMode = link.Band, link.ChannelWidthMHz, link.PHYMode
+	}
+	c.cachedWiFi = status
+	c.lastWiFiAt = now
+	return c.cachedWiFi
+}
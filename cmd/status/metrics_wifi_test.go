@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func TestParseAirportNetwork(t *testing.T) {
+	got := parseAirportNetwork("Current Wi-Fi Network: Coffee Shop\n", "en0")
+	if !got.Connected || got.SSID != "Coffee Shop" || got.Interface != "en0" {
+		t.Fatalf("unexpected status: %+v", got)
+	}
+	if got := parseAirportNetwork("You are not associated with an AirPort network.\n", "en0"); got.Connected {
+		t.Fatalf("expected disconnected, got %+v", got)
+	}
+}
+
+func TestParseNmcliWiFi(t *testing.T) {
+	out := "no:Neighbor:wlan0\nyes:Home\\:5G:wlan0\nno::wlan0\n"
+	got := parseNmcliWiFi(out)
+	if !got.Connected || got.SSID != "Home:5G" || got.Interface != "wlan0" {
+		t.Fatalf("unexpected status: %+v", got)
+	}
+	if got := parseNmcliWiFi("no:Neighbor:wlan0\n"); got.Connected {
+		t.Fatalf("expected no active network, got %+v", got)
+	}
+}
+
+const iwLink5GHzAX = `Connected to 3c:84:6a:12:34:56 (on wlan0)
+	SSID: Home-5G
+	freq: 5180.0
+	RX: 183219426 bytes (160124 packets)
+	TX: 20395144 bytes (61394 packets)
+	signal: -51 dBm
+	rx bitrate: 1200.9 MBit/s 80MHz HE-MCS 11 HE-NSS 2 HE-GI 0 HE-DCM 0
+	tx bitrate: 960.7 MBit/s 80MHz HE-MCS 9 HE-NSS 2 HE-GI 0 HE-DCM 0
+	bss flags: short-slot-time
+	dtim period: 3
+	beacon int: 100
+`
+
+const airportProfile5GHzAX = `Wi-Fi:
+
+      Software Versions:
+          CoreWLAN: 16.0 (1657)
+      Interfaces:
+        en0:
+          Card Type: Wi-Fi  (0x14E4, 0x4387)
+          Firmware Version: wl0: Jul 11 2023 01:34:58 version 20.10.1019.72.0.1.1.1 FWID 01-7ab28c7b
+          MAC Address: 3c:22:fb:00:11:22
+          Supported PHY Modes: 802.11 a/b/g/n/ac/ax
+          Status: Connected
+          Current Network Information:
+            Home-5G:
+              PHY Mode: 802.11ax
+              Channel: 36 (5GHz, 80MHz)
+              Country Code: US
+              Network Type: Infrastructure
+              Security: WPA2 Personal
+              Signal / Noise: -48 dBm / -92 dBm
+              Transmit Rate: 1201
+              MCS Index: 11
+          Other Local Wi-Fi Networks:
+            Neighbor:
+              PHY Mode: 802.11n
+              Channel: 6 (2GHz, 20MHz)
+              Network Type: Infrastructure
+`
+
+func TestParseWiFiLink5GHzAX(t *testing.T) {
+	want := WiFiStatus{Band: "5GHz", ChannelWidthMHz: 80, PHYMode: "802.11ax"}
+	if got := parseIWLink(iwLink5GHzAX); got != want {
+		t.Errorf("parseIWLink() = %+v, want %+v", got, want)
+	}
+	if got := parseAirportProfile(airportProfile5GHzAX); got != want {
+		t.Errorf("parseAirportProfile() = %+v, want %+v", got, want)
+	}
+	legacy := "Current Network Information:\n  Cafe:\n    PHY Mode: 802.11n\n    Channel: 6 (2GHz, 20MHz)\n"
+	if got := parseAirportProfile(legacy); got != (WiFiStatus{Band: "2.4GHz", ChannelWidthMHz: 20, PHYMode: "802.11n"}) {
+		t.Errorf("parseAirportProfile(2GHz) = %+v", got)
+	}
+}
+
+func TestParseIWLinkLegacyAndOtherBands(t *testing.T) {
+	for _, tc := range []struct {
+		out  string
+		want WiFiStatus
+	}{
+		{"freq: 2437\n\ttx bitrate: 144.4 MBit/s MCS 15 short GI\n", WiFiStatus{Band: "2.4GHz", ChannelWidthMHz: 20, PHYMode: "802.11n"}},
+		{"freq: 2412\n\ttx bitrate: 54.0 MBit/s\n", WiFiStatus{Band: "2.4GHz", ChannelWidthMHz: 20}},
+		{"freq: 5955\n\ttx bitrate: 2882.4 MBit/s 160MHz HE-MCS 11 HE-NSS 2\n", WiFiStatus{Band: "6GHz", ChannelWidthMHz: 160, PHYMode: "802.11ax"}},
+		{"freq: 5500\n\ttx bitrate: 866.7 MBit/s VHT-MCS 9 80MHz short GI VHT-NSS 2\n", WiFiStatus{Band: "5GHz", ChannelWidthMHz: 80, PHYMode: "802.11ac"}},
+		{"Not connected.\n", WiFiStatus{}},
+	} {
+		if got := parseIWLink(tc.out); got != tc.want {
+			t.Errorf("parseIWLink(%q) = %+v, want %+v", tc.out, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LocallyAdministered labels MACs with the locally administered bit set:
+// randomized Wi-Fi addresses, bridges and most virtual interfaces.
+const LocallyAdministered = "Locally administered"
+
+// ouiVendors maps the first three MAC octets to a vendor. It is a small
+// offline table of hardware and hypervisor prefixes commonly seen on
+// laptops, servers and VMs, not the full IEEE registry.
+var ouiVendors = map[string]string{
+	// Apple
+	"00:03:93": "Apple",
+	"00:0a:95": "Apple",
+	"00:1b:63": "Apple",
+	"00:1e:c2": "Apple",
+	"00:25:00": "Apple",
+	"28:cf:e9": "Apple",
+	"3c:07:54": "Apple",
+	"a4:5e:60": "Apple",
+	"ac:bc:32": "Apple",
+	"f0:18:98": "Apple",
+
+	"00:1b:21": "Intel",
+	"00:1e:67": "Intel",
+	"a0:36:9f": "Intel",
+	"00:e0:4c": "Realtek",
+	"00:10:18": "Broadcom",
+	"00:00:0c": "Cisco",
+	"b8:27:eb": "Raspberry Pi",
+	"dc:a6:32": "Raspberry Pi",
+
+	// Virtual
+	"00:05:69": "VMware (virtual)",
+	"00:0c:29": "VMware (virtual)",
+	"00:50:56": "VMware (virtual)",
+	"08:00:27": "VirtualBox (virtual)",
+	"00:15:5d": "Hyper-V (virtual)",
+	"00:16:3e": "Xen (virtual)",
+	"00:1c:42": "Parallels (virtual)",
+	"52:54:00": "QEMU/KVM (virtual)",
+}
+
+// macVendor returns the vendor for mac, LocallyAdministered for locally
+// administered addresses that aren't a known hypervisor prefix, or "" when
+// the MAC is missing, malformed or not in the table.
+func macVendor(mac string) string {
+	parts := strings.FieldsFunc(strings.ToLower(mac), func(r rune) bool { return r == ':' || r == '-' })
+	if len(parts) != 6 {
+		return ""
+	}
+	first, err := strconv.ParseUint(parts[0], 16, 8)
+	if err != nil {
+		return ""
+	}
+	prefix := strings.Join(parts[:3], ":")
+	if vendor, ok := ouiVendors[prefix]; ok {
+		return vendor
+	}
+	if first&0x02 != 0 {
+		return LocallyAdministered
+	}
+	return ""
+}
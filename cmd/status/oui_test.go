@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestMacVendor(t *testing.T) {
+	tests := []struct {
+		mac  string
+		want string
+	}{
+		{"00:50:56:ab:cd:ef", "VMware (virtual)"},
+		{"F0:18:98:12:34:56", "Apple"},
+		{"00-1b-21-aa-bb-cc", "Intel"},
+		{"52:54:00:12:34:56", "QEMU/KVM (virtual)"}, // known even though locally administered
+		{"02:42:ac:11:00:02", LocallyAdministered},  // Docker bridge
+		{"a6:83:e7:01:02:03", LocallyAdministered},  // randomized Wi-Fi address
+		{"00:11:22:33:44:55", ""},                   // unknown vendor
+		{"", ""},
+		{"zz:zz:zz:00:00:00", ""},
+	}
+	for _, tt := range tests {
+		if got := macVendor(tt.mac); got != tt.want {
+			t.Errorf("macVendor(%q) = %q, want %q", tt.mac, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// probeCacheTTL bounds how often connectivity probes hit the network.
+const probeCacheTTL = time.Minute
+
+// ProbeConfig describes the HTTP request used for connectivity checks. The
+// same probe is sent directly (captive-portal check) and through the
+// detected proxy (reachability check), so users on strict networks can point
+// both at an endpoint they're allowed to reach.
+type ProbeConfig struct {
+	URL          string
+	Method       string
+	Timeout      time.Duration
+	ExpectStatus int
+}
+
+// DefaultProbeConfig returns the built-in probe: a plain-HTTP endpoint that
+// answers 204, which captive portals intercept with a redirect or login page.
+func DefaultProbeConfig() ProbeConfig {
+	return ProbeConfig{
+		URL:          "http://connectivitycheck.gstatic.com/generate_204",
+		Method:       http.MethodGet,
+		Timeout:      3 * time.Second,
+		ExpectStatus: http.StatusNoContent,
+	}
+}
+
+// Validate checks the probe is usable.
+func (p ProbeConfig) Validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("probe url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("probe url %q: scheme must be http or https", p.URL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("probe url %q: missing host", p.URL)
+	}
+	switch p.Method {
+	case http.MethodGet, http.MethodHead:
+	default:
+		return fmt.Errorf("probe method %q: must be GET or HEAD", p.Method)
+	}
+	if p.Timeout <= 0 || p.Timeout > 30*time.Second {
+		return fmt.Errorf("probe timeout %s: must be between 0 and 30s", p.Timeout)
+	}
+	if p.ExpectStatus < 100 || p.ExpectStatus > 599 {
+		return fmt.Errorf("probe expected status %d: not an HTTP status", p.ExpectStatus)
+	}
+	return nil
+}
+
+// probeResult is the outcome of one probe request.
+type probeResult struct {
+	Status  int
+	Latency time.Duration
+	Err     error
+}
+
+func (r probeResult) matches(p ProbeConfig) bool {
+	return r.Err == nil && r.Status == p.ExpectStatus
+}
+
+// runProbe sends the probe, optionally via an HTTP proxy. Redirects are not
+// followed: a captive portal's redirect is the signal we're looking for.
+func runProbe(ctx context.Context, p ProbeConfig, proxy *url.URL) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	transport := &http.Transport{Proxy: nil}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, p.Method, p.URL, nil)
+	if err != nil {
+		return probeResult{Err: err}
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return probeResult{Err: err}
+	}
+	resp.Body.Close()
+	return probeResult{Status: resp.StatusCode, Latency: time.Since(start)}
+}
+
+// checkConnectivity runs the captive-portal probe and, for HTTP(S) proxies,
+// the proxy reachability probe.
+func checkConnectivity(ctx context.Context, p ProbeConfig, proxy ProxyStatus) ConnectivityStatus {
+	var status ConnectivityStatus
+
+	direct := runProbe(ctx, p, nil)
+	status.Checked = true
+	status.LatencyMs = float64(direct.Latency.Microseconds()) / 1000
+	if direct.Err != nil {
+		status.Error = direct.Err.Error()
+	} else {
+		status.CaptivePortal = !direct.matches(p)
+	}
+
+	if proxy.Enabled && (proxy.Type == "HTTP" || proxy.Type == "HTTPS") && proxy.Host != "" {
+		status.ProxyChecked = true
+		via := runProbe(ctx, p, &url.URL{Scheme: "http", Host: proxy.Host})
+		status.ProxyReachable = via.matches(p)
+		if via.Err != nil && status.Error == "" {
+			status.Error = "proxy: " + via.Err.Error()
+		}
+	}
+	return status
+}
+
+func (c *Collector) collectConnectivity(ctx context.Context, now time.Time, proxy ProxyStatus) ConnectivityStatus {
+	if c.Probe == nil {
+		return ConnectivityStatus{}
+	}
+	if !c.lastProbeAt.IsZero() && now.Sub(c.lastProbeAt) < probeCacheTTL {
+		return c.cachedConnectivity
+	}
+	c.cachedConnectivity = checkConnectivity(ctx, *c.Probe, proxy)
+	c.lastProbeAt = now
+	if c.cachedConnectivity.Error != "" {
+		logDegraded(c.logger(), "connectivity", errors.New(c.cachedConnectivity.Error))
+	}
+	return c.cachedConnectivity
+}
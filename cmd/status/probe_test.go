@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCustomProbeAgainstExpectedStatus(t *testing.T) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	probe := ProbeConfig{URL: srv.URL + "/ok", Method: http.MethodHead, Timeout: time.Second, ExpectStatus: http.StatusAccepted}
+	if err := probe.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	got := checkConnectivity(context.Background(), probe, ProxyStatus{})
+	if !got.Checked || got.CaptivePortal || got.Error != "" {
+		t.Fatalf("expected clean connectivity, got %+v", got)
+	}
+
+	// The same probe is reused through an HTTP proxy.
+	host := strings.TrimPrefix(srv.URL, "http://")
+	got = checkConnectivity(context.Background(), probe, ProxyStatus{Enabled: true, Type: "HTTP", Host: host})
+	if !got.ProxyChecked || !got.ProxyReachable {
+		t.Fatalf("expected proxy reachable, got %+v", got)
+	}
+	for _, m := range methods {
+		if m != http.MethodHead {
+			t.Fatalf("expected configured HEAD method, got %v", methods)
+		}
+	}
+}
+
+func TestProbeDetectsCaptivePortal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	probe := DefaultProbeConfig()
+	probe.URL = srv.URL
+	got := checkConnectivity(context.Background(), probe, ProxyStatus{})
+	if !got.CaptivePortal {
+		t.Fatalf("redirect should be reported as captive portal, got %+v", got)
+	}
+
+	res := runProbe(context.Background(), probe, &url.URL{Scheme: "http", Host: "127.0.0.1:1"})
+	if res.Err == nil {
+		t.Fatal("expected error probing through an unreachable proxy")
+	}
+}
+
+func TestProbeConfigValidate(t *testing.T) {
+	base := DefaultProbeConfig()
+	if err := base.Validate(); err != nil {
+		t.Fatalf("default probe invalid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*ProbeConfig)
+	}{
+		{"bad scheme", func(p *ProbeConfig) { p.URL = "ftp://example.com" }},
+		{"missing host", func(p *ProbeConfig) { p.URL = "http:///path" }},
+		{"bad method", func(p *ProbeConfig) { p.Method = "DELETE" }},
+		{"zero timeout", func(p *ProbeConfig) { p.Timeout = 0 }},
+		{"bad status", func(p *ProbeConfig) { p.ExpectStatus = 42 }},
+	}
+	for _, tt := range tests {
+		p := base
+		tt.mutate(&p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
+
+func TestConnectivityProbeStopsAtItsCollectorTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	probe := DefaultProbeConfig()
+	probe.URL = srv.URL
+	c := NewCollector()
+	c.Probe = &probe
+	c.CollectorTimeouts = map[string]time.Duration{"connectivity": 100 * time.Millisecond}
+
+	// The probe starts from the proxy task, as in CollectContext.
+	var got ConnectivityStatus
+	start := time.Now()
+	g := newCollectGroup(context.Background(), c)
+	g.run("proxy", func(context.Context) error {
+		g.run("connectivity", func(ctx context.Context) error {
+			got = c.collectConnectivity(ctx, start, ProxyStatus{})
+			return nil
+		})
+		return nil
+	})
+	g.wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("probe held the collection for %v, want about 100ms", elapsed)
+	}
+	if !got.Checked || got.Error == "" {
+		t.Fatalf("expected a timed-out probe, got %+v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// JSONProfile selects how much of a snapshot JSON output carries.
+type JSONProfile int
+
+const (
+	// JSONFull emits the whole MetricsSnapshot.
+	JSONFull JSONProfile = iota
+	// JSONCompact emits only the headline metrics, as CompactSnapshot.
+	JSONCompact
+)
+
+func (p JSONProfile) String() string {
+	if p == JSONCompact {
+		return "compact"
+	}
+	return "full"
+}
+
+// parseJSONProfile maps the -json-profile flag value to a JSONProfile.
+func parseJSONProfile(s string) (JSONProfile, error) {
+	switch s {
+	case "", "full":
+		return JSONFull, nil
+	case "compact":
+		return JSONCompact, nil
+	}
+	return JSONFull, fmt.Errorf("unknown JSON profile %q (want full or compact)", s)
+}
+
+// CompactSnapshot is the headline subset of a MetricsSnapshot. It shares
+// SnapshotSchemaVersion: every field here has the name and meaning it has
+// in the full snapshot, and Profile tells the two apart.
+type CompactSnapshot struct {
+	SchemaVersion string    `json:"schema_version"`
+	Profile       string    `json:"profile"` // Always "compact"
+	CollectedAt   time.Time `json:"collected_at"`
+	Host          string    `json:"host"`
+	HealthScore   int       `json:"health_score"`
+
+	CPUUsage        float64 `json:"cpu_usage"`         // CPUStatus.Usage
+	MemoryPercent   float64 `json:"memory_percent"`    // MemoryStatus.UsedPercent
+	DiskUsedPercent float64 `json:"disk_used_percent"` // Used over total across the listed disks
+
+	RxRateMBs float64 `json:"rx_rate_mbs"` // Summed over non-loopback interfaces
+	TxRateMBs float64 `json:"tx_rate_mbs"`
+
+	ProxyEnabled bool   `json:"proxy_enabled"`
+	ProxyType    string `json:"proxy_type,omitempty"`
+}
+
+// compactSnapshot reduces m to its headline metrics.
+func compactSnapshot(m MetricsSnapshot) CompactSnapshot {
+	var used, total uint64
+	for _, d := range m.Disks {
+		used += d.Used
+		total += d.Total
+	}
+	var diskPercent float64
+	if total > 0 {
+		diskPercent = float64(used) / float64(total) * 100
+	}
+	rx, tx := totalNetworkRates(m.Network)
+	c := CompactSnapshot{
+		SchemaVersion:   m.SchemaVersion,
+		Profile:         JSONCompact.String(),
+		CollectedAt:     m.CollectedAt,
+		Host:            m.Host,
+		HealthScore:     m.HealthScore,
+		CPUUsage:        m.CPU.Usage,
+		MemoryPercent:   m.Memory.UsedPercent,
+		DiskUsedPercent: diskPercent,
+		RxRateMBs:       rx,
+		TxRateMBs:       tx,
+		ProxyEnabled:    m.Proxy.Enabled,
+	}
+	if m.Proxy.Enabled {
+		c.ProxyType = m.Proxy.Type
+	}
+	return c
+}
+
+// profiled returns what JSON output should encode for m under p.
+func profiled(m MetricsSnapshot, p JSONProfile) any {
+	if p == JSONCompact {
+		return compactSnapshot(m)
+	}
+	return m
+}
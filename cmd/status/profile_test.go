@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompactProfileOmitsDetail(t *testing.T) {
+	snap := MetricsSnapshot{
+		SchemaVersion: SnapshotSchemaVersion,
+		CollectedAt:   time.Unix(1700000000, 0).UTC(),
+		Host:          "mbp",
+		HealthScore:   88,
+		CPU:           CPUStatus{Usage: 42.5, PerCore: []float64{80, 5}},
+		Memory:        MemoryStatus{UsedPercent: 61},
+		Disks: []DiskStatus{
+			{Mount: "/", Used: 300, Total: 1000, UsedPercent: 30},
+			{Mount: "/Volumes/Backup", Used: 900, Total: 1000, UsedPercent: 90},
+		},
+		Network: []NetworkStatus{
+			{Name: "en0", RxRateMBs: 2, TxRateMBs: 0.5},
+			{Name: "utun3", RxRateMBs: 1, TxRateMBs: 0.25},
+			{Name: "lo0", RxRateMBs: 50, Loopback: true},
+		},
+		Proxy: ProxyStatus{Enabled: true, Type: "SOCKS", Host: "127.0.0.1:1080"},
+	}
+
+	got := compactSnapshot(snap)
+	want := CompactSnapshot{
+		SchemaVersion:   SnapshotSchemaVersion,
+		Profile:         "compact",
+		CollectedAt:     snap.CollectedAt,
+		Host:            "mbp",
+		HealthScore:     88,
+		CPUUsage:        42.5,
+		MemoryPercent:   61,
+		DiskUsedPercent: 60,
+		RxRateMBs:       3,
+		TxRateMBs:       0.75,
+		ProxyEnabled:    true,
+		ProxyType:       "SOCKS",
+	}
+	if got != want {
+		t.Fatalf("compactSnapshot() = %+v, want %+v", got, want)
+	}
+
+	out, err := json.Marshal(profiled(snap, JSONCompact))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, detail := range []string{"per_core", "mount", "/Volumes/Backup", "en0", "127.0.0.1:1080"} {
+		if strings.Contains(string(out), detail) {
+			t.Errorf("compact JSON contains %q: %s", detail, out)
+		}
+	}
+	if !strings.HasPrefix(string(out), `{"schema_version":"`+SnapshotSchemaVersion+`"`) {
+		t.Errorf("compact JSON should lead with the schema version: %s", out)
+	}
+
+	full, err := json.Marshal(profiled(snap, JSONFull))
+	if err != nil || !strings.Contains(string(full), "/Volumes/Backup") {
+		t.Fatalf("full profile dropped detail: %v", err)
+	}
+}
+
+func TestParseJSONProfile(t *testing.T) {
+	for in, want := range map[string]JSONProfile{"": JSONFull, "full": JSONFull, "compact": JSONCompact} {
+		if got, err := parseJSONProfile(in); err != nil || got != want {
+			t.Errorf("parseJSONProfile(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseJSONProfile("tiny"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
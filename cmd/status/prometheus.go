@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content types for the two exposition formats served on /metrics.
+const (
+	promTextContentType    = "text/plain; version=0.0.4"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+var (
+	promMetricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	promLabelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// promLabel is a single label pair on a Prometheus sample.
+type promLabel struct {
+	name  string
+	value string
+}
+
+// promWriter emits Prometheus text exposition format, or OpenMetrics when
+// openMetrics is set. OpenMetrics samples carry the timestamp ts, in
+// seconds, so scrapers record collection time rather than scrape time.
+type promWriter struct {
+	w           *bufio.Writer
+	err         error
+	openMetrics bool
+	ts          time.Time
+}
+
+func (p *promWriter) header(name, help string) {
+	if !promMetricNameRE.MatchString(name) {
+		p.fail(fmt.Errorf("invalid metric name %q", name))
+		return
+	}
+	if p.openMetrics {
+		p.printf("# TYPE %s gauge\n# HELP %s %s\n", name, name, help)
+		return
+	}
+	p.printf("# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+func (p *promWriter) sample(name string, value float64, labels ...promLabel) {
+	if !promMetricNameRE.MatchString(name) {
+		p.fail(fmt.Errorf("invalid metric name %q", name))
+		return
+	}
+	for _, l := range labels {
+		if !promLabelNameRE.MatchString(l.name) || strings.HasPrefix(l.name, "__") {
+			p.fail(fmt.Errorf("invalid label name %q on %s", l.name, name))
+			return
+		}
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(l.name)
+			b.WriteString(`="`)
+			b.WriteString(escapePromLabel(l.value))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	if p.openMetrics && !p.ts.IsZero() {
+		ts := float64(p.ts.UnixMilli()) / 1000
+		p.printf("%s %s %s\n", b.String(), strconv.FormatFloat(value, 'g', -1, 64), strconv.FormatFloat(ts, 'f', -1, 64))
+		return
+	}
+	p.printf("%s %s\n", b.String(), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (p *promWriter) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *promWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func escapePromLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+// writePrometheus writes headline snapshot metrics as Prometheus gauges.
+func writePrometheus(w io.Writer, m MetricsSnapshot) error {
+	return writeExposition(&promWriter{w: bufio.NewWriter(w)}, m)
+}
+
+// writeOpenMetrics writes the same gauges as writePrometheus in OpenMetrics
+// format, timestamped with the snapshot's collection time and terminated by
+// the required # EOF marker.
+func writeOpenMetrics(w io.Writer, m MetricsSnapshot) error {
+	return writeExposition(&promWriter{w: bufio.NewWriter(w), openMetrics: true, ts: m.CollectedAt}, m)
+}
+
+func writeExposition(p *promWriter, m MetricsSnapshot) error {
+	p.header("mole_health_score", "System health score (0-100).")
+	p.sample("mole_health_score", float64(m.HealthScore))
+
+	p.header("mole_cpu_usage_percent", "Total CPU usage percent.")
+	p.sample("mole_cpu_usage_percent", m.CPU.Usage)
+
+	p.header("mole_cpu_steal_percent", "CPU time taken by the hypervisor for other guests.")
+	p.sample("mole_cpu_steal_percent", m.CPU.StealPercent)
+
+	p.header("mole_memory_used_percent", "Memory used percent.")
+	p.sample("mole_memory_used_percent", m.Memory.UsedPercent)
+
+	if len(m.Disks) > 0 {
+		p.header("mole_disk_used_percent", "Disk used percent per mount.")
+		for _, d := range m.Disks {
+			p.sample("mole_disk_used_percent", d.UsedPercent, promLabel{"mount", d.Mount})
+		}
+	}
+
+	if len(m.Network) > 0 {
+		p.header("mole_network_rx_mbs", "Network receive rate in MB/s.")
+		for _, n := range m.Network {
+			p.sample("mole_network_rx_mbs", n.RxRateMBs, promLabel{"interface", n.Name})
+		}
+		p.header("mole_network_tx_mbs", "Network transmit rate in MB/s.")
+		for _, n := range m.Network {
+			p.sample("mole_network_tx_mbs", n.TxRateMBs, promLabel{"interface", n.Name})
+		}
+	}
+
+	proxy := 0.0
+	if m.Proxy.Enabled {
+		proxy = 1
+	}
+	p.header("mole_proxy_enabled", "Whether a proxy is active (1) or not (0).")
+	p.sample("mole_proxy_enabled", proxy)
+
+	if p.openMetrics {
+		p.printf("# EOF\n")
+	}
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
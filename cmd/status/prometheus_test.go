@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	snap := MetricsSnapshot{
+		HealthScore: 88,
+		CPU:         CPUStatus{Usage: 12.5},
+		Disks:       []DiskStatus{{Mount: `/Volumes/My "Disk"`, UsedPercent: 40}},
+		Network:     []NetworkStatus{{Name: "en0", RxRateMBs: 1.25, TxRateMBs: 0.5}},
+		Proxy:       ProxyStatus{Enabled: true},
+	}
+
+	var buf bytes.Buffer
+	if err := writePrometheus(&buf, snap); err != nil {
+		t.Fatalf("writePrometheus: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE mole_cpu_usage_percent gauge\n",
+		"mole_health_score 88\n",
+		"mole_cpu_usage_percent 12.5\n",
+		`mole_disk_used_percent{mount="/Volumes/My \"Disk\""} 40` + "\n",
+		`mole_network_rx_mbs{interface="en0"} 1.25` + "\n",
+		`mole_network_tx_mbs{interface="en0"} 0.5` + "\n",
+		"mole_proxy_enabled 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	snap := MetricsSnapshot{
+		CollectedAt: time.UnixMilli(1700000000250),
+		HealthScore: 88,
+		Network:     []NetworkStatus{{Name: "en0", RxRateMBs: 1.25}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeOpenMetrics(&buf, snap); err != nil {
+		t.Fatalf("writeOpenMetrics: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE mole_health_score gauge\n# HELP mole_health_score System health score (0-100).\n",
+		"mole_health_score 88 1700000000.25\n",
+		`mole_network_rx_mbs{interface="en0"} 1.25 1700000000.25` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "mole_proxy_enabled 0 1700000000.25\n# EOF\n") {
+		t.Errorf("output should end with the last sample and # EOF:\n%s", out)
+	}
+
+	// The legacy format has neither timestamps nor the EOF marker.
+	buf.Reset()
+	if err := writePrometheus(&buf, snap); err != nil {
+		t.Fatalf("writePrometheus: %v", err)
+	}
+	if legacy := buf.String(); strings.Contains(legacy, "# EOF") || strings.Contains(legacy, "1700000000") {
+		t.Errorf("text format should stay untimestamped:\n%s", legacy)
+	}
+}
+
+func TestPromWriterRejectsInvalidNames(t *testing.T) {
+	for _, tc := range []struct {
+		metric string
+		label  string
+	}{
+		{metric: "mole-cpu"},
+		{metric: "9mole"},
+		{metric: "mole_cpu", label: "bad-label"},
+		{metric: "mole_cpu", label: "__reserved"},
+	} {
+		var buf bytes.Buffer
+		p := &promWriter{w: bufio.NewWriter(&buf)}
+		var labels []promLabel
+		if tc.label != "" {
+			labels = append(labels, promLabel{tc.label, "x"})
+		}
+		p.sample(tc.metric, 1, labels...)
+		if p.err == nil {
+			t.Errorf("sample(%q, label %q) should fail", tc.metric, tc.label)
+		}
+	}
+}
+
+func TestPromServerNegotiatesOpenMetrics(t *testing.T) {
+	p := &promServer{}
+	_ = p.sink(MetricsSnapshot{CollectedAt: time.UnixMilli(1700000000000)})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,text/plain;q=0.5")
+	rec := httptest.NewRecorder()
+	p.handleMetrics(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != openMetricsContentType {
+		t.Fatalf("Content-Type = %q, want OpenMetrics", ct)
+	}
+	if !strings.HasSuffix(rec.Body.String(), "# EOF\n") {
+		t.Fatalf("OpenMetrics body missing # EOF:\n%s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	p.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != promTextContentType {
+		t.Fatalf("default Content-Type = %q, want text format", ct)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quotaSaveInterval bounds how often accumulated usage is written to disk;
+// a crash loses at most this much accounting. A clean exit saves through
+// SaveQuotas.
+const quotaSaveInterval = time.Minute
+
+var defaultQuotaAlertLevels = []float64{80, 95}
+
+// Quota is a monthly data cap for one interface, e.g. a metered LTE link.
+type Quota struct {
+	Interface   string
+	LimitBytes  uint64
+	ResetDay    int       // Day of month the period restarts (1-28)
+	AlertLevels []float64 // Percent thresholds that raise alerts
+}
+
+// QuotaStatus is the usage of a Quota in the current period.
+type QuotaStatus struct {
+	Interface   string    `json:"interface"`
+	UsedBytes   uint64    `json:"used_bytes"`
+	LimitBytes  uint64    `json:"limit_bytes"`
+	PercentUsed float64   `json:"percent_used"`
+	PeriodStart time.Time `json:"period_start"`
+	NextReset   time.Time `json:"next_reset"`
+}
+
+// quotaUsage is the persisted accumulation for one interface.
+type quotaUsage struct {
+	PeriodStart time.Time `json:"period_start"`
+	UsedBytes   uint64    `json:"used_bytes"`
+}
+
+// quotaPeriodStart returns the start of the billing period containing now.
+func quotaPeriodStart(now time.Time, resetDay int) time.Time {
+	resetDay = min(max(resetDay, 1), 28)
+	start := time.Date(now.Year(), now.Month(), resetDay, 0, 0, 0, 0, now.Location())
+	if now.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// parseQuota parses a "quota.<iface>" preference value such as
+// "50GB reset=1 alert=80,95".
+func parseQuota(iface, value string) (Quota, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return Quota{}, fmt.Errorf("quota.%s: missing limit", iface)
+	}
+	limit, err := parseByteSize(fields[0])
+	if err != nil || limit == 0 {
+		return Quota{}, fmt.Errorf("quota.%s: invalid limit %q", iface, fields[0])
+	}
+	q := Quota{Interface: iface, LimitBytes: limit, ResetDay: 1, AlertLevels: defaultQuotaAlertLevels}
+	for _, f := range fields[1:] {
+		key, val, _ := strings.Cut(f, "=")
+		switch key {
+		case "reset":
+			day, err := strconv.Atoi(val)
+			if err != nil || day < 1 || day > 28 {
+				return Quota{}, fmt.Errorf("quota.%s: reset day %q must be 1-28", iface, val)
+			}
+			q.ResetDay = day
+		case "alert":
+			var levels []float64
+			for part := range strings.SplitSeq(val, ",") {
+				level, err := strconv.ParseFloat(part, 64)
+				if err != nil || level <= 0 {
+					return Quota{}, fmt.Errorf("quota.%s: invalid alert level %q", iface, part)
+				}
+				levels = append(levels, level)
+			}
+			sort.Float64s(levels)
+			q.AlertLevels = levels
+		default:
+			return Quota{}, fmt.Errorf("quota.%s: unknown option %q", iface, f)
+		}
+	}
+	return q, nil
+}
+
+// quotasFromPrefs collects every quota.<iface> preference.
+func quotasFromPrefs(prefs map[string]string) ([]Quota, error) {
+	var quotas []Quota
+	for key, value := range prefs {
+		iface, ok := strings.CutPrefix(key, "quota.")
+		if !ok || iface == "" {
+			continue
+		}
+		q, err := parseQuota(iface, value)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, q)
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Interface < quotas[j].Interface })
+	return quotas, nil
+}
+
+// parseByteSize parses sizes like "500MB", "1.5GiB" or "2048". Decimal units
+// (KB, MB, GB, TB) match how carriers bill; binary units use the i suffix.
+func parseByteSize(s string) (uint64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		size   float64
+	}{
+		{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"B", 1},
+	}
+	mult := 1.0
+	for _, u := range units {
+		if num, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, mult = strings.TrimSpace(num), u.size
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(v * mult), nil
+}
+
+func (c *Collector) hasQuota(iface string) bool {
+	return slices.ContainsFunc(c.Quotas, func(q Quota) bool { return q.Interface == iface })
+}
+
+// addQuotaBytes records traffic for a quota interface between two counter
+// readings. A counter reset counts everything since the reset.
+func (c *Collector) addQuotaBytes(cur, prev uint64, iface string) {
+	if !c.hasQuota(iface) {
+		return
+	}
+	if c.quotaPending == nil {
+		c.quotaPending = make(map[string]uint64)
+	}
+	if cur >= prev {
+		c.quotaPending[iface] += cur - prev
+	} else {
+		c.quotaPending[iface] += cur
+	}
+}
+
+// trackQuotas folds pending traffic into each quota, resets usage when a
+// new period starts, persists state periodically and returns the status of
+// every quota plus an alert for the highest alert level crossed.
+func (c *Collector) trackQuotas(now time.Time) ([]QuotaStatus, []Alert) {
+	if len(c.Quotas) == 0 {
+		return nil, nil
+	}
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+	if c.quotaUsage == nil {
+		usage, err := loadQuotaUsage(c.QuotaStatePath)
+		if err != nil {
+			c.logger().Warn("quota usage reset", "path", c.QuotaStatePath, "reason", err)
+		}
+		c.quotaUsage = usage
+	}
+
+	var statuses []QuotaStatus
+	var alerts []Alert
+	dirty := false
+	for _, q := range c.Quotas {
+		start := quotaPeriodStart(now, q.ResetDay)
+		usage := c.quotaUsage[q.Interface]
+		if !usage.PeriodStart.Equal(start) {
+			usage = quotaUsage{PeriodStart: start}
+			delete(c.quotaUnsaved, q.Interface)
+			c.lastQuotaSave = time.Time{} // Persist the reset right away
+			dirty = true
+		}
+		if pending := c.quotaPending[q.Interface]; pending > 0 {
+			usage.UsedBytes += pending
+			if c.quotaUnsaved == nil {
+				c.quotaUnsaved = make(map[string]uint64)
+			}
+			c.quotaUnsaved[q.Interface] += pending
+			delete(c.quotaPending, q.Interface)
+			dirty = true
+		}
+		c.quotaUsage[q.Interface] = usage
+
+		pct := float64(usage.UsedBytes) / float64(q.LimitBytes) * 100
+		statuses = append(statuses, QuotaStatus{
+			Interface:   q.Interface,
+			UsedBytes:   usage.UsedBytes,
+			LimitBytes:  q.LimitBytes,
+			PercentUsed: pct,
+			PeriodStart: start,
+			NextReset:   start.AddDate(0, 1, 0),
+		})
+
+		for i := len(q.AlertLevels) - 1; i >= 0; i-- {
+			if pct < q.AlertLevels[i] {
+				continue
+			}
+			level := AlertWarn
+			if pct >= 100 {
+				level = AlertCritical
+			}
+			alerts = append(alerts, Alert{
+				Metric:  "network.quota_percent",
+				Subject: q.Interface,
+				Level:   level,
+				Value:   pct,
+				Message: fmt.Sprintf("%s used %.0f%% of its %s quota", q.Interface, pct, humanBytes(q.LimitBytes)),
+			})
+			break
+		}
+	}
+
+	c.quotaDirty = c.quotaDirty || dirty
+	if c.quotaDirty && c.QuotaStatePath != "" && now.Sub(c.lastQuotaSave) >= quotaSaveInterval {
+		if err := c.mergeAndSaveQuotas(); err != nil {
+			logDegraded(c.logger(), "quota", err)
+		}
+		c.lastQuotaSave = now
+	}
+	return statuses, alerts
+}
+
+// SaveQuotas writes quota usage not yet saved to QuotaStatePath. Call it on
+// exit so the usage since the last periodic save is kept.
+func (c *Collector) SaveQuotas() error {
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+	if !c.quotaDirty || c.QuotaStatePath == "" {
+		return nil
+	}
+	return c.mergeAndSaveQuotas()
+}
+
+// mergeAndSaveQuotas writes quota usage, adding only the bytes counted since
+// the last save to what the file holds now: another instance (the TUI and
+// a -watch daemon, say) may share the file and have saved its own counts
+// meanwhile. An interface whose period differs from the file's takes this
+// collector's usage. The caller holds quotaMu.
+func (c *Collector) mergeAndSaveQuotas() error {
+	// An unreadable file was already reported on load; replace it.
+	merged, _ := loadQuotaUsage(c.QuotaStatePath)
+	for iface, usage := range c.quotaUsage {
+		if saved, ok := merged[iface]; ok && saved.PeriodStart.Equal(usage.PeriodStart) {
+			usage.UsedBytes = saved.UsedBytes + c.quotaUnsaved[iface]
+		}
+		merged[iface] = usage
+		c.quotaUsage[iface] = usage
+	}
+	if err := saveQuotaUsage(c.QuotaStatePath, merged); err != nil {
+		return err
+	}
+	clear(c.quotaUnsaved)
+	c.quotaDirty = false
+	return nil
+}
+
+// loadQuotaUsage reads usage saved by saveQuotaUsage. A missing file is a
+// fresh start; an unreadable one also starts from zero but is reported.
+func loadQuotaUsage(path string) (map[string]quotaUsage, error) {
+	usage := make(map[string]quotaUsage)
+	if path == "" {
+		return usage, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return usage, nil
+	}
+	if err != nil {
+		return usage, err
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return make(map[string]quotaUsage), fmt.Errorf("reading quota usage: %w", err)
+	}
+	return usage, nil
+}
+
+func saveQuotaUsage(path string, usage map[string]quotaUsage) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuotaAccumulatesAndAlerts(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "quota.json")
+	c := NewCollector()
+	c.Quotas = []Quota{{Interface: "wwan0", LimitBytes: 1000, ResetDay: 1, AlertLevels: []float64{80, 95}}}
+	c.QuotaStatePath = statePath
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	c.addQuotaBytes(500, 0, "wwan0")
+	c.addQuotaBytes(9999, 0, "en0") // no quota configured
+	statuses, alerts := c.trackQuotas(now)
+	if len(statuses) != 1 || statuses[0].UsedBytes != 500 || statuses[0].PercentUsed != 50 {
+		t.Fatalf("unexpected status: %+v", statuses)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("no alert expected at 50%%, got %v", alerts)
+	}
+
+	c.addQuotaBytes(850, 500, "wwan0")
+	statuses, alerts = c.trackQuotas(now.Add(time.Minute))
+	if statuses[0].UsedBytes != 850 {
+		t.Fatalf("expected 850 bytes used, got %d", statuses[0].UsedBytes)
+	}
+	if len(alerts) != 1 || alerts[0].Level != AlertWarn || alerts[0].Subject != "wwan0" {
+		t.Fatalf("expected warn alert after crossing 80%%, got %v", alerts)
+	}
+
+	c.addQuotaBytes(1100, 850, "wwan0")
+	_, alerts = c.trackQuotas(now.Add(2 * time.Minute))
+	if len(alerts) != 1 || alerts[0].Level != AlertCritical {
+		t.Fatalf("expected a single critical alert over the cap, got %v", alerts)
+	}
+
+	// Usage survives a restart.
+	restarted := NewCollector()
+	restarted.Quotas = c.Quotas
+	restarted.QuotaStatePath = statePath
+	statuses, _ = restarted.trackQuotas(now.Add(3 * time.Minute))
+	if statuses[0].UsedBytes != 1100 {
+		t.Fatalf("expected persisted 1100 bytes, got %d", statuses[0].UsedBytes)
+	}
+}
+
+func TestSaveQuotasFlushesUnsavedUsage(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "quota.json")
+	if err := os.WriteFile(statePath, []byte(`{"wwan0":{"period_st`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := &recordingHandler{}
+	c := NewCollector()
+	c.Logger = slog.New(h)
+	c.Quotas = []Quota{{Interface: "wwan0", LimitBytes: 1000, ResetDay: 1}}
+	c.QuotaStatePath = statePath
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// A torn file starts from zero, but says so.
+	c.trackQuotas(now)
+	if len(h.attrs("quota usage reset")) != 1 {
+		t.Fatal("expected a warning about the unreadable quota file")
+	}
+
+	// Usage from within quotaSaveInterval is only written on exit.
+	c.addQuotaBytes(300, 0, "wwan0")
+	c.trackQuotas(now.Add(10 * time.Second))
+	if usage, _ := loadQuotaUsage(statePath); usage["wwan0"].UsedBytes != 0 {
+		t.Fatalf("usage saved before quotaSaveInterval: %+v", usage)
+	}
+	if err := c.SaveQuotas(); err != nil {
+		t.Fatalf("SaveQuotas: %v", err)
+	}
+	usage, err := loadQuotaUsage(statePath)
+	if err != nil || usage["wwan0"].UsedBytes != 300 {
+		t.Fatalf("saved usage = %+v, %v; want 300 bytes", usage, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestQuotaInstancesSharingAFileKeepEachOthersBytes(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "quota.json")
+	quotas := []Quota{{Interface: "wwan0", LimitBytes: 1 << 30, ResetDay: 1}}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// A TUI and a -watch daemon start from the same file.
+	tui, daemon := NewCollector(), NewCollector()
+	for _, c := range []*Collector{tui, daemon} {
+		c.Quotas = quotas
+		c.QuotaStatePath = statePath
+		c.trackQuotas(now)
+	}
+
+	tui.addQuotaBytes(100, 0, "wwan0")
+	daemon.addQuotaBytes(250, 0, "wwan0")
+	tui.trackQuotas(now.Add(time.Second))
+	daemon.trackQuotas(now.Add(time.Second))
+	for _, c := range []*Collector{tui, daemon} {
+		if err := c.SaveQuotas(); err != nil {
+			t.Fatalf("SaveQuotas: %v", err)
+		}
+	}
+	usage, _ := loadQuotaUsage(statePath)
+	if got := usage["wwan0"].UsedBytes; got != 350 {
+		t.Fatalf("saved usage = %d, want both instances' 350 bytes", got)
+	}
+
+	// Saving again with nothing new must not count the bytes twice.
+	daemon.addQuotaBytes(260, 250, "wwan0")
+	daemon.trackQuotas(now.Add(2 * time.Second))
+	if err := daemon.SaveQuotas(); err != nil {
+		t.Fatalf("SaveQuotas: %v", err)
+	}
+	if usage, _ := loadQuotaUsage(statePath); usage["wwan0"].UsedBytes != 360 {
+		t.Fatalf("saved usage = %d, want 360", usage["wwan0"].UsedBytes)
+	}
+}
+
+func TestQuotaResetsOnConfiguredDay(t *testing.T) {
+	c := NewCollector()
+	c.Quotas = []Quota{{Interface: "wwan0", LimitBytes: 1000, ResetDay: 15}}
+
+	before := time.Date(2025, 1, 14, 23, 59, 0, 0, time.UTC)
+	c.addQuotaBytes(700, 0, "wwan0")
+	statuses, _ := c.trackQuotas(before)
+	if statuses[0].UsedBytes != 700 || !statuses[0].PeriodStart.Equal(time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected period before reset: %+v", statuses[0])
+	}
+
+	after := time.Date(2025, 1, 15, 0, 1, 0, 0, time.UTC)
+	c.addQuotaBytes(750, 700, "wwan0")
+	statuses, _ = c.trackQuotas(after)
+	if statuses[0].UsedBytes != 50 {
+		t.Fatalf("expected usage to restart on the 15th, got %d", statuses[0].UsedBytes)
+	}
+	if !statuses[0].NextReset.Equal(time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected next reset: %v", statuses[0].NextReset)
+	}
+}
+
+func TestParseQuota(t *testing.T) {
+	q, err := parseQuota("wwan0", "50GB reset=5 alert=90,75")
+	if err != nil {
+		t.Fatalf("parseQuota: %v", err)
+	}
+	if q.LimitBytes != 50e9 || q.ResetDay != 5 || q.AlertLevels[0] != 75 || q.AlertLevels[1] != 90 {
+		t.Fatalf("unexpected quota: %+v", q)
+	}
+	if q, _ := parseQuota("wwan0", "2GiB"); q.LimitBytes != 2<<30 || q.ResetDay != 1 {
+		t.Fatalf("unexpected binary quota: %+v", q)
+	}
+	for _, bad := range []string{"", "lots", "10GB reset=31", "10GB alert=x", "10GB color=red"} {
+		if _, err := parseQuota("wwan0", bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
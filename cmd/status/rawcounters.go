@@ -0,0 +1,26 @@
+package main
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// RawCounters is one unprocessed read of every interface's cumulative
+// counters, for consumers that compute rates over their own windows. No
+// deltas, smoothing or filtering are applied; counter resets are the
+// consumer's to handle.
+type RawCounters struct {
+	CollectedAt time.Time            `json:"collected_at"` // Taken right after the read, nanosecond precision
+	Counters    []net.IOCountersStat `json:"counters"`
+}
+
+// CollectRawCounters reads the per-interface counters once. It holds no
+// state, so calls are independent.
+func CollectRawCounters() (RawCounters, error) {
+	stats, err := collectIOCountersSafely(true)
+	if err != nil {
+		return RawCounters{}, err
+	}
+	return RawCounters{CollectedAt: time.Now(), Counters: stats}, nil
+}
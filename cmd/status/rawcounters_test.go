@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	gopsutilnet "github.com/shirou/gopsutil/v4/net"
+)
+
+func TestRawCountersJSONCarriesEveryCounter(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{
+		{Name: "en0", BytesSent: 1, BytesRecv: 2, PacketsSent: 3, PacketsRecv: 4, Errin: 5, Errout: 6, Dropin: 7, Dropout: 8, Fifoin: 9, Fifoout: 10},
+		{Name: "lo0", BytesSent: 11, BytesRecv: 12},
+	}
+	stubNetworkCounters(t, &counters)
+
+	before := time.Now()
+	raw, err := CollectRawCounters()
+	if err != nil {
+		t.Fatalf("CollectRawCounters() error: %v", err)
+	}
+	if raw.CollectedAt.Before(before) || raw.CollectedAt.After(time.Now()) {
+		t.Fatalf("CollectedAt = %v, want the read time", raw.CollectedAt)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		CollectedAt string           `json:"collected_at"`
+		Counters    []map[string]any `json:"counters"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	at, err := time.Parse(time.RFC3339Nano, decoded.CollectedAt)
+	if err != nil || !at.Equal(raw.CollectedAt) {
+		t.Fatalf("collected_at = %q, want %v at full precision", decoded.CollectedAt, raw.CollectedAt)
+	}
+	if len(decoded.Counters) != 2 {
+		t.Fatalf("counters = %v, want both interfaces", decoded.Counters)
+	}
+	want := map[string]float64{
+		"bytesSent": 1, "bytesRecv": 2, "packetsSent": 3, "packetsRecv": 4, "errin": 5,
+		"errout": 6, "dropin": 7, "dropout": 8, "fifoin": 9, "fifoout": 10,
+	}
+	en0 := decoded.Counters[0]
+	if en0["name"] != "en0" {
+		t.Fatalf("first counter = %v, want en0", en0)
+	}
+	for key, value := range want {
+		if en0[key] != value {
+			t.Errorf("en0 %s = %v, want %v", key, en0[key], value)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+const redactMark = "x"
+
+// redactSnapshot returns a copy of m with interface and listener IPs,
+// hostnames, the SSH client and the proxy host masked, and process command
+// lines and users dropped. The collector keeps raw values; only rendered
+// output is redacted.
+func redactSnapshot(m MetricsSnapshot) MetricsSnapshot {
+	if len(m.Network) > 0 {
+		network := make([]NetworkStatus, len(m.Network))
+		copy(network, m.Network)
+		for i := range network {
+			network[i].IP = redactIP(network[i].IP)
+		}
+		m.Network = network
+	}
+	if len(m.Events) > 0 {
+		events := make([]Event, len(m.Events))
+		copy(events, m.Events)
+		for i := range events {
+			switch events[i].Kind {
+			case EventIPChanged:
+				events[i].Old = redactIP(events[i].Old)
+				events[i].New = redactIP(events[i].New)
+			case EventNetworkChanged:
+				events[i].Old = redactWords(events[i].Old)
+				events[i].New = redactWords(events[i].New)
+			}
+		}
+		m.Events = events
+	}
+	m.Routes.DefaultGateway = redactIP(m.Routes.DefaultGateway)
+	if len(m.Routes.Defaults) > 0 {
+		defaults := make([]DefaultRoute, len(m.Routes.Defaults))
+		copy(defaults, m.Routes.Defaults)
+		for i := range defaults {
+			defaults[i].Gateway = redactIP(defaults[i].Gateway)
+		}
+		m.Routes.Defaults = defaults
+	}
+	if len(m.NetworkMounts) > 0 {
+		mounts := make([]DiskStatus, len(m.NetworkMounts))
+		copy(mounts, m.NetworkMounts)
+		for i := range mounts {
+			if server := mountServer(mounts[i].Device); server != "" {
+				mounts[i].Device = strings.Replace(mounts[i].Device, server, redactMark, 1)
+			}
+		}
+		m.NetworkMounts = mounts
+	}
+	if len(m.RemoteHosts) > 0 {
+		remotes := make([]RemoteHost, len(m.RemoteHosts))
+		copy(remotes, m.RemoteHosts)
+		for i := range remotes {
+			remotes[i].IP = redactIP(remotes[i].IP)
+			if remotes[i].Hostname != "" {
+				remotes[i].Hostname = redactMark
+			}
+		}
+		m.RemoteHosts = remotes
+	}
+	if len(m.Listeners) > 0 {
+		listeners := make([]ListenerStatus, len(m.Listeners))
+		copy(listeners, m.Listeners)
+		for i := range listeners {
+			listeners[i].Addr = redactIP(listeners[i].Addr)
+			if listeners[i].Hostname != "" {
+				listeners[i].Hostname = redactMark
+			}
+		}
+		m.Listeners = listeners
+	}
+	if len(m.TopProcesses) > 0 {
+		// Command lines carry hosts, addresses and often credentials
+		// (-p<password>, bearer tokens), too varied to mask piecemeal.
+		procs := make([]ProcessInfo, len(m.TopProcesses))
+		copy(procs, m.TopProcesses)
+		for i := range procs {
+			procs[i].Cmdline = ""
+			if procs[i].Username != "" {
+				procs[i].Username = redactMark
+			}
+		}
+		m.TopProcesses = procs
+	}
+	m.Session.SSHClient = redactIP(m.Session.SSHClient)
+	m.Proxy.Host = redactHostPort(m.Proxy.Host)
+	if len(m.Proxy.Effective) > 0 {
+		effective := make([]EffectiveProxy, len(m.Proxy.Effective))
+		copy(effective, m.Proxy.Effective)
+		for i := range effective {
+			effective[i].Env = redactHostPort(effective[i].Env)
+			effective[i].System = redactHostPort(effective[i].System)
+			effective[i].CommandLine = redactHostPort(effective[i].CommandLine)
+		}
+		m.Proxy.Effective = effective
+	}
+	if len(m.Proxy.Sources) > 0 {
+		sources := make([]ProxySourceReport, len(m.Proxy.Sources))
+		copy(sources, m.Proxy.Sources)
+		for i := range sources {
+			sources[i].Host = redactHostPort(sources[i].Host)
+		}
+		m.Proxy.Sources = sources
+	}
+	return m
+}
+
+// redactWords applies redactIP to each space-separated word.
+func redactWords(s string) string {
+	words := strings.Split(s, " ")
+	for i, w := range words {
+		words[i] = redactIP(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// redactIP masks the last octet of an IPv4 address or the interface
+// identifier (last 64 bits) of an IPv6 address. Non-IP values pass through.
+func redactIP(raw string) string {
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return raw
+	}
+	if addr.Is4() {
+		s := addr.String()
+		return s[:strings.LastIndex(s, ".")+1] + redactMark
+	}
+	b := addr.As16()
+	groups := make([]string, 0, 4)
+	for i := 0; i < 8; i += 2 {
+		groups = append(groups, fmt.Sprintf("%x", uint16(b[i])<<8|uint16(b[i+1])))
+	}
+	return strings.Join(groups, ":") + ":" + redactMark
+}
+
+// redactHostPort masks the IP part of a "host:port" or bare host value.
+func redactHostPort(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	host, port, err := net.SplitHostPort(raw)
+	if err != nil {
+		return redactIP(raw)
+	}
+	redacted := redactIP(host)
+	if redacted == host {
+		return raw
+	}
+	return net.JoinHostPort(redacted, port)
+}
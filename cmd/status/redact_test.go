@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedactIP(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"192.168.1.23", "192.168.1.x"},
+		{"2001:db8:1:2:aaaa:bbbb:cccc:dddd", "2001:db8:1:2:x"},
+		{"fe80::1", "fe80:0:0:0:x"},
+		{"", ""},
+		{"not-an-ip", "not-an-ip"},
+	}
+	for _, tt := range tests {
+		if got := redactIP(tt.in); got != tt.want {
+			t.Errorf("redactIP(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactSnapshotJSON(t *testing.T) {
+	snap := MetricsSnapshot{
+		Network: []NetworkStatus{{Name: "en0", IP: "192.168.1.23"}},
+		Proxy:   ProxyStatus{Enabled: true, Type: "HTTP", Host: "10.0.0.8:7890"},
+		Session: SessionInfo{SSH: true, SSHClient: "203.0.113.7"},
+		Listeners: []ListenerStatus{
+			{Proto: "tcp", Addr: "192.168.1.23", Port: 22, Hostname: "laptop.lan"},
+			{Proto: "tcp", Addr: "*", Port: 80},
+		},
+		TopProcesses: []ProcessInfo{
+			{PID: 42, Name: "mysql", Username: "alice", Cmdline: "mysql -h 10.0.0.9 -uroot -phunter2"},
+		},
+	}
+
+	raw, _ := json.Marshal(snap)
+	if !strings.Contains(string(raw), "192.168.1.23") || !strings.Contains(string(raw), "10.0.0.8:7890") {
+		t.Fatalf("unredacted JSON should keep raw addresses: %s", raw)
+	}
+
+	redacted, _ := json.Marshal(redactSnapshot(snap))
+	out := string(redacted)
+	if strings.Contains(out, "192.168.1.23") || strings.Contains(out, "10.0.0.8") || strings.Contains(out, "203.0.113.7") || strings.Contains(out, "laptop.lan") ||
+		strings.Contains(out, "hunter2") || strings.Contains(out, "alice") {
+		t.Fatalf("redacted JSON leaked an address: %s", out)
+	}
+	if !strings.Contains(out, `"ip":"192.168.1.x"`) || !strings.Contains(out, `"host":"10.0.0.x:7890"`) || !strings.Contains(out, `"ssh_client":"203.0.113.x"`) ||
+		!strings.Contains(out, `"addr":"192.168.1.x"`) || !strings.Contains(out, `"addr":"*"`) || !strings.Contains(out, `"username":"x"`) {
+		t.Fatalf("unexpected redacted JSON: %s", out)
+	}
+
+	// Redaction must not touch the caller's snapshot.
+	if snap.Network[0].IP != "192.168.1.23" || snap.TopProcesses[0].Cmdline == "" {
+		t.Fatalf("redactSnapshot mutated input: %+v", snap)
+	}
+}
+
+func TestRenderNetworkCardRedacted(t *testing.T) {
+	snap := redactSnapshot(MetricsSnapshot{
+		Network: []NetworkStatus{{Name: "en0", IP: "192.168.1.23"}},
+	})
+	card := renderNetworkCard(snap.Network, snap.NetworkHistory, snap.Proxy, snap.TCP, 60, FormatOptions{Spark: SparkBlocks})
+	joined := strings.Join(card.lines, "\n")
+	if !strings.Contains(joined, "192.168.1.x") || strings.Contains(joined, "192.168.1.23") {
+		t.Fatalf("network card should show redacted IP, got %q", joined)
+	}
+}
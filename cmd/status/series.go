@@ -0,0 +1,94 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// defaultMaxSeries caps per-key collector state (interfaces, mounts, PIDs)
+// when Collector.MaxSeries is unset. Each series is a few hundred bytes, so
+// the cap bounds that state to roughly a megabyte.
+const defaultMaxSeries = 4096
+
+type seriesKind int
+
+const (
+	seriesInterface seriesKind = iota
+	seriesMount
+	seriesProcess
+)
+
+// trackedSeries is one entry of per-key state and when it was last updated.
+type trackedSeries struct {
+	kind seriesKind
+	name string
+	pid  int32
+	at   time.Time
+}
+
+func (c *Collector) maxSeries() int {
+	if c.MaxSeries > 0 {
+		return c.MaxSeries
+	}
+	return defaultMaxSeries
+}
+
+// trackedSeries lists every keyed series with its last update time, taken
+// from the timestamps the state already carries.
+func (c *Collector) trackedSeries() []trackedSeries {
+	series := make([]trackedSeries, 0, len(c.prevNetAt)+len(c.diskTrend)+len(c.prevProcCPU))
+	for name, at := range c.prevNetAt {
+		series = append(series, trackedSeries{kind: seriesInterface, name: name, at: at})
+	}
+	for mount, samples := range c.diskTrend {
+		if len(samples) > 0 {
+			series = append(series, trackedSeries{kind: seriesMount, name: mount, at: samples[len(samples)-1].at})
+		}
+	}
+	for pid, s := range c.prevProcCPU {
+		series = append(series, trackedSeries{kind: seriesProcess, pid: pid, at: s.at})
+	}
+	return series
+}
+
+// evictSeries drops the least recently updated series until at most
+// maxSeries remain. Disappeared mounts and exited PIDs are already pruned by
+// their collectors; this bounds what churn leaves behind on long-lived
+// daemons, such as container veth interfaces and PIDs missed by truncated
+// process passes. It returns the number of series evicted.
+func (c *Collector) evictSeries() int {
+	series := c.trackedSeries()
+	excess := len(series) - c.maxSeries()
+	if excess <= 0 {
+		return 0
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].at.Before(series[j].at) })
+	for _, s := range series[:excess] {
+		c.dropSeries(s)
+	}
+	return excess
+}
+
+func (c *Collector) dropSeries(s trackedSeries) {
+	switch s.kind {
+	case seriesInterface:
+		delete(c.prevNet, s.name)
+		delete(c.prevNetAt, s.name)
+		delete(c.prevCarrier, s.name)
+		delete(c.nicDrivers, s.name)
+		delete(c.flapHistory, s.name)
+		delete(c.sessionBase, s.name)
+		delete(c.prevIPs, s.name)
+		delete(c.ifaceRxHist, s.name)
+		delete(c.lastActiveAt, s.name)
+		delete(c.dormantTicks, s.name)
+		delete(c.ifaceTxHist, s.name)
+		c.ifaceOrder = slices.DeleteFunc(c.ifaceOrder, func(name string) bool { return name == s.name })
+	case seriesMount:
+		delete(c.diskTrend, s.name)
+		delete(c.diskAlerts, s.name)
+	case seriesProcess:
+		delete(c.prevProcCPU, s.pid)
+	}
+}
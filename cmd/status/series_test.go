@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestEvictSeriesDropsLeastRecentlyUpdated(t *testing.T) {
+	base := time.Unix(1000, 0)
+	c := NewCollector()
+	c.MaxSeries = 3
+	c.prevIPs = make(map[string]string)
+	c.prevProcCPU = make(map[int32]procCPUSample)
+	c.diskTrend = map[string][]usageSample{"/": {{at: base.Add(5 * time.Second)}}}
+
+	for i, name := range []string{"veth1", "veth2", "en0"} {
+		at := base.Add(time.Duration(i) * 2 * time.Second)
+		c.prevNet[name] = net.IOCountersStat{Name: name}
+		c.prevNetAt[name] = at
+		c.sessionBase[name] = net.IOCountersStat{Name: name}
+		c.prevIPs[name] = "10.0.0.1"
+	}
+	c.prevProcCPU[42] = procCPUSample{at: base.Add(1 * time.Second)}
+	c.prevProcCPU[43] = procCPUSample{at: base.Add(6 * time.Second)}
+	c.ifaceOrder = []string{"veth1", "en0", "veth2"}
+
+	// Six series, cap three: veth1 (0s), pid 42 (1s) and veth2 (2s) go.
+	if n := c.evictSeries(); n != 3 {
+		t.Fatalf("evictSeries() = %d, want 3", n)
+	}
+	for _, name := range []string{"veth1", "veth2"} {
+		if _, ok := c.prevNetAt[name]; ok {
+			t.Errorf("%s still tracked", name)
+		}
+		if _, ok := c.sessionBase[name]; ok {
+			t.Errorf("%s session base not evicted", name)
+		}
+		if _, ok := c.prevIPs[name]; ok {
+			t.Errorf("%s IP not evicted", name)
+		}
+	}
+	if _, ok := c.prevProcCPU[42]; ok {
+		t.Error("pid 42 still tracked")
+	}
+	if got := strings.Join(c.ifaceOrder, ","); got != "en0" {
+		t.Errorf("ifaceOrder = %s, want en0", got)
+	}
+	if _, ok := c.prevNet["en0"]; !ok {
+		t.Error("en0 evicted; it was updated more recently")
+	}
+	if _, ok := c.diskTrend["/"]; !ok {
+		t.Error("/ evicted; it was updated more recently")
+	}
+	if _, ok := c.prevProcCPU[43]; !ok {
+		t.Error("pid 43 evicted; it was updated most recently")
+	}
+
+	if n := c.evictSeries(); n != 0 {
+		t.Fatalf("second evictSeries() = %d, want 0 at the cap", n)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink receives every snapshot produced in watch mode.
+type Sink func(MetricsSnapshot) error
+
+// publishSnapshot fans snap out to every sink. A failing or panicking sink
+// is reported but never stops the remaining sinks.
+func publishSnapshot(snap MetricsSnapshot, sinks ...Sink) []error {
+	var errs []error
+	for i, sink := range sinks {
+		if err := callSink(sink, snap); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", i, err))
+		}
+	}
+	return errs
+}
+
+func callSink(sink Sink, snap MetricsSnapshot) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sink panic: %v", r)
+		}
+	}()
+	return sink(snap)
+}
+
+// compactSink writes one formatCompact line per snapshot, preceded by a
+// line per event, alert and change.
+func compactSink(w io.Writer, opts FormatOptions) Sink {
+	return func(m MetricsSnapshot) error {
+		for _, e := range m.Events {
+			if _, err := fmt.Fprintf(w, "event: %s\n", e); err != nil {
+				return err
+			}
+		}
+		for _, a := range m.Alerts {
+			if _, err := fmt.Fprintf(w, "alert: %s\n", a); err != nil {
+				return err
+			}
+		}
+		for _, c := range m.Changes {
+			if _, err := fmt.Fprintf(w, "change: %s %s\n", c.Metric, c.Reason); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintln(w, formatCompact(m, opts))
+		return err
+	}
+}
+
+// jsonlSink writes one JSON object per line, trimmed to profile.
+func jsonlSink(w io.Writer, profile JSONProfile) Sink {
+	enc := json.NewEncoder(w)
+	return func(m MetricsSnapshot) error {
+		return enc.Encode(profiled(m, profile))
+	}
+}
+
+// openJSONLFileSink appends JSON lines to path, creating it if needed.
+func openJSONLFileSink(path string, profile JSONProfile) (Sink, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return jsonlSink(f, profile), f, nil
+}
+
+// promServer serves the latest snapshot as Prometheus metrics, and recent
+// rates as JSON for charting.
+type promServer struct {
+	mu      sync.RWMutex
+	latest  MetricsSnapshot
+	ready   bool
+	total   []RatePoint            // Aggregate rates, oldest first
+	history map[string][]RatePoint // Per-interface rates, same window
+	srv     *http.Server
+}
+
+// RatePoint is one tick of receive and transmit rates in MB/s.
+type RatePoint struct {
+	At        time.Time `json:"at"`
+	RxRateMBs float64   `json:"rx_rate_mbs"`
+	TxRateMBs float64   `json:"tx_rate_mbs"`
+}
+
+func (p *promServer) sink(m MetricsSnapshot) error {
+	p.mu.Lock()
+	p.latest = m
+	p.ready = true
+	p.recordRates(m)
+	p.mu.Unlock()
+	return nil
+}
+
+// recordRates appends m's rates to the history, keeping the last
+// NetworkHistorySize ticks. Interfaces with no sample left in the window
+// are forgotten. The caller holds p.mu.
+func (p *promServer) recordRates(m MetricsSnapshot) {
+	trim := func(points []RatePoint) []RatePoint {
+		if len(points) > NetworkHistorySize {
+			points = append(points[:0:0], points[len(points)-NetworkHistorySize:]...)
+		}
+		return points
+	}
+	// The listed interfaces may leave some out (top N, -min-rate, veths
+	// folded into containers); the collector's own aggregate covers them.
+	rx, tx := totalNetworkRates(m.Network)
+	if h := m.NetworkHistory; len(h.RxHistory) > 0 && len(h.TxHistory) > 0 {
+		rx, tx = h.RxHistory[len(h.RxHistory)-1], h.TxHistory[len(h.TxHistory)-1]
+	}
+	p.total = trim(append(p.total, RatePoint{At: m.CollectedAt, RxRateMBs: rx, TxRateMBs: tx}))
+	if p.history == nil {
+		p.history = make(map[string][]RatePoint)
+	}
+	for _, n := range m.Network {
+		p.history[n.Name] = trim(append(p.history[n.Name], RatePoint{At: m.CollectedAt, RxRateMBs: n.RxRateMBs, TxRateMBs: n.TxRateMBs}))
+	}
+	oldest := p.total[0].At
+	for name, points := range p.history {
+		if points[len(points)-1].At.Before(oldest) {
+			delete(p.history, name)
+		}
+	}
+}
+
+// handleHistory serves recent rates for ?iface=<name>, or the aggregate
+// across interfaces without it, oldest first. ?limit=N returns only the
+// last N points; at most NetworkHistorySize are kept.
+func (p *promServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	limit := NetworkHistorySize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, NetworkHistorySize)
+	}
+
+	p.mu.RLock()
+	points := p.total
+	if iface := r.URL.Query().Get("iface"); iface != "" {
+		var ok bool
+		if points, ok = p.history[iface]; !ok {
+			p.mu.RUnlock()
+			http.Error(w, fmt.Sprintf("unknown interface %q", iface), http.StatusNotFound)
+			return
+		}
+	}
+	points = slices.Clone(points[max(0, len(points)-limit):])
+	p.mu.RUnlock()
+
+	if points == nil {
+		points = []RatePoint{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(points)
+}
+
+func (p *promServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	latest, ready := p.latest, p.ready
+	p.mu.RUnlock()
+	if !ready {
+		http.Error(w, "no snapshot collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	// Scrapers that prefer OpenMetrics say so in Accept; everyone else
+	// gets the legacy text format.
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		w.Header().Set("Content-Type", openMetricsContentType)
+		_ = writeOpenMetrics(w, latest)
+		return
+	}
+	w.Header().Set("Content-Type", promTextContentType)
+	_ = writePrometheus(w, latest)
+}
+
+func (p *promServer) Close() error {
+	return p.srv.Close()
+}
+
+// startPrometheusSink listens on addr and serves /metrics from the sink's
+// most recent snapshot and /history from its recent rates.
+func startPrometheusSink(addr string) (Sink, io.Closer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &promServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", p.handleMetrics)
+	mux.HandleFunc("/history", p.handleHistory)
+	p.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := p.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "metrics server error: %v\n", err)
+		}
+	}()
+	return p.sink, p, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublishSnapshotFansOutToAllSinks(t *testing.T) {
+	var first, second []float64
+	sinks := []Sink{
+		func(m MetricsSnapshot) error {
+			first = append(first, m.CPU.Usage)
+			return errors.New("disk full")
+		},
+		func(MetricsSnapshot) error { panic("boom") },
+		func(m MetricsSnapshot) error {
+			second = append(second, m.CPU.Usage)
+			return nil
+		},
+	}
+
+	for tick := range 3 {
+		errs := publishSnapshot(MetricsSnapshot{CPU: CPUStatus{Usage: float64(tick)}}, sinks...)
+		if len(errs) != 2 {
+			t.Fatalf("tick %d: expected errors from failing and panicking sinks, got %v", tick, errs)
+		}
+	}
+
+	if len(first) != 3 || len(second) != 3 {
+		t.Fatalf("expected both sinks to receive every tick, got %v and %v", first, second)
+	}
+	if second[2] != 2 {
+		t.Fatalf("unexpected snapshot order: %v", second)
+	}
+}
+
+func TestJSONLFileSinkAppendsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.jsonl")
+	sink, closer, err := openJSONLFileSink(path, JSONFull)
+	if err != nil {
+		t.Fatalf("openJSONLFileSink: %v", err)
+	}
+	for _, host := range []string{"a", "b"} {
+		if err := sink(MetricsSnapshot{Host: host}); err != nil {
+			t.Fatalf("sink: %v", err)
+		}
+	}
+	closer.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	var hosts []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var m MetricsSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("invalid JSON line: %v", err)
+		}
+		hosts = append(hosts, m.Host)
+	}
+	if strings.Join(hosts, ",") != "a,b" {
+		t.Fatalf("unexpected lines: %v", hosts)
+	}
+}
+
+func TestPromServerServesInterfaceHistory(t *testing.T) {
+	p := &promServer{}
+	start := time.Unix(1700000000, 0)
+	for i := range NetworkHistorySize + 5 {
+		_ = p.sink(MetricsSnapshot{
+			CollectedAt: start.Add(time.Duration(i) * time.Second),
+			Network: []NetworkStatus{
+				{Name: "en0", RxRateMBs: float64(i), TxRateMBs: 0.5},
+				{Name: "en7", RxRateMBs: 1, TxRateMBs: 1},
+			},
+			// Includes 2 MB/s on interfaces left out of the list.
+			NetworkHistory: NetworkHistory{RxHistory: []float64{0, float64(i) + 3}, TxHistory: []float64{0, 3.5}},
+		})
+	}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		p.handleHistory(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) []RatePoint {
+		t.Helper()
+		var points []RatePoint
+		if err := json.Unmarshal(rec.Body.Bytes(), &points); err != nil {
+			t.Fatalf("decode %q: %v", rec.Body.String(), err)
+		}
+		return points
+	}
+
+	rec := get("/history?iface=en0")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("known interface: status %d", rec.Code)
+	}
+	points := decode(rec)
+	if len(points) != NetworkHistorySize {
+		t.Fatalf("history should be bounded to %d points, got %d", NetworkHistorySize, len(points))
+	}
+	last := points[len(points)-1]
+	if last.RxRateMBs != NetworkHistorySize+4 || !last.At.Equal(start.Add((NetworkHistorySize+4)*time.Second)) {
+		t.Fatalf("last point = %+v", last)
+	}
+
+	if points := decode(get("/history?iface=en0&limit=3")); len(points) != 3 || points[2] != last {
+		t.Fatalf("limit=3 returned %+v", points)
+	}
+	if points := decode(get("/history")); len(points) == 0 || points[len(points)-1].RxRateMBs != last.RxRateMBs+3 || points[len(points)-1].TxRateMBs != 3.5 {
+		t.Fatalf("aggregate history should follow the collector's totals, got %+v", points[len(points)-1])
+	}
+	if rec := get("/history?iface=utun9"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown interface: status %d, want 404", rec.Code)
+	}
+	if rec := get("/history?limit=-1"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad limit: status %d, want 400", rec.Code)
+	}
+}
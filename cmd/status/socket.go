@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// socketClientBuffer is how many snapshots a slow client may fall behind
+// before it is disconnected.
+const socketClientBuffer = 8
+
+// socketServer streams newline-delimited JSON snapshots to every client
+// connected to a Unix socket.
+type socketServer struct {
+	path string
+	ln   net.Listener
+
+	mu      sync.Mutex
+	clients map[*socketClient]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type socketClient struct {
+	conn net.Conn
+	out  chan []byte
+	once sync.Once
+}
+
+func (cl *socketClient) close() {
+	cl.once.Do(func() {
+		close(cl.out)
+		_ = cl.conn.Close()
+	})
+}
+
+// startSocketSink listens on a Unix socket at path, replacing a stale socket
+// file left by an earlier run, and streams each snapshot to all clients.
+// Snapshots name processes and addresses, so only the owner may connect.
+func startSocketSink(path string) (Sink, io.Closer, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Bind inside a private directory and move the socket into place once
+	// it is owner-only: a socket bound at path would be reachable with the
+	// umask's mode until chmod.
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".sock")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "s")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, nil, err
+	}
+	// Close removes the socket at path itself.
+	ln.SetUnlinkOnClose(false)
+	err = os.Chmod(tmp, 0o600)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = ln.Close()
+		return nil, nil, err
+	}
+	s := &socketServer{path: path, ln: ln, clients: make(map[*socketClient]struct{})}
+	s.wg.Add(1)
+	go s.accept()
+	return s.sink, s, nil
+}
+
+func (s *socketServer) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				fmt.Fprintf(os.Stderr, "socket accept error: %v\n", err)
+			}
+			return
+		}
+		cl := &socketClient{conn: conn, out: make(chan []byte, socketClientBuffer)}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.clients[cl] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(2)
+		go s.write(cl)
+		go s.watch(cl)
+	}
+}
+
+// write sends queued snapshots until the client goes away or is dropped.
+func (s *socketServer) write(cl *socketClient) {
+	defer s.wg.Done()
+	defer s.drop(cl)
+	for line := range cl.out {
+		if _, err := cl.conn.Write(line); err != nil {
+			return
+		}
+	}
+}
+
+// watch drains client input so a disconnect is noticed even between
+// snapshots; clients are not expected to send anything.
+func (s *socketServer) watch(cl *socketClient) {
+	defer s.wg.Done()
+	_, _ = io.Copy(io.Discard, cl.conn)
+	s.drop(cl)
+}
+
+func (s *socketServer) drop(cl *socketClient) {
+	s.mu.Lock()
+	delete(s.clients, cl)
+	s.mu.Unlock()
+	cl.close()
+}
+
+func (s *socketServer) sink(m MetricsSnapshot) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for cl := range s.clients {
+		select {
+		case cl.out <- line:
+		default:
+			// Too far behind; the writer exits once the channel closes.
+			delete(s.clients, cl)
+			cl.close()
+		}
+	}
+	return nil
+}
+
+// Close stops accepting, disconnects every client, waits for their
+// goroutines and removes the socket file.
+func (s *socketServer) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	clients := s.clients
+	s.clients = make(map[*socketClient]struct{})
+	s.mu.Unlock()
+
+	err := s.ln.Close()
+	for cl := range clients {
+		cl.close()
+	}
+	s.wg.Wait()
+	if rmErr := os.Remove(s.path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) && err == nil {
+		err = rmErr
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func waitSocketClients(t *testing.T, srv *socketServer, want int) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		srv.mu.Lock()
+		n := len(srv.clients)
+		srv.mu.Unlock()
+		if n == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d socket clients, want %d", n, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSocketSinkStreamsToClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.sock")
+	sink, closer, err := startSocketSink(path)
+	if err != nil {
+		t.Fatalf("startSocketSink: %v", err)
+	}
+	srv := closer.(*socketServer)
+	if info, err := os.Stat(path); err != nil {
+		t.Fatalf("stat socket: %v", err)
+	} else if info.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, want owner-only", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("bind directory left behind: %v", entries)
+	}
+
+	dial := func() *bufio.Reader {
+		t.Helper()
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return bufio.NewReader(conn)
+	}
+	readers := []*bufio.Reader{dial(), dial()}
+
+	// Wait for both connections to be registered before publishing.
+	waitSocketClients(t, srv, 2)
+
+	for _, host := range []string{"first", "second"} {
+		if err := sink(MetricsSnapshot{Host: host}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, r := range readers {
+		for _, want := range []string{"first", "second"} {
+			line, err := r.ReadBytes('\n')
+			if err != nil {
+				t.Fatalf("client %d: read: %v", i, err)
+			}
+			var snap MetricsSnapshot
+			if err := json.Unmarshal(line, &snap); err != nil || snap.Host != want {
+				t.Fatalf("client %d: got %q (%v), want host %q", i, line, err, want)
+			}
+		}
+	}
+
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket file should be removed on close, stat err = %v", err)
+	}
+}
+
+func TestSocketSinkDropsDisconnectedClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.sock")
+	sink, closer, err := startSocketSink(path)
+	if err != nil {
+		t.Fatalf("startSocketSink: %v", err)
+	}
+	defer closer.Close()
+	srv := closer.(*socketServer)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSocketClients(t, srv, 1)
+	_ = conn.Close()
+	waitSocketClients(t, srv, 0)
+	if err := sink(MetricsSnapshot{}); err != nil {
+		t.Fatalf("sink with no clients: %v", err)
+	}
+}
+
+func TestStartSocketSinkRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("keep me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := startSocketSink(path); err == nil {
+		t.Fatal("expected an error for an existing regular file")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SparkStyle selects the glyphs sparklines are drawn with, for terminals
+// whose fonts lack some of them.
+type SparkStyle int
+
+const (
+	// SparkBlocks uses the eight block elements ▁ to █.
+	SparkBlocks SparkStyle = iota
+	// SparkBraille uses braille bars, which many fonts without block
+	// elements still carry.
+	SparkBraille
+	// SparkASCII uses plain ASCII for any terminal.
+	SparkASCII
+)
+
+var sparkGlyphs = map[SparkStyle][]rune{
+	SparkBlocks:  []rune("▁▂▃▄▅▆▇█"),
+	SparkBraille: []rune("⣀⣤⣶⣿"),
+	SparkASCII:   []rune("_.-:=+*#"),
+}
+
+func (s SparkStyle) String() string {
+	switch s {
+	case SparkBraille:
+		return "braille"
+	case SparkASCII:
+		return "ascii"
+	}
+	return "blocks"
+}
+
+// parseSparkStyle maps the -spark-style flag value to a SparkStyle.
+func parseSparkStyle(s string) (SparkStyle, error) {
+	switch s {
+	case "", "blocks":
+		return SparkBlocks, nil
+	case "braille":
+		return SparkBraille, nil
+	case "ascii":
+		return SparkASCII, nil
+	}
+	return SparkBlocks, fmt.Errorf("unknown spark style %q (want blocks, braille or ascii)", s)
+}
+
+// renderSparkline draws one glyph per value, scaled between the series'
+// own minimum and maximum.
+func renderSparkline(values []float64, style SparkStyle) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return renderSparklineRange(values, slices.Min(values), slices.Max(values), style)
+}
+
+// renderSparklineRange draws one glyph per value, scaled from lo (lowest
+// glyph) to hi (highest). A flat series, where hi equals lo, is drawn at
+// the top when it is above zero and at the bottom otherwise, so an all-zero
+// window shows as a baseline rather than dividing by zero.
+func renderSparklineRange(values []float64, lo, hi float64, style SparkStyle) string {
+	glyphs, ok := sparkGlyphs[style]
+	if !ok {
+		glyphs = sparkGlyphs[SparkBlocks]
+	}
+	top := len(glyphs) - 1
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		switch {
+		case hi > lo:
+			level = min(max(int((v-lo)/(hi-lo)*float64(top)), 0), top)
+		case hi > 0:
+			level = top
+		}
+		b.WriteRune(glyphs[level])
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSparklineStyles(t *testing.T) {
+	series := []float64{10, 12, 14, 16, 18, 20, 22, 24}
+	tests := []struct {
+		style SparkStyle
+		want  string
+	}{
+		{SparkBlocks, "▁▂▃▄▅▆▇█"},
+		{SparkBraille, "⣀⣀⣀⣤⣤⣶⣶⣿"},
+		{SparkASCII, "_.-:=+*#"},
+	}
+	for _, tt := range tests {
+		if got := renderSparkline(series, tt.style); got != tt.want {
+			t.Errorf("%s: renderSparkline() = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestRenderSparklineFlatSeries(t *testing.T) {
+	for _, style := range []SparkStyle{SparkBlocks, SparkBraille, SparkASCII} {
+		glyphs := sparkGlyphs[style]
+		if got, want := renderSparkline([]float64{0, 0, 0}, style), strings.Repeat(string(glyphs[0]), 3); got != want {
+			t.Errorf("%s: all-zero series = %q, want baseline %q", style, got, want)
+		}
+		if got, want := renderSparkline([]float64{4, 4}, style), strings.Repeat(string(glyphs[len(glyphs)-1]), 2); got != want {
+			t.Errorf("%s: flat non-zero series = %q, want %q", style, got, want)
+		}
+	}
+	if got := renderSparkline(nil, SparkBlocks); got != "" {
+		t.Errorf("empty series = %q", got)
+	}
+}
+
+func TestParseSparkStyle(t *testing.T) {
+	for _, style := range []SparkStyle{SparkBlocks, SparkBraille, SparkASCII} {
+		if got, err := parseSparkStyle(style.String()); err != nil || got != style {
+			t.Errorf("parseSparkStyle(%q) = %v, %v", style, got, err)
+		}
+	}
+	if _, err := parseSparkStyle("emoji"); err == nil {
+		t.Error("expected error for unknown style")
+	}
+}
+
+func TestTemplateSparkline(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{sparkline .NetworkHistory.RxHistory}}`, FormatOptions{Spark: SparkASCII})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	snap := MetricsSnapshot{NetworkHistory: NetworkHistory{RxHistory: []float64{0, 1, 2}}}
+	if err := tmpl.Execute(&b, snap); err != nil || b.String() != "_:#" {
+		t.Fatalf("template sparkline = %q, %v", b.String(), err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// collectorStateVersion is bumped when savedState changes incompatibly; a
+// file with another version is ignored.
+const collectorStateVersion = 1
+
+// ErrStaleState is returned by LoadState when the saved state is older than
+// a rate baseline may be, so the collector starts fresh.
+var ErrStaleState = errors.New("collector state is stale")
+
+// savedState is the network rate state that lets a restarted collector
+// continue where it stopped: counter baselines, session totals and the
+// rate history.
+type savedState struct {
+	Version     int                           `json:"version"`
+	LastNetAt   time.Time                     `json:"last_net_at"`
+	PrevNet     map[string]net.IOCountersStat `json:"prev_net"`
+	PrevNetAt   map[string]time.Time          `json:"prev_net_at"`
+	SessionBase map[string]net.IOCountersStat `json:"session_base"`
+	RxHistory   []float64                     `json:"rx_history"`
+	TxHistory   []float64                     `json:"tx_history"`
+	IfaceRx     map[string][]float64          `json:"iface_rx_history,omitempty"`
+	IfaceTx     map[string][]float64          `json:"iface_tx_history,omitempty"`
+}
+
+// SaveState writes the collector's network rate state to path as JSON. Like
+// SampleInterface, it must not be called concurrently with Collect.
+func (c *Collector) SaveState(path string) error {
+	s := savedState{
+		Version:     collectorStateVersion,
+		LastNetAt:   c.lastNetAt,
+		PrevNet:     c.prevNet,
+		PrevNetAt:   c.prevNetAt,
+		SessionBase: c.sessionBase,
+		RxHistory:   c.rxHistoryBuf.Slice(),
+		TxHistory:   c.txHistoryBuf.Slice(),
+		IfaceRx:     historySlices(c.ifaceRxHist),
+		IfaceTx:     historySlices(c.ifaceTxHist),
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	// Interface names and session totals are nobody else's business.
+	return writeFileAtomic(path, data, 0600)
+}
+
+// LoadState restores state written by SaveState, so the next Collect
+// computes rates against the saved counters instead of starting a new
+// baseline. State saved longer ago than a tick may span (the clock jump
+// limit) is not restored and ErrStaleState is returned; so is nothing for
+// a file from another version. It must be called before the first Collect.
+func (c *Collector) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var s savedState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("reading collector state: %w", err)
+	}
+	if s.Version != collectorStateVersion {
+		return fmt.Errorf("collector state version %d, want %d", s.Version, collectorStateVersion)
+	}
+	if elapsed := time.Since(s.LastNetAt); s.LastNetAt.IsZero() || elapsed < 0 || elapsed > max(clockJumpFactor*c.interval(), minClockJump) {
+		return ErrStaleState
+	}
+
+	c.lastNetAt = s.LastNetAt
+	for name, stat := range s.PrevNet {
+		c.prevNet[name] = stat
+	}
+	for name, at := range s.PrevNetAt {
+		c.prevNetAt[name] = at
+	}
+	for name, base := range s.SessionBase {
+		c.sessionBase[name] = base
+	}
+	for _, v := range s.RxHistory {
+		c.rxHistoryBuf.Add(v)
+	}
+	for _, v := range s.TxHistory {
+		c.txHistoryBuf.Add(v)
+	}
+	for name, rx := range s.IfaceRx {
+		tx := s.IfaceTx[name]
+		for i, v := range rx {
+			var t float64
+			if i < len(tx) {
+				t = tx[i]
+			}
+			c.addInterfaceHistory(name, v, t)
+		}
+	}
+	return nil
+}
+
+func historySlices(bufs map[string]*RingBuffer) map[string][]float64 {
+	if len(bufs) == 0 {
+		return nil
+	}
+	out := make(map[string][]float64, len(bufs))
+	for name, rb := range bufs {
+		out[name] = rb.Slice()
+	}
+	return out
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so a crash mid-write never leaves a torn file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
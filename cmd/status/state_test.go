@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gopsutilnet "github.com/shirou/gopsutil/v4/net"
+)
+
+func TestSaveLoadStateContinuesRates(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000, BytesSent: 500}}
+	stubNetworkCounters(t, &counters)
+
+	const mb = 1024 * 1024
+	before := NewCollector()
+	start := time.Now().Add(-3 * time.Second)
+	before.collectNetwork(start)
+	counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000 + 2*mb, BytesSent: 500 + mb}}
+	before.collectNetwork(start.Add(time.Second))
+
+	path := filepath.Join(t.TempDir(), "state", "collector.json")
+	if err := before.SaveState(path); err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("state file = %v, %v; want owner-only", info, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+
+	// A restarted daemon: 2s later the counters moved another 4 MB down.
+	after := NewCollector()
+	if err := after.LoadState(path); err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	counters = []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000 + 6*mb, BytesSent: 500 + mb}}
+	stats, _ := after.collectNetwork(start.Add(3 * time.Second))
+	if len(stats) != 1 {
+		t.Fatalf("first tick after restore should report rates, got %+v", stats)
+	}
+	if stats[0].RxRateMBs != 2 || stats[0].TxRateMBs != 0 {
+		t.Fatalf("rates after restore = %v/%v, want 2/0 MB/s", stats[0].RxRateMBs, stats[0].TxRateMBs)
+	}
+	if stats[0].SessionRxBytes != 6*mb {
+		t.Fatalf("session rx = %d, want the total since the first start", stats[0].SessionRxBytes)
+	}
+	if got := after.rxHistoryBuf.Slice(); len(got) != 2 || got[0] != 2 || got[1] != 2 {
+		t.Fatalf("history = %v, want the saved sample then the new one", got)
+	}
+	if got := after.ifaceRxHist["en0"].Slice(); len(got) != 2 {
+		t.Fatalf("interface history = %v, want two samples", got)
+	}
+}
+
+func TestLoadStateRejectsStaleState(t *testing.T) {
+	counters := []gopsutilnet.IOCountersStat{{Name: "en0", BytesRecv: 1000}}
+	stubNetworkCounters(t, &counters)
+
+	c := NewCollector()
+	c.collectNetwork(time.Now().Add(-time.Hour))
+	path := filepath.Join(t.TempDir(), "collector.json")
+	if err := c.SaveState(path); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := NewCollector()
+	if err := fresh.LoadState(path); !errors.Is(err, ErrStaleState) {
+		t.Fatalf("LoadState() = %v, want ErrStaleState", err)
+	}
+	if !fresh.lastNetAt.IsZero() || len(fresh.prevNet) != 0 {
+		t.Fatal("stale state should not be restored")
+	}
+}
+
+func TestLoadStateBadFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewCollector().LoadState(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing file: %v, want not exist", err)
+	}
+	path := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(path, []byte(`{"version":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewCollector().LoadState(path); err == nil {
+		t.Fatal("truncated state should fail to load")
+	}
+}
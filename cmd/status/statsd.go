@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// statsdMaxPacket keeps batched packets under a typical Ethernet MTU so
+// they are never fragmented.
+const statsdMaxPacket = 1432
+
+// StatsDTagFormat selects how tags are attached to StatsD lines.
+type StatsDTagFormat string
+
+const (
+	StatsDTagsDogStatsD StatsDTagFormat = "dogstatsd" // name:1|g|#interface:en0
+	StatsDTagsInflux    StatsDTagFormat = "influx"    // name,interface=en0:1|g
+	StatsDTagsNone      StatsDTagFormat = "none"      // name:1|g
+)
+
+func parseStatsDTagFormat(s string) (StatsDTagFormat, error) {
+	switch f := StatsDTagFormat(strings.ToLower(s)); f {
+	case StatsDTagsDogStatsD, StatsDTagsInflux, StatsDTagsNone:
+		return f, nil
+	case "":
+		return StatsDTagsDogStatsD, nil
+	}
+	return "", fmt.Errorf("unknown statsd tag format %q (want dogstatsd, influx or none)", s)
+}
+
+// statsdLine formats one gauge. Tags are name/value pairs.
+func statsdLine(format StatsDTagFormat, name string, value float64, tags ...promLabel) string {
+	v := strconv.FormatFloat(value, 'f', -1, 64)
+	if len(tags) == 0 || format == StatsDTagsNone {
+		return name + ":" + v + "|g"
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	if format == StatsDTagsInflux {
+		for _, t := range tags {
+			b.WriteString("," + t.name + "=" + statsdTagValue(t.value))
+		}
+		b.WriteString(":" + v + "|g")
+		return b.String()
+	}
+	b.WriteString(":" + v + "|g|#")
+	for i, t := range tags {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(t.name + ":" + statsdTagValue(t.value))
+	}
+	return b.String()
+}
+
+// statsdTagValue strips characters that delimit StatsD lines and tags.
+func statsdTagValue(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', ',', '#', '=', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, v)
+}
+
+// statsdLines renders the headline snapshot metrics as StatsD gauges.
+func statsdLines(m MetricsSnapshot, format StatsDTagFormat) []string {
+	lines := []string{
+		statsdLine(format, "mole.health.score", float64(m.HealthScore)),
+		statsdLine(format, "mole.cpu.percent", m.CPU.Usage),
+		statsdLine(format, "mole.mem.percent", m.Memory.UsedPercent),
+	}
+	for _, d := range m.Disks {
+		lines = append(lines, statsdLine(format, "mole.disk.used_percent", d.UsedPercent, promLabel{"mount", d.Mount}))
+	}
+	for _, n := range m.Network {
+		iface := promLabel{"interface", n.Name}
+		lines = append(lines,
+			statsdLine(format, "mole.net.rx", n.RxRateMBs, iface),
+			statsdLine(format, "mole.net.tx", n.TxRateMBs, iface))
+	}
+	return lines
+}
+
+// batchStatsD joins lines into newline-separated packets no larger than max.
+func batchStatsD(lines []string, max int) []string {
+	var packets []string
+	var b strings.Builder
+	for _, l := range lines {
+		if b.Len() > 0 && b.Len()+1+len(l) > max {
+			packets = append(packets, b.String())
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(l)
+	}
+	if b.Len() > 0 {
+		packets = append(packets, b.String())
+	}
+	return packets
+}
+
+// startStatsDSink sends each snapshot to a StatsD daemon at addr over UDP,
+// batched into as few packets as fit. Send failures are dropped: a missing
+// daemon must not disrupt watch mode.
+func startStatsDSink(addr string, format StatsDTagFormat) (Sink, io.Closer, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	sink := func(m MetricsSnapshot) error {
+		for _, packet := range batchStatsD(statsdLines(m, format), statsdMaxPacket) {
+			_, _ = conn.Write([]byte(packet))
+		}
+		return nil
+	}
+	return sink, conn, nil
+}
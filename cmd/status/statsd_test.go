@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsDSinkSendsBatchedGauges(t *testing.T) {
+	ln, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp listener unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	sink, closer, err := startStatsDSink(ln.LocalAddr().String(), StatsDTagsDogStatsD)
+	if err != nil {
+		t.Fatalf("startStatsDSink: %v", err)
+	}
+	defer closer.Close()
+
+	snap := MetricsSnapshot{
+		HealthScore: 90,
+		CPU:         CPUStatus{Usage: 12.5},
+		Disks:       []DiskStatus{{Mount: "/", UsedPercent: 40}},
+		Network:     []NetworkStatus{{Name: "en0", RxRateMBs: 1.5, TxRateMBs: 0.25}},
+	}
+	if err := sink(snap); err != nil {
+		t.Fatalf("sink: %v", err)
+	}
+
+	buf := make([]byte, statsdMaxPacket)
+	_ = ln.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := ln.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read packet: %v", err)
+	}
+	lines := strings.Split(string(buf[:n]), "\n")
+	for _, want := range []string{
+		"mole.health.score:90|g",
+		"mole.cpu.percent:12.5|g",
+		"mole.disk.used_percent:40|g|#mount:/",
+		"mole.net.rx:1.5|g|#interface:en0",
+		"mole.net.tx:0.25|g|#interface:en0",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("packet missing %q:\n%s", want, buf[:n])
+		}
+	}
+}
+
+func TestStatsDLineTagFormats(t *testing.T) {
+	tag := promLabel{"interface", "en0"}
+	if got := statsdLine(StatsDTagsInflux, "mole.net.rx", 2, tag); got != "mole.net.rx,interface=en0:2|g" {
+		t.Errorf("influx line = %q", got)
+	}
+	if got := statsdLine(StatsDTagsNone, "mole.net.rx", 2, tag); got != "mole.net.rx:2|g" {
+		t.Errorf("untagged line = %q", got)
+	}
+}
+
+func TestBatchStatsDSplitsAtLimit(t *testing.T) {
+	got := batchStatsD([]string{"aaaa", "bbbb", "cccc"}, 9)
+	want := []string{"aaaa\nbbbb", "cccc"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("batchStatsD() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snapshot store defaults: 16 segments of 4 MiB keep roughly an hour of
+// one-second snapshots in 64 MiB.
+const (
+	defaultStoreSegmentBytes = 4 << 20
+	defaultStoreSegments     = 16
+	storeSegmentPrefix       = "snapshots-"
+	storeSegmentSuffix       = ".jsonl"
+)
+
+// StoreOptions bounds a SnapshotStore. Zero fields use the defaults.
+type StoreOptions struct {
+	SegmentBytes int64 // Rotate to a new segment past this size
+	MaxSegments  int   // Delete the oldest segments beyond this count
+}
+
+// SnapshotStore is an append-only on-disk ring of snapshots: JSON lines
+// split across numbered segment files, with the oldest segment deleted once
+// MaxSegments is exceeded. It serves "the last hour" without a TSDB.
+type SnapshotStore struct {
+	dir  string
+	opts StoreOptions
+
+	mu   sync.Mutex
+	seq  int // Sequence number of the current segment
+	f    *os.File
+	size int64
+}
+
+// OpenSnapshotStore opens (creating if needed) a store rooted at dir and
+// resumes appending to its newest segment.
+func OpenSnapshotStore(dir string, opts StoreOptions) (*SnapshotStore, error) {
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = defaultStoreSegmentBytes
+	}
+	if opts.MaxSegments <= 0 {
+		opts.MaxSegments = defaultStoreSegments
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &SnapshotStore{dir: dir, opts: opts}
+	seqs, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	seq := 1
+	if len(seqs) > 0 {
+		seq = seqs[len(seqs)-1]
+	}
+	if err := s.openSegment(seq); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenSnapshotStoreReadOnly opens an existing store for Last and Range. It
+// creates nothing, opens no segment for writing and works on read-only
+// directories; Append fails.
+func OpenSnapshotStoreReadOnly(dir string, opts StoreOptions) (*SnapshotStore, error) {
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = defaultStoreSegmentBytes
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &SnapshotStore{dir: dir, opts: opts}, nil
+}
+
+func (s *SnapshotStore) segmentPath(seq int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%06d%s", storeSegmentPrefix, seq, storeSegmentSuffix))
+}
+
+// segments returns the sequence numbers of existing segments, oldest first.
+func (s *SnapshotStore) segments() ([]int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []int
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), storeSegmentPrefix)
+		if !ok {
+			continue
+		}
+		name, ok = strings.CutSuffix(name, storeSegmentSuffix)
+		if !ok {
+			continue
+		}
+		if seq, err := strconv.Atoi(name); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+	return seqs, nil
+}
+
+func (s *SnapshotStore) openSegment(seq int) error {
+	f, err := os.OpenFile(s.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.seq, s.f, s.size = seq, f, info.Size()
+	return nil
+}
+
+// Append writes m to the current segment, rotating first when the segment
+// is full.
+func (s *SnapshotStore) Append(m MetricsSnapshot) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return errors.New("snapshot store is read-only")
+	}
+	if s.size > 0 && s.size+int64(len(line)) > s.opts.SegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// rotate starts the next segment and deletes segments past MaxSegments.
+func (s *SnapshotStore) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	if err := s.openSegment(s.seq + 1); err != nil {
+		return err
+	}
+	seqs, err := s.segments()
+	if err != nil {
+		return err
+	}
+	for len(seqs) > s.opts.MaxSegments {
+		if err := os.Remove(s.segmentPath(seqs[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		seqs = seqs[1:]
+	}
+	return nil
+}
+
+// Sink appends every snapshot to the store.
+func (s *SnapshotStore) Sink() Sink {
+	return s.Append
+}
+
+// Last returns up to n of the most recent snapshots, oldest first. Segments
+// are read newest first, stopping once n snapshots are found.
+func (s *SnapshotStore) Last(n int) ([]MetricsSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seqs, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	var result []MetricsSnapshot
+	for i := len(seqs) - 1; i >= 0 && len(result) < n; i-- {
+		snaps, err := s.readSegment(seqs[i], func(MetricsSnapshot) bool { return true })
+		if err != nil {
+			return nil, err
+		}
+		result = append(snaps, result...)
+	}
+	if len(result) > n {
+		result = result[len(result)-n:]
+	}
+	return result, nil
+}
+
+// Range returns snapshots collected in [from, to], oldest first. A zero to
+// means "until now".
+func (s *SnapshotStore) Range(from, to time.Time) ([]MetricsSnapshot, error) {
+	return s.scan(func(m MetricsSnapshot) bool {
+		return !m.CollectedAt.Before(from) && (to.IsZero() || !m.CollectedAt.After(to))
+	})
+}
+
+// scan reads every segment in order.
+func (s *SnapshotStore) scan(keep func(MetricsSnapshot) bool) ([]MetricsSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seqs, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	var result []MetricsSnapshot
+	for _, seq := range seqs {
+		snaps, err := s.readSegment(seq, keep)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, snaps...)
+	}
+	return result, nil
+}
+
+// readSegment returns the snapshots in segment seq that keep accepts. Lines
+// that fail to decode, such as a write cut short by a crash, are skipped,
+// and a segment rotated away meanwhile reads as empty. The caller holds
+// s.mu.
+func (s *SnapshotStore) readSegment(seq int, keep func(MetricsSnapshot) bool) ([]MetricsSnapshot, error) {
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var result []MetricsSnapshot
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), max(int(s.opts.SegmentBytes)+1, defaultStoreSegmentBytes))
+	for sc.Scan() {
+		var m MetricsSnapshot
+		if json.Unmarshal(sc.Bytes(), &m) == nil && keep(m) {
+			result = append(result, m)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.segmentPath(seq), err)
+	}
+	return result, nil
+}
+
+// Close closes the current segment.
+func (s *SnapshotStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	return s.f.Close()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func storeSnap(at time.Time, score int) MetricsSnapshot {
+	return MetricsSnapshot{SchemaVersion: SnapshotSchemaVersion, CollectedAt: at, HealthScore: score}
+}
+
+func TestSnapshotStoreAppendAndLast(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenSnapshotStore(dir, StoreOptions{})
+	if err != nil {
+		t.Fatalf("OpenSnapshotStore: %v", err)
+	}
+	base := time.Unix(1_700_000_000, 0).UTC()
+	for i := range 5 {
+		if err := s.Append(storeSnap(base.Add(time.Duration(i)*time.Second), i)); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Reopening resumes the same store.
+	s, err = OpenSnapshotStore(dir, StoreOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	if err := s.Append(storeSnap(base.Add(5*time.Second), 5)); err != nil {
+		t.Fatalf("Append after reopen: %v", err)
+	}
+
+	got, err := s.Last(2)
+	if err != nil {
+		t.Fatalf("Last: %v", err)
+	}
+	if len(got) != 2 || got[0].HealthScore != 4 || got[1].HealthScore != 5 {
+		t.Fatalf("Last(2) = %+v, want scores 4 and 5", got)
+	}
+}
+
+func TestSnapshotStoreRange(t *testing.T) {
+	s, err := OpenSnapshotStore(t.TempDir(), StoreOptions{})
+	if err != nil {
+		t.Fatalf("OpenSnapshotStore: %v", err)
+	}
+	defer s.Close()
+	base := time.Unix(1_700_000_000, 0).UTC()
+	for i := range 10 {
+		_ = s.Append(storeSnap(base.Add(time.Duration(i)*time.Minute), i))
+	}
+
+	got, err := s.Range(base.Add(3*time.Minute), base.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if len(got) != 3 || got[0].HealthScore != 3 || got[2].HealthScore != 5 {
+		t.Fatalf("Range(3m, 5m) = %+v, want scores 3..5", got)
+	}
+	if open, _ := s.Range(base.Add(8*time.Minute), time.Time{}); len(open) != 2 {
+		t.Fatalf("open-ended Range returned %d snapshots, want 2", len(open))
+	}
+}
+
+func TestSnapshotStoreRotationEvictsOldest(t *testing.T) {
+	dir := t.TempDir()
+	// One snapshot line is ~1 KB, so each segment holds a single snapshot.
+	s, err := OpenSnapshotStore(dir, StoreOptions{SegmentBytes: 100, MaxSegments: 3})
+	if err != nil {
+		t.Fatalf("OpenSnapshotStore: %v", err)
+	}
+	defer s.Close()
+	base := time.Unix(1_700_000_000, 0).UTC()
+	for i := range 6 {
+		if err := s.Append(storeSnap(base.Add(time.Duration(i)*time.Second), i)); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 segments after rotation, got %d", len(entries))
+	}
+	got, err := s.Last(10)
+	if err != nil {
+		t.Fatalf("Last: %v", err)
+	}
+	if len(got) != 3 || got[0].HealthScore != 3 {
+		t.Fatalf("Last(10) = %+v, want 3 snapshots starting at score 3", got)
+	}
+}
+
+func TestSnapshotStoreReadOnly(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "typo")
+	if _, err := OpenSnapshotStoreReadOnly(missing, StoreOptions{}); err == nil {
+		t.Fatal("expected an error opening a missing store")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Fatalf("read-only open created %s", missing)
+	}
+
+	dir := t.TempDir()
+	w, err := OpenSnapshotStore(dir, StoreOptions{SegmentBytes: 100})
+	if err != nil {
+		t.Fatalf("OpenSnapshotStore: %v", err)
+	}
+	base := time.Unix(1_700_000_000, 0).UTC()
+	for i := range 4 {
+		if err := w.Append(storeSnap(base.Add(time.Duration(i)*time.Second), i)); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+	w.Close()
+	// An unreadable oldest segment: Last must stop before reaching it.
+	oldest := filepath.Join(dir, storeSegmentPrefix+"000001"+storeSegmentSuffix)
+	if err := os.Remove(oldest); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(oldest, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenSnapshotStoreReadOnly(dir, StoreOptions{})
+	if err != nil {
+		t.Fatalf("OpenSnapshotStoreReadOnly: %v", err)
+	}
+	defer r.Close()
+	got, err := r.Last(2)
+	if err != nil || len(got) != 2 || got[0].HealthScore != 2 || got[1].HealthScore != 3 {
+		t.Fatalf("Last(2) = %+v, %v; want scores 2 and 3", got, err)
+	}
+	if err := r.Append(storeSnap(base, 9)); err == nil {
+		t.Fatal("Append on a read-only store should fail")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the helpers available to user templates. Numeric
+// arguments may be any integer or float field of the snapshot.
+func templateFuncs(opts FormatOptions) template.FuncMap {
+	return template.FuncMap{
+		"humanizeBytes": func(v any) (string, error) {
+			f, err := templateNumber(v)
+			if err != nil {
+				return "", err
+			}
+			if f < 0 {
+				return "", fmt.Errorf("negative byte count %v", f)
+			}
+			return humanBytes(uint64(f)), nil
+		},
+		"humanizeRate": func(v any) (string, error) {
+			f, err := templateNumber(v)
+			if err != nil {
+				return "", err
+			}
+			return formatRateWith(f, opts), nil
+		},
+		// ifaceLabel applies -display-names, as in
+		// {{range .Network}}{{ifaceLabel .Name $.Network}}{{end}}.
+		"ifaceLabel": func(name string, stats []NetworkStatus) string {
+			return opts.interfaceLabel(name, stats)
+		},
+		// sparkline draws a history such as .NetworkHistory.RxHistory.
+		"sparkline": func(values []float64) string {
+			return renderSparkline(values, opts.Spark)
+		},
+	}
+}
+
+func templateNumber(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+// ParseTemplate compiles a user template over MetricsSnapshot. Syntax errors
+// and unknown functions are reported here, before any collection runs.
+func ParseTemplate(text string, opts FormatOptions) (*template.Template, error) {
+	t, err := template.New("status").Funcs(templateFuncs(opts)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return t, nil
+}
+
+// RenderTemplate renders snap with a one-off template using the default
+// format options.
+func RenderTemplate(tmpl string, snap MetricsSnapshot) (string, error) {
+	t, err := ParseTemplate(tmpl, FormatOptions{})
+	if err != nil {
+		return "", err
+	}
+	return executeTemplate(t, snap)
+}
+
+func executeTemplate(t *template.Template, snap MetricsSnapshot) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, snap); err != nil {
+		return "", fmt.Errorf("render template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// loadTemplateFlag returns the -template text, reading it from a file when
+// the value starts with "@".
+func loadTemplateFlag(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// templateSink writes one rendered template per snapshot, adding a trailing
+// newline when the template has none.
+func templateSink(w io.Writer, t *template.Template) Sink {
+	return func(m MetricsSnapshot) error {
+		out, err := executeTemplate(t, m)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	}
+}
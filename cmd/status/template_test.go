@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func templateSnapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		Host:   "studio",
+		CPU:    CPUStatus{Usage: 12.5},
+		Memory: MemoryStatus{Used: 3 << 30, UsedPercent: 40},
+		Network: []NetworkStatus{
+			{Name: "en0", RxRateMBs: 2.5, TxRateMBs: 0.25, IP: "192.168.1.5"},
+		},
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl := `{{.Host}} cpu={{printf "%.0f" .CPU.Usage}}% mem={{humanizeBytes .Memory.Used}}` +
+		`{{range .Network}} {{.Name}} ↓{{humanizeRate .RxRateMBs}}{{end}}`
+	got, err := RenderTemplate(tmpl, templateSnapshot())
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	if want := "studio cpu=12% mem=3.0 GB en0 ↓2.5 MB/s"; got != want {
+		t.Fatalf("RenderTemplate() = %q, want %q", got, want)
+	}
+}
+
+func TestParseTemplateRejectsInvalid(t *testing.T) {
+	for _, tmpl := range []string{"{{.Host", "{{humanize .Host}}"} {
+		if _, err := ParseTemplate(tmpl, FormatOptions{}); err == nil || !strings.Contains(err.Error(), "invalid template") {
+			t.Errorf("ParseTemplate(%q) error = %v, want invalid template", tmpl, err)
+		}
+	}
+}
+
+func TestRenderTemplateReportsBadFields(t *testing.T) {
+	if _, err := RenderTemplate("{{.Nope}}", templateSnapshot()); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+	if _, err := RenderTemplate("{{humanizeBytes .Host}}", templateSnapshot()); err == nil {
+		t.Fatal("expected an error for a non-numeric helper argument")
+	}
+}
+
+func TestTemplateSinkAddsNewline(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Host}}", FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := templateSink(&buf, tmpl)(templateSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "studio\n" {
+		t.Fatalf("templateSink wrote %q", buf.String())
+	}
+}
+
+func TestTemplateIfaceLabel(t *testing.T) {
+	opts := FormatOptions{DisplayNames: map[string]string{"en0": "Home"}}
+	tmpl, err := ParseTemplate(`{{range .Network}}{{ifaceLabel .Name $.Network}} {{.IP}}{{end}}`, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := executeTemplate(tmpl, templateSnapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Home 192.168.1.5"; got != want {
+		t.Fatalf("template = %q, want %q", got, want)
+	}
+}
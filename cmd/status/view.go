@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -20,6 +21,9 @@ var (
 	primaryStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#BD93F9"))
 )
 
+// diskFullWarnWindow shows a fill warning when a disk is projected full within it.
+const diskFullWarnWindow = 24 * time.Hour
+
 const (
 	colWidth    = 38
 	iconCPU     = "◉"
@@ -244,6 +248,16 @@ func renderCPUCard(cpu CPUStatus, thermal ThermalStatus) cardData {
 	if thermal.CPUTemp > 0 {
 		headerText += fmt.Sprintf(" @ %s°C", colorizeTemp(thermal.CPUTemp))
 	}
+	if cpu.StealPercent >= cpuStealNotablePercent {
+		headerText += " " + warnStyle.Render(fmt.Sprintf("steal %.1f%%", cpu.StealPercent))
+	}
+	if thermal.Throttled {
+		label := "throttled"
+		if thermal.CPUSpeedLimit > 0 && thermal.CPUSpeedLimit < 100 {
+			label = fmt.Sprintf("throttled %d%%", thermal.CPUSpeedLimit)
+		}
+		headerText += " " + warnStyle.Render(label)
+	}
 
 	lines = append(lines, fmt.Sprintf("Total  %s  %s", usageBar, headerText))
 
@@ -263,7 +277,11 @@ func renderCPUCard(cpu CPUStatus, thermal ThermalStatus) cardData {
 		maxCores := min(len(cores), 3)
 		for i := range maxCores {
 			c := cores[i]
-			lines = append(lines, fmt.Sprintf("Core%-2d %s  %5.1f%%", c.idx+1, progressBar(c.val), c.val))
+			line := fmt.Sprintf("Core%-2d %s  %5.1f%%", c.idx+1, progressBar(c.val), c.val)
+			if c.idx < len(cpu.PerCoreTemp) && cpu.PerCoreTemp[c.idx] > 0 {
+				line += fmt.Sprintf(" @ %s°C", colorizeTemp(cpu.PerCoreTemp[c.idx]))
+			}
+			lines = append(lines, line)
 		}
 	}
 
@@ -346,7 +364,7 @@ func renderMemoryCard(mem MemoryStatus, cardWidth int) cardData {
 	return cardData{icon: iconMemory, title: "Memory", lines: lines}
 }
 
-func renderDiskCard(disks []DiskStatus, io DiskIOStatus) cardData {
+func renderDiskCard(disks []DiskStatus, io DiskIOStatus, arrays []ArrayStatus) cardData {
 	var lines []string
 	if len(disks) == 0 {
 		lines = append(lines, subtleStyle.Render("Collecting..."))
@@ -368,6 +386,22 @@ func renderDiskCard(disks []DiskStatus, io DiskIOStatus) cardData {
 		} else if len(disks) == 1 {
 			lines = append(lines, formatDiskMetaLine(disks[0]))
 		}
+		for _, d := range disks {
+			if d.TimeToFullSeconds > 0 && d.TimeToFullSeconds < diskFullWarnWindow.Seconds() {
+				eta := formatUptime(uint64(d.TimeToFullSeconds))
+				lines = append(lines, warnStyle.Render(fmt.Sprintf("%-6s %s full in ~%s", "FILL", d.Mount, eta)))
+			}
+		}
+	}
+	for _, a := range arrays {
+		if a.Healthy() {
+			continue
+		}
+		text := fmt.Sprintf("%-6s %s %s", "RAID", a.Name, a.State)
+		if len(a.DegradedDisks) > 0 {
+			text += " · " + strings.Join(a.DegradedDisks, ", ")
+		}
+		lines = append(lines, dangerStyle.Render(text))
 	}
 	readBar := ioBar(io.ReadRate)
 	writeBar := ioBar(io.WriteRate)
@@ -427,12 +461,13 @@ func ioBar(rate float64) string {
 	return okStyle.Render(bar)
 }
 
-func renderProcessCard(procs []ProcessInfo) cardData {
+func renderProcessCard(procs []ProcessInfo, truncated bool) cardData {
 	var lines []string
 	maxProcs := 3
 	for i, p := range procs {
-		if i >= maxProcs {
-			break
+		// Watchlisted processes are shown even below the top three.
+		if i >= maxProcs && !p.Watchlisted {
+			continue
 		}
 		name := shorten(p.Name, 12)
 		cpuBar := miniBar(p.CPU)
@@ -441,17 +476,20 @@ func renderProcessCard(procs []ProcessInfo) cardData {
 	if len(lines) == 0 {
 		lines = append(lines, subtleStyle.Render("No data"))
 	}
+	if truncated {
+		lines = append(lines, subtleStyle.Render("Partial scan"))
+	}
 	return cardData{icon: iconProcs, title: "Processes", lines: lines}
 }
 
-func buildCards(m MetricsSnapshot, width int) []cardData {
+func buildCards(m MetricsSnapshot, width int, opts FormatOptions) []cardData {
 	cards := []cardData{
 		renderCPUCard(m.CPU, m.Thermal),
 		renderMemoryCard(m.Memory, width),
-		renderDiskCard(m.Disks, m.DiskIO),
+		renderDiskCard(m.Disks, m.DiskIO, m.StorageArrays),
 		renderBatteryCard(m.Batteries, m.Thermal),
-		renderProcessCard(m.TopProcesses),
-		renderNetworkCard(m.Network, m.NetworkHistory, m.Proxy, width),
+		renderProcessCard(m.TopProcesses, m.TopTruncated),
+		renderNetworkCard(m.Network, m.NetworkHistory, m.Proxy, m.TCP, width, opts),
 	}
 	// Sensors card disabled - redundant with CPU temp
 	// if hasSensorData(m.Sensors) {
@@ -460,25 +498,50 @@ func buildCards(m MetricsSnapshot, width int) []cardData {
 	return cards
 }
 
+func formatLinkSpeed(mbps int) string {
+	if mbps >= 1000 && mbps%1000 == 0 {
+		return fmt.Sprintf("%dG", mbps/1000)
+	}
+	return fmt.Sprintf("%dM", mbps)
+}
+
 func miniBar(percent float64) string {
 	filled := max(min(int(percent/20), 5), 0)
 	return colorizePercent(percent, strings.Repeat("▮", filled)+strings.Repeat("▯", 5-filled))
 }
 
-func renderNetworkCard(netStats []NetworkStatus, history NetworkHistory, proxy ProxyStatus, cardWidth int) cardData {
+func renderNetworkCard(netStats []NetworkStatus, history NetworkHistory, proxy ProxyStatus, tcp TCPStatus, cardWidth int, opts FormatOptions) cardData {
 	var lines []string
 	var totalRx, totalTx float64
-	var primaryIP string
+	var primaryIP, primaryName string
+	sessionRx, sessionTx := history.SessionRxBytes, history.SessionTxBytes
 
 	for _, n := range netStats {
+		if n.Loopback {
+			continue
+		}
 		totalRx += n.RxRateMBs
 		totalTx += n.TxRateMBs
 		if primaryIP == "" && n.IP != "" && n.Name == "en0" {
-			primaryIP = n.IP
+			primaryIP, primaryName = n.IP, n.Name
+		}
+	}
+	// netStats may be in stable or pinned order; show how full the busiest
+	// link with a known speed is.
+	var busiest *NetworkStatus
+	for i, n := range netStats {
+		if n.Loopback || n.LinkSpeedMbps <= 0 {
+			continue
+		}
+		if busiest == nil || n.RxRateMBs+n.TxRateMBs > busiest.RxRateMBs+busiest.TxRateMBs {
+			busiest = &netStats[i]
 		}
 	}
 
-	if len(netStats) == 0 {
+	if len(netStats) == 0 && len(history.RxHistory) > 0 {
+		// Collected, but every interface is under the rate floor.
+		lines = []string{subtleStyle.Render("All interfaces idle")}
+	} else if len(netStats) == 0 {
 		lines = []string{subtleStyle.Render("Collecting...")}
 	} else {
 		// Calculate dynamic width
@@ -488,29 +551,62 @@ func renderNetworkCard(netStats []NetworkStatus, history NetworkHistory, proxy P
 		graphWidth := min(max(cardWidth-22, 5), 16)
 
 		// sparkline graphs
-		rxSparkline := sparkline(history.RxHistory, totalRx, graphWidth)
-		txSparkline := sparkline(history.TxHistory, totalTx, graphWidth)
+		rxSparkline := sparkline(history.RxHistory, totalRx, graphWidth, opts.Spark)
+		txSparkline := sparkline(history.TxHistory, totalTx, graphWidth, opts.Spark)
 		lines = append(lines, fmt.Sprintf("Down   %s  %s", rxSparkline, formatRate(totalRx)))
 		lines = append(lines, fmt.Sprintf("Up     %s  %s", txSparkline, formatRate(totalTx)))
+		if busiest != nil {
+			util := max(busiest.RxUtilization, busiest.TxUtilization)
+			line := fmt.Sprintf("Link   %s  %.0f%% of %s", miniBar(util), util, formatLinkSpeed(busiest.LinkSpeedMbps))
+			if busiest.Implausible {
+				line += " " + subtleStyle.Render("(?)")
+			}
+			lines = append(lines, line)
+		}
 		// Show proxy and IP on one line.
 		var infoParts []string
 		if proxy.Enabled {
-			infoParts = append(infoParts, "Proxy "+proxy.Type)
+			label := "Proxy " + proxy.Type
+			if proxy.Underlying != "" {
+				label += " via " + proxy.Underlying
+				if proxy.UnderlyingGuess {
+					label += " " + subtleStyle.Render("(?)")
+				}
+			}
+			infoParts = append(infoParts, label)
+		}
+		if proxy.Flapping {
+			infoParts = append(infoParts, warnStyle.Render("Proxy flapping"))
+		}
+		if tcp.RetransPercent >= tcpRetransWarnPercent {
+			infoParts = append(infoParts, warnStyle.Render(fmt.Sprintf("TCP retrans %.1f%%", tcp.RetransPercent)))
+		}
+		if hasProxyConflict(proxy.Effective) {
+			infoParts = append(infoParts, warnStyle.Render("CLI/system proxy differ"))
+		}
+		for _, n := range netStats {
+			if n.Flapping {
+				infoParts = append(infoParts, warnStyle.Render(opts.interfaceLabel(n.Name, netStats)+" link flapping"))
+			}
+			if n.Bursting {
+				infoParts = append(infoParts, warnStyle.Render(opts.interfaceLabel(n.Name, netStats)+" burst"))
+			}
 		}
 		if primaryIP != "" {
-			infoParts = append(infoParts, primaryIP)
+			infoParts = append(infoParts, opts.interfaceLabel(primaryName, netStats)+" "+primaryIP)
 		}
 		if len(infoParts) > 0 {
 			lines = append(lines, strings.Join(infoParts, " · "))
 		}
+		if sessionRx+sessionTx > 0 {
+			lines = append(lines, subtleStyle.Render(fmt.Sprintf("Total  ↓%s ↑%s", humanBytesCompact(sessionRx), humanBytesCompact(sessionTx))))
+		}
 	}
 	return cardData{icon: iconNetwork, title: "Network", lines: lines}
 }
 
 // 8 levels: ▁▂▃▄▅▆▇█
-func sparkline(history []float64, current float64, width int) string {
-	blocks := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
-
+func sparkline(history []float64, current float64, width int, style SparkStyle) string {
 	data := make([]float64, 0, width)
 	if len(history) > 0 {
 		// Take the most recent points.
@@ -528,23 +624,15 @@ func sparkline(history []float64, current float64, width int) string {
 		data = data[len(data)-width:]
 	}
 
+	// Scale from zero with a small floor, so idle noise stays near the
+	// baseline instead of filling the graph.
 	maxVal := 0.1
 	for _, v := range data {
 		if v > maxVal {
 			maxVal = v
 		}
 	}
-
-	var builder strings.Builder
-	for _, v := range data {
-		level := max(int((v/maxVal)*float64(len(blocks)-1)), 0)
-		if level >= len(blocks) {
-			level = len(blocks) - 1
-		}
-		builder.WriteRune(blocks[level])
-	}
-
-	result := builder.String()
+	result := renderSparklineRange(data, 0, maxVal, style)
 	if current > 8 {
 		return dangerStyle.Render(result)
 	}
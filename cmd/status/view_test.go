@@ -747,6 +747,20 @@ func TestMiniBar(t *testing.T) {
 	}
 }
 
+func TestRenderNetworkCardLinkShowsBusiestInterface(t *testing.T) {
+	// Stable or pinned order can put an idle NIC first.
+	stats := []NetworkStatus{
+		{Name: "en0", RxRateMBs: 0.01, LinkSpeedMbps: 1000},
+		{Name: "en1", RxRateMBs: 50, LinkSpeedMbps: 10000, RxUtilization: 4},
+		{Name: "utun3", TxRateMBs: 80},
+	}
+	card := renderNetworkCard(stats, NetworkHistory{}, ProxyStatus{}, TCPStatus{}, 60, FormatOptions{Spark: SparkBlocks})
+	got := stripANSI(strings.Join(card.lines, "\n"))
+	if !strings.Contains(got, "4% of 10G") {
+		t.Fatalf("Link line should describe en1, got %q", got)
+	}
+}
+
 func TestFormatDiskLine(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -831,7 +845,7 @@ func TestRenderDiskCardAddsMetaLineForSingleDisk(t *testing.T) {
 		Used:        263 << 30,
 		Total:       926 << 30,
 		Fstype:      "apfs",
-	}}, DiskIOStatus{ReadRate: 0, WriteRate: 0.1})
+	}}, DiskIOStatus{ReadRate: 0, WriteRate: 0.1}, nil)
 
 	if len(card.lines) != 4 {
 		t.Fatalf("renderDiskCard() single disk expected 4 lines, got %d", len(card.lines))
@@ -843,11 +857,30 @@ func TestRenderDiskCardAddsMetaLineForSingleDisk(t *testing.T) {
 	}
 }
 
+func TestRenderDiskCardShowsDegradedArrays(t *testing.T) {
+	card := renderDiskCard([]DiskStatus{
+		{UsedPercent: 28.4, Used: 263 << 30, Total: 926 << 30, Fstype: "ext4"},
+	}, DiskIOStatus{}, []ArrayStatus{
+		{Name: "md1", Type: "mdraid", State: "ACTIVE"},
+		{Name: "tank", Type: "zfs", State: "DEGRADED", DegradedDisks: []string{"sdb"}},
+	})
+
+	var raidLines []string
+	for _, line := range card.lines {
+		if plain := stripANSI(line); strings.HasPrefix(plain, "RAID") {
+			raidLines = append(raidLines, plain)
+		}
+	}
+	if len(raidLines) != 1 || raidLines[0] != "RAID   tank DEGRADED · sdb" {
+		t.Fatalf("expected only the degraded array to be shown, got %q", raidLines)
+	}
+}
+
 func TestRenderDiskCardDoesNotAddMetaLineForMultipleDisks(t *testing.T) {
 	card := renderDiskCard([]DiskStatus{
 		{UsedPercent: 28.4, Used: 263 << 30, Total: 926 << 30, Fstype: "apfs"},
 		{UsedPercent: 50.0, Used: 500 << 30, Total: 1000 << 30, Fstype: "apfs"},
-	}, DiskIOStatus{})
+	}, DiskIOStatus{}, nil)
 
 	if len(card.lines) != 4 {
 		t.Fatalf("renderDiskCard() multiple disks expected 4 lines, got %d", len(card.lines))
@@ -973,7 +1006,7 @@ func TestSparkline(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := sparkline(tt.history, tt.current, tt.width)
+			got := sparkline(tt.history, tt.current, tt.width, SparkBlocks)
 			if tt.width == 0 {
 				return
 			}
@@ -1182,3 +1215,18 @@ func containsMiddle(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRenderNetworkCardUsesDisplayNames(t *testing.T) {
+	stats := []NetworkStatus{
+		{Name: "en0", IP: "192.168.1.2", RxRateMBs: 1},
+		{Name: "utun3", TxRateMBs: 1, Flapping: true},
+	}
+	opts := FormatOptions{DisplayNames: map[string]string{"en0": "Home"}}
+	card := renderNetworkCard(stats, NetworkHistory{}, ProxyStatus{}, TCPStatus{}, 60, opts)
+	got := stripANSI(strings.Join(card.lines, "\n"))
+	for _, want := range []string{"Home 192.168.1.2", "VPN link flapping"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("network card %q missing %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WaybarMetric selects the metric shown as a waybar module's text and
+// percentage.
+type WaybarMetric string
+
+const (
+	WaybarCPU     WaybarMetric = "cpu"
+	WaybarMemory  WaybarMetric = "memory"
+	WaybarDisk    WaybarMetric = "disk"
+	WaybarNetwork WaybarMetric = "network"
+	WaybarHealth  WaybarMetric = "health"
+)
+
+// Waybar classes, for styling the module in waybar's CSS.
+const (
+	waybarNormal   = "normal"
+	waybarWarning  = "warning"
+	waybarCritical = "critical"
+)
+
+func parseWaybarMetric(s string) (WaybarMetric, error) {
+	switch m := WaybarMetric(strings.ToLower(s)); m {
+	case WaybarCPU, WaybarMemory, WaybarDisk, WaybarNetwork, WaybarHealth:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown waybar metric %q (want cpu, memory, disk, network or health)", s)
+}
+
+// WaybarOutput is the JSON a waybar custom module with "return-type": "json"
+// reads per line. i3status-rust's custom block accepts the same fields.
+type WaybarOutput struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+// FormatWaybar renders m for waybar with metric as the headline. Class is
+// the worse of the headline's own level (the TUI's 60% and 85% bar colors;
+// below 60 and 40 for the health score) and the most severe active alert.
+// The tooltip lists every metric and alert; waybar reads it as Pango markup,
+// so it is escaped.
+func FormatWaybar(m MetricsSnapshot, metric WaybarMetric, opts FormatOptions) WaybarOutput {
+	pct := func(v float64) string { return opts.Numbers.format(v, 1) + "%" }
+	var disk float64
+	if len(m.Disks) > 0 {
+		disk = m.Disks[0].UsedPercent
+	}
+	rx, tx := totalNetworkRates(m.Network)
+	link := fastestLinkMbps(m.Network)
+	rates := fmt.Sprintf("↓%s ↑%s", formatLinkRateWith(rx, link, opts), formatLinkRateWith(tx, link, opts))
+
+	var out WaybarOutput
+	var value float64
+	switch metric {
+	case WaybarMemory:
+		value = m.Memory.UsedPercent
+		out.Text = "MEM " + pct(value)
+	case WaybarDisk:
+		value = disk
+		out.Text = "DISK " + pct(value)
+	case WaybarNetwork:
+		value = networkUtilization(m.Network)
+		out.Text = rates
+	case WaybarHealth:
+		value = float64(m.HealthScore)
+		out.Text = fmt.Sprintf("♥ %d", m.HealthScore)
+	default:
+		value = m.CPU.Usage
+		out.Text = "CPU " + pct(value)
+	}
+	out.Percentage = int(min(max(value, 0), 100) + 0.5)
+
+	level := percentLevel(value)
+	if metric == WaybarHealth {
+		level = healthLevel(m.HealthScore)
+	}
+	for _, a := range m.Alerts {
+		switch {
+		case a.Level == AlertCritical:
+			level = waybarCritical
+		case a.Level == AlertWarn && level == waybarNormal:
+			level = waybarWarning
+		}
+	}
+	out.Class = level
+
+	lines := []string{
+		"CPU " + pct(m.CPU.Usage),
+		"Memory " + pct(m.Memory.UsedPercent),
+	}
+	if len(m.Disks) > 0 {
+		lines = append(lines, fmt.Sprintf("Disk %s %s", m.Disks[0].Mount, pct(disk)))
+	}
+	lines = append(lines, "Network "+rates)
+	if n, ok := primaryNetwork(m.Network); ok {
+		lines = append(lines, opts.interfaceLabel(n.Name, m.Network)+" "+n.IP)
+	}
+	health := fmt.Sprintf("Health %d", m.HealthScore)
+	if m.HealthScoreMsg != "" {
+		health += " (" + m.HealthScoreMsg + ")"
+	}
+	lines = append(lines, health)
+	for _, a := range m.Alerts {
+		lines = append(lines, a.String())
+	}
+	out.Tooltip = pangoEscaper.Replace(strings.Join(lines, "\n"))
+	return out
+}
+
+var pangoEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// networkUtilization is the busiest direction of the busiest interface
+// with a known link speed, or 0 when no speed is known.
+func networkUtilization(stats []NetworkStatus) float64 {
+	var util float64
+	for _, n := range stats {
+		if n.Loopback || n.LinkSpeedMbps <= 0 {
+			continue
+		}
+		util = max(util, n.RxUtilization, n.TxUtilization)
+	}
+	return util
+}
+
+func percentLevel(percent float64) string {
+	switch {
+	case percent >= 85:
+		return waybarCritical
+	case percent >= 60:
+		return waybarWarning
+	}
+	return waybarNormal
+}
+
+func healthLevel(score int) string {
+	switch {
+	case score < 40:
+		return waybarCritical
+	case score < 60:
+		return waybarWarning
+	}
+	return waybarNormal
+}
+
+// waybarSink writes one FormatWaybar JSON object per line, as waybar's
+// continuous custom modules expect.
+func waybarSink(w io.Writer, metric WaybarMetric, opts FormatOptions) Sink {
+	return func(m MetricsSnapshot) error {
+		data, err := json.Marshal(FormatWaybar(m, metric, opts))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormatWaybarFields(t *testing.T) {
+	snap := MetricsSnapshot{
+		CPU:            CPUStatus{Usage: 72.4},
+		Memory:         MemoryStatus{UsedPercent: 30},
+		Disks:          []DiskStatus{{Mount: "/", UsedPercent: 50}},
+		Network:        []NetworkStatus{{Name: "en0", RxRateMBs: 1.5, TxRateMBs: 0.25}},
+		HealthScore:    88,
+		HealthScoreMsg: "Good",
+	}
+
+	data, err := json.Marshal(FormatWaybar(snap, WaybarCPU, FormatOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != "CPU 72.4%" || got["class"] != "warning" || got["percentage"] != 72.0 {
+		t.Fatalf("waybar output = %v", got)
+	}
+	tooltip, _ := got["tooltip"].(string)
+	for _, want := range []string{"Memory 30.0%", "Disk / 50.0%", "Network ↓1.5 MB/s ↑256.0 KB/s", "Health 88 (Good)"} {
+		if !strings.Contains(tooltip, want) {
+			t.Errorf("tooltip %q missing %q", tooltip, want)
+		}
+	}
+}
+
+func TestFormatWaybarClass(t *testing.T) {
+	critical := Alert{Metric: "disk.read_only", Subject: "/data", Level: AlertCritical, Message: "/data was remounted <read-only>"}
+	warn := Alert{Metric: "net.upload", Subject: "en0", Level: AlertWarn, Message: "en0 uploading"}
+	tests := []struct {
+		name   string
+		snap   MetricsSnapshot
+		metric WaybarMetric
+		want   string
+	}{
+		{"idle", MetricsSnapshot{CPU: CPUStatus{Usage: 10}}, WaybarCPU, "normal"},
+		{"busy cpu", MetricsSnapshot{CPU: CPUStatus{Usage: 90}}, WaybarCPU, "critical"},
+		{"warning alert", MetricsSnapshot{Alerts: []Alert{warn}}, WaybarMemory, "warning"},
+		{"critical alert beats metric", MetricsSnapshot{Alerts: []Alert{warn, critical}}, WaybarCPU, "critical"},
+		{"low health", MetricsSnapshot{HealthScore: 50}, WaybarHealth, "warning"},
+		{"healthy", MetricsSnapshot{HealthScore: 95}, WaybarHealth, "normal"},
+	}
+	for _, tt := range tests {
+		if got := FormatWaybar(tt.snap, tt.metric, FormatOptions{}); got.Class != tt.want {
+			t.Errorf("%s: class = %q, want %q", tt.name, got.Class, tt.want)
+		}
+	}
+
+	out := FormatWaybar(MetricsSnapshot{Alerts: []Alert{critical}}, WaybarCPU, FormatOptions{})
+	if !strings.Contains(out.Tooltip, "&lt;read-only&gt;") {
+		t.Fatalf("tooltip should escape Pango markup: %q", out.Tooltip)
+	}
+}
+
+func TestFormatWaybarHeadline(t *testing.T) {
+	snap := MetricsSnapshot{
+		Memory:      MemoryStatus{UsedPercent: 45.6},
+		Network:     []NetworkStatus{{Name: "en0", RxRateMBs: 50, LinkSpeedMbps: 1000, RxUtilization: 41.9}},
+		HealthScore: 77,
+	}
+	tests := []struct {
+		metric  WaybarMetric
+		text    string
+		percent int
+	}{
+		{WaybarMemory, "MEM 45.6%", 46},
+		{WaybarNetwork, "↓50.0 MB/s ↑0.0 MB/s", 42},
+		{WaybarHealth, "♥ 77", 77},
+	}
+	for _, tt := range tests {
+		got := FormatWaybar(snap, tt.metric, FormatOptions{})
+		if got.Text != tt.text || got.Percentage != tt.percent {
+			t.Errorf("%s: text %q percentage %d, want %q %d", tt.metric, got.Text, got.Percentage, tt.text, tt.percent)
+		}
+	}
+	if _, err := parseWaybarMetric("gpu"); err == nil {
+		t.Fatal("expected an error for an unknown metric")
+	}
+}
+
+func TestFormatWaybarTooltipUsesDisplayNames(t *testing.T) {
+	snap := MetricsSnapshot{Network: []NetworkStatus{{Name: "en0", IP: "192.168.1.2"}}}
+	opts := FormatOptions{DisplayNames: map[string]string{"en0": "Home"}}
+	if got := FormatWaybar(snap, WaybarNetwork, opts).Tooltip; !strings.Contains(got, "Home 192.168.1.2") {
+		t.Fatalf("tooltip %q should name en0 as Home", got)
+	}
+}